@@ -0,0 +1,76 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Properties of ASCII runes, looked up by the default predicates before
+// falling back to the unicode package, which dominates scans of mostly
+// ASCII input otherwise. Custom predicates don't use the table.
+const (
+	ascii_space  uint8 = 1 << iota // unicode.IsSpace()
+	ascii_letter                   // unicode.IsLetter()
+	ascii_digit                    // unicode.IsDigit()
+	ascii_punct                    // unicode.IsPunct()
+	ascii_symbol                   // unicode.IsSymbol()
+)
+
+// Properties of each ASCII rune, derived from the unicode package so that
+// lookups agree with it.
+var ascii_props = func() [utf8.RuneSelf]uint8 {
+	var props [utf8.RuneSelf]uint8
+	for ch := rune(0); ch < utf8.RuneSelf; ch++ {
+		for _, p := range []struct {
+			prop uint8
+			is   func(rune) bool
+		}{
+			{ascii_space, unicode.IsSpace},
+			{ascii_letter, unicode.IsLetter},
+			{ascii_digit, unicode.IsDigit},
+			{ascii_punct, unicode.IsPunct},
+			{ascii_symbol, unicode.IsSymbol},
+		} {
+			if p.is(ch) {
+				props[ch] |= p.prop
+			}
+		}
+	}
+
+	return props
+}()
+
+// Returns true if the rune is white space, as unicode.IsSpace().
+func is_space(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return ascii_props[ch]&ascii_space != 0
+	}
+
+	return unicode.IsSpace(ch)
+}
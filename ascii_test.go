@@ -0,0 +1,69 @@
+package textparser_test
+
+import (
+	"testing"
+	"unicode"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// Test that the ASCII fast paths of the default predicates agree with the
+// unicode package.
+func TestASCIIPredicates(t *testing.T) {
+	type TestData struct {
+		Name      string
+		Predicate func(ch rune, i int, runes []rune) bool
+		Expected  func(ch rune, i int) bool
+	}
+
+	test_list := []TestData{
+		{
+			Name:      "IsSpaceRune",
+			Predicate: textparser.IsSpaceRune,
+			Expected: func(ch rune, i int) bool {
+				return unicode.IsSpace(ch)
+			},
+		},
+		{
+			Name:      "IsDigitRune",
+			Predicate: textparser.IsDigitRune,
+			Expected: func(ch rune, i int) bool {
+				return unicode.IsDigit(ch)
+			},
+		},
+		{
+			Name:      "IsIdentRune",
+			Predicate: textparser.IsIdentRune,
+			Expected: func(ch rune, i int) bool {
+				return unicode.IsLetter(ch) || ch == '_' ||
+					(!unicode.IsPunct(ch) && (i > 0 && unicode.IsDigit(ch) ||
+						unicode.IsMark(ch)))
+			},
+		},
+		{
+			Name:      "IsSymbolRune",
+			Predicate: textparser.IsSymbolRune,
+			Expected: func(ch rune, i int) bool {
+				if ok, _ := textparser.IsQuoteRune(ch); ok || i > 0 {
+					return false
+				}
+				return unicode.IsSymbol(ch) || unicode.IsPunct(ch)
+			},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			for ch := rune(0); ch < 0x300; ch++ {
+				for i := 0; i < 2; i++ {
+					got := test_data.Predicate(ch, i, nil)
+					expected := test_data.Expected(ch, i)
+					if got != expected {
+						st.Errorf("%U at %d: got %t, expected %t", ch, i,
+							got, expected)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// Whether a symbol token is an opening or closing bracket (see
+// SetBrackets()).
+type BracketKind int
+
+const (
+	BracketNone BracketKind = iota
+	BracketOpen
+	BracketClose
+)
+
+// Returns a string representation of the bracket kind: "", "open", or
+// "close".
+func (b BracketKind) String() string {
+	switch b {
+	case BracketOpen:
+		return "open"
+	case BracketClose:
+		return "close"
+	}
+
+	return ""
+}
+
+// Sets the pairs of opening and closing brackets, e.g., "()" or "[]",
+// replacing any set before. A bracket that starts a symbol token is emitted
+// as a symbol token on its own, with Token.Bracket set to BracketOpen or
+// BracketClose. Pairs that are not exactly two runes are ignored.
+func (ts *TokenScanner) SetBrackets(pairs ...string) {
+	ts.brackets = ts.brackets[:0]
+	for _, pair := range pairs {
+		runes := []rune(pair)
+		if len(runes) == 2 {
+			ts.brackets = append(ts.brackets, [2]rune{runes[0], runes[1]})
+		}
+	}
+}
+
+// Returns the bracket pairs set with SetBrackets().
+func (ts *TokenScanner) Brackets() []string {
+	pairs := make([]string, 0, len(ts.brackets))
+	for _, pair := range ts.brackets {
+		pairs = append(pairs, string(pair[:]))
+	}
+
+	return pairs
+}
+
+// Returns the bracket that pairs with ch, e.g., ')' for '(' or '(' for ')',
+// and whether ch is a bracket at all.
+func (ts *TokenScanner) MatchingBracket(ch rune) (rune, bool) {
+	for _, pair := range ts.brackets {
+		switch ch {
+		case pair[0]:
+			return pair[1], true
+		case pair[1]:
+			return pair[0], true
+		}
+	}
+
+	return 0, false
+}
+
+// Returns the kind of bracket ch is, if any.
+func (ts *TokenScanner) bracket_kind(ch rune) BracketKind {
+	for _, pair := range ts.brackets {
+		switch ch {
+		case pair[0]:
+			return BracketOpen
+		case pair[1]:
+			return BracketClose
+		}
+	}
+
+	return BracketNone
+}
+
+// Reads a bracket set with SetBrackets(), if one is the next rune. Returns a
+// nil token if there is none.
+func (ts *TokenScanner) get_bracket() (*Token, error) {
+	ch, err := ts.peek_rune()
+	if err != nil {
+		return nil, err
+	}
+
+	kind := ts.bracket_kind(ch)
+	if kind == BracketNone {
+		return nil, nil
+	}
+
+	return ts.get_single_symbol(kind)
+}
+
+// Reads the next rune as a symbol token of its own.
+func (ts *TokenScanner) get_single_symbol(bracket BracketKind) (*Token, error) {
+	runes, total_size, err := ts.get_n_runes(1)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  1,
+		FirstRune: runes[0],
+		Type:      TokenTypeSymbol,
+		Bracket:   bracket,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
@@ -0,0 +1,63 @@
+package textparser_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestBrackets(t *testing.T) {
+	p := textparser.NewScannerString("f((a]) <x>")
+	p.SetBrackets("()", "[]", "<")
+	p.IsSymbolRune = func(ch rune, i int, runes []rune) bool {
+		// Greedy, to check that brackets are split off.
+		return ch == '(' || ch == ')' || ch == ']' || ch == '<' || ch == '>'
+	}
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text+" "+token.Bracket.String())
+	}
+
+	expected := []string{"f ", "( open", "( open", "a ", "] close",
+		") close", "< ", "x ", "> "}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if got := p.Brackets(); !reflect.DeepEqual(got, []string{"()", "[]"}) {
+		t.Errorf("got %#v, expected %#v", got, []string{"()", "[]"})
+	}
+
+	if ch, ok := p.MatchingBracket(']'); !ok || ch != '[' {
+		t.Errorf("got %q %t, expected '[' true", ch, ok)
+	}
+}
+
+func TestBracketJSON(t *testing.T) {
+	token := &textparser.Token{Text: "(", NumBytes: 1, NumChars: 1,
+		FirstRune: '(', Type: textparser.TokenTypeSymbol,
+		Bracket: textparser.BracketOpen}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("couldn't marshal token: %s", err)
+	}
+
+	expected := `{"type":"Symbol","text":"(","num_bytes":1,"num_chars":1,` +
+		`"first_rune":"(","bracket":"open"}`
+	if string(data) != expected {
+		t.Errorf("got %s, expected %s", data, expected)
+	}
+
+	got := new(textparser.Token)
+	if err = json.Unmarshal(data, got); err != nil {
+		t.Fatalf("couldn't unmarshal token: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, token) {
+		t.Errorf("got %#v, expected %#v", got, token)
+	}
+}
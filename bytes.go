@@ -0,0 +1,146 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode/utf8"
+	"unsafe"
+)
+
+// The bytes read for tokens in byte mode (see ByteTokens).
+type byte_buffers struct {
+	scratch []byte    // Bytes of the runes read for the current token.
+	emitted [2][]byte // Alternating copies for scanned tokens.
+	idx     int
+
+	// The reader of the current token, and how much of its input had been
+	// read when the token started.
+	reader *rune_buffer
+	start  int
+}
+
+// Returns true if the tokens returned by Scan() are only valid until the next
+// call to Scan(), because ReuseToken or ByteTokens is set, so that callers
+// retaining tokens must copy them first with CloneMutable().
+func (ts *TokenScanner) TokensReused() bool {
+	return ts.ReuseToken || ts.ByteTokens
+}
+
+// Starts recording the runes of a new token.
+func (ts *TokenScanner) reset_bytes() {
+	ts.bytes.scratch = ts.bytes.scratch[:0]
+	ts.bytes.reader = ts.reader
+	ts.bytes.start = ts.reader.consumed
+}
+
+// Records a rune read for the current token.
+func (ts *TokenScanner) record_rune(ch rune) {
+	ts.bytes.scratch = utf8.AppendRune(ts.bytes.scratch, ch)
+}
+
+// Forgets the last rune recorded for the current token.
+func (ts *TokenScanner) unrecord_rune() {
+	_, size := utf8.DecodeLastRune(ts.bytes.scratch)
+	ts.bytes.scratch = ts.bytes.scratch[:len(ts.bytes.scratch)-size]
+}
+
+// Returns the runes as the text of a token. In byte mode, if the runes are
+// exactly those read for the token, the text refers to the bytes read
+// instead of being copied: to the input itself if it is held in memory (see
+// NewScannerFile()), or else to the bytes recorded for the token.
+func (ts *TokenScanner) token_text(runes []rune) string {
+	if !ts.ByteTokens {
+		return runes_to_string(runes)
+	}
+
+	b := ts.bytes.scratch
+	i := 0
+	for _, ch := range runes {
+		got, size := utf8.DecodeRune(b[i:])
+		if size == 0 || got != ch {
+			return runes_to_string(runes)
+		}
+		i += size
+	}
+
+	if i != len(b) || i == 0 {
+		return runes_to_string(runes)
+	}
+
+	// Invalid UTF-8 in the input is recorded as utf8.RuneError, which takes
+	// more bytes, so the input is only referred to if the lengths match.
+	if r := ts.bytes.reader; r == ts.reader && r.data != nil &&
+		r.consumed-ts.bytes.start == len(b) {
+		return unsafe.String(&r.data[ts.bytes.start], len(b))
+	}
+
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// Moves text referring to the bytes read for the current token to one of the
+// alternating buffers for scanned tokens, so that it stays valid until the
+// next call to Scan(), even if the token is restored by UnreadToken(), and
+// sets Token.Bytes.
+func (ts *TokenScanner) pin_bytes(token *Token) {
+	buf := &ts.bytes
+	if ts.refers_to_scratch(token.Text) || ts.refers_to_scratch(token.Raw) {
+		buf.idx ^= 1
+		emitted := append(buf.emitted[buf.idx][:0], buf.scratch...)
+		buf.emitted[buf.idx] = emitted
+
+		token.Text = ts.move_to(token.Text, emitted)
+		token.Raw = ts.move_to(token.Raw, emitted)
+	}
+
+	token.Bytes = unsafe.Slice(unsafe.StringData(token.Text), len(token.Text))
+}
+
+// Returns true if the string refers to the bytes read for the current token.
+func (ts *TokenScanner) refers_to_scratch(s string) bool {
+	b := ts.bytes.scratch
+	if s == "" || len(b) == 0 {
+		return false
+	}
+
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+	p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+
+	return p >= start && p < start+uintptr(len(b))
+}
+
+// Returns the string at the same offset in emitted if it refers to the bytes
+// read for the current token, which emitted is a copy of.
+func (ts *TokenScanner) move_to(s string, emitted []byte) string {
+	if !ts.refers_to_scratch(s) {
+		return s
+	}
+
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(ts.bytes.scratch)))
+	offset := int(uintptr(unsafe.Pointer(unsafe.StringData(s))) - start)
+
+	return unsafe.String(&emitted[offset], len(s))
+}
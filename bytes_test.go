@@ -0,0 +1,106 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestByteTokens(t *testing.T) {
+	input := "alpha = \"b\\\"c\" + 0x1F; // note\n" +
+		"été <= 'x' [1, 2.5]\n"
+
+	var expected []string
+	p := textparser.NewScannerString(input)
+	p.SetComments()
+	p.CollapseEscapes = true
+	for p.Scan() {
+		expected = append(expected, p.TokenText())
+	}
+
+	p = textparser.NewScannerString(input)
+	p.SetComments()
+	p.CollapseEscapes = true
+	p.ByteTokens = true
+	p.ReuseToken = true
+
+	var got []string
+	for p.Scan() {
+		token := p.Token()
+		if string(token.Bytes) != token.Text {
+			t.Errorf("got bytes %q for text %q", token.Bytes, token.Text)
+		}
+		got = append(got, strings.Clone(token.Text))
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestByteTokensRetained(t *testing.T) {
+	p := textparser.NewScannerString("one two three")
+	p.ByteTokens = true
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"one", "two", "three"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if !p.TokensReused() {
+		t.Errorf("expected TokensReused() with ByteTokens set")
+	}
+}
+
+func TestByteTokensUnread(t *testing.T) {
+	p := textparser.NewScannerString("first second")
+	p.ByteTokens = true
+
+	p.Scan()
+	p.Scan()
+	if err := p.UnreadToken(); err != nil {
+		t.Fatalf("couldn't unread: %s", err)
+	}
+
+	// The previous token is the skipped white space.
+	if got := p.TokenText(); got != " " {
+		t.Errorf("got %q after unread, expected %q", got, " ")
+	}
+
+	p.Scan()
+	if got := p.TokenText(); got != "second" {
+		t.Errorf("got %q after rescan, expected %q", got, "second")
+	}
+}
+
+func TestByteTokensAllocs(t *testing.T) {
+	input := strings.Repeat("alpha beta_2 gamma delta ", 200)
+
+	allocs := func(byte_tokens bool) float64 {
+		return testing.AllocsPerRun(10, func() {
+			p := textparser.NewScannerString(input)
+			p.ReuseToken = true
+			p.ByteTokens = byte_tokens
+			for p.Scan() {
+			}
+		})
+	}
+
+	with, without := allocs(true), allocs(false)
+	if with >= without {
+		t.Errorf("got %.0f allocations in byte mode, expected fewer than %.0f",
+			with, without)
+	}
+}
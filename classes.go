@@ -28,6 +28,7 @@ package textparser
 
 import (
 	"unicode"
+	"unicode/utf8"
 )
 
 // This function is the default value for the `IsEscapeRune` field in
@@ -53,6 +54,10 @@ func IsSymbolRune(ch rune, i int, runes []rune) bool {
 		return false
 	}
 
+	if ch < utf8.RuneSelf {
+		return ascii_props[ch]&(ascii_punct|ascii_symbol) != 0
+	}
+
 	if unicode.IsSymbol(ch) {
 		return true
 	}
@@ -68,6 +73,10 @@ func IsSymbolRune(ch rune, i int, runes []rune) bool {
 // `TokenScanner`. Where `i` is the index of `ch` in the current token parse,
 // and `runes` is the list of runes already excepted for the current token.
 func IsDigitRune(ch rune, i int, runes []rune) bool {
+	if ch < utf8.RuneSelf {
+		return ascii_props[ch]&ascii_digit != 0
+	}
+
 	return unicode.IsDigit(ch)
 }
 
@@ -75,6 +84,12 @@ func IsDigitRune(ch rune, i int, runes []rune) bool {
 // `TokenScanner`. Where `i` is the index of `ch` in the current token parse,
 // and `runes` is the list of runes already excepted for the current token.
 func IsIdentRune(ch rune, i int, runes []rune) bool {
+	if ch < utf8.RuneSelf {
+		props := ascii_props[ch]
+		return props&ascii_letter != 0 || ch == '_' ||
+			(i > 0 && props&ascii_digit != 0)
+	}
+
 	if unicode.IsLetter(ch) {
 		return true
 	}
@@ -150,9 +165,5 @@ func IsQuoteRune(ch rune) (bool, rune) {
 // `TokenScanner`. Where `i` is the index of `ch` in the current token parse,
 // and `runes` is the list of runes already excepted for the current token.
 func IsSpaceRune(ch rune, i int, runes []rune) bool {
-	if unicode.IsSpace(ch) {
-		return true
-	}
-
-	return false
+	return is_space(ch)
 }
@@ -0,0 +1,218 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// The class of the first rune of a token, determining which recognizer reads
+// the token.
+type rune_class int
+
+const (
+	class_none rune_class = iota
+	class_whitespace
+	class_comment
+	class_quoted
+	class_ident
+	class_number
+	class_symbol
+	class_unknown
+	class_directive
+	class_section
+	class_datetime
+	class_dsv // Delimited mode, which bypasses classification.
+	class_parameter
+	class_variable
+	class_markdown // Markdown inline mode, which bypasses classification.
+	class_address
+	class_url
+	class_continuation
+	class_custom // A token read by a matcher added with SetMatchers().
+)
+
+// Order in which classes are checked by classify(), unless changed with
+// SetMatchers().
+var class_order = []rune_class{class_directive, class_continuation,
+	class_whitespace, class_comment, class_section, class_quoted, class_parameter,
+	class_variable, class_url, class_address, class_datetime, class_ident,
+	class_number, class_symbol}
+
+// Returns the name of the recognizer for the class.
+func (c rune_class) String() string {
+	names := [...]string{"none", "whitespace", "comment", "quoted", "ident",
+		"number", "symbol", "unknown", "directive", "section", "datetime",
+		"delimited", "parameter", "variable", "markdown", "address", "url",
+		"continuation", "custom"}
+	if c < 0 || int(c) > len(names)-1 {
+		return ""
+	}
+
+	return names[c]
+}
+
+// Returns the first matcher in the chain matching the next rune in the input,
+// without consuming it, or nil if none does. See Scan() for the default
+// order.
+func (ts *TokenScanner) classify() (TokenMatcher, error) {
+	ch, _, err := ts.get_one_rune()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ts.unread_rune(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range ts.matchers {
+		// The built-in matchers are checked without an interface call.
+		if c, ok := m.(class_matcher); ok {
+			if ts.class_matches(rune_class(c), ch) {
+				return m, nil
+			}
+			continue
+		}
+
+		if m.Match(ts, ch) {
+			return m, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Returns true if a token of the class starts with ch, the next rune in the
+// input.
+func (ts *TokenScanner) class_matches(class rune_class, ch rune) bool {
+	switch class {
+	case class_directive:
+		return ts.is_line_directive_start()
+	case class_continuation:
+		return len(ts.continuations) > 0 && ts.continuation_len() > 0
+	case class_whitespace:
+		return ts.IsSpaceRune(ch, 0, nil)
+	case class_comment:
+		return ts.match_comment(ch) != nil
+	case class_section:
+		return ch == '[' && ts.SectionHeaders && ts.line_blank
+	case class_quoted:
+		ok, _ := ts.IsQuoteRune(ch)
+		return ok || ts.match_string_prefix() != nil
+	case class_parameter:
+		return ts.is_parameter_start()
+	case class_variable:
+		return ts.is_variable_start()
+	case class_url:
+		return ts.url_len() > 0
+	case class_address:
+		return ts.address_len() > 0
+	case class_datetime:
+		return ts.DateTimes && (len(ts.datetime_layouts) > 0 ||
+			is_ascii_digit(ch)) && ts.datetime_len() > 0
+	case class_ident:
+		return ts.IsIdentRune(ch, 0, nil) ||
+			(ts.DisableNumbers && ts.IsDigitRune(ch, 0, nil))
+	case class_number:
+		if ts.DisableNumbers {
+			return false
+		}
+		if ts.IsDigitRune(ch, 0, nil) {
+			return true
+		}
+		return ts.is_number_sign(ch) && ts.sign_starts_number() &&
+			ts.check_next_rune_class_n(ts.IsDigitRune, 2)
+	case class_symbol:
+		return ts.IsSymbolRune(ch, 0, nil)
+	}
+
+	return false
+}
+
+// Reads a token with the recognizer for the class.
+func (ts *TokenScanner) read_class(class rune_class) (*Token, error) {
+	switch class {
+	case class_dsv:
+		return ts.get_dsv()
+	case class_markdown:
+		return ts.get_markdown()
+	case class_continuation:
+		return ts.get_continuation()
+	case class_whitespace:
+		return ts.get_whitespace()
+	case class_comment:
+		return ts.get_comment()
+	case class_section:
+		return ts.get_section()
+	case class_datetime:
+		return ts.get_datetime()
+	case class_directive:
+		return ts.get_line_directive()
+	case class_quoted:
+		return ts.get_quoted()
+	case class_parameter:
+		return ts.get_parameter()
+	case class_variable:
+		return ts.get_variable()
+	case class_url:
+		return ts.get_url()
+	case class_address:
+		return ts.get_address()
+	case class_ident:
+		return ts.get_ident()
+	case class_number:
+		return ts.get_number()
+	case class_symbol:
+		return ts.get_symbol()
+	}
+
+	return ts.get_unknown()
+}
+
+// Returns true if the scanner is configured to recognize the class.
+func (ts *TokenScanner) class_enabled(class rune_class) bool {
+	switch class {
+	case class_directive:
+		return ts.line_directive_prefix != nil
+	case class_comment:
+		return len(ts.comments) > 0
+	case class_section:
+		return ts.SectionHeaders
+	case class_datetime:
+		return ts.DateTimes
+	case class_parameter:
+		return ts.ParameterPrefixes != ""
+	case class_variable:
+		return ts.Variables
+	case class_address:
+		return ts.Addresses
+	case class_url:
+		return ts.URLs
+	case class_continuation:
+		return len(ts.continuations) > 0
+	case class_number:
+		return !ts.DisableNumbers
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A stable, machine-readable code identifying a kind of scan error or
+// diagnostic, e.g., for suppression comments, CI baselines, and
+// documentation links. Codes are never renumbered or reused; String()
+// returns the code in the form "E001", and Name() a descriptive name, e.g.,
+// "UnterminatedString".
+type ErrorCode int
+
+// Error codes. The values are part of the API and never change.
+const (
+	// No error.
+	CodeNone ErrorCode = -1
+
+	// An error without a more specific code, e.g., a read error.
+	CodeOther ErrorCode = 0
+
+	CodeUnterminatedString         ErrorCode = 1
+	CodeUnterminatedComment        ErrorCode = 2
+	CodeUnterminatedSection        ErrorCode = 3
+	CodeUnterminatedQuotedField    ErrorCode = 4
+	CodeUnexpectedAfterQuotedField ErrorCode = 5
+	CodeUnrecognizedCharacter      ErrorCode = 6
+	CodeTagSyntax                  ErrorCode = 7
+	CodeUnbalancedBracket          ErrorCode = 8
+	CodeTagValue                   ErrorCode = 9
+	CodeUnterminatedVariable       ErrorCode = 10
+	CodeMalformedEntry             ErrorCode = 11
+	CodeSyntax                     ErrorCode = 12
+	CodeIndentation                ErrorCode = 13
+	CodeDeadlineExceeded           ErrorCode = 14
+	CodeUnbalancedRegion           ErrorCode = 15
+	CodeTokenTooLong               ErrorCode = 16
+)
+
+var error_code_names = map[ErrorCode]string{
+	CodeNone:                       "None",
+	CodeOther:                      "Other",
+	CodeUnterminatedString:         "UnterminatedString",
+	CodeUnterminatedComment:        "UnterminatedComment",
+	CodeUnterminatedSection:        "UnterminatedSection",
+	CodeUnterminatedQuotedField:    "UnterminatedQuotedField",
+	CodeUnexpectedAfterQuotedField: "UnexpectedAfterQuotedField",
+	CodeUnrecognizedCharacter:      "UnrecognizedCharacter",
+	CodeTagSyntax:                  "TagSyntax",
+	CodeUnbalancedBracket:          "UnbalancedBracket",
+	CodeTagValue:                   "TagValue",
+	CodeUnterminatedVariable:       "UnterminatedVariable",
+	CodeMalformedEntry:             "MalformedEntry",
+	CodeSyntax:                     "Syntax",
+	CodeIndentation:                "Indentation",
+	CodeDeadlineExceeded:           "DeadlineExceeded",
+	CodeUnbalancedRegion:           "UnbalancedRegion",
+	CodeTokenTooLong:               "TokenTooLong",
+}
+
+// Returns the code in the form "E001", or the empty string for CodeNone.
+func (c ErrorCode) String() string {
+	if c == CodeNone {
+		return ""
+	}
+
+	return fmt.Sprintf("E%03d", int(c))
+}
+
+// Returns the descriptive name of the code, e.g., "UnterminatedString".
+func (c ErrorCode) Name() string {
+	if name, ok := error_code_names[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+// Returns the error code for a string in the form "E001" or a name, e.g.,
+// "UnterminatedString", as in suppression comments.
+func ParseErrorCode(s string) (ErrorCode, error) {
+	if len(s) > 1 && (s[0] == 'E' || s[0] == 'e') {
+		if num, err := strconv.Atoi(s[1:]); err == nil && num >= 0 {
+			return ErrorCode(num), nil
+		}
+	}
+
+	for code, name := range error_code_names {
+		if strings.EqualFold(name, s) {
+			return code, nil
+		}
+	}
+
+	return CodeNone, fmt.Errorf("unknown error code %q", s)
+}
+
+// Returns the code of the error: the Code of a ScanError (anywhere in the
+// chain), CodeOther for any other error, or CodeNone for nil.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeNone
+	}
+
+	var scan_err *ScanError
+	if errors.As(err, &scan_err) {
+		return scan_err.Code
+	}
+
+	return CodeOther
+}
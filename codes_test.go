@@ -0,0 +1,84 @@
+package textparser_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestErrorCodes(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Profile  *textparser.Profile
+		Input    string
+		Expected textparser.ErrorCode
+	}
+
+	unknown_error := &textparser.Profile{
+		Name: "unknown error",
+		Configure: func(ts *textparser.TokenScanner) {
+			ts.Unknown = textparser.UnknownError
+		},
+	}
+
+	test_list := []TestData{
+		{"none", nil, "a b", textparser.CodeNone},
+		{"string", nil, `a "b`, textparser.CodeUnterminatedString},
+		{"comment", nil, "a /* b", textparser.CodeUnterminatedComment},
+		{"section", textparser.ProfileINI(), "[a\n",
+			textparser.CodeUnterminatedSection},
+		{"quoted field", textparser.ProfileCSV(), `"a`,
+			textparser.CodeUnterminatedQuotedField},
+		{"after quoted field", textparser.ProfileCSV(), `"a"b`,
+			textparser.CodeUnexpectedAfterQuotedField},
+		{"unrecognized", unknown_error, "a \x00",
+			textparser.CodeUnrecognizedCharacter},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := test_data.Profile.NewScanner(
+				strings.NewReader(test_data.Input))
+			_, err := textparser.TokenizeAll(p)
+
+			code := textparser.ErrorCodeOf(err)
+			if code != test_data.Expected {
+				st.Errorf("got %s (%v), expected %s", code.Name(), err,
+					test_data.Expected.Name())
+			}
+		})
+	}
+}
+
+func TestErrorCodeNames(t *testing.T) {
+	code := textparser.CodeUnterminatedString
+	if code.String() != "E001" || code.Name() != "UnterminatedString" {
+		t.Errorf("got %s %s, expected E001 UnterminatedString", code,
+			code.Name())
+	}
+
+	for _, s := range []string{"E001", "e1", "UnterminatedString",
+		"unterminatedstring"} {
+		got, err := textparser.ParseErrorCode(s)
+		if err != nil || got != code {
+			t.Errorf("%q: got %s (%v), expected %s", s, got, err, code)
+		}
+	}
+
+	if _, err := textparser.ParseErrorCode("bogus"); err == nil {
+		t.Errorf("expected an error for an unknown code")
+	}
+
+	if code := textparser.ErrorCodeOf(fmt.Errorf("wrapped: %w",
+		errors.New("plain"))); code != textparser.CodeOther {
+		t.Errorf("got %s, expected %s", code, textparser.CodeOther)
+	}
+
+	_, err := textparser.ParseTagSpec("a,")
+	if code := textparser.ErrorCodeOf(err); code != textparser.CodeTagSyntax {
+		t.Errorf("got %s, expected %s", code, textparser.CodeTagSyntax)
+	}
+}
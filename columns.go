@@ -0,0 +1,233 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode"
+)
+
+// Controls how columns are counted in Position.
+type ColumnMode int
+
+// Supported column modes.
+const (
+	// Each rune counts as one column.
+	ColumnRunes ColumnMode = iota
+
+	// Each extended grapheme cluster counts as one column, so that, e.g., a
+	// letter followed by combining marks, or an emoji ZWJ sequence, is a
+	// single column, as an editor would display it.
+	ColumnGraphemes
+
+	// Each byte of input counts as one column, so that the column is the
+	// byte offset within the line plus 1, e.g., for `grep -b` pipelines.
+	// With an encoding other than UTF-8 (see NewScannerEncoding()), bytes
+	// of the original encoding are counted.
+	ColumnBytes
+
+	// Each UTF-16 code unit counts as one column, so that runes outside the
+	// Basic Multilingual Plane count as two, as in the Language Server
+	// Protocol's default position encoding.
+	ColumnUTF16
+)
+
+// Returns a string representation of the column mode.
+func (m ColumnMode) String() string {
+	names := [...]string{"Runes", "Graphemes", "Bytes", "UTF16"}
+	if m < 0 || int(m) > len(names)-1 {
+		return ""
+	}
+
+	return names[m]
+}
+
+// State carried between runes to find grapheme cluster boundaries.
+type grapheme_state struct {
+	prev          rune
+	ri_count      int  // Number of consecutive regional indicators.
+	pictographic  bool // Last non-extending rune was pictographic.
+	after_zwj     bool // Previous rune was a ZWJ after a pictograph.
+	has_prev_rune bool
+}
+
+// Updates the line and column to account for the rune, which was just
+// accepted into the current token.
+func (ts *TokenScanner) advance_position(ch rune, size int) {
+	if ch == ts.eol {
+		ts.last_line_addition++
+		ts.last_col = 1
+		ts.grapheme = grapheme_state{}
+		ts.line_blank = true
+		return
+	}
+
+	if ts.line_blank && !is_space(ch) {
+		ts.line_blank = false
+	}
+
+	ts.last_col += column_width(ts.ColumnMode, ch, size, &ts.grapheme)
+}
+
+// Returns the number of columns taken by the rune, of the given size in
+// bytes, according to the column mode.
+func column_width(mode ColumnMode, ch rune, size int, g *grapheme_state) int {
+	switch mode {
+	case ColumnGraphemes:
+		if !g.is_boundary(ch) {
+			return 0
+		}
+	case ColumnBytes:
+		return size
+	case ColumnUTF16:
+		if ch >= 0x10000 && ch <= unicode.MaxRune {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+const zwj = 0x200D
+
+func is_grapheme_extend(ch rune) bool {
+	if ch == zwj {
+		return true
+	}
+
+	// Emoji skin tone modifiers.
+	if ch >= 0x1F3FB && ch <= 0x1F3FF {
+		return true
+	}
+
+	return unicode.In(ch, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func is_regional_indicator(ch rune) bool {
+	return ch >= 0x1F1E6 && ch <= 0x1F1FF
+}
+
+// Approximates the Extended_Pictographic property.
+func is_pictographic(ch rune) bool {
+	switch {
+	case ch >= 0x1F000 && ch <= 0x1FAFF:
+		return true
+	case ch >= 0x2600 && ch <= 0x27BF:
+		return true
+	case ch == 0x00A9 || ch == 0x00AE || ch == 0x203C || ch == 0x2049:
+		return true
+	}
+
+	return unicode.Is(unicode.So, ch) && ch > 0x2100
+}
+
+// Hangul syllable types, for the rules keeping syllables together.
+func hangul_type(ch rune) byte {
+	switch {
+	case ch >= 0x1100 && ch <= 0x115F, ch >= 0xA960 && ch <= 0xA97C:
+		return 'L'
+	case ch >= 0x1160 && ch <= 0x11A7, ch >= 0xD7B0 && ch <= 0xD7C6:
+		return 'V'
+	case ch >= 0x11A8 && ch <= 0x11FF, ch >= 0xD7CB && ch <= 0xD7FB:
+		return 'T'
+	case ch >= 0xAC00 && ch <= 0xD7A3:
+		if (ch-0xAC00)%28 == 0 {
+			return 'v' // LV
+		}
+		return 't' // LVT
+	}
+
+	return 0
+}
+
+// Returns true if a grapheme cluster boundary falls before the rune, and
+// records the rune for the next call. This implements the main rules of
+// Unicode Standard Annex #29, omitting prepended concatenation marks.
+func (g *grapheme_state) is_boundary(ch rune) bool {
+	prev, had_prev := g.prev, g.has_prev_rune
+	after_zwj := g.after_zwj
+
+	g.prev = ch
+	g.has_prev_rune = true
+	g.after_zwj = ch == zwj && g.pictographic
+
+	if !had_prev {
+		g.update_runs(ch)
+		return true
+	}
+
+	// CR LF.
+	if prev == '\r' && ch == '\n' {
+		return false
+	}
+
+	if is_grapheme_extend(ch) {
+		return false
+	}
+
+	// Hangul syllable sequences.
+	switch hangul_type(prev) {
+	case 'L':
+		if t := hangul_type(ch); t == 'L' || t == 'V' || t == 'v' ||
+			t == 't' {
+			return false
+		}
+	case 'V', 'v':
+		if t := hangul_type(ch); t == 'V' || t == 'T' {
+			return false
+		}
+	case 'T', 't':
+		if hangul_type(ch) == 'T' {
+			return false
+		}
+	}
+
+	// Emoji ZWJ sequences.
+	if after_zwj && is_pictographic(ch) {
+		g.update_runs(ch)
+		return false
+	}
+
+	// Regional indicators pair up into flags.
+	if is_regional_indicator(ch) && g.ri_count%2 == 1 {
+		g.ri_count++
+		return false
+	}
+
+	g.update_runs(ch)
+
+	return true
+}
+
+func (g *grapheme_state) update_runs(ch rune) {
+	if is_regional_indicator(ch) {
+		g.ri_count++
+	} else {
+		g.ri_count = 0
+	}
+
+	g.pictographic = is_pictographic(ch)
+}
@@ -0,0 +1,89 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"testing"
+)
+
+func TestColumnGraphemes(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Runes    int // Column of the last token, counting runes.
+		Clusters int // Column of the last token, counting graphemes.
+	}{
+		{"ascii", `foo = x`, 7, 7},
+		{"combining mark", "cafe\u0301 = x", 9, 8},
+		{"emoji ZWJ sequence",
+			"'\U0001F468\u200d\U0001F469\u200d\U0001F467' x", 9, 5},
+		{"skin tone modifier", "'\U0001F44D\U0001F3FD' x", 6, 5},
+		{"flags", "'\U0001F1FA\U0001F1F8\U0001F1EB\U0001F1F7' x", 8, 6},
+		{"hangul jamo", "'\u1100\u1161\u11a8' x", 7, 5},
+		{"after newline", "cafe\u0301\n x", 2, 2},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			for _, mode := range []textparser.ColumnMode{
+				textparser.ColumnRunes, textparser.ColumnGraphemes} {
+				p := textparser.NewScannerString(test_data.Input)
+				p.ColumnMode = mode
+
+				col := 0
+				for p.Scan() {
+					col = p.Position().Column
+				}
+
+				expected := test_data.Runes
+				if mode == textparser.ColumnGraphemes {
+					expected = test_data.Clusters
+				}
+
+				if col != expected {
+					st.Errorf("%s: got column %d, expected %d", mode, col,
+						expected)
+				}
+			}
+		})
+	}
+}
+
+func TestColumnBytesAndUTF16(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Bytes int // Column of the last token, counting bytes.
+		UTF16 int // Column of the last token, counting UTF-16 code units.
+	}{
+		{"ascii", `foo = x`, 7, 7},
+		{"combining mark", "cafe\u0301 = x", 10, 9},
+		{"CJK", "\u65e5\u672c x", 8, 4},
+		{"astral", "'\U0001F44D\U0001F3FD' x", 12, 8},
+		{"after newline", "\u65e5\n x", 2, 2},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			for _, mode := range []textparser.ColumnMode{
+				textparser.ColumnBytes, textparser.ColumnUTF16} {
+				p := textparser.NewScannerString(test_data.Input)
+				p.ColumnMode = mode
+
+				col := 0
+				for p.Scan() {
+					col = p.Position().Column
+				}
+
+				expected := test_data.Bytes
+				if mode == textparser.ColumnUTF16 {
+					expected = test_data.UTF16
+				}
+
+				if col != expected {
+					st.Errorf("%s: got column %d, expected %d", mode, col,
+						expected)
+				}
+			}
+		})
+	}
+}
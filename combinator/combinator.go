@@ -0,0 +1,423 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package combinator provides parser combinators over the tokens of a
+// textparser.TokenScanner, so that small grammars can be declared rather
+// than written by hand, e.g.,
+//
+//	assign := combinator.Seq(combinator.Type(textparser.TokenTypeIdent),
+//	    combinator.Text("="), value)
+//
+// Alternatives backtrack automatically: the scanner can only unread a
+// single token, so tokens are buffered by an Input, which can be reset to
+// any earlier token. When parsing fails, the error reports the furthest
+// position reached, along with everything that was expected there.
+package combinator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// A parser, which either succeeds, consuming tokens and returning a value,
+// or fails, returning false. After a failure, callers reset the input to
+// where the parser started (see Input.Mark()); the combinators in this
+// package do so.
+type Parser func(in *Input) (interface{}, bool)
+
+// Tokens from a scanner, buffered so that parsers can backtrack.
+type Input struct {
+	ts     *textparser.TokenScanner
+	tokens []*textparser.ScannedToken
+	pos    int
+	done   bool  // The scanner has no more tokens.
+	err    error // A scan error, which ends parsing.
+
+	fail failure // The furthest failure so far.
+}
+
+// A failure to find what was expected.
+type failure struct {
+	pos      textparser.Position
+	token    *textparser.ScannedToken // The token found, or nil at the end.
+	expected []string
+	failed   bool
+}
+
+// Returns an input reading tokens from the scanner.
+func NewInput(ts *textparser.TokenScanner) *Input {
+	return &Input{ts: ts}
+}
+
+// Returns the next token without consuming it, or nil at the end of the
+// input or after a scan error.
+func (in *Input) Peek() *textparser.ScannedToken {
+	for in.pos >= len(in.tokens) && !in.done {
+		if !in.ts.Scan() {
+			in.done = true
+			if err := in.ts.Err(); err != nil && err != io.EOF {
+				in.err = err
+			}
+			break
+		}
+
+		token := in.ts.ScannedToken()
+		if in.ts.TokensReused() {
+			token.Token = token.Token.CloneMutable()
+		}
+		in.tokens = append(in.tokens, token)
+	}
+
+	if in.pos < len(in.tokens) {
+		return in.tokens[in.pos]
+	}
+
+	return nil
+}
+
+// Returns the next token and consumes it, or returns nil at the end of the
+// input.
+func (in *Input) Next() *textparser.ScannedToken {
+	token := in.Peek()
+	if token != nil {
+		in.pos++
+	}
+
+	return token
+}
+
+// Returns a mark for the current position, to pass to Reset().
+func (in *Input) Mark() int {
+	return in.pos
+}
+
+// Moves back (or forward) to a position returned by Mark().
+func (in *Input) Reset(mark int) {
+	in.pos = mark
+}
+
+// Returns the scan error that ended the input, if any.
+func (in *Input) Err() error {
+	return in.err
+}
+
+// Records that what was expected, e.g., `"="` or "identifier", was not
+// found at the current position, for the error returned by Parse(). Only
+// failures at the furthest position are kept.
+func (in *Input) Fail(expected string) {
+	pos, token := in.position()
+	f := &in.fail
+
+	switch {
+	case !f.failed || pos.Offset > f.pos.Offset:
+		*f = failure{pos: pos, token: token, expected: []string{expected},
+			failed: true}
+	case pos.Offset == f.pos.Offset:
+		for _, e := range f.expected {
+			if e == expected {
+				return
+			}
+		}
+		f.expected = append(f.expected, expected)
+	}
+}
+
+// Returns the position of the next token and the token, or the position
+// just past the last token and nil at the end of the input.
+func (in *Input) position() (textparser.Position, *textparser.ScannedToken) {
+	if token := in.Peek(); token != nil {
+		return token.Start, token
+	}
+
+	if len(in.tokens) > 0 {
+		return in.tokens[len(in.tokens)-1].End, nil
+	}
+
+	return *in.ts.EndPosition(), nil
+}
+
+// Returns the error for the furthest failure, a ScanError with
+// textparser.CodeSyntax, e.g.,
+//
+//	Expected "=" or ":" at config.txt:3:5 (31). Found "{".
+func (in *Input) Error() error {
+	if in.err != nil {
+		return in.err
+	}
+
+	f := &in.fail
+	if !f.failed {
+		return nil
+	}
+
+	err := &textparser.ScanError{
+		Msg:   "Expected " + join_alternatives(f.expected),
+		Start: f.pos,
+		End:   f.pos,
+		Code:  textparser.CodeSyntax,
+	}
+	if f.token != nil {
+		err.Detail = fmt.Sprintf("Found %q.", f.token.Text)
+	} else {
+		err.Detail = "Reached end of input."
+		err.Err = io.EOF
+	}
+
+	return err
+}
+
+// Returns the list in the form "a", "a or b", or "a, b, or c".
+func join_alternatives(list []string) string {
+	switch len(list) {
+	case 1:
+		return list[0]
+	case 2:
+		return list[0] + " or " + list[1]
+	}
+
+	return strings.Join(list[:len(list)-1], ", ") + ", or " +
+		list[len(list)-1]
+}
+
+// Runs the parser on all remaining tokens from the scanner, failing unless
+// it consumes them all. Returns the value, or the error for the furthest
+// failure (see Input.Error()), or a scan error.
+func Parse(p Parser, ts *textparser.TokenScanner) (interface{}, error) {
+	in := NewInput(ts)
+
+	value, ok := p(in)
+	if ok && in.Peek() != nil {
+		in.Fail("end of input")
+		ok = false
+	}
+
+	if in.err != nil {
+		return nil, in.err
+	}
+	if !ok {
+		return nil, in.Error()
+	}
+
+	return value, nil
+}
+
+// Returns a parser matching one token of the given type, e.g.,
+// textparser.TokenTypeIdent, with the token as its value.
+func Type(token_type textparser.TokenType) Parser {
+	name := strings.ToLower(token_type.String())
+
+	return Token(name, func(token *textparser.ScannedToken) bool {
+		return token.Type == token_type
+	})
+}
+
+// Returns a parser matching one token with the given text, e.g., "=" or
+// "while", with the token as its value. Quoted strings never match.
+func Text(text string) Parser {
+	return Token(fmt.Sprintf("%q", text),
+		func(token *textparser.ScannedToken) bool {
+			return token.Text == text &&
+				token.Type != textparser.TokenTypeString
+		})
+}
+
+// Returns a parser matching one token accepted by the predicate, with the
+// token as its value. The name describes the expected token in errors.
+func Token(name string, accept func(*textparser.ScannedToken) bool) Parser {
+	return func(in *Input) (interface{}, bool) {
+		token := in.Peek()
+		if token == nil || !accept(token) {
+			in.Fail(name)
+			return nil, false
+		}
+
+		in.pos++
+
+		return token, true
+	}
+}
+
+// Returns a parser matching the parsers one after another, with a
+// []interface{} of their values as its value.
+func Seq(parsers ...Parser) Parser {
+	return func(in *Input) (interface{}, bool) {
+		mark := in.Mark()
+		values := make([]interface{}, 0, len(parsers))
+
+		for _, p := range parsers {
+			value, ok := p(in)
+			if !ok {
+				in.Reset(mark)
+				return nil, false
+			}
+			values = append(values, value)
+		}
+
+		return values, true
+	}
+}
+
+// Returns a parser matching the first of the parsers that matches, trying
+// each from the same position, with the value of that parser.
+func Choice(parsers ...Parser) Parser {
+	return func(in *Input) (interface{}, bool) {
+		mark := in.Mark()
+
+		for _, p := range parsers {
+			if value, ok := p(in); ok {
+				return value, true
+			}
+			in.Reset(mark)
+			if in.err != nil {
+				break
+			}
+		}
+
+		return nil, false
+	}
+}
+
+// Returns a parser matching the parser zero or more times, as often as it
+// matches, with a []interface{} of its values as its value. Matching stops
+// if the parser matches without consuming any tokens.
+func Many(p Parser) Parser {
+	return func(in *Input) (interface{}, bool) {
+		values := []interface{}{}
+
+		for {
+			mark := in.Mark()
+			value, ok := p(in)
+			if !ok {
+				in.Reset(mark)
+				return values, in.err == nil
+			}
+			values = append(values, value)
+
+			if in.Mark() == mark {
+				return values, true
+			}
+		}
+	}
+}
+
+// Returns a parser matching the parser one or more times (see Many()).
+func Many1(p Parser) Parser {
+	return Map(Seq(p, Many(p)), func(v interface{}) interface{} {
+		pair := v.([]interface{})
+		return append([]interface{}{pair[0]}, pair[1].([]interface{})...)
+	})
+}
+
+// Returns a parser matching the parser or nothing, with nil as its value in
+// the latter case.
+func Opt(p Parser) Parser {
+	return func(in *Input) (interface{}, bool) {
+		mark := in.Mark()
+		if value, ok := p(in); ok {
+			return value, true
+		}
+		in.Reset(mark)
+
+		return nil, in.err == nil
+	}
+}
+
+// Returns a parser matching the parser, with its value converted by f.
+func Map(p Parser, f func(interface{}) interface{}) Parser {
+	return func(in *Input) (interface{}, bool) {
+		value, ok := p(in)
+		if !ok {
+			return nil, false
+		}
+
+		return f(value), true
+	}
+}
+
+// Returns a parser matching items separated by sep, e.g., the arguments of
+// a call, with a []interface{} of the values of the items as its value. It
+// matches zero items if the first does not match.
+func SepBy(item, sep Parser) Parser {
+	rest := Many(Map(Seq(sep, item), func(v interface{}) interface{} {
+		return v.([]interface{})[1]
+	}))
+
+	return Map(Opt(Seq(item, rest)), func(v interface{}) interface{} {
+		if v == nil {
+			return []interface{}{}
+		}
+		pair := v.([]interface{})
+		return append([]interface{}{pair[0]}, pair[1].([]interface{})...)
+	})
+}
+
+// Returns a parser that calls the parser returned by f, which is only
+// called once, when first needed. Use it for recursive grammars, where a
+// parser refers to itself, e.g.,
+//
+//	var expr combinator.Parser
+//	expr = combinator.Choice(number,
+//	    combinator.Seq(combinator.Text("("),
+//	        combinator.Lazy(func() combinator.Parser { return expr }),
+//	        combinator.Text(")")))
+func Lazy(f func() Parser) Parser {
+	var p Parser
+
+	return func(in *Input) (interface{}, bool) {
+		if p == nil {
+			p = f()
+		}
+
+		return p(in)
+	}
+}
+
+// Returns a parser matching the parser, reporting a failure where it
+// started as the name, e.g., "expression", instead of the failures inside
+// it, unless they got further.
+func Label(name string, p Parser) Parser {
+	return func(in *Input) (interface{}, bool) {
+		mark := in.Mark()
+		saved := in.fail
+		saved.expected = append([]string(nil), saved.expected...)
+
+		value, ok := p(in)
+		if ok {
+			return value, true
+		}
+
+		in.Reset(mark)
+		if pos, _ := in.position(); in.fail.pos.Offset <= pos.Offset {
+			in.fail = saved
+			in.Fail(name)
+		}
+
+		return nil, false
+	}
+}
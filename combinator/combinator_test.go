@@ -0,0 +1,144 @@
+package combinator_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+	c "github.com/cuberat/go-textparser/combinator"
+)
+
+// Returns a parser for lists of assignments, e.g., "a = f(1, (2)); b = x",
+// with a value of strings in the form "a=f(1,2)".
+func assignments() c.Parser {
+	ident := c.Type(textparser.TokenTypeIdent)
+	number := c.Type(textparser.TokenTypeInt)
+
+	text := func(v interface{}) string {
+		switch v := v.(type) {
+		case *textparser.ScannedToken:
+			return v.Text
+		case string:
+			return v
+		}
+		return fmt.Sprint(v)
+	}
+
+	var value c.Parser
+	value_ref := c.Lazy(func() c.Parser { return value })
+
+	call := c.Map(c.Seq(ident, c.Text("("),
+		c.SepBy(value_ref, c.Text(",")), c.Text(")")),
+		func(v interface{}) interface{} {
+			parts := v.([]interface{})
+			var args []string
+			for _, arg := range parts[2].([]interface{}) {
+				args = append(args, text(arg))
+			}
+			return text(parts[0]) + "(" + strings.Join(args, ",") + ")"
+		})
+
+	group := c.Map(c.Seq(c.Text("("), value_ref, c.Text(")")),
+		func(v interface{}) interface{} {
+			return v.([]interface{})[1]
+		})
+
+	value = c.Label("value", c.Choice(call, ident, number, group))
+
+	assign := c.Map(c.Seq(ident, c.Text("="), value, c.Opt(c.Text(";"))),
+		func(v interface{}) interface{} {
+			parts := v.([]interface{})
+			return text(parts[0]) + "=" + text(parts[2])
+		})
+
+	return c.Many(assign)
+}
+
+func TestParse(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []interface{}
+	}
+
+	test_list := []TestData{
+		{"empty", "", []interface{}{}},
+		{"assignments", "a = f(1, (2)); b = x c = g()",
+			[]interface{}{"a=f(1,2)", "b=x", "c=g()"}},
+		{"backtracking", "a = f b = (f)",
+			[]interface{}{"a=f", "b=f"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScannerString(test_data.Input)
+
+			got, err := c.Parse(assignments(), ts)
+			if err != nil {
+				st.Fatalf("got error %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{"missing value", "a = ;",
+			`Expected value at :1:5 (4). Found ";".`},
+		{"furthest failure", "a = f(1 2)",
+			`Expected "," or ")" at :1:9 (8). Found "2".`},
+		{"end of input", "a = f(1,",
+			"Expected value at :1:9 (8). Reached end of input."},
+		{"trailing tokens", "a = 1 2",
+			`Expected ";", ident, or end of input at :1:7 (6). ` +
+				`Found "2".`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScannerString(test_data.Input)
+
+			_, err := c.Parse(assignments(), ts)
+			if err == nil {
+				st.Fatalf("got no error, expected %q", test_data.Expected)
+			}
+
+			if got := err.Error(); got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+			if code := textparser.ErrorCodeOf(err); code !=
+				textparser.CodeSyntax {
+				st.Errorf("got code %s, expected %s", code.Name(),
+					textparser.CodeSyntax.Name())
+			}
+		})
+	}
+}
+
+func TestMany1(t *testing.T) {
+	p := c.Many1(c.Type(textparser.TokenTypeInt))
+
+	if _, err := c.Parse(p, textparser.NewScannerString("")); err == nil {
+		t.Errorf("got no error for empty input")
+	}
+
+	got, err := c.Parse(p, textparser.NewScannerString("1 2"))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if n := len(got.([]interface{})); n != 2 {
+		t.Errorf("got %d values, expected 2", n)
+	}
+}
@@ -0,0 +1,204 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A kind of comment recognized by a scanner.
+type CommentStyle struct {
+	Start string // Opening delimiter, e.g., "//", "/*", "#", or "--".
+	End   string // Closing delimiter, or "" if the comment ends at the EOL.
+}
+
+// Returns a string representation of the style, e.g., "//" or "/* */".
+func (c CommentStyle) String() string {
+	if c.End == "" {
+		return c.Start
+	}
+
+	return c.Start + " " + c.End
+}
+
+// Comment styles recognized by default.
+var DefaultCommentStyles = []CommentStyle{
+	{Start: "//"},
+	{Start: "/*", End: "*/"},
+}
+
+type comment_delims struct {
+	start []rune
+	end   []rune
+}
+
+// Sets the kinds of comments recognized, replacing the defaults (see
+// DefaultCommentStyles). With no styles, nothing is treated as a comment.
+// Where the start of one style is a prefix of another, the longer one wins.
+func (ts *TokenScanner) SetComments(styles ...CommentStyle) {
+	ts.comments = make([]comment_delims, 0, len(styles))
+	for _, style := range styles {
+		if style.Start == "" {
+			continue
+		}
+		ts.comments = append(ts.comments, comment_delims{
+			start: []rune(style.Start),
+			end:   []rune(style.End),
+		})
+	}
+
+	sort.SliceStable(ts.comments, func(i, j int) bool {
+		return len(ts.comments[i].start) > len(ts.comments[j].start)
+	})
+}
+
+// Returns the comment styles recognized by the scanner.
+func (ts *TokenScanner) CommentStyles() []CommentStyle {
+	styles := make([]CommentStyle, 0, len(ts.comments))
+	for _, c := range ts.comments {
+		styles = append(styles, CommentStyle{Start: string(c.start),
+			End: string(c.end)})
+	}
+
+	return styles
+}
+
+// Returns the comment style starting at the next rune, ch, or nil if none
+// does, without consuming any input.
+func (ts *TokenScanner) match_comment(ch rune) *comment_delims {
+	for i := range ts.comments {
+		c := &ts.comments[i]
+		if c.start[0] == ch && ts.peek_prefix(c.start) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Returns true if the upcoming input starts with the runes, without
+// consuming any input.
+func (ts *TokenScanner) peek_prefix(prefix []rune) bool {
+	runes, err := ts.peek_multirune(len(prefix))
+	if err != nil || len(runes) < len(prefix) {
+		return false
+	}
+
+	for i, ch := range prefix {
+		if runes[i] != ch {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (ts *TokenScanner) get_comment() (*Token, error) {
+	ch, err := ts.peek_rune()
+	if err != nil {
+		return nil, err
+	}
+
+	style := ts.match_comment(ch)
+	if style == nil {
+		return nil, nil
+	}
+
+	start_pos, alone := *ts.pos, ts.line_blank
+
+	all_runes, _, err := ts.get_n_runes(len(style.start))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(style.end) == 0 {
+		// This is a line comment.
+		chars, err := ts.read_until(ts.eol)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		all_runes = append(all_runes, chars...)
+	} else {
+		// This is a multi-line comment. Read up to each occurrence of the
+		// last rune of the closing delimiter, until the body ends with the
+		// closing delimiter.
+		last := style.end[len(style.end)-1]
+		for {
+			runes, err := ts.read_until(last)
+			if err != nil {
+				return nil, ts.unterminated_error("comment",
+					fmt.Sprintf("Couldn't find end of comment (%s).",
+						string(style.end)), CodeUnterminatedComment, err)
+			}
+			all_runes = append(all_runes, runes...)
+
+			if has_rune_suffix(all_runes[len(style.start):], style.end) {
+				break
+			}
+		}
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(all_runes),
+		NumBytes:  ts.last_byte_len,
+		NumChars:  len(all_runes),
+		FirstRune: all_runes[0],
+		Type:      TokenTypeComment,
+	}
+
+	ts.set_token(token)
+
+	// A line comment includes the end of line, which is not part of the
+	// last line of the comment.
+	end_line := ts.end_pos.Line
+	if all_runes[len(all_runes)-1] == ts.eol {
+		end_line--
+	}
+	body := all_runes[len(style.start) : len(all_runes)-len(style.end)]
+	ts.note_suppression(string(body), start_pos, alone, end_line)
+
+	return token, nil
+}
+
+func has_rune_suffix(runes, suffix []rune) bool {
+	if len(runes) < len(suffix) {
+		return false
+	}
+
+	runes = runes[len(runes)-len(suffix):]
+	for i, ch := range suffix {
+		if runes[i] != ch {
+			return false
+		}
+	}
+
+	return true
+}
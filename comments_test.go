@@ -0,0 +1,77 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSetComments(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Styles   []textparser.CommentStyle
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "hash",
+			Styles:   []textparser.CommentStyle{{Start: "#"}},
+			Input:    "a # b\n// c",
+			Expected: []string{"a", " ", "# b\n", "/", "/", " ", "c"},
+		},
+		{
+			Name: "longest start wins",
+			Styles: []textparser.CommentStyle{{Start: "-"},
+				{Start: "--", End: "--"}},
+			Input:    "a -- b -- c - d",
+			Expected: []string{"a", " ", "-- b --", " ", "c", " ", "- d"},
+		},
+		{
+			Name:     "multi-rune end",
+			Styles:   []textparser.CommentStyle{{Start: "{-", End: "-}"}},
+			Input:    "{- a -- } -} b",
+			Expected: []string{"{- a -- } -}", " ", "b"},
+		},
+		{
+			Name:     "none",
+			Styles:   nil,
+			Input:    "a // b",
+			Expected: []string{"a", " ", "/", "/", " ", "b"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SkipWhitespace = false
+			p.SkipComments = false
+			p.SetComments(test_data.Styles...)
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSetCommentsUnterminated(t *testing.T) {
+	p := textparser.NewScannerString("a (* b")
+	p.SetComments(textparser.CommentStyle{Start: "(*", End: "*)"})
+
+	for p.Scan() {
+	}
+
+	err := p.Err()
+	if err == nil || !strings.Contains(err.Error(), "(*)") {
+		t.Errorf("got %v, expected an unterminated comment error", err)
+	}
+}
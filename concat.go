@@ -0,0 +1,135 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A run of adjacent string literals being merged (see ConcatStrings).
+type string_run struct {
+	token   Token           // The first literal, merged into.
+	span    Span            // From the first literal to the last.
+	raw     []string        // Raw text of the literals and the gaps between.
+	content []string        // Contents of the literals, without quotes.
+	parts   []Span          // Spans of the literals.
+	gap     []*ScannedToken // Tokens after the last literal, not skipped.
+	gap_raw []string        // Raw text of all tokens after the last literal.
+
+	// The token returned before the run started, and its span.
+	old_token *Token
+	old_span  Span
+}
+
+// Starts a run of string literals with the token.
+func (ts *TokenScanner) start_string_run(token *Token, span Span) {
+	content, _ := token.Content(nil)
+	ts.string_run = &string_run{
+		token:     *clone_token(token),
+		span:      span,
+		raw:       []string{strings.Clone(token.RawText())},
+		content:   []string{strings.Clone(content)},
+		parts:     []Span{span},
+		old_token: ts.old_token,
+		old_span:  Span{Start: *ts.old_pos, End: ts.old_end_pos},
+	}
+}
+
+// Adds the token, read while a run of string literals is open, to the run.
+// Returns false if the token ends the run instead.
+func (ts *TokenScanner) add_to_string_run(
+	class rune_class,
+	token *Token,
+	span Span,
+	skip bool,
+) bool {
+	run := ts.string_run
+
+	switch {
+	case is_gap_class(class):
+		run.gap_raw = append(run.gap_raw, strings.Clone(token.RawText()))
+		if !skip {
+			run.gap = append(run.gap, &ScannedToken{
+				Token: clone_token(token),
+				Span:  span,
+			})
+		}
+		return true
+
+	case token.Type == TokenTypeString && !skip:
+		content, _ := token.Content(nil)
+		run.raw = append(run.raw, run.gap_raw...)
+		run.raw = append(run.raw, strings.Clone(token.RawText()))
+		run.content = append(run.content, strings.Clone(content))
+		run.parts = append(run.parts, span)
+		run.span.End = span.End
+		run.gap = nil
+		run.gap_raw = nil
+
+		return true
+	}
+
+	return false
+}
+
+// Ends the run of string literals. Returns the merged token, followed by the
+// tokens read after the last literal that are not skipped, and restores the
+// token returned before the run started as the previous token.
+func (ts *TokenScanner) end_string_run() []*ScannedToken {
+	run := ts.string_run
+	ts.string_run = nil
+
+	ts.old_token = run.old_token
+	*ts.old_pos = run.old_span.Start
+	ts.old_end_pos = run.old_span.End
+
+	token := new(Token)
+	*token = run.token
+	if len(run.parts) > 1 {
+		quote := run.token.RawText()[len(run.token.Subtype):]
+		_, open_size := utf8.DecodeRuneInString(quote)
+		_, close_size := utf8.DecodeLastRuneInString(quote)
+
+		raw := strings.Join(run.raw, "")
+		*token = Token{
+			Text: run.token.Subtype + quote[:open_size] +
+				strings.Join(run.content, "") + quote[len(quote)-close_size:],
+			Raw:       raw,
+			NumBytes:  len(raw),
+			NumChars:  utf8.RuneCountInString(raw),
+			FirstRune: run.token.FirstRune,
+			Type:      TokenTypeString,
+			Subtype:   run.token.Subtype,
+			Parts:     run.parts,
+		}
+	}
+
+	tokens := []*ScannedToken{{Token: token, Span: run.span}}
+
+	return append(tokens, run.gap...)
+}
@@ -0,0 +1,100 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestConcatStrings(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string // Type, text, and raw text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "adjacent",
+			Input: `x = "ab" "cd";`,
+			Expected: []string{"Ident x x", "Symbol = =",
+				`String "abcd" "ab" "cd"`, "Symbol ; ;"},
+		},
+		{
+			Name:  "comments and lines",
+			Input: "\"a\" // one\n  \"b\" /* two */ 'c'",
+			Expected: []string{
+				"String \"abc\" \"a\" // one\n  \"b\" /* two */ 'c'"},
+		},
+		{
+			Name:     "single",
+			Input:    `"a" + "b" `,
+			Expected: []string{`String "a" "a"`, "Symbol + +", `String "b" "b"`},
+		},
+		{
+			Name:  "whitespace kept",
+			Input: `"a" "b" x`,
+			Setup: func(p *textparser.TokenScanner) {
+				p.SkipWhitespace = false
+			},
+			Expected: []string{`String "ab" "a" "b"`, "Whitespace    ",
+				"Ident x x"},
+		},
+		{
+			Name:  "token limit stops lookahead",
+			Input: `"a" "b" x`,
+			Setup: func(p *textparser.TokenScanner) {
+				p.ScanLimit(1, 0)
+			},
+			Expected: []string{`String "a" "a"`},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.ConcatStrings = true
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text+" "+
+					token.RawText())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestConcatStringsSpans(t *testing.T) {
+	p := textparser.NewScannerString("f(\"ab\"\n  \"cd\")")
+	p.ConcatStrings = true
+
+	var got []string
+	for p.Scan() {
+		st := p.ScannedToken()
+		got = append(got, st.String())
+		for _, part := range st.Parts {
+			got = append(got, "  "+part.String())
+		}
+	}
+
+	expected := []string{
+		`:1:1-1:2 (0-1) t=Ident r=f nc=1 nb=1: "f"`,
+		`:1:2-1:3 (1-2) t=Symbol r=( nc=1 nb=1: "("`,
+		`:1:3-2:7 (2-13) t=String r=" nc=11 nb=11: "\"abcd\""`,
+		"  :1:3-1:7 (2-6)",
+		"  :2:3-2:7 (9-13)",
+		`:2:7-2:8 (13-14) t=Symbol r=) nc=1 nb=1: ")"`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
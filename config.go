@@ -0,0 +1,443 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// An entry of a configuration file of key/value pairs and blocks in braces,
+// e.g., the "server" block or the "port" entry in
+//
+//	server "main" {
+//	    host = "example.com"
+//	    port = 8080
+//	}
+type ConfigNode struct {
+	Key      string        // Unquoted key, or "" for the root.
+	KeyToken *ScannedToken // The key as scanned, or nil for the root.
+
+	// The values of an entry, or the labels of a block, e.g., "main" above.
+	Values []*ConfigValue
+
+	// The entries of a block, in order.
+	Children []*ConfigNode
+
+	// The braces of a block, or nil for an entry without a block.
+	Open, Close *ScannedToken
+}
+
+// A value in a configuration file: one token, or adjacent tokens without
+// space between them, e.g., "/var/log/x.log".
+type ConfigValue struct {
+	Tokens []*ScannedToken
+	Text   string      // The value as scanned.
+	Value  interface{} // The value after coercion (see ConfigCoercer).
+	Span
+}
+
+// Converts a value, e.g., to a number, for ParseConfig().
+type ConfigCoercer func(v *ConfigValue) (interface{}, error)
+
+// Returns true if the node has a block in braces, even an empty one.
+func (n *ConfigNode) IsBlock() bool {
+	return n.Open != nil
+}
+
+// Returns the span of source the node was parsed from, from its key through
+// its closing brace or last value. The span of the root is that of its
+// entries.
+func (n *ConfigNode) Span() Span {
+	var span Span
+
+	switch {
+	case n.KeyToken != nil:
+		span = n.KeyToken.Span
+	case len(n.Children) > 0:
+		span.Start = n.Children[0].Span().Start
+		span.End = n.Children[len(n.Children)-1].Span().End
+		return span
+	default:
+		return span
+	}
+
+	if n.Close != nil {
+		span.End = n.Close.End
+	} else if len(n.Values) > 0 {
+		span.End = n.Values[len(n.Values)-1].End
+	}
+
+	return span
+}
+
+// Returns the first child with the key, or nil.
+func (n *ConfigNode) Child(key string) *ConfigNode {
+	for _, child := range n.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Returns the entries of the block as nested maps. An entry without values
+// maps to true, one with a single value to that value, and one with several
+// values to a []interface{}. A block maps to a map of its entries, nested
+// under its labels, if any, e.g., {"server": {"main": {"port": 8080}}} for
+// the example of ConfigNode. A key occurring more than once maps to a
+// []interface{} of its values.
+func (n *ConfigNode) Map() map[string]interface{} {
+	m := make(map[string]interface{})
+
+	for _, child := range n.Children {
+		keys := []string{child.Key}
+
+		var value interface{}
+		switch {
+		case child.IsBlock():
+			for _, label := range child.Values {
+				keys = append(keys, fmt.Sprint(label.Value))
+			}
+			value = child.Map()
+		case len(child.Values) == 0:
+			value = true
+		case len(child.Values) == 1:
+			value = child.Values[0].Value
+		default:
+			values := make([]interface{}, 0, len(child.Values))
+			for _, v := range child.Values {
+				values = append(values, v.Value)
+			}
+			value = values
+		}
+
+		config_insert(m, keys, value)
+	}
+
+	config_finish(m)
+
+	return m
+}
+
+// Values of a key occurring more than once, while building a map in Map().
+type config_repeated []interface{}
+
+// Sets the value under the keys in m, creating maps for all but the last
+// key, and collecting repeated values in a config_repeated list.
+func config_insert(
+	m map[string]interface{},
+	keys []string,
+	value interface{},
+) {
+	for _, key := range keys[:len(keys)-1] {
+		sub, ok := m[key].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			m[key] = sub
+		}
+		m = sub
+	}
+
+	key := keys[len(keys)-1]
+	switch old := m[key].(type) {
+	case nil:
+		m[key] = value
+	case config_repeated:
+		m[key] = append(old, value)
+	default:
+		m[key] = config_repeated{old, value}
+	}
+}
+
+// Replaces the config_repeated lists in m and the maps nested in it with
+// plain lists.
+func config_finish(m map[string]interface{}) {
+	for key, value := range m {
+		switch v := value.(type) {
+		case config_repeated:
+			m[key] = []interface{}(v)
+		case map[string]interface{}:
+			config_finish(v)
+		}
+	}
+}
+
+// The default ConfigCoercer. A single token is converted by type: integers
+// to int64, floats to float64, quoted strings to their unquoted text, "true"
+// and "false" to bool, dates and times to time.Time (see DateTimes), and IP
+// addresses to netip.Prefix (see Addresses). Anything else is a string.
+func CoerceConfigValue(v *ConfigValue) (interface{}, error) {
+	if len(v.Tokens) != 1 {
+		return v.Text, nil
+	}
+
+	token := v.Tokens[0]
+	text := strings.ReplaceAll(token.ValueText(), "_", "")
+
+	switch token.Type {
+	case TokenTypeInt:
+		return strconv.ParseInt(text, 0, 64)
+	case TokenTypeFloat:
+		return strconv.ParseFloat(text, 64)
+	case TokenTypeString:
+		return config_unquote(token.ValueText()), nil
+	case TokenTypeIdent:
+		switch token.Text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case TokenTypeDateTime:
+		return token.Time(), nil
+	case TokenTypeAddress:
+		return token.Prefix(), nil
+	}
+
+	return token.Text, nil
+}
+
+// Returns the text of a quoted string without its quotes, interpreting
+// escapes in double-quoted strings as in Go, where possible.
+func config_unquote(text string) string {
+	if strings.HasPrefix(text, `"`) {
+		if s, err := strconv.Unquote(text); err == nil {
+			return s
+		}
+	}
+
+	_, open_size := utf8.DecodeRuneInString(text)
+	_, close_size := utf8.DecodeLastRuneInString(text)
+	if open_size+close_size > len(text) {
+		return text
+	}
+
+	return text[open_size : len(text)-close_size]
+}
+
+// Scans all remaining tokens from the scanner, e.g., one set up by
+// ProfileConfig(), and returns the root of the configuration tree, with the
+// values converted by coerce, or by CoerceConfigValue() if nil.
+//
+// Each entry is a key, an identifier or quoted string, optionally followed
+// by "=" or ":", then by values, and ends at ";", at the end of the line, or
+// at a "}". Values are separated by space or commas. An entry followed by a
+// block in braces is a block, and its values are labels. Comment tokens are
+// ignored, and so are line endings right after a separator, so long values
+// may continue on the next line.
+//
+// Syntax errors are ScanErrors with CodeSyntax, or CodeUnbalancedBracket for
+// braces, and coercion errors ScanErrors with CodeMalformedEntry.
+func ParseConfig(ts *TokenScanner, coerce ConfigCoercer) (*ConfigNode, error) {
+	tokens, err := TokenizeAll(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	if coerce == nil {
+		coerce = CoerceConfigValue
+	}
+
+	c := &config_parser{coerce: coerce, end: *ts.EndPosition()}
+	for _, token := range tokens {
+		if token.Type != TokenTypeComment {
+			c.tokens = append(c.tokens, token)
+		}
+	}
+
+	root := new(ConfigNode)
+	if root.Children, _, err = c.block(nil); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// The state of a call to ParseConfig().
+type config_parser struct {
+	tokens []*ScannedToken
+	i      int
+	coerce ConfigCoercer
+	end    Position
+}
+
+// Returns the next token, or nil at the end.
+func (c *config_parser) peek() *ScannedToken {
+	if c.i < len(c.tokens) {
+		return c.tokens[c.i]
+	}
+
+	return nil
+}
+
+// Parses entries up to the brace closing open, or to the end of the input
+// if open is nil.
+func (c *config_parser) block(
+	open *ScannedToken,
+) ([]*ConfigNode, *ScannedToken, error) {
+	nodes := []*ConfigNode{}
+
+	for {
+		token := c.peek()
+		switch {
+		case token == nil && open != nil:
+			return nil, nil, &ScanError{
+				Msg:    "Unterminated block",
+				Detail: "Couldn't find closing brace (}).",
+				Start:  open.Start,
+				End:    c.end,
+				Err:    io.EOF,
+				Code:   CodeUnbalancedBracket,
+			}
+		case token == nil:
+			return nodes, nil, nil
+		case is_symbol_text(token, ";"):
+			c.i++
+			continue
+		case is_symbol_text(token, "}"):
+			if open == nil {
+				return nil, nil, &ScanError{
+					Msg:   `Unexpected "}"`,
+					Start: token.Start,
+					End:   token.Start,
+					Code:  CodeUnbalancedBracket,
+				}
+			}
+			c.i++
+			return nodes, token, nil
+		}
+
+		node, err := c.entry()
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+// Parses an entry, and its block, if any.
+func (c *config_parser) entry() (*ConfigNode, error) {
+	key := c.peek()
+	if key.Type != TokenTypeIdent && key.Type != TokenTypeString {
+		return nil, &ScanError{
+			Msg:    "Expected key",
+			Detail: fmt.Sprintf("Found %q.", key.Text),
+			Start:  key.Start,
+			End:    key.Start,
+			Code:   CodeSyntax,
+		}
+	}
+	c.i++
+
+	node := &ConfigNode{Key: key.Text, KeyToken: key}
+	if key.Type == TokenTypeString {
+		node.Key = config_unquote(key.Text)
+	}
+
+	prev := key
+	continued := false
+	if token := c.peek(); token != nil && token.Start.Line == key.End.Line &&
+		(is_symbol_text(token, "=") || is_symbol_text(token, ":")) {
+		prev = token
+		continued = true
+		c.i++
+	}
+
+	var value *ConfigValue
+	for {
+		token := c.peek()
+		if token == nil || is_symbol_text(token, "}") {
+			break
+		}
+
+		if is_symbol_text(token, ";") {
+			c.i++
+			break
+		}
+
+		new_line := token.Start.Line > prev.End.Line
+		if is_symbol_text(token, "{") {
+			c.i++
+			children, close, err := c.block(token)
+			if err != nil {
+				return nil, err
+			}
+			node.Open, node.Close, node.Children = token, close, children
+			break
+		}
+
+		if new_line && !continued {
+			break
+		}
+		continued = false
+
+		if is_symbol_text(token, ",") {
+			value = nil
+			continued = true
+			prev = token
+			c.i++
+			continue
+		}
+
+		if value != nil && token.Start.Offset == prev.End.Offset {
+			value.Tokens = append(value.Tokens, token)
+			value.Text += token.RawText()
+			value.End = token.End
+		} else {
+			value = &ConfigValue{Tokens: []*ScannedToken{token},
+				Text: token.RawText(), Span: token.Span}
+			node.Values = append(node.Values, value)
+		}
+
+		prev = token
+		c.i++
+	}
+
+	for _, v := range node.Values {
+		var err error
+		if v.Value, err = c.coerce(v); err != nil {
+			return nil, &ScanError{
+				Msg:    fmt.Sprintf("Invalid value for %q", node.Key),
+				Detail: fmt.Sprintf("%s.", err),
+				Start:  v.Start,
+				End:    v.Start,
+				Code:   CodeMalformedEntry,
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func is_symbol_text(token *ScannedToken, text string) bool {
+	return token.Type == TokenTypeSymbol && token.Text == text
+}
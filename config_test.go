@@ -0,0 +1,161 @@
+package textparser_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestParseConfig(t *testing.T) {
+	input := `# Server settings.
+server "main" {
+    host = "example.com"
+    port: 8080
+    ratio 0.5; debug
+    listen 80, 443
+    root /var/www/html  // Adjacent tokens form one value.
+    tags =
+        a b
+}
+server "backup" { port = 8081 }
+enabled = true
+`
+
+	p := textparser.ProfileConfig().NewScanner(strings.NewReader(input))
+	root, err := textparser.ParseConfig(p, nil)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := root.Map()
+	expected := map[string]interface{}{
+		"server": map[string]interface{}{
+			"main": map[string]interface{}{
+				"host":   "example.com",
+				"port":   int64(8080),
+				"ratio":  0.5,
+				"debug":  true,
+				"listen": []interface{}{int64(80), int64(443)},
+				"root":   "/var/www/html",
+				"tags":   []interface{}{"a", "b"},
+			},
+			"backup": map[string]interface{}{
+				"port": int64(8081),
+			},
+		},
+		"enabled": true,
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	server := root.Child("server")
+	if !server.IsBlock() || len(server.Children) != 7 {
+		t.Fatalf("got %#v, expected a block with 7 entries", server)
+	}
+
+	port := server.Child("port")
+	span := port.Span()
+	if span.Start.Line != 4 || span.Start.Column != 5 ||
+		span.End.Column != 15 {
+		t.Errorf("got span %s for port, expected 4:5-4:15", span)
+	}
+
+	if span = server.Span(); span.Start.Line != 2 || span.End.Line != 10 {
+		t.Errorf("got span %s for server, expected lines 2-10", span)
+	}
+}
+
+func TestParseConfigRepeated(t *testing.T) {
+	p := textparser.ProfileConfig().NewScanner(
+		strings.NewReader("a 1; a 2; a 3\nb { c 1 } b { c 2 }"))
+	root, err := textparser.ParseConfig(p, nil)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := root.Map()
+	expected := map[string]interface{}{
+		"a": []interface{}{int64(1), int64(2), int64(3)},
+		"b": []interface{}{map[string]interface{}{"c": int64(1)},
+			map[string]interface{}{"c": int64(2)}},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestParseConfigCoercer(t *testing.T) {
+	p := textparser.ProfileConfig().NewScanner(
+		strings.NewReader("size 10k\nname x"))
+	coerce := func(v *textparser.ConfigValue) (interface{}, error) {
+		if strings.HasSuffix(v.Text, "k") {
+			var n int
+			_, err := fmt.Sscanf(v.Text, "%dk", &n)
+			return n * 1024, err
+		}
+		return textparser.CoerceConfigValue(v)
+	}
+
+	root, err := textparser.ParseConfig(p, coerce)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := root.Map()
+	expected := map[string]interface{}{"size": 10240, "name": "x"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected textparser.ErrorCode
+		Message  string
+	}
+
+	test_list := []TestData{
+		{"unterminated block", "a {\n b 1\n",
+			textparser.CodeUnbalancedBracket,
+			"Unterminated block opened at :1:3 (2), unterminated at EOF " +
+				":3:1 (9). Couldn't find closing brace (})."},
+		{"extra brace", "a 1 }", textparser.CodeUnbalancedBracket,
+			`Unexpected "}" at :1:5 (4).`},
+		{"bad key", "= 1", textparser.CodeSyntax,
+			`Expected key at :1:1 (0). Found "=".`},
+		{"bad value", "a 99999999999999999999",
+			textparser.CodeMalformedEntry,
+			`Invalid value for "a" at :1:3 (2). strconv.ParseInt: ` +
+				`parsing "99999999999999999999": value out of range.`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.ProfileConfig().NewScanner(
+				strings.NewReader(test_data.Input))
+			_, err := textparser.ParseConfig(p, nil)
+
+			var scan_err *textparser.ScanError
+			if !errors.As(err, &scan_err) {
+				st.Fatalf("got %v, expected a ScanError", err)
+			}
+
+			if scan_err.Code != test_data.Expected {
+				st.Errorf("got code %s, expected %s", scan_err.Code.Name(),
+					test_data.Expected.Name())
+			}
+			if got := err.Error(); got != test_data.Message {
+				st.Errorf("got %q, expected %q", got, test_data.Message)
+			}
+		})
+	}
+}
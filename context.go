@@ -0,0 +1,108 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// What is known about the token being scanned besides its own runes, for
+// predicates deciding, e.g., whether a '/' starts a regular expression or is
+// a division, based on the token before it (see WithContext()).
+type ScanContext struct {
+	// A copy of the previous token other than white space and comments, or
+	// nil at the start of the input. If ByteTokens is set, its Text and Raw
+	// are empty.
+	Prev *Token
+
+	// The position of the start of the token being scanned.
+	Position Position
+
+	// The opening brackets (see SetBrackets()) enclosing the token being
+	// scanned, innermost last, so that len(Open) is the nesting depth.
+	Open []rune
+}
+
+// A predicate like IsIdentRune, also receiving the context of the token
+// being scanned.
+type ContextPredicate func(ch rune, i int, runes []rune, ctx *ScanContext) bool
+
+// A predicate like IsQuoteRune, also receiving the context of the token
+// being scanned.
+type ContextQuotePredicate func(ch rune, ctx *ScanContext) (bool, rune)
+
+// The state behind ScanContext.
+type scan_context struct {
+	ctx  ScanContext
+	prev Token
+}
+
+// Returns the context of the token being scanned, or, between calls to
+// Scan(), of the next one. The context is only valid until the next call to
+// Scan().
+func (ts *TokenScanner) ScanContext() *ScanContext {
+	ts.scan_ctx.ctx.Position = *ts.pos
+	return &ts.scan_ctx.ctx
+}
+
+// Returns a predicate to set, e.g., as IsSymbolRune or IsIdentRune, which
+// calls p with the context of the token being scanned.
+func (ts *TokenScanner) WithContext(
+	p ContextPredicate,
+) func(ch rune, i int, runes []rune) bool {
+	return func(ch rune, i int, runes []rune) bool {
+		return p(ch, i, runes, ts.ScanContext())
+	}
+}
+
+// Returns a predicate to set as IsQuoteRune, which calls p with the context
+// of the token being scanned, e.g., to read "/re/" as a string only where an
+// operand is expected.
+func (ts *TokenScanner) WithQuoteContext(
+	p ContextQuotePredicate,
+) func(ch rune) (bool, rune) {
+	return func(ch rune) (bool, rune) {
+		return p(ch, ts.ScanContext())
+	}
+}
+
+// Records the token in the context of the tokens that follow.
+func (ts *TokenScanner) note_scan_context(token *Token) {
+	sc := &ts.scan_ctx
+
+	switch token.Bracket {
+	case BracketOpen:
+		sc.ctx.Open = append(sc.ctx.Open, token.FirstRune)
+	case BracketClose:
+		if n := len(sc.ctx.Open); n > 0 {
+			sc.ctx.Open = sc.ctx.Open[:n-1]
+		}
+	}
+
+	sc.prev = *token
+	if ts.ByteTokens {
+		sc.prev.Text = ""
+		sc.prev.Raw = ""
+	}
+	sc.ctx.Prev = &sc.prev
+}
@@ -0,0 +1,74 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// Test that a '/' is read as opening a regular expression only where an
+// operand is expected.
+func TestWithQuoteContext(t *testing.T) {
+	p := textparser.NewScannerString("a = (b) / c / d; r = /x+/g")
+	p.SetBrackets("()")
+	p.IsQuoteRune = p.WithQuoteContext(func(ch rune,
+		ctx *textparser.ScanContext) (bool, rune) {
+		if ch != '/' {
+			return textparser.IsQuoteRune(ch)
+		}
+		prev := ctx.Prev
+		return prev == nil || (prev.Type == textparser.TokenTypeSymbol &&
+			prev.Bracket != textparser.BracketClose), '/'
+	})
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected := []string{"Ident a", "Symbol =", "Symbol (", "Ident b",
+		"Symbol )", "Symbol /", "Ident c", "Symbol /", "Ident d",
+		"Symbol ;", "Ident r", "Symbol =", "String /x+/", "Ident g"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+// Test that predicates see the nesting and position of the token.
+func TestWithContext(t *testing.T) {
+	p := textparser.NewScannerString("[a:b [c:d]] a:b")
+	p.SetBrackets("[]")
+
+	var starts []string
+	p.IsIdentRune = p.WithContext(func(ch rune, i int, runes []rune,
+		ctx *textparser.ScanContext) bool {
+		if ch == ':' && i > 0 {
+			return len(ctx.Open) > 0
+		}
+		ok := textparser.IsIdentRune(ch, i, runes)
+		if ok && i == 0 {
+			starts = append(starts,
+				string(ch)+" "+ctx.Position.String()+" "+string(ctx.Open))
+		}
+		return ok
+	})
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"[", "a:b", "[", "c:d", "]", "]", "a", ":", "b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	// Classification and reading each check the first rune.
+	expected_starts := []string{"a :1:2 (1) [", "a :1:2 (1) [",
+		"c :1:7 (6) [[", "c :1:7 (6) [[", "a :1:13 (12) ",
+		"a :1:13 (12) ", "b :1:15 (14) ", "b :1:15 (14) "}
+	if !reflect.DeepEqual(starts, expected_starts) {
+		t.Errorf("got %#v, expected %#v", starts, expected_starts)
+	}
+}
@@ -0,0 +1,182 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// A sequence at the end of a line joining it with the next one (see
+// SetLineContinuations()).
+type LineContinuation struct {
+	// The sequence, e.g., "\\" or ",".
+	Marker string
+
+	// The marker is scanned as a token of its own, e.g., a trailing comma,
+	// rather than as part of the continuation.
+	Keep bool
+}
+
+// Returns a string representation of the continuation, e.g., "\\" or
+// ", (kept)".
+func (c LineContinuation) String() string {
+	if c.Keep {
+		return c.Marker + " (kept)"
+	}
+
+	return c.Marker
+}
+
+// The backslash-newline continuation of shells and makefiles.
+var BackslashContinuation = LineContinuation{Marker: "\\"}
+
+// The most spaces and tabs allowed between a marker and the end of line.
+const max_continuation_space = 64
+
+// Sets the line continuations, replacing any set before. A marker followed
+// by nothing but spaces and tabs up to the end of the line joins the line
+// with the next one: the marker (unless Keep is set), the spaces, and the
+// end of line are scanned as a single whitespace token, skipped if
+// SkipWhitespace is set. The line number still advances, but otherwise the
+// line break is not treated as one, e.g., no section header may follow it,
+// and it does not end a line for SetIndentation(). With no continuations,
+// lines are not joined. Continuations are not recognized in delimited or
+// markdown mode.
+func (ts *TokenScanner) SetLineContinuations(conts ...LineContinuation) {
+	ts.continuations = ts.continuations[:0]
+	for _, c := range conts {
+		if c.Marker != "" {
+			ts.continuations = append(ts.continuations, c)
+		}
+	}
+	ts.after_marker = false
+}
+
+// Returns the line continuations set with SetLineContinuations().
+func (ts *TokenScanner) LineContinuations() []LineContinuation {
+	return append([]LineContinuation(nil), ts.continuations...)
+}
+
+// Returns the number of runes in the line continuation at the current
+// position, or 0 if there is none.
+func (ts *TokenScanner) continuation_len() int {
+	longest := 0
+	for _, c := range ts.continuations {
+		if n := len([]rune(c.Marker)); !c.Keep && n > longest {
+			longest = n
+		}
+	}
+
+	runes := ts.peek_upto(longest + max_continuation_space + 2)
+
+	if ts.after_marker {
+		if n := ts.line_end_len(runes); n > 0 {
+			return n
+		}
+	}
+
+	for _, c := range ts.continuations {
+		marker := []rune(c.Marker)
+		if c.Keep || !has_rune_prefix(runes, marker) {
+			continue
+		}
+
+		if n := ts.line_end_len(runes[len(marker):]); n > 0 {
+			return len(marker) + n
+		}
+	}
+
+	return 0
+}
+
+// Returns the number of runes in spaces and tabs followed by the end of
+// line at the start of the runes, or 0 if they don't start that way.
+func (ts *TokenScanner) line_end_len(runes []rune) int {
+	for i, ch := range runes {
+		switch {
+		case ch == ts.eol:
+			return i + 1
+		case ch == '\r' && i+1 < len(runes) && runes[i+1] == ts.eol:
+			return i + 2
+		case ch != ' ' && ch != '\t':
+			return 0
+		}
+	}
+
+	return 0
+}
+
+// Returns true if the runes start with the prefix.
+func has_rune_prefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+
+	return has_rune_suffix(runes[:len(prefix)], prefix)
+}
+
+// Records whether the token is a marker kept as a token of its own, which
+// the end of its line continues.
+func (ts *TokenScanner) note_continuation(class rune_class, token *Token) {
+	ts.after_marker = false
+	if class == class_comment || class == class_quoted {
+		return
+	}
+
+	for _, c := range ts.continuations {
+		if c.Keep && token.Text == c.Marker {
+			ts.after_marker = true
+			return
+		}
+	}
+}
+
+// Reads a line continuation. Returns a nil token if there is none.
+func (ts *TokenScanner) get_continuation() (*Token, error) {
+	n := ts.continuation_len()
+	if n == 0 {
+		return nil, nil
+	}
+
+	runes, _, err := ts.get_n_runes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	// The joined line is not a new line.
+	ts.line_blank = false
+	ts.joined++
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  ts.last_byte_len,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeWhitespace,
+	}
+
+	ts.set_token(token)
+
+	return token, nil
+}
@@ -0,0 +1,92 @@
+package textparser_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestLineContinuations(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Conts    []textparser.LineContinuation
+		Input    string
+		Expected []string
+	}
+
+	comma := textparser.LineContinuation{Marker: ",", Keep: true}
+
+	test_list := []TestData{
+		{Name: "backslash",
+			Conts: []textparser.LineContinuation{
+				textparser.BackslashContinuation},
+			Input: "CFLAGS = -O2 \\\n\t-g\\  \r\nall\n",
+			Expected: []string{"1:1 CFLAGS", "1:7 ' '", "1:8 =", "1:9 ' '",
+				"1:10 -", "1:11 O2", "1:13 ' '", "1:14 '\\\\\\n'",
+				"2:1 '\\t'", "2:2 -", "2:3 g", "2:4 '\\\\  \\r\\n'",
+				"3:1 all", "3:4 '\\n'"}},
+		{Name: "kept comma", Conts: []textparser.LineContinuation{comma},
+			Input: "f(a,\n  b)\n",
+			Expected: []string{"1:1 f", "1:2 (", "1:3 a", "1:4 ,",
+				"1:5 '\\n'", "2:1 '  '", "2:3 b", "2:4 )", "2:5 '\\n'"}},
+		{Name: "not at end of line",
+			Conts: []textparser.LineContinuation{
+				textparser.BackslashContinuation, comma},
+			Input: "a \\ b, c\n",
+			Expected: []string{"1:1 a", "1:2 ' '", "1:3 \\", "1:4 ' '",
+				"1:5 b", "1:6 ,", "1:7 ' '", "1:8 c", "1:9 '\\n'"}},
+		{Name: "none", Input: "a\\\nb",
+			Expected: []string{"1:1 a", "1:2 \\", "1:3 '\\n'", "2:1 b"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SkipWhitespace = false
+			p.SetLineContinuations(test_data.Conts...)
+
+			var got []string
+			for p.Scan() {
+				pos := p.Position()
+				text := p.TokenText()
+				if p.Token().Type == textparser.TokenTypeWhitespace {
+					text = strconv.Quote(text)
+					text = "'" + text[1:len(text)-1] + "'"
+				}
+				got = append(got, fmt.Sprintf("%d:%d %s", pos.Line,
+					pos.Column, text))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestLineContinuationIndentation(t *testing.T) {
+	p := textparser.NewScannerString("if a \\\n    and b:\n  c\n")
+	p.SetLineContinuations(textparser.BackslashContinuation)
+	p.SetIndentation(textparser.IndentSpaces)
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.Token().Type.String()+" "+p.TokenText())
+	}
+
+	expected := []string{"Ident if", "Ident a", "Ident and", "Ident b",
+		"Symbol :", "Newline ", "Indent   ", "Ident c", "Newline ",
+		"Dedent "}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	summary := p.ConfigSummary()
+	if !reflect.DeepEqual(summary.Continuations, []string{"\\"}) {
+		t.Errorf("got continuations %#v, expected backslash",
+			summary.Continuations)
+	}
+}
@@ -0,0 +1,194 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// A cursor over tokens scanned in full, e.g., by TokenizeAll(), for parsers
+// that navigate back and forth rather than consume a stream. The cursor is
+// at an index from 0 (before the first token) to Len() (past the last
+// token), and Next() returns the token at the index.
+type TokenCursor struct {
+	tokens []*ScannedToken
+	index  int
+}
+
+// Returns a cursor before the first of the tokens.
+func NewTokenCursor(tokens []*ScannedToken) *TokenCursor {
+	return &TokenCursor{tokens: tokens}
+}
+
+// Scans all remaining tokens from the scanner with TokenizeAll() and returns
+// a cursor before the first of them. On error, the cursor holds the tokens
+// scanned so far.
+func TokenizeCursor(ts *TokenScanner) (*TokenCursor, error) {
+	tokens, err := TokenizeAll(ts)
+
+	return NewTokenCursor(tokens), err
+}
+
+// Returns the number of tokens.
+func (c *TokenCursor) Len() int {
+	return len(c.tokens)
+}
+
+// Returns the index of the cursor, i.e., of the token Next() returns.
+func (c *TokenCursor) Index() int {
+	return c.index
+}
+
+// Returns the tokens.
+func (c *TokenCursor) Tokens() []*ScannedToken {
+	return c.tokens
+}
+
+// Returns the token at the cursor and moves past it, or returns nil at the
+// end.
+func (c *TokenCursor) Next() *ScannedToken {
+	if c.index >= len(c.tokens) {
+		return nil
+	}
+
+	c.index++
+
+	return c.tokens[c.index-1]
+}
+
+// Moves back before the previous token and returns it, or returns nil at the
+// start.
+func (c *TokenCursor) Prev() *ScannedToken {
+	if c.index <= 0 {
+		return nil
+	}
+
+	c.index--
+
+	return c.tokens[c.index]
+}
+
+// Returns the token at the cursor without moving, or nil at the end.
+func (c *TokenCursor) Peek() *ScannedToken {
+	return c.PeekN(0)
+}
+
+// Returns the token n tokens past the cursor without moving, or nil if there
+// is none. PeekN(0) is Peek(), and PeekN(-1) the token before the cursor.
+func (c *TokenCursor) PeekN(n int) *ScannedToken {
+	i := c.index + n
+	if i < 0 || i >= len(c.tokens) {
+		return nil
+	}
+
+	return c.tokens[i]
+}
+
+// Moves the cursor to the index, clamped to the range from 0 to Len(). Use
+// with Index() to backtrack.
+func (c *TokenCursor) Seek(index int) {
+	switch {
+	case index < 0:
+		index = 0
+	case index > len(c.tokens):
+		index = len(c.tokens)
+	}
+
+	c.index = index
+}
+
+// Returns the tokens from index from up to index to, clamped to the range
+// from 0 to Len(). The slice shares the cursor's backing array.
+func (c *TokenCursor) Slice(from, to int) []*ScannedToken {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(c.tokens) {
+		to = len(c.tokens)
+	}
+	if from > to {
+		from = to
+	}
+
+	return c.tokens[from:to]
+}
+
+// Moves the cursor to the first token at or past it for which match returns
+// true and returns the token, without moving past it. If there is none,
+// returns nil and leaves the cursor where it was.
+func (c *TokenCursor) Find(match func(st *ScannedToken) bool) *ScannedToken {
+	for i := c.index; i < len(c.tokens); i++ {
+		if match(c.tokens[i]) {
+			c.index = i
+			return c.tokens[i]
+		}
+	}
+
+	return nil
+}
+
+// Returns a ScanError with CodeSyntax at the token at the cursor, with the
+// message formatted as by fmt.Sprintf(), e.g.,
+//
+//	Expected "=" at config.txt:3:5 (31). Found "{".
+func (c *TokenCursor) Errorf(format string, args ...interface{}) error {
+	return c.ErrorAt(c.index, format, args...)
+}
+
+// Returns a ScanError with CodeSyntax at the token at the index, as
+// Errorf() does at the cursor. At Len(), the error is at the end of the last
+// token, reporting the end of input.
+func (c *TokenCursor) ErrorAt(
+	index int,
+	format string,
+	args ...interface{},
+) error {
+	err := &ScanError{
+		Msg:  fmt.Sprintf(format, args...),
+		Code: CodeSyntax,
+	}
+	if index < 0 {
+		index = 0
+	}
+
+	switch {
+	case index < len(c.tokens):
+		err.Start = c.tokens[index].Start
+		err.Detail = fmt.Sprintf("Found %q.", c.tokens[index].Text)
+	case len(c.tokens) > 0:
+		err.Start = c.tokens[len(c.tokens)-1].End
+		err.Detail = "Reached end of input."
+		err.Err = io.EOF
+	default:
+		err.Detail = "Reached end of input."
+		err.Err = io.EOF
+	}
+	err.End = err.Start
+
+	return err
+}
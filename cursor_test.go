@@ -0,0 +1,105 @@
+package textparser_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func cursor_texts(tokens []*textparser.ScannedToken) []string {
+	texts := []string{}
+	for _, st := range tokens {
+		texts = append(texts, st.Text)
+	}
+
+	return texts
+}
+
+func new_test_cursor(t *testing.T, input string) *textparser.TokenCursor {
+	ts := textparser.NewScanner(strings.NewReader(input))
+	ts.SkipWhitespace = true
+	ts.SetFilename("test.txt")
+
+	c, err := textparser.TokenizeCursor(ts)
+	if err != nil {
+		t.Fatalf("TokenizeCursor() failed: %s", err)
+	}
+
+	return c
+}
+
+func TestTokenCursor(t *testing.T) {
+	c := new_test_cursor(t, "a = b + c")
+
+	got := []string{}
+	for st := c.Next(); st != nil; st = c.Next() {
+		got = append(got, st.Text)
+	}
+	expected := []string{"a", "=", "b", "+", "c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if st := c.Prev(); st == nil || st.Text != "c" {
+		t.Errorf("got %v from Prev(), expected c", st)
+	}
+	if st := c.PeekN(-1); st == nil || st.Text != "+" {
+		t.Errorf("got %v from PeekN(-1), expected +", st)
+	}
+	if st := c.Peek(); st == nil || st.Text != "c" || c.Index() != 4 {
+		t.Errorf("got %v at %d from Peek(), expected c at 4", st,
+			c.Index())
+	}
+
+	c.Seek(-3)
+	if st := c.Prev(); st != nil || c.Index() != 0 {
+		t.Errorf("got %v at %d from Prev() at the start, expected nil at 0",
+			st, c.Index())
+	}
+
+	st := c.Find(func(st *textparser.ScannedToken) bool {
+		return st.Type == textparser.TokenTypeSymbol && st.Text == "+"
+	})
+	if st == nil || c.Index() != 3 {
+		t.Errorf("got %v at %d from Find(), expected + at 3", st, c.Index())
+	}
+	if st := c.Find(func(st *textparser.ScannedToken) bool {
+		return st.Text == "a"
+	}); st != nil || c.Index() != 3 {
+		t.Errorf("got %v at %d from a failed Find(), expected nil at 3", st,
+			c.Index())
+	}
+
+	got = cursor_texts(c.Slice(1, 10))
+	expected = []string{"=", "b", "+", "c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v from Slice(), expected %#v", got, expected)
+	}
+}
+
+func TestTokenCursorErrors(t *testing.T) {
+	c := new_test_cursor(t, "a =\n  {")
+
+	c.Seek(2)
+	err := c.Errorf("Expected %s", "value")
+	expected := `Expected value at test.txt:2:3 (6). Found "{".`
+	if err.Error() != expected {
+		t.Errorf("got %q, expected %q", err, expected)
+	}
+	if code := textparser.ErrorCodeOf(err); code != textparser.CodeSyntax {
+		t.Errorf("got code %s, expected Syntax", code.Name())
+	}
+
+	err = c.ErrorAt(c.Len(), "Expected %q", ";")
+	expected = `Expected ";" at test.txt:2:4 (7). Reached end of input.`
+	if err.Error() != expected {
+		t.Errorf("got %q, expected %q", err, expected)
+	}
+	if errors.Is(err, io.EOF) {
+		t.Errorf("got an error matching io.EOF")
+	}
+}
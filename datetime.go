@@ -0,0 +1,132 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"time"
+	"unicode"
+)
+
+// Layouts for parsing the forms matched by match_datetime(), after the
+// separator between date and time has been normalized to 'T'. Fractional
+// seconds are accepted by time.Parse() without being in the layout.
+var toml_datetime_layouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"15:04:05",
+	"15:04",
+}
+
+// Sets the layouts, in the form used by time.Parse(), e.g., time.RFC1123 or
+// "Jan _2 15:04:05", of the dates and times emitted as TokenTypeDateTime
+// tokens when DateTimes is set, replacing the default forms. Layouts are
+// tried in order, and the longest match for a layout wins. Call with no
+// layouts to restore the default forms (see DateTimes).
+func (ts *TokenScanner) SetDateTimeLayouts(layouts ...string) {
+	ts.datetime_layouts = ts.datetime_layouts[:0]
+	for _, layout := range layouts {
+		if layout != "" {
+			ts.datetime_layouts = append(ts.datetime_layouts, layout)
+		}
+	}
+}
+
+// Returns the layouts set with SetDateTimeLayouts(), in order.
+func (ts *TokenScanner) DateTimeLayouts() []string {
+	return append([]string(nil), ts.datetime_layouts...)
+}
+
+// Returns the number of runes of the date or time starting at the next rune,
+// and its parsed value, or 0 if there is none, without consuming any input.
+func (ts *TokenScanner) match_datetime_value() (int, time.Time) {
+	next := ts.peek_upto(max_datetime_runes)
+
+	if len(ts.datetime_layouts) == 0 {
+		n := match_datetime(next)
+		if n == 0 {
+			return 0, time.Time{}
+		}
+		return n, parse_datetime(next[:n])
+	}
+
+	if len(next) == 0 || !(unicode.IsLetter(next[0]) ||
+		unicode.IsDigit(next[0])) {
+		return 0, time.Time{}
+	}
+
+	for _, layout := range ts.datetime_layouts {
+		if n, t := match_layout(layout, next); n > 0 {
+			return n, t
+		}
+	}
+
+	return 0, time.Time{}
+}
+
+// Returns the length and value of the longest prefix of s that parses with
+// the layout, ending at a word boundary, or 0 if there is none.
+func match_layout(layout string, s []rune) (int, time.Time) {
+	for n := len(s); n > 0; n-- {
+		if n < len(s) && (unicode.IsLetter(s[n]) || unicode.IsDigit(s[n])) {
+			continue
+		}
+		if unicode.IsSpace(s[n-1]) {
+			continue
+		}
+
+		if t, err := time.Parse(layout, string(s[:n])); err == nil {
+			return n, t
+		}
+	}
+
+	return 0, time.Time{}
+}
+
+// Returns the value of a date or time matched by match_datetime(), or the
+// zero time if it is out of range, e.g., "2024-13-01". Values without a time
+// zone are in UTC, and times without a date are on January 1 of year 0.
+func parse_datetime(runes []rune) time.Time {
+	s := []rune(string(runes))
+	if len(s) > 10 && (s[10] == 't' || s[10] == ' ') {
+		s[10] = 'T'
+	}
+	if s[len(s)-1] == 'z' {
+		s[len(s)-1] = 'Z'
+	}
+
+	text := string(s)
+	for _, layout := range toml_datetime_layouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,114 @@
+package textparser_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestDateTimeValues(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Layouts  []string
+		Input    string
+		Expected []string
+		Times    []time.Time
+	}
+
+	zone := time.FixedZone("", -7*60*60)
+
+	test_list := []TestData{
+		{"default", nil,
+			"2024-01-02T03:04:05Z 1979-05-27 07:32:00.5-07:00 07:32 " +
+				"2024-02-30",
+			[]string{"2024-01-02T03:04:05Z", "1979-05-27 07:32:00.5-07:00",
+				"07:32", "2024-02-30"},
+			[]time.Time{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				time.Date(1979, 5, 27, 7, 32, 0, 5e8, zone),
+				time.Date(0, 1, 1, 7, 32, 0, 0, time.UTC), {}}},
+		{"layouts", []string{"Jan _2 15:04:05", time.RFC1123},
+			"Jan  2 03:04:05 host Tue, 02 Jan 2024 03:04:05 UTC",
+			[]string{"Jan  2 03:04:05", "host",
+				"Tue, 02 Jan 2024 03:04:05 UTC"},
+			[]time.Time{time.Date(0, 1, 2, 3, 4, 5, 0, time.UTC), {},
+				time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SkipWhitespace = true
+			p.DateTimes = true
+			p.SetDateTimeLayouts(test_data.Layouts...)
+
+			var (
+				got   []string
+				times []time.Time
+			)
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Text)
+				times = append(times, token.Time())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+
+			if len(times) != len(test_data.Times) {
+				st.Fatalf("got %d times, expected %d", len(times),
+					len(test_data.Times))
+			}
+			for i, tm := range times {
+				if !tm.Equal(test_data.Times[i]) {
+					st.Errorf("time %d: got %s, expected %s", i, tm,
+						test_data.Times[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDateTimeJSON(t *testing.T) {
+	p := textparser.NewScannerString("2024-01-02T03:04:05.5Z")
+	p.DateTimes = true
+
+	tokens := scan_all(t, p)
+	data, err := json.Marshal(tokens[0])
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := new(textparser.Token)
+	if err = json.Unmarshal(data, got); err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	if !got.Time().Equal(tokens[0].Time()) || got.Text != tokens[0].Text {
+		t.Errorf("got %#v, expected %#v", got, tokens[0])
+	}
+}
+
+func TestDateTimeGob(t *testing.T) {
+	p := textparser.NewScannerString("2024-01-02T03:04:05.5Z")
+	p.DateTimes = true
+
+	tokens := scan_all(t, p)
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(tokens[0]); err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := new(textparser.Token)
+	if err := gob.NewDecoder(buf).Decode(got); err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	if !got.Time().Equal(tokens[0].Time()) || got.Text != tokens[0].Text {
+		t.Errorf("got %#v, expected %#v", got, tokens[0])
+	}
+}
@@ -0,0 +1,107 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The number of runes read between checks of the clock.
+const deadline_check_runes = 256
+
+// The underlying error of the ScanError returned when a call to Scan()
+// exceeds the budget set by SetDeadline(), for use with errors.Is().
+var ErrDeadlineExceeded = errors.New("scan deadline exceeded")
+
+// The state of the per-call deadline (see SetDeadline()).
+type deadline_state struct {
+	budget  time.Duration
+	at      time.Time // When the current call to Scan() times out.
+	runes   int       // Runes left until the next check of the clock.
+	expired bool
+}
+
+// Limits each call to Scan() to the duration d, so that pathological input,
+// e.g., a gigabyte-long string, cannot stall the caller in a single read.
+// A call that runs over is aborted with a ScanError having the code
+// CodeDeadlineExceeded and wrapping ErrDeadlineExceeded. The clock is only
+// checked every few hundred runes, so the abort is not exact. Scanning may
+// continue after a timeout, but resumes wherever the aborted read stopped.
+// A duration of 0 or less removes the deadline.
+func (ts *TokenScanner) SetDeadline(d time.Duration) {
+	if d <= 0 {
+		ts.deadline = nil
+		return
+	}
+
+	ts.deadline = &deadline_state{budget: d}
+}
+
+// Starts the clock for a call to Scan().
+func (ts *TokenScanner) start_deadline() {
+	dl := ts.deadline
+	dl.at = time.Now().Add(dl.budget)
+	dl.runes = deadline_check_runes
+	dl.expired = false
+}
+
+// Returns true if the deadline has passed, counting a rune read.
+func (ts *TokenScanner) deadline_passed() bool {
+	dl := ts.deadline
+	if dl.expired {
+		return true
+	}
+
+	if dl.runes--; dl.runes > 0 {
+		return false
+	}
+
+	dl.runes = deadline_check_runes
+	dl.expired = !time.Now().Before(dl.at)
+
+	return dl.expired
+}
+
+// Returns the error for a call to Scan() that exceeded the deadline. The
+// runes read by the aborted recognizer are accounted for, so that the next
+// call to Scan() starts at the right position.
+func (ts *TokenScanner) deadline_error() *ScanError {
+	ts.last_byte_len = ts.read_bytes
+	end := ts.pending_end_pos()
+
+	return &ScanError{
+		Msg: "Scan aborted",
+		Detail: fmt.Sprintf("The token started at %s; the deadline is %s.",
+			ts.pos, ts.deadline.budget),
+		Start: end,
+		End:   end,
+		Err:   ErrDeadlineExceeded,
+		Code:  CodeDeadlineExceeded,
+	}
+}
@@ -0,0 +1,157 @@
+package textparser_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSetDeadline(t *testing.T) {
+	long_string := `"` + strings.Repeat("x", 10000) + `"`
+
+	type TestData struct {
+		Name     string
+		Deadline time.Duration
+		Input    string
+		Expected []string
+		Timeout  bool
+	}
+
+	test_list := []TestData{
+		// The clock is only checked every few hundred runes, so short
+		// tokens never time out.
+		{"short tokens", time.Nanosecond, "a b c",
+			[]string{"Ident a", "Ident b", "Ident c"}, false},
+		{"long token", time.Nanosecond, "a " + long_string,
+			[]string{"Ident a"}, true},
+		{"long skipped whitespace", time.Nanosecond,
+			"a" + strings.Repeat(" ", 10000) + "b",
+			[]string{"Ident a"}, true},
+		{"no deadline", 0, "a " + long_string,
+			[]string{"Ident a", "String " + long_string}, false},
+		{"generous deadline", time.Hour, "a " + long_string,
+			[]string{"Ident a", "String " + long_string}, false},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScanner(strings.NewReader(test_data.Input))
+			p.SkipWhitespace = true
+			p.SetDeadline(test_data.Deadline)
+
+			got := []string{}
+			for p.Scan() {
+				got = append(got,
+					p.LastToken.Type.String()+" "+p.LastToken.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+
+			err := p.Err()
+			if !test_data.Timeout {
+				if err != nil && err != io.EOF {
+					st.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, textparser.ErrDeadlineExceeded) {
+				st.Errorf("got error %v, expected a timeout", err)
+			}
+			code := textparser.ErrorCodeOf(err)
+			if code != textparser.CodeDeadlineExceeded {
+				st.Errorf("got code %s, expected %s", code.Name(),
+					textparser.CodeDeadlineExceeded.Name())
+			}
+		})
+	}
+}
+
+func TestSetDeadlineResets(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader("a b"))
+	p.SetDeadline(time.Nanosecond)
+	p.Init(strings.NewReader(`"` + strings.Repeat("x", 10000) + `"`))
+
+	if !p.Scan() {
+		t.Errorf("Scan() failed after Init(): %v", p.Err())
+	}
+}
+
+func TestDeadlineResume(t *testing.T) {
+	type TestData struct {
+		Name  string
+		Input string
+	}
+
+	test_list := []TestData{
+		{"long white space", "a" + strings.Repeat(" ", 10000) + "b c\n d"},
+		{"long multibyte ident",
+			"a " + strings.Repeat("é", 1000) + " zz\n yy 1.5 -2"},
+	}
+
+	type TokenPos struct {
+		Text string
+		Pos  textparser.Position
+	}
+
+	// Only the tokens not cut short by the deadline are compared.
+	scan := func(input string, deadline time.Duration) []TokenPos {
+		p := textparser.NewScanner(strings.NewReader(input))
+		p.SkipWhitespace = true
+		p.SetDeadline(deadline)
+
+		toks := []TokenPos{}
+		for {
+			if !p.Scan() {
+				if errors.Is(p.Err(), textparser.ErrDeadlineExceeded) {
+					continue
+				}
+				break
+			}
+			if !strings.ContainsRune(p.LastToken.Text, 'é') {
+				toks = append(toks,
+					TokenPos{p.LastToken.Text, *p.Position()})
+			}
+		}
+
+		return toks
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			expected := scan(test_data.Input, 0)
+			got := scan(test_data.Input, time.Nanosecond)
+
+			if !reflect.DeepEqual(got, expected) {
+				st.Errorf("got %#v, expected %#v", got, expected)
+			}
+		})
+	}
+}
+
+func TestDeadlineErrorPosition(t *testing.T) {
+	input := "a " + strings.Repeat("x", 1000)
+	p := textparser.NewScanner(strings.NewReader(input))
+	p.SetDeadline(time.Nanosecond)
+
+	for p.Scan() {
+	}
+
+	var scan_err *textparser.ScanError
+	if !errors.As(p.Err(), &scan_err) {
+		t.Fatalf("got error %v, expected a ScanError", p.Err())
+	}
+
+	if scan_err.Start.Offset != scan_err.Start.Column-1 {
+		t.Errorf("error at offset %d, column %d, expected them to agree",
+			scan_err.Start.Offset, scan_err.Start.Column)
+	}
+}
@@ -0,0 +1,384 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// A summary of the configuration of a TokenScanner, for debugging, e.g., to
+// find out why two scanners behave differently. Sets of runes accepted by
+// predicates are found by probing the predicates, so they are limited to the
+// Basic Multilingual Plane below U+3000.
+type ConfigSummary struct {
+	// Recognizers in the order they are tried (see SetMatchers()), with
+	// "(skipped)" appended for those whose tokens are skipped.
+	Recognizers []string
+
+	// Names of the predicate functions, keyed by field name, e.g.,
+	// "IsIdentRune": "textparser.IsIdentRune".
+	Predicates map[string]string
+
+	Quotes           []string // Opening and closing quote pairs.
+	EscapeRunes      string   // Escape runes inside quotes.
+	CollapseEscapes  bool
+	DoubledQuotes    bool
+	ConcatStrings    bool
+	StringPrefixes   []string // Prefixes set by SetStringPrefixes().
+	Comments         []string // Comment delimiters.
+	TrailingComments bool
+	RegionMarkers    RegionMarkers
+	Operators        []string // Multi-rune operators.
+	Separators       []string // Set by SetNamespaceSeparators().
+	Brackets         []string // Bracket pairs.
+	Numbers          NumberSyntax
+	DisableNumbers   bool
+	NumberSuffixes   []string // Suffixes set by SetNumberSuffixes().
+	Signs            SignMode
+	AllowLeadingPlus bool
+	NumberLocale     NumberLocale
+	Parameters       string // Bind parameter prefixes, if any.
+	Variables        bool
+	VariableSigils   string
+	LineDirective    string // Line directive prefix, if any.
+	Normalize        Normalization
+	NormalizeEOL     bool // NormalizeValueEOL.
+	ColumnMode       ColumnMode
+	Unknown          UnknownMode
+	UnknownType      TokenType
+	Transforms       []TokenType // Token types with emit transforms.
+	Middleware       int         // Number of middleware functions (see Use()).
+	ReuseToken       bool
+	ByteTokens       bool
+	TokenLimit       int   // Token budget set by ScanLimit(), if any.
+	ByteLimit        int64 // Byte budget set by ScanLimit(), if any.
+	MaxTokenBytes    int
+	CachedPredicates []string // Predicates wrapped by CachePredicates().
+	Suppress         string   // Directive for suppression comments.
+	Filenames        FilenameStyle
+	FileRoot         string      // Root set by SetFileRoot(), if any.
+	LevelWords       []string    // Words set by SetLevelWords().
+	Keywords         []string    // Words set by SetKeywords().
+	SkipTypes        []TokenType // Types set by SkipTypes().
+	Addresses        bool
+	KeyValuePairs    bool
+	URLs             bool
+	DateTimeLayouts  []string // Layouts set by SetDateTimeLayouts().
+	Indentation      IndentPolicy
+	Continuations    []string // Line continuations, e.g., "\\".
+}
+
+const probe_limit = 0x3000
+
+// Returns a summary of the scanner's configuration.
+func (ts *TokenScanner) ConfigSummary() *ConfigSummary {
+	c := &ConfigSummary{
+		Predicates: map[string]string{
+			"IsIdentRune":  func_name(ts.IsIdentRune),
+			"IsSpaceRune":  func_name(ts.IsSpaceRune),
+			"IsQuoteRune":  func_name(ts.IsQuoteRune),
+			"IsEscapeRune": func_name(ts.IsEscapeRune),
+			"IsSymbolRune": func_name(ts.IsSymbolRune),
+			"IsDigitRune":  func_name(ts.IsDigitRune),
+
+			"IsIdentQuoteRune": func_name(ts.IsIdentQuoteRune),
+		},
+		CollapseEscapes:  ts.CollapseEscapes,
+		DoubledQuotes:    ts.DoubledQuotes,
+		ConcatStrings:    ts.ConcatStrings,
+		TrailingComments: ts.TrailingComments,
+		RegionMarkers:    ts.region_markers,
+		Parameters:       ts.ParameterPrefixes,
+		Variables:        ts.Variables,
+		VariableSigils:   ts.VariableSigils,
+		LineDirective:    string(ts.line_directive_prefix),
+		Normalize:        ts.Normalize,
+		NormalizeEOL:     ts.NormalizeValueEOL,
+		Numbers:          ts.Numbers,
+		DisableNumbers:   ts.DisableNumbers,
+		NumberSuffixes:   ts.NumberSuffixes(),
+		Separators:       ts.NamespaceSeparators(),
+		Signs:            ts.Signs,
+		AllowLeadingPlus: ts.AllowLeadingPlus,
+		NumberLocale:     ts.NumberLocale,
+		ColumnMode:       ts.ColumnMode,
+		Unknown:          ts.Unknown,
+		UnknownType:      ts.UnknownType,
+		Middleware:       len(ts.middleware),
+		ReuseToken:       ts.ReuseToken,
+		ByteTokens:       ts.ByteTokens,
+		TokenLimit:       ts.limit.tokens,
+		ByteLimit:        ts.limit.bytes,
+		MaxTokenBytes:    ts.MaxTokenBytes,
+		Suppress:         ts.suppress_directive,
+		Filenames:        ts.FilenameStyle,
+		FileRoot:         ts.file_root,
+		LevelWords:       ts.LevelWords(),
+		Keywords:         ts.Keywords(),
+		SkipTypes:        ts.SkippedTypes(),
+		Addresses:        ts.Addresses,
+		KeyValuePairs:    ts.KeyValuePairs,
+		URLs:             ts.URLs,
+		DateTimeLayouts:  ts.DateTimeLayouts(),
+		Indentation:      ts.Indentation(),
+	}
+
+	matchers := ts.matchers
+	if ts.dsv != nil {
+		matchers = []TokenMatcher{class_matcher(class_dsv)}
+	} else if ts.markdown != nil {
+		matchers = []TokenMatcher{class_matcher(class_markdown)}
+	}
+
+	for _, m := range matchers {
+		name := m.Name()
+		if class, ok := m.(class_matcher); ok {
+			if !ts.class_enabled(rune_class(class)) {
+				continue
+			}
+			if ts.skip_class(rune_class(class)) {
+				name += " (skipped)"
+			}
+		}
+		c.Recognizers = append(c.Recognizers, name)
+	}
+	if ts.dsv == nil && ts.markdown == nil {
+		c.Recognizers = append(c.Recognizers,
+			"unknown ("+ts.Unknown.String()+")")
+	}
+
+	for _, p := range ts.StringPrefixes() {
+		c.StringPrefixes = append(c.StringPrefixes, p.String())
+	}
+
+	if ts.IsQuoteRune != nil {
+		for ch := rune(0); ch < probe_limit; ch++ {
+			if ok, closing := ts.IsQuoteRune(ch); ok {
+				c.Quotes = append(c.Quotes, string([]rune{ch, closing}))
+			}
+		}
+	}
+
+	if ts.IsEscapeRune != nil {
+		var escapes []rune
+		for ch := rune(0); ch < probe_limit; ch++ {
+			if ts.IsEscapeRune(ch, 1, []rune{'"'}) {
+				escapes = append(escapes, ch)
+			}
+		}
+		c.EscapeRunes = string(escapes)
+	}
+
+	c.Operators = ts.Operators()
+	c.Brackets = ts.Brackets()
+
+	for _, cont := range ts.continuations {
+		c.Continuations = append(c.Continuations, cont.String())
+	}
+
+	for _, style := range ts.CommentStyles() {
+		c.Comments = append(c.Comments, style.String())
+	}
+
+	for token_type := range ts.transforms {
+		c.Transforms = append(c.Transforms, token_type)
+	}
+	sort.Slice(c.Transforms, func(i, j int) bool {
+		return c.Transforms[i] < c.Transforms[j]
+	})
+
+	for name := range ts.predicate_caches {
+		c.CachedPredicates = append(c.CachedPredicates, name)
+	}
+	sort.Strings(c.CachedPredicates)
+
+	return c
+}
+
+// Returns the name of a function value, shortening names from this package.
+func func_name(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if !v.IsValid() || v.IsNil() {
+		return "nil"
+	}
+
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if strings.HasPrefix(name, "go-textparser") {
+		name = strings.TrimPrefix(name, "go-")
+	}
+
+	if name == "textparser.(*PredicateCache).Check-fm" {
+		return "cached"
+	}
+
+	return name
+}
+
+func (c *ConfigSummary) lines() [][2]string {
+	predicates := make([]string, 0, len(c.Predicates))
+	for name, fn := range c.Predicates {
+		predicates = append(predicates, name+"="+fn)
+	}
+	sort.Strings(predicates)
+
+	transforms := make([]string, 0, len(c.Transforms))
+	for _, token_type := range c.Transforms {
+		transforms = append(transforms, token_type.name())
+	}
+
+	skip_types := make([]string, 0, len(c.SkipTypes))
+	for _, token_type := range c.SkipTypes {
+		skip_types = append(skip_types, token_type.name())
+	}
+
+	quote_all := func(list []string) string {
+		quoted := make([]string, 0, len(list))
+		for _, s := range list {
+			quoted = append(quoted, fmt.Sprintf("%q", s))
+		}
+		return strings.Join(quoted, " ")
+	}
+
+	return [][2]string{
+		{"recognizers", strings.Join(c.Recognizers, ", ")},
+		{"predicates", strings.Join(predicates, " ")},
+		{"quotes", quote_all(c.Quotes)},
+		{"string prefixes", quote_all(c.StringPrefixes)},
+		{"escapes", fmt.Sprintf("%q collapse=%t doubled=%t concat=%t",
+			c.EscapeRunes, c.CollapseEscapes, c.DoubledQuotes,
+			c.ConcatStrings)},
+		{"comments", fmt.Sprintf("%s trailing=%t", quote_all(c.Comments),
+			c.TrailingComments)},
+		{"regions", fmt.Sprintf("%q %q", c.RegionMarkers.Start,
+			c.RegionMarkers.End)},
+		{"operators", quote_all(c.Operators)},
+		{"namespace separators", quote_all(c.Separators)},
+		{"brackets", quote_all(c.Brackets)},
+		{"numbers", fmt.Sprintf(
+			"%s signs=%s plus=%t locale=%s disabled=%t", c.Numbers,
+			c.Signs, c.AllowLeadingPlus, c.NumberLocale, c.DisableNumbers)},
+		{"number suffixes", quote_all(c.NumberSuffixes)},
+		{"parameters", fmt.Sprintf("%q variables=%t sigils=%q",
+			c.Parameters, c.Variables, c.VariableSigils)},
+		{"line directive", fmt.Sprintf("%q", c.LineDirective)},
+		{"normalize", fmt.Sprintf("%s eol=%t", c.Normalize,
+			c.NormalizeEOL)},
+		{"columns", c.ColumnMode.String()},
+		{"unknown", fmt.Sprintf("%s type=%s", c.Unknown,
+			c.UnknownType.name())},
+		{"transforms", strings.Join(transforms, " ")},
+		{"middleware", fmt.Sprintf("%d", c.Middleware)},
+		{"reuse token", fmt.Sprintf("%t bytes=%t", c.ReuseToken,
+			c.ByteTokens)},
+		{"limits", fmt.Sprintf("tokens=%d bytes=%d token-bytes=%d",
+			c.TokenLimit, c.ByteLimit, c.MaxTokenBytes)},
+		{"cached", strings.Join(c.CachedPredicates, " ")},
+		{"suppress", fmt.Sprintf("%q", c.Suppress)},
+		{"filenames", fmt.Sprintf("%s root=%q", c.Filenames, c.FileRoot)},
+		{"urls", fmt.Sprintf("%t", c.URLs)},
+		{"layouts", quote_all(c.DateTimeLayouts)},
+		{"indentation", c.Indentation.String()},
+		{"continuations", quote_all(c.Continuations)},
+		{"keywords", strings.Join(c.Keywords, " ")},
+		{"skip types", strings.Join(skip_types, " ")},
+		{"log lines", fmt.Sprintf("levels=%s addresses=%t key-values=%t",
+			strings.Join(c.LevelWords, ","), c.Addresses, c.KeyValuePairs)},
+	}
+}
+
+// Returns a multi-line, human-readable representation of the configuration,
+// with the values aligned after the longest label.
+func (c *ConfigSummary) String() string {
+	lines := c.lines()
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line[0])+1)
+	}
+
+	b := new(strings.Builder)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%-*s %s\n", width, line[0]+":", line[1])
+	}
+
+	return b.String()
+}
+
+// Returns a single-line representation of the configuration as a Go
+// composite literal.
+func (c *ConfigSummary) GoString() string {
+	// Conversion to a type without methods avoids recursing into GoString.
+	type plain ConfigSummary
+	s := fmt.Sprintf("%#v", plain(*c))
+
+	return "textparser.ConfigSummary" + s[strings.IndexByte(s, '{'):]
+}
+
+// Returns the name of the profile, or "default" for a nil profile.
+func (p *Profile) display_name() string {
+	if p == nil {
+		return "default"
+	}
+
+	return p.Name
+}
+
+// Returns a multi-line, human-readable representation of the profile,
+// listing the configuration of a scanner it sets up.
+func (p *Profile) String() string {
+	return fmt.Sprintf("Profile %q:\n%s", p.display_name(),
+		p.NewScanner(strings.NewReader("")).ConfigSummary())
+}
+
+// Returns a single-line representation of the profile, in the style of a Go
+// composite literal, with the configure function given by name. See String()
+// for the configuration of a scanner it sets up.
+func (p *Profile) GoString() string {
+	if p == nil {
+		return "(*textparser.Profile)(nil)"
+	}
+
+	configure := "(func(*textparser.TokenScanner))(nil)"
+	if p.Configure != nil {
+		configure = func_name(p.Configure)
+	}
+
+	return fmt.Sprintf("&textparser.Profile{Name: %q, Configure: %s}", p.Name,
+		configure)
+}
@@ -0,0 +1,129 @@
+package textparser_test
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestConfigSummary(t *testing.T) {
+	ts := textparser.NewScanner(strings.NewReader(""))
+	ts.SkipComments = false
+	ts.SetLineDirective("#line", textparser.ParseLineDirectiveC)
+	ts.SetTransform(textparser.TokenTypeIdent, textparser.FoldCase)
+	ts.CachePredicates(16)
+
+	c := ts.ConfigSummary()
+
+	expected_recognizers := []string{"directive", "whitespace (skipped)",
+		"comment", "quoted", "ident", "number", "symbol", "unknown (Stop)"}
+	if !reflect.DeepEqual(c.Recognizers, expected_recognizers) {
+		t.Errorf("recognizers: got %#v, expected %#v", c.Recognizers,
+			expected_recognizers)
+	}
+
+	expected_quotes := []string{`""`, "''", "``"}
+	if !reflect.DeepEqual(c.Quotes, expected_quotes) {
+		t.Errorf("quotes: got %#v, expected %#v", c.Quotes, expected_quotes)
+	}
+
+	if c.EscapeRunes != `\` {
+		t.Errorf("escapes: got %q, expected %q", c.EscapeRunes, `\`)
+	}
+
+	if c.LineDirective != "#line" {
+		t.Errorf("line directive: got %q, expected %q", c.LineDirective,
+			"#line")
+	}
+
+	expected_transforms := []textparser.TokenType{textparser.TokenTypeIdent}
+	if !reflect.DeepEqual(c.Transforms, expected_transforms) {
+		t.Errorf("transforms: got %#v, expected %#v", c.Transforms,
+			expected_transforms)
+	}
+
+	if got := c.Predicates["IsIdentRune"]; got != "cached" {
+		t.Errorf("IsIdentRune: got %q, expected %q", got, "cached")
+	}
+
+	if got := c.Predicates["IsQuoteRune"]; got != "textparser.IsQuoteRune" {
+		t.Errorf("IsQuoteRune: got %q, expected %q", got,
+			"textparser.IsQuoteRune")
+	}
+}
+
+func TestProfileString(t *testing.T) {
+	profile := &textparser.Profile{
+		Name: "test",
+		Configure: func(ts *textparser.TokenScanner) {
+			ts.SkipWhitespace = false
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				if ch == '«' {
+					return true, '»'
+				}
+				return false, 0
+			}
+		},
+	}
+
+	s := profile.String()
+	for _, expected := range []string{
+		`Profile "test":`,
+		"recognizers:          whitespace, comment (skipped), quoted,",
+		`quotes:               "«»"`,
+		"IsQuoteRune=textparser_test.TestProfileString.func1.1",
+		`comments:             "//" "/* */"`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("got %q, expected it to contain %q", s, expected)
+		}
+	}
+
+	// The values line up after the longest label.
+	value_at := regexp.MustCompile(`^[a-z ]+: +`)
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")[1:]
+	for _, line := range lines {
+		at := len(value_at.FindString(line))
+		if at != len("namespace separators: ") {
+			t.Errorf("got value at byte %d in %q, expected %d", at, line,
+				len("namespace separators: "))
+		}
+	}
+
+	gs := profile.GoString()
+	expected_gs := `&textparser.Profile{Name: "test", ` +
+		`Configure: textparser_test.TestProfileString.func1}`
+	if gs != expected_gs {
+		t.Errorf("got %q, expected %q", gs, expected_gs)
+	}
+
+	// The fields shown are those of the struct, in order.
+	var fields []string
+	for _, m := range regexp.MustCompile(`(?:{|, )(\w+): `).
+		FindAllStringSubmatch(gs, -1) {
+		fields = append(fields, m[1])
+	}
+	profile_type := reflect.TypeOf(textparser.Profile{})
+	var expected_fields []string
+	for i := 0; i < profile_type.NumField(); i++ {
+		expected_fields = append(expected_fields, profile_type.Field(i).Name)
+	}
+	if !reflect.DeepEqual(fields, expected_fields) {
+		t.Errorf("got fields %q, expected %q", fields, expected_fields)
+	}
+
+	empty := &textparser.Profile{Name: "empty"}
+	expected_gs = `&textparser.Profile{Name: "empty", ` +
+		`Configure: (func(*textparser.TokenScanner))(nil)}`
+	if gs := empty.GoString(); gs != expected_gs {
+		t.Errorf("got %q, expected %q", gs, expected_gs)
+	}
+
+	var nil_profile *textparser.Profile
+	if s := nil_profile.String(); !strings.HasPrefix(s, `Profile "default":`) {
+		t.Errorf("got %q, expected the default profile", s)
+	}
+}
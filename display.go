@@ -0,0 +1,85 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+)
+
+// Maximum number of characters of token text included by Token.String(),
+// and so by ScannedToken.String() and TokenView.String(), and in the text
+// of logged token events. Longer text is cut short and followed by a note
+// giving its full length, so that huge tokens, such as embedded files, do
+// not flood logs and traces. Set to 0 for no limit. See also
+// Token.FullString().
+var DisplayTextLimit = 200
+
+// Returns text cut to at most limit characters, and whether it was cut. A
+// limit of 0 or less means no limit.
+func elide_text(text string, limit int) (string, bool) {
+	if limit <= 0 || len(text) <= limit {
+		return text, false
+	}
+
+	n := 0
+	for i := range text {
+		if n == limit {
+			return text[:i], true
+		}
+		n++
+	}
+
+	return text, false
+}
+
+// Returns the text quoted, cut to limit characters with a note of the full
+// length, e.g., "aaaa"... (1048576 bytes).
+func display_text(text string, limit int) string {
+	shown, cut := elide_text(text, limit)
+	if !cut {
+		return fmt.Sprintf("%q", text)
+	}
+
+	return fmt.Sprintf("%q... (%d bytes)", shown, len(text))
+}
+
+// Returns text for a log event, cut to DisplayTextLimit characters with a
+// note of the full length.
+func log_text(text string) string {
+	shown, cut := elide_text(text, DisplayTextLimit)
+	if !cut {
+		return text
+	}
+
+	return fmt.Sprintf("%s... (%d bytes)", shown, len(text))
+}
+
+// Returns a string representation of the token like String(), but always
+// with the full text, regardless of DisplayTextLimit.
+func (t *Token) FullString() string {
+	return t.format(0)
+}
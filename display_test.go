@@ -0,0 +1,69 @@
+package textparser_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestDisplayTextLimit(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Limit    int
+		Text     string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{"short", 5, "abc", `t=String r=a nc=3 nb=3: "abc"`},
+		{"exact", 3, "abc", `t=String r=a nc=3 nb=3: "abc"`},
+		{"cut", 2, "abc", `t=String r=a nc=3 nb=3: "ab"... (3 bytes)`},
+		{"cut runes", 2, "\u00e9\u00e9\u00e9",
+			`t=String r=` + "\u00e9" + ` nc=3 nb=6: "` +
+				"\u00e9\u00e9" + `"... (6 bytes)`},
+		{"no limit", 0, "abcdef", `t=String r=a nc=6 nb=6: "abcdef"`},
+	}
+
+	saved := textparser.DisplayTextLimit
+	defer func() { textparser.DisplayTextLimit = saved }()
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			textparser.DisplayTextLimit = test_data.Limit
+			first := []rune(test_data.Text)[0]
+			token := &textparser.Token{
+				Text:      test_data.Text,
+				NumBytes:  len(test_data.Text),
+				NumChars:  len([]rune(test_data.Text)),
+				FirstRune: first,
+				Type:      textparser.TokenTypeString,
+			}
+
+			if got := token.String(); got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+
+			if got := token.FullString(); !strings.HasSuffix(got,
+				`"`+test_data.Text+`"`) {
+				st.Errorf("got %q, expected full text", got)
+			}
+		})
+	}
+}
+
+func TestDisplayTextLimitLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	p := textparser.NewScannerString(strings.Repeat("x", 1000))
+	p.SetLogger(slog.New(slog.NewTextHandler(buf, nil)))
+	p.LogLevel = slog.LevelInfo
+	scan_all(t, p)
+
+	expected := "text=\"" + strings.Repeat("x", textparser.DisplayTextLimit) +
+		"... (1000 bytes)\""
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("got %q, expected it to contain %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,212 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A problem found while scanning.
+type Diagnostic struct {
+	Pos  Position  // Where the problem was found.
+	Msg  string    // Description of the problem.
+	Code ErrorCode // Stable code identifying the kind of problem.
+}
+
+// Returns a string representation of the diagnostic, e.g.,
+//
+//	foo.txt:3:10 (31): E001 UnterminatedString: Unterminated string ...
+func (d *Diagnostic) String() string {
+	if d.Code == CodeNone {
+		return fmt.Sprintf("%s: %s", &d.Pos, d.Msg)
+	}
+
+	return fmt.Sprintf("%s: %s %s: %s", &d.Pos, d.Code, d.Code.Name(),
+		d.Msg)
+}
+
+// A range of indexes [Start, End) into a slice of tokens.
+type TokenRange struct {
+	Start int
+	End   int
+}
+
+// A fully scanned document, as returned by ScanDocument().
+type Document struct {
+	Filename string // Filename, if any.
+	Source   []byte // The scanned text.
+
+	// The tokens scanned from the document, excluding any skipped by the
+	// profile.
+	Tokens []*ScannedToken
+
+	// Byte offset of the start of each line, starting with line 1 at index
+	// 0.
+	LineOffsets []int
+
+	// Index for converting between byte offsets and positions.
+	Index *LineIndex
+
+	// For each line, starting with line 1 at index 0, the range of Tokens
+	// that intersect the line. Tokens spanning several lines appear in the
+	// range of each of those lines.
+	LineTokens []TokenRange
+
+	// Problems found while scanning. Scanning stops at the first error, so
+	// there is at most one scan error.
+	Diagnostics []*Diagnostic
+
+	// Diagnostics suppressed by suppression comments (see
+	// SetSuppressDirective()), and the suppression comments themselves.
+	Suppressed   []*Diagnostic
+	Suppressions []Suppression
+
+	// Comment styles of the scanner, longest first.
+	comments []CommentStyle
+}
+
+// Scans all of the input using a scanner configured by the profile (nil for
+// the default configuration) and returns the tokens together with a line
+// index, per-line token ranges, and diagnostics. If the reader has a Name()
+// method, such as *os.File, its result is used as the filename. Scan errors
+// are reported as diagnostics; the returned error is only for failures
+// reading the input.
+func ScanDocument(r io.Reader, profile *Profile) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := profile.NewScanner(bytes.NewReader(data))
+
+	// Normalize here rather than in the scanner, so that the line offsets
+	// agree with the token offsets.
+	if form, ok := ts.Normalize.form(); ok {
+		data = form.Bytes(data)
+		ts.Init(bytes.NewReader(data))
+		profile.Apply(ts)
+		ts.Normalize = NoNormalization
+	}
+
+	doc := &Document{Source: data}
+	if named, ok := r.(interface{ Name() string }); ok {
+		ts.SetFilename(named.Name())
+		doc.Filename = ts.pos.Filename
+	}
+
+	doc.comments = ts.CommentStyles()
+
+	doc.Tokens, err = TokenizeAll(ts)
+	doc.Suppressions = ts.Suppressions()
+	if err != nil {
+		doc.AddDiagnostic(&Diagnostic{
+			Pos:  *ts.Position(),
+			Msg:  err.Error(),
+			Code: ErrorCodeOf(err),
+		})
+	}
+
+	doc.Index = NewLineIndexEOL(data, ts.eol)
+	doc.Index.Filename = doc.Filename
+	doc.Index.ColumnMode = ts.ColumnMode
+	doc.LineOffsets = doc.Index.lines
+
+	doc.LineTokens = make([]TokenRange, len(doc.LineOffsets))
+	for line := range doc.LineTokens {
+		doc.LineTokens[line] = doc.token_range(line + 1)
+	}
+
+	return doc, nil
+}
+
+// Adds a diagnostic, e.g., one found by a linter working on the tokens, to
+// Diagnostics, or to Suppressed if one of Suppressions applies to it.
+func (doc *Document) AddDiagnostic(diag *Diagnostic) {
+	for i := range doc.Suppressions {
+		s := &doc.Suppressions[i]
+		if s.Line == diag.Pos.Line && s.Matches(diag.Code) {
+			doc.Suppressed = append(doc.Suppressed, diag)
+			return
+		}
+	}
+
+	doc.Diagnostics = append(doc.Diagnostics, diag)
+}
+
+func (doc *Document) token_range(line int) TokenRange {
+	n := len(doc.Tokens)
+
+	start := sort.Search(n, func(i int) bool {
+		end := doc.Tokens[i].End
+		return end.Line > line || (end.Line == line && end.Column > 1)
+	})
+
+	end := sort.Search(n, func(i int) bool {
+		return doc.Tokens[i].Start.Line > line
+	})
+
+	if end < start {
+		end = start
+	}
+
+	return TokenRange{Start: start, End: end}
+}
+
+// Returns the number of lines in the document.
+func (doc *Document) NumLines() int {
+	return len(doc.LineOffsets)
+}
+
+// Returns the text of the given line (starting at 1), including the
+// end-of-line character, if any.
+func (doc *Document) LineText(line int) string {
+	if line < 1 || line > len(doc.LineOffsets) {
+		return ""
+	}
+
+	start := doc.LineOffsets[line-1]
+	end := len(doc.Source)
+	if line < len(doc.LineOffsets) {
+		end = doc.LineOffsets[line]
+	}
+
+	return string(doc.Source[start:end])
+}
+
+// Returns the tokens intersecting the given line (starting at 1).
+func (doc *Document) TokensOnLine(line int) []*ScannedToken {
+	if line < 1 || line > len(doc.LineTokens) {
+		return nil
+	}
+
+	r := doc.LineTokens[line-1]
+
+	return doc.Tokens[r.Start:r.End]
+}
@@ -0,0 +1,111 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanDocument(t *testing.T) {
+	src := "foo = 'bar\nbaz'\n\n// comment\nqux"
+
+	profile := &textparser.Profile{
+		Name: "comments",
+		Configure: func(ts *textparser.TokenScanner) {
+			ts.SkipComments = false
+		},
+	}
+
+	doc, err := textparser.ScanDocument(strings.NewReader(src), profile)
+	if err != nil {
+		t.Fatalf("couldn't scan document: %s", err)
+	}
+
+	token_list := make([]string, 0, len(doc.Tokens))
+	for _, tok := range doc.Tokens {
+		token_list = append(token_list, tok.Text)
+	}
+
+	expected := []string{"foo", "=", "'bar\nbaz'", "// comment\n", "qux"}
+	if !reflect.DeepEqual(token_list, expected) {
+		t.Errorf("got %#v, expected %#v", token_list, expected)
+	}
+
+	expected_offsets := []int{0, 11, 16, 17, 28}
+	if !reflect.DeepEqual(doc.LineOffsets, expected_offsets) {
+		t.Errorf("got offsets %v, expected %v", doc.LineOffsets,
+			expected_offsets)
+	}
+
+	expected_ranges := []textparser.TokenRange{
+		{Start: 0, End: 3},
+		{Start: 2, End: 3},
+		{Start: 3, End: 3},
+		{Start: 3, End: 4},
+		{Start: 4, End: 5},
+	}
+	if !reflect.DeepEqual(doc.LineTokens, expected_ranges) {
+		t.Errorf("got ranges %v, expected %v", doc.LineTokens,
+			expected_ranges)
+	}
+
+	if doc.NumLines() != 5 || doc.LineText(4) != "// comment\n" ||
+		doc.LineText(5) != "qux" {
+		t.Errorf("unexpected line text: %q, %q", doc.LineText(4),
+			doc.LineText(5))
+	}
+
+	if got := doc.TokensOnLine(2); len(got) != 1 || got[0].Text != "'bar\nbaz'" {
+		t.Errorf("unexpected tokens on line 2: %v", got)
+	}
+
+	if len(doc.Diagnostics) != 0 {
+		t.Errorf("unexpected diagnostics: %v", doc.Diagnostics)
+	}
+}
+
+func TestScanDocumentDiagnostics(t *testing.T) {
+	doc, err := textparser.ScanDocument(strings.NewReader("foo\n'bar"), nil)
+	if err != nil {
+		t.Fatalf("couldn't scan document: %s", err)
+	}
+
+	if len(doc.Tokens) != 1 {
+		t.Errorf("got %d tokens, expected 1", len(doc.Tokens))
+	}
+
+	if len(doc.Diagnostics) != 1 || doc.Diagnostics[0].Pos.Line != 2 {
+		t.Fatalf("unexpected diagnostics: %v", doc.Diagnostics)
+	}
+
+	if code := doc.Diagnostics[0].Code; code !=
+		textparser.CodeUnterminatedString {
+		t.Errorf("got code %s, expected %s", code,
+			textparser.CodeUnterminatedString)
+	}
+}
+
+func TestScanDocumentFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(filename, []byte("foo bar"), 0644); err != nil {
+		t.Fatalf("couldn't write file: %s", err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("couldn't open file: %s", err)
+	}
+	defer f.Close()
+
+	doc, err := textparser.ScanDocument(f, nil)
+	if err != nil {
+		t.Fatalf("couldn't scan document: %s", err)
+	}
+
+	if doc.Filename != filename || doc.Tokens[1].Start.Filename != filename {
+		t.Errorf("got filename %q, expected %q", doc.Filename, filename)
+	}
+}
@@ -0,0 +1,212 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// An entry in a dotenv or properties file, as returned by ParseDotenv().
+type EnvEntry struct {
+	Key string
+
+	// The value, unquoted and unescaped, with variable references kept as
+	// written.
+	Value string
+
+	Export bool     // True if the entry had an "export" prefix.
+	Refs   []string // Names of variables referenced by the value, in order.
+
+	KeyPos Position
+
+	// Position of the value, or of the end of the separator if the value is
+	// empty.
+	ValuePos Position
+}
+
+// Parses a dotenv or properties file, scanned with ProfileDotenv(). Each
+// entry is a key, "=" or ":", and a value, which may be empty, on the rest
+// of the line. The parts of a value, unquoted words, quoted strings, and
+// variable references, are joined with the white space between them, e.g.,
+// the value of KEY=a "b c"$D is "a b c$D". In double-quoted strings, "\n",
+// "\r", and "\t" stand for control characters, and a backslash escapes any
+// other rune, e.g., "\$" is a dollar sign rather than a reference.
+//
+// Malformed lines are skipped with a diagnostic giving their position, with
+// CodeMalformedEntry. Scan errors, e.g., an unterminated quoted value, end
+// parsing with a diagnostic. Diagnostics honor suppression comments (see
+// SetSuppressDirective()). The error is only for failures reading the input.
+func ParseDotenv(r io.Reader) ([]EnvEntry, []*Diagnostic, error) {
+	doc, err := ScanDocument(r, ProfileDotenv())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Scanning stops at a scan error, so the entry it is on is incomplete.
+	stop_line := -1
+	for _, diags := range [][]*Diagnostic{doc.Diagnostics, doc.Suppressed} {
+		if len(diags) > 0 {
+			stop_line = diags[0].Pos.Line
+		}
+	}
+
+	var entries []EnvEntry
+
+	tokens := doc.Tokens
+	for i := 0; i < len(tokens); {
+		// The tokens of an entry start on a new line.
+		j := i + 1
+		for j < len(tokens) && tokens[j].Start.Line <= tokens[j-1].End.Line {
+			j++
+		}
+
+		if stop_line > 0 && tokens[j-1].End.Line >= stop_line {
+			break
+		}
+
+		entry, diag := parse_env_entry(doc.Source, tokens[i:j])
+		if diag != nil {
+			doc.AddDiagnostic(diag)
+		} else {
+			entries = append(entries, *entry)
+		}
+
+		i = j
+	}
+
+	diags := doc.Diagnostics
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Pos.Offset < diags[j].Pos.Offset
+	})
+
+	return entries, diags, nil
+}
+
+// Returns a diagnostic for a malformed entry.
+func env_error(pos Position, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{Pos: pos, Msg: fmt.Sprintf(format, args...),
+		Code: CodeMalformedEntry}
+}
+
+// Parses the tokens of one entry.
+func parse_env_entry(src []byte, line []*ScannedToken) (*EnvEntry,
+	*Diagnostic) {
+	entry := new(EnvEntry)
+
+	k := 0
+	if len(line) > 2 && line[0].Type == TokenTypeIdent &&
+		line[0].Text == "export" && line[1].Type == TokenTypeIdent {
+		entry.Export = true
+		k = 1
+	}
+
+	key := line[k]
+	if key.Type != TokenTypeIdent {
+		return nil, env_error(key.Start, "Expected key, found %q", key.Text)
+	}
+	entry.Key = key.Text
+	entry.KeyPos = key.Start
+
+	if k+1 == len(line) {
+		return nil, env_error(key.End, "Expected '=' after key %q", key.Text)
+	}
+
+	sep := line[k+1]
+	if sep.Type != TokenTypeSymbol || (sep.Text != "=" && sep.Text != ":") {
+		return nil, env_error(sep.Start, "Expected '=' after key %q, found %q",
+			key.Text, sep.Text)
+	}
+
+	values := line[k+2:]
+	entry.ValuePos = sep.End
+	if len(values) > 0 {
+		entry.ValuePos = values[0].Start
+	}
+
+	b := new(strings.Builder)
+	for n, token := range values {
+		if n > 0 {
+			b.Write(src[values[n-1].End.Offset:token.Start.Offset])
+		}
+
+		text := string(src[token.Start.Offset:token.End.Offset])
+		switch token.Type {
+		case TokenTypeString:
+			entry.Refs = env_unquote(b, text, entry.Refs)
+		case TokenTypeVariable:
+			b.WriteString(text)
+			entry.Refs = append(entry.Refs, VariableName(text))
+		default:
+			b.WriteString(text)
+		}
+	}
+	entry.Value = b.String()
+
+	return entry, nil
+}
+
+// Writes the contents of a quoted string to b, processing the escapes of a
+// double-quoted string, and returns refs with the names of any variables it
+// references appended.
+func env_unquote(b *strings.Builder, s string, refs []string) []string {
+	if s[0] == '\'' {
+		b.WriteString(s[1 : len(s)-1])
+		return refs
+	}
+
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+
+		case ch == '$':
+			if name := VariableName(s[i:]); name != "" {
+				refs = append(refs, name)
+			}
+		}
+
+		b.WriteByte(ch)
+	}
+
+	return refs
+}
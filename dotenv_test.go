@@ -0,0 +1,101 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestProfileDotenv(t *testing.T) {
+	input := "export A=x#y ${B:-z}/$C # c\nD='e f'"
+	p := textparser.ProfileDotenv().NewScanner(strings.NewReader(input))
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected := []string{"Ident export", "Ident A", "Symbol =", "Ident x#y",
+		"Variable ${B:-z}", "Ident /", "Variable $C", "Ident D", "Symbol =",
+		"String 'e f'"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	input := "# settings\n" +
+		"export HOST = example.com\n" +
+		"URL=http://$HOST:80/a?b=c  # comment\n" +
+		"EMPTY=\n" +
+		"MSG=\"line 1\\nline \\\"2\\\" \\$X ${Y}\"\n" +
+		"MULTI='a\n  b' c\n" +
+		"bad line\n" +
+		"app.name: demo\n"
+
+	entries, diags, err := textparser.ParseDotenv(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type entry struct {
+		Key    string
+		Value  string
+		Export bool
+		Refs   []string
+		Line   int
+	}
+
+	var got []entry
+	for _, e := range entries {
+		got = append(got, entry{e.Key, e.Value, e.Export, e.Refs,
+			e.ValuePos.Line})
+	}
+
+	expected := []entry{
+		{"HOST", "example.com", true, nil, 2},
+		{"URL", "http://$HOST:80/a?b=c", false, []string{"HOST"}, 3},
+		{"EMPTY", "", false, nil, 4},
+		{"MSG", "line 1\nline \"2\" $X ${Y}", false, []string{"Y"}, 5},
+		{"MULTI", "a\n  b c", false, nil, 6},
+		{"app.name", "demo", false, nil, 9},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if len(diags) != 1 || diags[0].String() !=
+		`:8:5 (135): E011 MalformedEntry: Expected '=' after key "bad", `+
+			`found "line"` {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	input := "A=1\n" +
+		"# textparser:ignore E011\n" +
+		"oops\n" +
+		"=2\n" +
+		"B=\"unterminated\n"
+
+	entries, diags, err := textparser.ParseDotenv(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(entries) != 1 || entries[0].Key != "A" {
+		t.Errorf("unexpected entries: %#v", entries)
+	}
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.Code.Name())
+	}
+
+	expected := []string{"MalformedEntry", "UnterminatedString"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v (%v)", got, expected, diags)
+	}
+}
@@ -0,0 +1,225 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// State of delimiter-separated-values mode, set by SetDelimited().
+type dsv_state struct {
+	delim        rune
+	quote        rune // 0 if fields cannot be quoted.
+	field_done   bool // The current field has been emitted.
+	record_start bool // No token has been emitted for the current record.
+}
+
+// Switches the scanner to delimiter-separated-values (e.g., CSV or TSV)
+// mode, where Scan() emits a TokenTypeField token for each field, including
+// empty ones, a TokenTypeDelimiter token for each delimiter, and a
+// TokenTypeNewline token for each line ending ("\n" or "\r\n"). Blank lines
+// produce only a newline token.
+//
+// If quote is not 0, fields starting with it are quoted as in RFC 4180:
+// they may contain delimiters and line endings, and a doubled quote stands
+// for one quote. The Text of a quoted field is its value; its Raw text is
+// the field as scanned, including the quotes.
+//
+// A delim of 0 switches back to normal scanning. Any other delim switches
+// markdown inline mode (see SetMarkdownInline()) off.
+func (ts *TokenScanner) SetDelimited(delim, quote rune) {
+	if delim == 0 {
+		ts.dsv = nil
+		return
+	}
+
+	ts.markdown = nil
+	ts.dsv = &dsv_state{delim: delim, quote: quote, record_start: true}
+}
+
+// Returns the length in runes of the line ending starting at the next rune,
+// or 0 if there is none.
+func (ts *TokenScanner) dsv_newline_len(next []rune) int {
+	switch {
+	case len(next) > 0 && next[0] == '\n':
+		return 1
+	case len(next) > 1 && next[0] == '\r' && next[1] == '\n':
+		return 2
+	}
+
+	return 0
+}
+
+// Reads the next field, delimiter, or newline.
+func (ts *TokenScanner) get_dsv() (*Token, error) {
+	d := ts.dsv
+	next := ts.peek_upto(2)
+
+	at_delim := len(next) > 0 && next[0] == d.delim
+	newline_len := ts.dsv_newline_len(next)
+
+	switch {
+	case at_delim || newline_len > 0 || len(next) == 0:
+		// An empty field comes before a delimiter, and before the end of
+		// a record that has had a delimiter.
+		if !d.field_done && (at_delim || !d.record_start) {
+			d.field_done = true
+			d.record_start = false
+			return ts.dsv_token(TokenTypeField, nil, 0), nil
+		}
+
+		if len(next) == 0 {
+			return nil, io.EOF
+		}
+
+		n := 1
+		token_type := TokenTypeDelimiter
+		if !at_delim {
+			n = newline_len
+			token_type = TokenTypeNewline
+		}
+
+		runes, total_size, err := ts.get_n_runes(n)
+		if err != nil {
+			return nil, err
+		}
+
+		d.field_done = false
+		d.record_start = token_type == TokenTypeNewline
+
+		return ts.dsv_token(token_type, runes, total_size), nil
+	}
+
+	d.field_done = true
+	d.record_start = false
+
+	if d.quote != 0 && next[0] == d.quote {
+		return ts.get_dsv_quoted()
+	}
+
+	var (
+		runes      []rune
+		total_size int
+	)
+
+	for {
+		next = ts.peek_upto(2)
+		if len(next) == 0 || next[0] == d.delim ||
+			ts.dsv_newline_len(next) > 0 {
+			break
+		}
+
+		chars, size, err := ts.get_n_runes(1)
+		if err != nil {
+			return nil, err
+		}
+		runes = append(runes, chars...)
+		total_size += size
+	}
+
+	return ts.dsv_token(TokenTypeField, runes, total_size), nil
+}
+
+// Reads a quoted field.
+func (ts *TokenScanner) get_dsv_quoted() (*Token, error) {
+	d := ts.dsv
+
+	raw, total_size, err := ts.get_n_runes(1)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []rune
+	for {
+		chars, size, err := ts.get_n_runes(1)
+		if err != nil {
+			return nil, ts.unterminated_error("quoted field",
+				fmt.Sprintf("Couldn't find closing quote (%c).", d.quote),
+				CodeUnterminatedQuotedField, err)
+		}
+		raw = append(raw, chars...)
+		total_size += size
+
+		if chars[0] != d.quote {
+			value = append(value, chars[0])
+			continue
+		}
+
+		// A doubled quote stands for one quote.
+		if ts.check_next_rune_char(d.quote) {
+			chars, size, err = ts.get_n_runes(1)
+			if err != nil {
+				return nil, err
+			}
+			raw = append(raw, chars...)
+			total_size += size
+			value = append(value, d.quote)
+			continue
+		}
+
+		break
+	}
+
+	next := ts.peek_upto(2)
+	if len(next) > 0 && next[0] != d.delim && ts.dsv_newline_len(next) == 0 {
+		end := ts.pending_end_pos()
+		return nil, &ScanError{
+			Msg:   fmt.Sprintf("Unexpected %q after quoted field", next[0]),
+			Start: end,
+			End:   end,
+			Code:  CodeUnexpectedAfterQuotedField,
+		}
+	}
+
+	token := ts.dsv_token(TokenTypeField, raw, total_size)
+	token.Raw = token.Text
+	token.Text = runes_to_string(value)
+
+	return token, nil
+}
+
+// Returns a token for the runes read.
+func (ts *TokenScanner) dsv_token(token_type TokenType, runes []rune,
+	total_size int) *Token {
+	token := ts.new_token()
+	*token = Token{
+		Text:     ts.token_text(runes),
+		NumBytes: total_size,
+		NumChars: len(runes),
+		Type:     token_type,
+	}
+
+	if len(runes) > 0 {
+		token.FirstRune = runes[0]
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token
+}
@@ -0,0 +1,103 @@
+package textparser_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestDelimited(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Profile  *textparser.Profile
+		Input    string
+		Expected []string // Type, offset, and text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:    "csv",
+			Profile: textparser.ProfileCSV(),
+			Input:   "a,\"b,\"\"c\"\"\"\r\n\n,d,\n\"\"",
+			Expected: []string{"Field 0 a", "Delimiter 1 ,",
+				`Field 2 b,"c"`, "Newline 11 \r\n", "Newline 13 \n",
+				"Field 14 ", "Delimiter 14 ,", "Field 15 d",
+				"Delimiter 16 ,", "Field 17 ", "Newline 17 \n",
+				"Field 18 "},
+		},
+		{
+			Name:    "multi-line quoted field",
+			Profile: textparser.ProfileCSV(),
+			Input:   "\"x\ny\",z\n",
+			Expected: []string{"Field 0 x\ny", "Delimiter 5 ,",
+				"Field 6 z", "Newline 7 \n"},
+		},
+		{
+			Name:    "tsv",
+			Profile: textparser.ProfileTSV(),
+			Input:   "a b\t\"c\"\t",
+			Expected: []string{"Field 0 a b", "Delimiter 3 \t",
+				`Field 4 "c"`, "Delimiter 7 \t", "Field 8 "},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := test_data.Profile.NewScanner(
+				strings.NewReader(test_data.Input))
+
+			tokens, err := textparser.TokenizeAll(p)
+			if err != nil {
+				st.Fatalf("scan failed: %s", err)
+			}
+
+			var got []string
+			for _, token := range tokens {
+				got = append(got, fmt.Sprintf("%s %d %s", token.Type,
+					token.Start.Offset, token.Text))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestDelimitedRaw(t *testing.T) {
+	p := textparser.ProfileCSV().NewScanner(strings.NewReader(`"a""b",c`))
+
+	tokens := scan_all(t, p)
+	if len(tokens) != 3 {
+		t.Fatalf("got %d tokens, expected 3", len(tokens))
+	}
+
+	expected := &textparser.Token{Text: `a"b`, Raw: `"a""b"`, NumBytes: 6,
+		NumChars: 6, FirstRune: '"', Type: textparser.TokenTypeField}
+	if !reflect.DeepEqual(tokens[0], expected) {
+		t.Errorf("got %#v, expected %#v", tokens[0], expected)
+	}
+
+	if tokens[2].Raw != "" {
+		t.Errorf("got raw text %q for an unquoted field", tokens[2].Raw)
+	}
+}
+
+func TestDelimitedErrors(t *testing.T) {
+	for input, expected := range map[string]string{
+		`a,"b`: "Unterminated quoted field opened at :1:3 (2), " +
+			"unterminated at EOF :1:5 (4). Couldn't find closing quote (\").",
+		`"b"c`: "Unexpected 'c' after quoted field at :1:4 (3).",
+	} {
+		p := textparser.ProfileCSV().NewScanner(strings.NewReader(input))
+		for p.Scan() {
+		}
+
+		if err := p.Err(); err == nil || err.Error() != expected {
+			t.Errorf("%q: got %v, expected %q", input, err, expected)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	utf8 "unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// Returns a new TokenScanner reading from r, which provides text in the given
+// encoding, e.g., charmap.Windows1252 or unicode.UTF16(...) from the
+// golang.org/x/text/encoding packages. Byte offsets and counts reported by the
+// scanner refer to the original, encoded input.
+func NewScannerEncoding(r io.Reader, enc encoding.Encoding) *TokenScanner {
+	return NewScannerTransformer(r, enc.NewDecoder())
+}
+
+// Returns a new TokenScanner reading from r through the transformer, which
+// must produce UTF-8. Byte offsets and counts reported by the scanner refer to
+// the input of the transformer. Bytes it consumes without output before a
+// token, e.g., a byte order mark, move the token's start rather than being
+// counted in it. Setting Normalize on the scanner makes offsets refer to the
+// normalized UTF-8 text instead.
+func NewScannerTransformer(
+	r io.Reader,
+	t transform.Transformer,
+) *TokenScanner {
+	dr := &decoding_reader{
+		src:   bufio.NewReader(r),
+		t:     t,
+		sizes: new(rune_sizes),
+	}
+	t.Reset()
+
+	ts := NewScanner(dr)
+	ts.source_sizes = dr.sizes
+
+	return ts
+}
+
+// Sizes in the original input of each decoded rune, in order, with a cursor
+// tracking the scanner's progress through them.
+type rune_sizes struct {
+	sizes  []rune_size
+	cursor int
+}
+
+// The size of a rune in the original input, and the number of bytes before it
+// that were consumed without output, e.g., a byte order mark.
+type rune_size struct {
+	skip, size int
+}
+
+func (rs *rune_sizes) push(skip, size int) {
+	// Drop sizes the scanner is done with, keeping one for unreading.
+	if rs.cursor > 4096 {
+		n := copy(rs.sizes, rs.sizes[rs.cursor-1:])
+		rs.sizes = rs.sizes[:n]
+		rs.cursor = 1
+	}
+
+	rs.sizes = append(rs.sizes, rune_size{skip: skip, size: size})
+}
+
+func (rs *rune_sizes) next() (skip, size int) {
+	if rs.cursor >= len(rs.sizes) {
+		return 0, 0
+	}
+
+	rs.cursor++
+
+	return rs.sizes[rs.cursor-1].skip, rs.sizes[rs.cursor-1].size
+}
+
+// Marks the bytes skipped before the last rune returned by next() as
+// accounted for, so they are not counted again when it is read again.
+func (rs *rune_sizes) drop_skip() {
+	if rs.cursor > 0 {
+		rs.sizes[rs.cursor-1].skip = 0
+	}
+}
+
+func (rs *rune_sizes) back() {
+	if rs.cursor > 0 {
+		rs.cursor--
+	}
+}
+
+// A reader decoding its source through a transformer, feeding it as few bytes
+// as possible at a time so that the number of source bytes behind each decoded
+// rune is known.
+type decoding_reader struct {
+	src     *bufio.Reader
+	t       transform.Transformer
+	sizes   *rune_sizes
+	pending []byte // Decoded bytes not yet returned by Read().
+	dst     [64]byte
+	eof     bool
+}
+
+func (dr *decoding_reader) Read(p []byte) (int, error) {
+	for len(dr.pending) == 0 {
+		if dr.eof {
+			return 0, io.EOF
+		}
+
+		if err := dr.decode_some(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dr.pending)
+	dr.pending = dr.pending[n:]
+
+	return n, nil
+}
+
+// Decodes the smallest prefix of the remaining source that produces output,
+// and records the size of each rune produced.
+func (dr *decoding_reader) decode_some() error {
+	skipped := 0
+
+	for n := 1; ; n++ {
+		src, err := dr.src.Peek(n)
+		at_eof := false
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			at_eof = true
+			if len(src) == 0 && skipped == 0 {
+				dr.eof = true
+				return nil
+			}
+		}
+
+		n_dst, n_src, t_err := dr.t.Transform(dr.dst[:], src, at_eof)
+		if t_err != nil && !errors.Is(t_err, transform.ErrShortSrc) {
+			return t_err
+		}
+
+		if n_src == 0 {
+			if at_eof {
+				dr.eof = true
+				return nil
+			}
+			continue
+		}
+
+		dr.src.Discard(n_src)
+
+		if n_dst == 0 {
+			// Consumed without output, e.g., a byte order mark. Record
+			// it with the next rune.
+			skipped += n_src
+			n = 0
+			continue
+		}
+
+		// Attribute the source bytes to the first rune produced.
+		out := dr.dst[:n_dst]
+		size := n_src
+		for len(out) > 0 {
+			_, rune_size := utf8.DecodeRune(out)
+			dr.sizes.push(skipped, size)
+			skipped, size = 0, 0
+			out = out[rune_size:]
+		}
+
+		dr.pending = append(dr.pending[:0], dr.dst[:n_dst]...)
+
+		return nil
+	}
+}
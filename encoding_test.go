@@ -0,0 +1,87 @@
+package textparser_test
+
+import (
+	"bytes"
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestScannerEncoding(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Encoding encoding.Encoding
+		Offsets  []int
+		NumBytes []int
+	}{
+		{"Windows-1252", charmap.Windows1252, []int{0, 6, 8},
+			[]int{5, 1, 7}},
+		{"ISO-8859-1", charmap.ISO8859_1, []int{0, 6, 8}, []int{5, 1, 7}},
+		// The encoder writes a byte order mark, which comes before the
+		// first token.
+		{"UTF-16LE with BOM",
+			unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+			[]int{2, 14, 18}, []int{10, 2, 14}},
+		{"UTF-8 with BOM", unicode.UTF8BOM, []int{3, 11, 13},
+			[]int{7, 1, 8}},
+	}
+
+	text := "caféé = 'naïve'"
+	expected := []string{"caféé", "=", "'naïve'"}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			src, err := test_data.Encoding.NewEncoder().Bytes([]byte(text))
+			if err != nil {
+				st.Fatalf("couldn't encode input: %s", err)
+			}
+
+			p := textparser.NewScannerEncoding(bytes.NewReader(src),
+				test_data.Encoding)
+
+			var (
+				token_list []string
+				offsets    []int
+				num_bytes  []int
+			)
+			for p.Scan() {
+				token_list = append(token_list, p.TokenText())
+				offsets = append(offsets, p.Position().Offset)
+				num_bytes = append(num_bytes, p.Token().NumBytes)
+
+				// The span of the token covers exactly its bytes.
+				piece, err := test_data.Encoding.NewDecoder().Bytes(
+					src[p.Position().Offset:p.EndPosition().Offset])
+				if err != nil {
+					st.Fatalf("couldn't decode token: %s", err)
+				}
+				if string(piece) != p.TokenText() {
+					st.Errorf("got %q from the input at the token's span, "+
+						"expected %q", piece, p.TokenText())
+				}
+			}
+
+			if !reflect.DeepEqual(token_list, expected) {
+				st.Errorf("got %#v, expected %#v", token_list, expected)
+			}
+
+			if !reflect.DeepEqual(offsets, test_data.Offsets) {
+				st.Errorf("got offsets %v, expected %v", offsets,
+					test_data.Offsets)
+			}
+
+			if !reflect.DeepEqual(num_bytes, test_data.NumBytes) {
+				st.Errorf("got sizes %v, expected %v", num_bytes,
+					test_data.NumBytes)
+			}
+
+			if end := p.EndPosition().Offset; end != len(src) {
+				st.Errorf("got end offset %d, expected %d", end, len(src))
+			}
+		})
+	}
+}
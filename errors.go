@@ -0,0 +1,100 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// An error found while scanning a construct, such as a quoted string or a
+// multi-line comment. It reports both where the construct started and where
+// scanning gave up, as these may be far apart.
+type ScanError struct {
+	Msg    string    // Short description, e.g., "Unterminated string".
+	Detail string    // Additional information, if any.
+	Start  Position  // Position where the construct started.
+	End    Position  // Position where scanning gave up.
+	Err    error     // The underlying error, e.g., io.EOF, if any.
+	Code   ErrorCode // Stable code identifying the kind of error.
+}
+
+// Returns the error message, e.g.,
+//
+//	Unterminated string opened at foo.txt:3:10 (31), unterminated at EOF
+//	foo.txt:120:1 (2041). Couldn't find end quote (").
+func (e *ScanError) Error() string {
+	msg := e.Msg
+	if e.Start != e.End {
+		msg = fmt.Sprintf("%s opened at %s", msg, &e.Start)
+		if e.Err == io.EOF {
+			msg = fmt.Sprintf("%s, unterminated at EOF %s", msg, &e.End)
+		} else {
+			msg = fmt.Sprintf("%s, unterminated at %s", msg, &e.End)
+		}
+	} else {
+		msg = fmt.Sprintf("%s at %s", msg, &e.Start)
+	}
+
+	if e.Err != nil && e.Err != io.EOF {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+
+	if e.Detail != "" {
+		return fmt.Sprintf("%s. %s", msg, e.Detail)
+	}
+
+	return msg + "."
+}
+
+// Returns the underlying error, unless it is io.EOF, so that
+// errors.Is(err, io.EOF) does not mistake a ScanError for the normal end of
+// the input.
+func (e *ScanError) Unwrap() error {
+	if e.Err == io.EOF {
+		return nil
+	}
+
+	return e.Err
+}
+
+// Returns a ScanError for an unterminated construct of the given kind (e.g.,
+// "string") that started at the current token position.
+func (ts *TokenScanner) unterminated_error(
+	kind, detail string,
+	code ErrorCode,
+	err error,
+) *ScanError {
+	return &ScanError{
+		Msg:    "Unterminated " + kind,
+		Detail: detail,
+		Start:  *ts.pos,
+		End:    ts.pending_end_pos(),
+		Err:    err,
+		Code:   code,
+	}
+}
@@ -0,0 +1,54 @@
+package textparser_test
+
+import (
+	"errors"
+	textparser "github.com/cuberat/go-textparser"
+	"io"
+	"testing"
+)
+
+func TestScanErrorRange(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:  `string`,
+			Input: "foo = \"bar\nbaz\nqux",
+			Expected: `Unterminated string opened at test_file:1:7 (6), ` +
+				`unterminated at EOF test_file:3:4 (18). Couldn't find ` +
+				`end quote (").`,
+		},
+		{
+			Name:  `block comment`,
+			Input: "foo /* bar\n* baz",
+			Expected: `Unterminated comment opened at test_file:1:5 (4), ` +
+				`unterminated at EOF test_file:2:6 (16). Couldn't find ` +
+				`end of comment (*/).`,
+		},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetFilename("test_file")
+			for p.Scan() {
+			}
+
+			err := p.Err()
+			var scan_err *textparser.ScanError
+			if !errors.As(err, &scan_err) {
+				st.Fatalf("expected a ScanError, got %v", err)
+			}
+
+			if err.Error() != test_data.Expected {
+				st.Errorf("got %q, expected %q", err, test_data.Expected)
+			}
+
+			if errors.Is(err, io.EOF) {
+				st.Errorf("error should not match io.EOF")
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package exprparser parses expressions from the tokens of a
+// textparser.TokenScanner with a configurable operator-precedence (Pratt)
+// parser, producing a tree of literals, identifiers, unary and binary
+// operators, and function calls, with positions on every node.
+package exprparser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// The kind of an expression node.
+type Kind int
+
+const (
+	KindLiteral Kind = iota // A number, string, or other literal.
+	KindIdent               // An identifier.
+	KindUnary               // A prefix operator applied to one operand.
+	KindBinary              // An infix operator applied to two operands.
+	KindCall                // A function call.
+)
+
+var kind_names = [...]string{"Literal", "Ident", "Unary", "Binary", "Call"}
+
+// Returns the name of the kind, e.g., "Binary".
+func (k Kind) String() string {
+	if k < 0 || int(k) > len(kind_names)-1 {
+		return ""
+	}
+
+	return kind_names[k]
+}
+
+// A node of an expression tree.
+type Node struct {
+	Kind Kind
+
+	// The literal or identifier, the operator, or the opening parenthesis
+	// of a call.
+	Token *textparser.ScannedToken
+
+	// The operands of an operator, or the arguments of a call.
+	Args []*Node
+
+	// The function of a call, e.g., the identifier "f" in "f(x)".
+	Func *Node
+
+	// The closing parenthesis of a call.
+	Close *textparser.ScannedToken
+}
+
+// Returns the span of source the node was parsed from, not including any
+// parentheses around it.
+func (n *Node) Span() textparser.Span {
+	span := n.Token.Span
+
+	switch n.Kind {
+	case KindBinary:
+		span.Start = n.Args[0].Span().Start
+		span.End = n.Args[1].Span().End
+	case KindUnary:
+		span.End = n.Args[0].Span().End
+	case KindCall:
+		span.Start = n.Func.Span().Start
+		span.End = n.Close.End
+	}
+
+	return span
+}
+
+// Returns the expression as text, with every operator application in
+// parentheses, e.g., "(a + (b * f(c, -d)))".
+func (n *Node) String() string {
+	switch n.Kind {
+	case KindUnary:
+		op := n.Token.Text
+		if n.Token.Type == textparser.TokenTypeIdent {
+			// Keep word operators apart from their operands.
+			op += " "
+		}
+		return "(" + op + n.Args[0].String() + ")"
+	case KindBinary:
+		return "(" + n.Args[0].String() + " " + n.Token.Text + " " +
+			n.Args[1].String() + ")"
+	case KindCall:
+		args := make([]string, 0, len(n.Args))
+		for _, arg := range n.Args {
+			args = append(args, arg.String())
+		}
+		return n.Func.String() + "(" + strings.Join(args, ", ") + ")"
+	}
+
+	return n.Token.Text
+}
+
+// An infix operator.
+type infix_op struct {
+	prec  int
+	right bool // Right-associative.
+}
+
+// A table of operators. Higher precedences bind tighter, and must be greater
+// than 0. Operators are matched by the text of symbol and identifier tokens,
+// so words such as "and" or "not" may be operators, too.
+type Parser struct {
+	prefix map[string]int
+	infix  map[string]infix_op
+
+	// Precedence of function calls, e.g., "f(x, y)", which applies to a
+	// "(" following an expression. Calls are not recognized if it is 0.
+	CallPrecedence int
+}
+
+// Returns a parser without any operators.
+func New() *Parser {
+	return &Parser{
+		prefix: make(map[string]int),
+		infix:  make(map[string]infix_op),
+	}
+}
+
+// Returns a parser with the operators of C-like languages, from loosest to
+// tightest: "||"; "&&"; "==" and "!="; "<", "<=", ">", and ">="; "+" and
+// "-"; "*", "/", and "%"; prefix "-", "+", and "!"; right-associative "**";
+// and function calls.
+func Default() *Parser {
+	p := New()
+
+	levels := [][]string{{"||"}, {"&&"}, {"==", "!="},
+		{"<", "<=", ">", ">="}, {"+", "-"}, {"*", "/", "%"}}
+	for i, ops := range levels {
+		for _, op := range ops {
+			p.AddInfix(op, i+1)
+		}
+	}
+
+	for _, op := range []string{"-", "+", "!"} {
+		p.AddPrefix(op, 7)
+	}
+	p.AddInfixRight("**", 8)
+	p.CallPrecedence = 9
+
+	return p
+}
+
+// Adds a prefix operator, e.g., "-" or "not", replacing any prefix operator
+// with the same text. Its operand extends over operators of higher
+// precedence only.
+func (p *Parser) AddPrefix(op string, prec int) {
+	p.prefix[op] = prec
+}
+
+// Adds a left-associative infix operator, e.g., "+", so that "a + b + c"
+// parses as "((a + b) + c)", replacing any infix operator with the same
+// text.
+func (p *Parser) AddInfix(op string, prec int) {
+	p.infix[op] = infix_op{prec: prec}
+}
+
+// Adds a right-associative infix operator, e.g., "**" or "=", so that
+// "a = b = c" parses as "(a = (b = c))", replacing any infix operator with
+// the same text.
+func (p *Parser) AddInfixRight(op string, prec int) {
+	p.infix[op] = infix_op{prec: prec, right: true}
+}
+
+// Returns the operators of more than one rune that are not words, sorted,
+// e.g., for TokenScanner.SetOperators().
+func (p *Parser) Operators() []string {
+	set := make(map[string]bool)
+	for op := range p.prefix {
+		set[op] = true
+	}
+	for op := range p.infix {
+		set[op] = true
+	}
+
+	var ops []string
+	for op := range set {
+		first, _ := utf8.DecodeRuneInString(op)
+		if utf8.RuneCountInString(op) > 1 &&
+			!textparser.IsIdentRune(first, 0, nil) {
+			ops = append(ops, op)
+		}
+	}
+	sort.Strings(ops)
+
+	return ops
+}
+
+// Sets up the scanner for the parser: adds the parser's operators to the
+// scanner's (see TokenScanner.SetOperators()), and scans signs as symbols
+// (see TokenScanner.Signs), so that "a-1" is a subtraction.
+func (p *Parser) Configure(ts *textparser.TokenScanner) {
+	ts.SetOperators(append(ts.Operators(), p.Operators()...)...)
+	ts.Signs = textparser.SignSymbol
+}
+
+// Parses one expression from the scanner, leaving any token after it
+// unread, e.g., the ";" in "a + b; c". Whitespace, comment, and newline
+// tokens are skipped. Syntax errors are ScanErrors with
+// textparser.CodeSyntax.
+func (p *Parser) ParseExpr(ts *textparser.TokenScanner) (*Node, error) {
+	s := &parse_state{p: p, ts: ts}
+
+	node, err := s.expr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.peeked != nil {
+		if err = ts.UnreadToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// Parses the remaining tokens from the scanner as a single expression.
+func (p *Parser) Parse(ts *textparser.TokenScanner) (*Node, error) {
+	s := &parse_state{p: p, ts: ts}
+
+	node, err := s.expr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		return nil, unexpected(token)
+	}
+
+	return node, nil
+}
+
+// The state of a call to Parse() or ParseExpr().
+type parse_state struct {
+	p      *Parser
+	ts     *textparser.TokenScanner
+	peeked *textparser.ScannedToken
+}
+
+// Returns the next significant token, or nil at the end of the input.
+func (s *parse_state) next() (*textparser.ScannedToken, error) {
+	if token := s.peeked; token != nil {
+		s.peeked = nil
+		return token, nil
+	}
+
+	for s.ts.Scan() {
+		token := s.ts.ScannedToken()
+		switch token.Type {
+		case textparser.TokenTypeWhitespace, textparser.TokenTypeComment,
+			textparser.TokenTypeNewline:
+			continue
+		}
+
+		if s.ts.TokensReused() {
+			token.Token = token.Token.CloneMutable()
+		}
+
+		return token, nil
+	}
+
+	if err := s.ts.Err(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Returns the next significant token without consuming it.
+func (s *parse_state) peek() (*textparser.ScannedToken, error) {
+	token, err := s.next()
+	s.peeked = token
+
+	return token, err
+}
+
+// Parses an expression whose operators bind tighter than min_prec.
+func (s *parse_state) expr(min_prec int) (*Node, error) {
+	token, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+
+	left, err := s.operand(token)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token, err = s.peek()
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return left, nil
+		}
+
+		if is_symbol(token, "(") && s.p.CallPrecedence > min_prec {
+			s.next()
+			if left, err = s.call(left, token); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		op, ok := s.p.infix[token.Text]
+		if !ok || !is_operator(token) || op.prec <= min_prec {
+			return left, nil
+		}
+		s.next()
+
+		right_prec := op.prec
+		if op.right {
+			right_prec--
+		}
+
+		right, err := s.expr(right_prec)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Node{Kind: KindBinary, Token: token,
+			Args: []*Node{left, right}}
+	}
+}
+
+// Parses the operand starting with the token: a literal, an identifier, a
+// prefix operator and its operand, or an expression in parentheses.
+func (s *parse_state) operand(token *textparser.ScannedToken) (*Node, error) {
+	if token == nil {
+		return nil, s.eof_error("Expected expression")
+	}
+
+	if prec, ok := s.p.prefix[token.Text]; ok && is_operator(token) {
+		operand, err := s.expr(prec)
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: KindUnary, Token: token, Args: []*Node{operand}},
+			nil
+	}
+
+	switch token.Type {
+	case textparser.TokenTypeSymbol:
+		if token.Text != "(" {
+			return nil, unexpected(token)
+		}
+		node, err := s.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = s.expect(")", token); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case textparser.TokenTypeIdent, textparser.TokenTypeParameter,
+		textparser.TokenTypeVariable:
+		return &Node{Kind: KindIdent, Token: token}, nil
+
+	case textparser.TokenTypeInt, textparser.TokenTypeFloat,
+		textparser.TokenTypeString, textparser.TokenTypeDateTime,
+		textparser.TokenTypeAddress, textparser.TokenTypeURL:
+		return &Node{Kind: KindLiteral, Token: token}, nil
+	}
+
+	return nil, unexpected(token)
+}
+
+// Parses the arguments of a call of fn, after the opening parenthesis.
+func (s *parse_state) call(
+	fn *Node,
+	open *textparser.ScannedToken,
+) (*Node, error) {
+	node := &Node{Kind: KindCall, Token: open, Func: fn, Args: []*Node{}}
+
+	token, err := s.peek()
+	if err != nil {
+		return nil, err
+	}
+	if token != nil && is_symbol(token, ")") {
+		s.next()
+		node.Close = token
+		return node, nil
+	}
+
+	for {
+		arg, err := s.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		node.Args = append(node.Args, arg)
+
+		token, err = s.next()
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, s.eof_error(fmt.Sprintf(
+				"Unterminated call opened at %s", &open.Start))
+		}
+
+		switch {
+		case is_symbol(token, ")"):
+			node.Close = token
+			return node, nil
+		case !is_symbol(token, ","):
+			return nil, &textparser.ScanError{
+				Msg:    "Expected ',' or ')'",
+				Detail: fmt.Sprintf("Found %q.", token.Text),
+				Start:  token.Start,
+				End:    token.Start,
+				Code:   textparser.CodeSyntax,
+			}
+		}
+	}
+}
+
+// Reads the closing symbol matching the open token.
+func (s *parse_state) expect(
+	text string,
+	open *textparser.ScannedToken,
+) (*textparser.ScannedToken, error) {
+	token, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, s.eof_error(fmt.Sprintf("Expected %q to close %q "+
+			"opened at %s", text, open.Text, &open.Start))
+	}
+
+	if !is_symbol(token, text) {
+		return nil, &textparser.ScanError{
+			Msg:    fmt.Sprintf("Expected %q", text),
+			Detail: fmt.Sprintf("Found %q.", token.Text),
+			Start:  token.Start,
+			End:    token.Start,
+			Code:   textparser.CodeSyntax,
+		}
+	}
+
+	return token, nil
+}
+
+// Returns a syntax error at the end of the input.
+func (s *parse_state) eof_error(msg string) *textparser.ScanError {
+	pos := *s.ts.EndPosition()
+
+	return &textparser.ScanError{
+		Msg:    msg,
+		Detail: "Reached end of input.",
+		Start:  pos,
+		End:    pos,
+		Err:    io.EOF,
+		Code:   textparser.CodeSyntax,
+	}
+}
+
+// Returns a syntax error for a token that cannot appear where it is.
+func unexpected(token *textparser.ScannedToken) *textparser.ScanError {
+	return &textparser.ScanError{
+		Msg:   fmt.Sprintf("Unexpected %q", token.Text),
+		Start: token.Start,
+		End:   token.Start,
+		Code:  textparser.CodeSyntax,
+	}
+}
+
+// Returns true if the token may be an operator.
+func is_operator(token *textparser.ScannedToken) bool {
+	return token.Type == textparser.TokenTypeSymbol ||
+		token.Type == textparser.TokenTypeIdent
+}
+
+func is_symbol(token *textparser.ScannedToken, text string) bool {
+	return token.Type == textparser.TokenTypeSymbol && token.Text == text
+}
@@ -0,0 +1,118 @@
+package exprparser_test
+
+import (
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+	"github.com/cuberat/go-textparser/exprparser"
+)
+
+func TestParse(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{"precedence", "a + b * c - d", "((a + (b * c)) - d)"},
+		{"parentheses", "(a + b) * c", "((a + b) * c)"},
+		{"prefix", "-a * -2 + !b", "(((-a) * (-2)) + (!b))"},
+		{"right associative", "2 ** 3 ** -x", "(2 ** (3 ** (-x)))"},
+		{"prefix below power", "-2 ** 2", "(-(2 ** 2))"},
+		{"comparison", "a < b && b <= c || !ok",
+			"(((a < b) && (b <= c)) || (!ok))"},
+		{"calls", `f() + g(1, "s", h(x)) * 2`,
+			`(f() + (g(1, "s", h(x)) * 2))`},
+		{"call of call", "f(a)(b)", "f(a)(b)"},
+		{"no spaces", "a-1", "(a - 1)"},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := exprparser.Default()
+			ts := textparser.NewScannerString(test_data.Input)
+			p.Configure(ts)
+
+			node, err := p.Parse(ts)
+			if err != nil {
+				st.Fatalf("got error %s", err)
+			}
+
+			if got := node.String(); got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{"missing operand", "a +",
+			"Expected expression at :1:4 (3). Reached end of input."},
+		{"unclosed parenthesis", "(a",
+			`Expected ")" to close "(" opened at :1:1 (0) at :1:3 (2). ` +
+				"Reached end of input."},
+		{"bad argument list", "f(a b)",
+			`Expected ',' or ')' at :1:5 (4). Found "b".`},
+		{"trailing token", "a b", `Unexpected "b" at :1:3 (2).`},
+		{"unexpected symbol", "* a", `Unexpected "*" at :1:1 (0).`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := exprparser.Default()
+			ts := textparser.NewScannerString(test_data.Input)
+			p.Configure(ts)
+
+			_, err := p.Parse(ts)
+			if err == nil {
+				st.Fatalf("got no error, expected %q", test_data.Expected)
+			}
+
+			if got := err.Error(); got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+			if code := textparser.ErrorCodeOf(err); code !=
+				textparser.CodeSyntax {
+				st.Errorf("got code %s, expected %s", code.Name(),
+					textparser.CodeSyntax.Name())
+			}
+		})
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	p := exprparser.New()
+	p.AddInfix("+", 1)
+	p.AddPrefix("not", 2)
+	p.AddInfixRight("=", 1)
+
+	ts := textparser.NewScannerString("not a + b; x")
+	p.Configure(ts)
+
+	node, err := p.ParseExpr(ts)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	if got, expected := node.String(), "((not a) + b)"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	span := node.Span()
+	if span.Start.Offset != 0 || span.End.Offset != 9 {
+		t.Errorf("got span %s, expected offsets 0-9", span)
+	}
+
+	if !ts.Scan() || ts.TokenText() != ";" {
+		t.Errorf("got %q after expression, expected \";\"",
+			ts.TokenText())
+	}
+}
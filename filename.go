@@ -0,0 +1,124 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Controls how file names are recorded in Position.Filename, so that
+// positions, e.g., in diagnostics or serialized token streams, are the same
+// across machines. Combine with |. The default, FilenameAsIs, records file
+// names as given.
+type FilenameStyle uint
+
+const (
+	// Make file names absolute.
+	FilenameAbsolute FilenameStyle = 1 << iota
+
+	// Make file names relative to the root set by SetFileRoot(), or to the
+	// current directory if none is set. File names outside the root are
+	// made absolute instead.
+	FilenameRelative
+
+	// Use forward slashes as separators, even on Windows.
+	FilenameSlash
+
+	// Record file names as given.
+	FilenameAsIs FilenameStyle = 0
+)
+
+// Returns a string representation of the style, e.g., "Relative|Slash".
+func (f FilenameStyle) String() string {
+	if f == FilenameAsIs {
+		return "AsIs"
+	}
+
+	s := ""
+	for i, name := range []string{"Absolute", "Relative", "Slash"} {
+		if f&(1<<i) != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += name
+		}
+	}
+
+	return s
+}
+
+// Sets the directory that file names are made relative to, and turns on
+// FilenameRelative. An empty dir means the current directory. Call this
+// before SetFilename().
+func (ts *TokenScanner) SetFileRoot(dir string) {
+	ts.file_root = dir
+	ts.FilenameStyle |= FilenameRelative
+}
+
+// Returns the directory set by SetFileRoot(), if any.
+func (ts *TokenScanner) FileRoot() string {
+	return ts.file_root
+}
+
+// Returns the file name as it would be recorded in Position.Filename,
+// according to FilenameStyle and the file root. The empty string is
+// returned as is.
+func (ts *TokenScanner) DisplayFilename(filename string) string {
+	if filename == "" || ts.FilenameStyle == FilenameAsIs {
+		return filename
+	}
+
+	name := filepath.Clean(filename)
+
+	if ts.FilenameStyle&(FilenameAbsolute|FilenameRelative) != 0 {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+
+	if ts.FilenameStyle&FilenameRelative != 0 {
+		root := ts.file_root
+		if root == "" {
+			root = "."
+		}
+
+		if abs_root, err := filepath.Abs(root); err == nil {
+			rel, err := filepath.Rel(abs_root, name)
+			if err == nil && rel != ".." &&
+				!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				name = rel
+			}
+		}
+	}
+
+	if ts.FilenameStyle&FilenameSlash != 0 {
+		name = filepath.ToSlash(name)
+	}
+
+	return name
+}
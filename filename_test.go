@@ -0,0 +1,80 @@
+package textparser_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestDisplayFilename(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Style    textparser.FilenameStyle
+		Root     string
+		Input    string
+		Expected string
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("couldn't get working directory: %s", err)
+	}
+
+	test_list := []TestData{
+		{"as is", textparser.FilenameAsIs, "", "./a/../b.go", "./a/../b.go"},
+		{"empty", textparser.FilenameAbsolute, "", "", ""},
+		{"absolute", textparser.FilenameAbsolute, "", "a/../b.go",
+			filepath.Join(wd, "b.go")},
+		{"relative to root", textparser.FilenameRelative, "/src/proj",
+			"/src/proj/pkg/a.go", "pkg/a.go"},
+		{"relative to working directory", textparser.FilenameRelative, "",
+			filepath.Join(wd, "pkg", "a.go"), "pkg/a.go"},
+		{"outside root", textparser.FilenameRelative, "/src/proj",
+			"/src/other/a.go", "/src/other/a.go"},
+		{"slash", textparser.FilenameSlash, "", "a/b.go", "a/b.go"},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString("")
+			p.FilenameStyle = test_data.Style
+			if test_data.Root != "" {
+				p.SetFileRoot(test_data.Root)
+			}
+
+			got := p.DisplayFilename(test_data.Input)
+			if got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSetFileRoot(t *testing.T) {
+	p := textparser.NewScannerString("a\n#line 10 \"/src/proj/gen/b.y\"\nb")
+	p.SetLineDirective("#line", textparser.ParseLineDirectiveC)
+	p.SetFileRoot("/src/proj")
+	p.SetFilename("/src/proj/a.txt")
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, token.Start.String())
+	}
+
+	expected := []string{"a.txt:1:1 (0)", "gen/b.y:10:1 (31)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if style := p.FilenameStyle.String(); style != "Relative" {
+		t.Errorf("got style %q, expected %q", style, "Relative")
+	}
+}
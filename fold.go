@@ -0,0 +1,102 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// Case-insensitive comparisons of token text. These fold case with
+// FoldCase(), the same as a FoldCase transform set on a scanner, rather than
+// with strings.EqualFold(), whose simple folding differs for some runes,
+// e.g., U+0130 (LATIN CAPITAL LETTER I WITH DOT ABOVE). Mixing the two can
+// make a keyword match in one place and not in another.
+
+// Returns true if the two strings are equal after folding case the same way
+// as the FoldCase transform.
+func EqualFold(a, b string) bool {
+	return a == b || FoldCase(a) == FoldCase(b)
+}
+
+// Returns true if the text of the token is equal to s, ignoring case.
+func (t *Token) EqualFold(s string) bool {
+	return t != nil && EqualFold(t.Text, s)
+}
+
+// Returns true if the text of the viewed token is equal to s, ignoring case.
+func (v TokenView) EqualFold(s string) bool {
+	return !v.IsZero() && EqualFold(v.Text(), s)
+}
+
+// Returns true if the token is an identifier, or a keyword set with
+// SetKeywords(), equal to the keyword, ignoring case.
+func (t *Token) IsKeyword(keyword string) bool {
+	return is_word_token(t) && EqualFold(t.Text, keyword)
+}
+
+// Returns true if the token is an identifier or a keyword.
+func is_word_token(t *Token) bool {
+	return t != nil &&
+		(t.Type == TokenTypeIdent || t.Type == TokenTypeKeyword)
+}
+
+// Returns true if the text of the two tokens is equal, ignoring case.
+func TokenTextEqualFold(a, b *Token) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return EqualFold(a.Text, b.Text)
+}
+
+// Returns the index of the first token that is an identifier or keyword equal
+// to the keyword, ignoring case, or -1 if there is none.
+func IndexKeyword(tokens []*Token, keyword string) int {
+	folded := FoldCase(keyword)
+	for i, t := range tokens {
+		if is_word_token(t) &&
+			(t.Text == keyword || FoldCase(t.Text) == folded) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns true if any of the tokens is an identifier or keyword equal to the
+// keyword, ignoring case.
+func HasKeyword(tokens []*Token, keyword string) bool {
+	return IndexKeyword(tokens, keyword) >= 0
+}
+
+// Returns true if any of the tokens is an identifier or keyword equal to one
+// of the keywords, ignoring case.
+func HasAnyKeyword(tokens []*Token, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if HasKeyword(tokens, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
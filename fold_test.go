@@ -0,0 +1,98 @@
+package textparser_test
+
+import (
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestEqualFold(t *testing.T) {
+	type TestData struct {
+		Name     string
+		A        string
+		B        string
+		Expected bool
+	}
+
+	test_list := []TestData{
+		{"same", "select", "select", true},
+		{"case", "SELECT", "select", true},
+		{"unicode", "\u00c9T\u00c9", "\u00e9t\u00e9", true},
+		{"different", "select", "insert", false},
+		// strings.EqualFold() would say these are different.
+		{"dotted capital I", "\u0130", "i", true},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			got := textparser.EqualFold(test_data.A, test_data.B)
+			if got != test_data.Expected {
+				st.Errorf("got %t, expected %t", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestHasKeyword(t *testing.T) {
+	p := textparser.NewScannerString(`INSERT into "select" Values (1)`)
+	tokens := scan_all(t, p)
+
+	if !textparser.HasKeyword(tokens, "insert") {
+		t.Errorf("expected to find keyword insert")
+	}
+
+	// A string is not a keyword, even if its text matches.
+	if textparser.HasKeyword(tokens, `"select"`) ||
+		textparser.HasKeyword(tokens, "select") {
+		t.Errorf("expected not to find keyword select")
+	}
+
+	if i := textparser.IndexKeyword(tokens, "VALUES"); i != 3 {
+		t.Errorf("got index %d, expected 3", i)
+	}
+
+	if !textparser.HasAnyKeyword(tokens, "update", "into") {
+		t.Errorf("expected to find keyword into")
+	}
+
+	if !tokens[0].IsKeyword("Insert") || tokens[2].IsKeyword("select") {
+		t.Errorf("IsKeyword() mismatch")
+	}
+
+	if !tokens[2].EqualFold(`"SELECT"`) || !tokens[2].View().EqualFold(
+		`"Select"`) {
+		t.Errorf("EqualFold() mismatch")
+	}
+
+	if !textparser.TokenTextEqualFold(tokens[0], &textparser.Token{
+		Text: "insert"}) {
+		t.Errorf("TokenTextEqualFold() mismatch")
+	}
+}
+
+func TestHasKeywordSetKeywords(t *testing.T) {
+	p := textparser.NewScannerString(`INSERT into t`)
+	p.SetKeywords("INSERT", "into")
+	tokens := scan_all(t, p)
+
+	if tokens[0].Type != textparser.TokenTypeKeyword {
+		t.Fatalf("got type %s, expected Keyword", tokens[0].Type)
+	}
+
+	if !textparser.HasKeyword(tokens, "insert") {
+		t.Errorf("expected to find keyword insert")
+	}
+
+	if i := textparser.IndexKeyword(tokens, "INTO"); i != 1 {
+		t.Errorf("got index %d, expected 1", i)
+	}
+
+	if !tokens[0].IsKeyword("insert") {
+		t.Errorf("IsKeyword() mismatch")
+	}
+
+	if !textparser.IsSQLKeyword(tokens[0]) ||
+		textparser.IsSQLKeyword(tokens[2]) {
+		t.Errorf("IsSQLKeyword() mismatch")
+	}
+}
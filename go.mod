@@ -1,3 +1,5 @@
 module github.com/cuberat/go-textparser
 
-go 1.14
+go 1.21
+
+require golang.org/x/text v0.21.0
@@ -0,0 +1,421 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package grammar parses token streams from a textparser.TokenScanner with
+// a grammar written in an EBNF-like notation, producing generic parse trees,
+// so that mini-languages can be defined without writing Go code, e.g.,
+//
+//	config = { setting } ;
+//	setting = IDENT "=" value [ ";" ] ;
+//	value = INT | STRING | IDENT | list ;
+//	list = "[" [ value { "," value } ] "]" ;
+//
+// A grammar is a list of rules, each a name, "=", an expression, and ";".
+// Expressions are made of
+//
+//   - quoted literals, e.g., "=", matching a token with that text;
+//   - token type names in upper case, e.g., IDENT, INT, FLOAT, or STRING,
+//     matching a token of that type (see textparser.TokenType);
+//   - names of other rules;
+//   - sequences, e.g., a b c;
+//   - alternatives, e.g., a | b, trying each in order;
+//   - optional parts in brackets, e.g., [ a ];
+//   - repetitions, zero or more times, in braces, e.g., { a };
+//   - groups in parentheses, e.g., ( a | b ) c.
+//
+// Comments are as in Go. The first rule is the start rule. Rules must not be
+// left-recursive, e.g., "expr = expr "+" term ;" does not terminate.
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	textparser "github.com/cuberat/go-textparser"
+	"github.com/cuberat/go-textparser/combinator"
+)
+
+// A node of a parse tree: a rule matched by a sequence of child nodes, or a
+// token.
+type Node struct {
+	Rule     string                   // The name of the rule, or "".
+	Token    *textparser.ScannedToken // The token, for a node without rule.
+	Children []*Node
+}
+
+// Returns the node as text, with rules in the form "name(child child)",
+// e.g., "setting(a = value(1))".
+func (n *Node) String() string {
+	if n.Rule == "" {
+		return n.Token.Text
+	}
+
+	children := make([]string, 0, len(n.Children))
+	for _, child := range n.Children {
+		children = append(children, child.String())
+	}
+
+	return n.Rule + "(" + strings.Join(children, " ") + ")"
+}
+
+// Returns the tokens of the node, in order.
+func (n *Node) Tokens() []*textparser.ScannedToken {
+	if n.Rule == "" {
+		return []*textparser.ScannedToken{n.Token}
+	}
+
+	var tokens []*textparser.ScannedToken
+	for _, child := range n.Children {
+		tokens = append(tokens, child.Tokens()...)
+	}
+
+	return tokens
+}
+
+// A compiled grammar.
+type Grammar struct {
+	start   string
+	names   []string // Rule names, in order.
+	parsers map[string]combinator.Parser
+}
+
+// Returns the rule names, in the order they were defined.
+func (g *Grammar) Rules() []string {
+	return append([]string(nil), g.names...)
+}
+
+// Parses all remaining tokens from the scanner with the start rule. Syntax
+// errors are ScanErrors with textparser.CodeSyntax, at the furthest position
+// reached.
+func (g *Grammar) Parse(ts *textparser.TokenScanner) (*Node, error) {
+	return g.ParseRule(g.start, ts)
+}
+
+// Parses all remaining tokens from the scanner with the named rule.
+func (g *Grammar) ParseRule(
+	rule string,
+	ts *textparser.TokenScanner,
+) (*Node, error) {
+	p, ok := g.parsers[rule]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule %q", rule)
+	}
+
+	value, err := combinator.Parse(p, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*Node)[0], nil
+}
+
+// Compiles the grammar. Errors are ScanErrors with textparser.CodeSyntax,
+// with positions in the grammar text.
+func Compile(src string) (*Grammar, error) {
+	ts := textparser.NewScannerString(src)
+	ts.SetFilename("grammar")
+	tokens, err := textparser.TokenizeAll(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compiler{
+		tokens: tokens,
+		end:    *ts.EndPosition(),
+		g:      &Grammar{parsers: make(map[string]combinator.Parser)},
+	}
+
+	if err = c.rules(); err != nil {
+		return nil, err
+	}
+
+	return c.g, nil
+}
+
+// The state of a call to Compile().
+type compiler struct {
+	tokens []*textparser.ScannedToken
+	i      int
+	end    textparser.Position
+	g      *Grammar
+
+	// References to rules, checked once all rules are known.
+	refs []*textparser.ScannedToken
+}
+
+// Returns the next token without consuming it, or nil at the end.
+func (c *compiler) peek() *textparser.ScannedToken {
+	if c.i < len(c.tokens) {
+		return c.tokens[c.i]
+	}
+
+	return nil
+}
+
+// Returns true if the next token is the symbol.
+func (c *compiler) at(symbol string) bool {
+	token := c.peek()
+
+	return token != nil && token.Type == textparser.TokenTypeSymbol &&
+		token.Text == symbol
+}
+
+// Consumes the symbol, or returns an error.
+func (c *compiler) expect(symbol string) error {
+	if !c.at(symbol) {
+		return c.error(fmt.Sprintf("Expected %q", symbol))
+	}
+	c.i++
+
+	return nil
+}
+
+// Returns a syntax error at the next token.
+func (c *compiler) error(msg string) *textparser.ScanError {
+	token := c.peek()
+	if token == nil {
+		return &textparser.ScanError{
+			Msg:    msg,
+			Detail: "Reached end of grammar.",
+			Start:  c.end,
+			End:    c.end,
+			Err:    io.EOF,
+			Code:   textparser.CodeSyntax,
+		}
+	}
+
+	return &textparser.ScanError{
+		Msg:    msg,
+		Detail: fmt.Sprintf("Found %q.", token.Text),
+		Start:  token.Start,
+		End:    token.Start,
+		Code:   textparser.CodeSyntax,
+	}
+}
+
+// Compiles all rules.
+func (c *compiler) rules() error {
+	for c.peek() != nil {
+		name := c.peek()
+		if name.Type != textparser.TokenTypeIdent {
+			return c.error("Expected rule name")
+		}
+		if _, ok := c.g.parsers[name.Text]; ok {
+			return &textparser.ScanError{
+				Msg:   fmt.Sprintf("Rule %q defined twice", name.Text),
+				Start: name.Start,
+				End:   name.Start,
+				Code:  textparser.CodeSyntax,
+			}
+		}
+		c.i++
+
+		if err := c.expect("="); err != nil {
+			return err
+		}
+
+		p, err := c.alternatives()
+		if err != nil {
+			return err
+		}
+
+		if err = c.expect(";"); err != nil {
+			return err
+		}
+
+		rule := name.Text
+		c.g.names = append(c.g.names, rule)
+		c.g.parsers[rule] = combinator.Map(p,
+			func(v interface{}) interface{} {
+				return []*Node{{Rule: rule, Children: v.([]*Node)}}
+			})
+	}
+
+	if len(c.g.names) == 0 {
+		return c.error("Expected rule")
+	}
+	c.g.start = c.g.names[0]
+
+	for _, ref := range c.refs {
+		if _, ok := c.g.parsers[ref.Text]; !ok {
+			return &textparser.ScanError{
+				Msg:   fmt.Sprintf("Undefined rule %q", ref.Text),
+				Start: ref.Start,
+				End:   ref.Start,
+				Code:  textparser.CodeSyntax,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Compiles alternatives separated by "|".
+func (c *compiler) alternatives() (combinator.Parser, error) {
+	var choices []combinator.Parser
+
+	for {
+		p, err := c.sequence()
+		if err != nil {
+			return nil, err
+		}
+		choices = append(choices, p)
+
+		if !c.at("|") {
+			break
+		}
+		c.i++
+	}
+
+	if len(choices) == 1 {
+		return choices[0], nil
+	}
+
+	return combinator.Choice(choices...), nil
+}
+
+// Compiles a sequence of terms, up to "|", ";", or a closing bracket.
+func (c *compiler) sequence() (combinator.Parser, error) {
+	var terms []combinator.Parser
+
+	for {
+		token := c.peek()
+		if token == nil || c.at("|") || c.at(";") || c.at(")") ||
+			c.at("]") || c.at("}") {
+			break
+		}
+
+		p, err := c.term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, p)
+	}
+
+	if len(terms) == 0 {
+		return nil, c.error("Expected expression")
+	}
+
+	return combinator.Map(combinator.Seq(terms...), flatten), nil
+}
+
+// Compiles a single term.
+func (c *compiler) term() (combinator.Parser, error) {
+	token := c.peek()
+	c.i++
+
+	switch token.Type {
+	case textparser.TokenTypeString:
+		text := unquote(token.Text)
+		return combinator.Map(combinator.Text(text), leaf), nil
+
+	case textparser.TokenTypeIdent:
+		if token_type, ok := token_type_named(token.Text); ok {
+			return combinator.Map(combinator.Type(token_type), leaf), nil
+		}
+
+		c.refs = append(c.refs, token)
+		name := token.Text
+		return combinator.Label(name, combinator.Lazy(
+			func() combinator.Parser {
+				return c.g.parsers[name]
+			})), nil
+
+	case textparser.TokenTypeSymbol:
+		closing := map[string]string{"(": ")", "[": "]", "{": "}"}
+		closer, ok := closing[token.Text]
+		if !ok {
+			break
+		}
+
+		p, err := c.alternatives()
+		if err != nil {
+			return nil, err
+		}
+		if err = c.expect(closer); err != nil {
+			return nil, err
+		}
+
+		switch token.Text {
+		case "[":
+			return combinator.Map(combinator.Opt(p),
+				func(v interface{}) interface{} {
+					if v == nil {
+						return []*Node{}
+					}
+					return v
+				}), nil
+		case "{":
+			return combinator.Map(combinator.Many(p), flatten), nil
+		}
+		return p, nil
+	}
+
+	c.i--
+
+	return nil, c.error("Expected expression")
+}
+
+// Returns the token type named in upper case, e.g., IDENT.
+func token_type_named(name string) (textparser.TokenType, bool) {
+	if name != strings.ToUpper(name) {
+		return 0, false
+	}
+
+	for t := textparser.TokenType(0); t.String() != ""; t++ {
+		if strings.ToUpper(t.String()) == name {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
+
+// Returns the text of a quoted literal without its quotes.
+func unquote(text string) string {
+	if s, err := strconv.Unquote(text); err == nil {
+		return s
+	}
+
+	return text[1 : len(text)-1]
+}
+
+// Returns a leaf node for a token matched by a combinator.
+func leaf(v interface{}) interface{} {
+	return []*Node{{Token: v.(*textparser.ScannedToken)}}
+}
+
+// Joins the lists of nodes in a []interface{} made by Seq() or Many().
+func flatten(v interface{}) interface{} {
+	nodes := []*Node{}
+	for _, item := range v.([]interface{}) {
+		nodes = append(nodes, item.([]*Node)...)
+	}
+
+	return nodes
+}
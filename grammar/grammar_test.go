@@ -0,0 +1,173 @@
+package grammar_test
+
+import (
+	"errors"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+	"github.com/cuberat/go-textparser/grammar"
+)
+
+const config_grammar = `
+// A list of settings.
+config = { setting } ;
+setting = IDENT "=" value [ ";" ] ;
+value = INT | STRING | IDENT | list ;
+list = "[" [ value { "," value } ] "]" ;
+`
+
+func TestParse(t *testing.T) {
+	g, err := grammar.Compile(config_grammar)
+	if err != nil {
+		t.Fatalf("couldn't compile grammar: %s", err)
+	}
+
+	type TestCase struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	tests := []TestCase{
+		{
+			Name:     "empty",
+			Input:    "",
+			Expected: "config()",
+		},
+		{
+			Name:  "settings",
+			Input: `a = 1; b = "x" c = [1, d]`,
+			Expected: `config(setting(a = value(1) ;) ` +
+				`setting(b = value("x")) ` +
+				`setting(c = value(list([ value(1) , value(d) ]))))`,
+		},
+		{
+			Name:     "empty list",
+			Input:    "a = []",
+			Expected: "config(setting(a = value(list([ ]))))",
+		},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScannerString(test_data.Input)
+			node, err := g.Parse(ts)
+			if err != nil {
+				st.Fatalf("couldn't parse: %s", err)
+			}
+
+			if got := node.String(); got != test_data.Expected {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	g, err := grammar.Compile(config_grammar)
+	if err != nil {
+		t.Fatalf("couldn't compile grammar: %s", err)
+	}
+
+	ts := textparser.NewScannerString("[1, 2]")
+	node, err := g.ParseRule("value", ts)
+	if err != nil {
+		t.Fatalf("couldn't parse: %s", err)
+	}
+
+	var texts []string
+	for _, token := range node.Tokens() {
+		texts = append(texts, token.Text)
+	}
+	if got, expected := len(texts), 5; got != expected {
+		t.Errorf("got %d tokens %q, expected %d", got, texts, expected)
+	}
+
+	if _, err = g.ParseRule("nope", ts); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	g, err := grammar.Compile(config_grammar)
+	if err != nil {
+		t.Fatalf("couldn't compile grammar: %s", err)
+	}
+
+	type TestCase struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	tests := []TestCase{
+		{
+			Name:     "missing value",
+			Input:    "a = ;",
+			Expected: `Expected value at :1:5 (4). Found ";".`,
+		},
+		{
+			Name:     "unclosed list",
+			Input:    "a = [1, 2",
+			Expected: `Expected "," or "]" at :1:10 (9). Reached end of input.`,
+		},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScannerString(test_data.Input)
+			_, err := g.Parse(ts)
+
+			var scan_err *textparser.ScanError
+			if !errors.As(err, &scan_err) {
+				st.Fatalf("got %#v, expected a ScanError", err)
+			}
+			if scan_err.Code != textparser.CodeSyntax {
+				st.Errorf("got code %s, expected Syntax", scan_err.Code)
+			}
+			if got := err.Error(); got != test_data.Expected {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	type TestCase struct {
+		Name    string
+		Grammar string
+		Line    int
+		Column  int
+	}
+
+	tests := []TestCase{
+		{Name: "no rules", Grammar: "// none\n", Line: 2, Column: 1},
+		{Name: "missing equals", Grammar: "a b ;", Line: 1, Column: 3},
+		{Name: "missing semicolon", Grammar: "a = b", Line: 1, Column: 6},
+		{Name: "unclosed group", Grammar: "a = ( b ;", Line: 1, Column: 9},
+		{Name: "empty alternative", Grammar: "a = b | ;", Line: 1, Column: 9},
+		{Name: "undefined rule", Grammar: "a = INT b ;", Line: 1, Column: 9},
+		{Name: "duplicate rule", Grammar: "a = INT ;\na = IDENT ;", Line: 2,
+			Column: 1},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			_, err := grammar.Compile(test_data.Grammar)
+
+			var scan_err *textparser.ScanError
+			if !errors.As(err, &scan_err) {
+				st.Fatalf("got %#v, expected a ScanError", err)
+			}
+			if scan_err.Code != textparser.CodeSyntax {
+				st.Errorf("got code %s, expected Syntax", scan_err.Code)
+			}
+			if scan_err.Start.Line != test_data.Line ||
+				scan_err.Start.Column != test_data.Column {
+				st.Errorf("got %d:%d, expected %d:%d (%s)",
+					scan_err.Start.Line, scan_err.Start.Column,
+					test_data.Line, test_data.Column, err)
+			}
+		})
+	}
+}
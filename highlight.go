@@ -0,0 +1,152 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bytes"
+)
+
+// A token in the shape used by syntax highlighting libraries, such as
+// chroma: the name of a token type and the source text of the token.
+type HighlightToken struct {
+	Type  string
+	Value string
+}
+
+// Maps tokens to highlighting type names.
+type HighlightMapping struct {
+	// Type names by token type.
+	Types map[TokenType]string
+
+	// Type names by exact token text, overriding Types, e.g., to map
+	// keywords to "Keyword".
+	Text map[string]string
+
+	// Type name for tokens not matched by Types or Text, and for input left
+	// over after a scan error.
+	Default string
+}
+
+// Returns a mapping to the token type names of the chroma syntax
+// highlighter, e.g., "LiteralString". The mapping is new, so it may be
+// modified, e.g., to add keywords.
+func ChromaMapping() *HighlightMapping {
+	return &HighlightMapping{
+		Types: map[TokenType]string{
+			TokenTypeWhitespace: "Text",
+			TokenTypeIdent:      "Name",
+			TokenTypeString:     "LiteralString",
+			TokenTypeComment:    "Comment",
+			TokenTypeInt:        "LiteralNumberInteger",
+			TokenTypeFloat:      "LiteralNumberFloat",
+			TokenTypeSymbol:     "Punctuation",
+			TokenTypeUnknown:    "Error",
+			TokenTypeSection:    "NameNamespace",
+			TokenTypeDateTime:   "LiteralDate",
+			TokenTypeParameter:  "NameVariable",
+			TokenTypeVariable:   "NameVariable",
+			TokenTypeText:       "Text",
+			TokenTypeEmphasis:   "GenericEmph",
+			TokenTypeCode:       "LiteralStringBacktick",
+			TokenTypeAutolink:   "NameAttribute",
+			TokenTypeLevel:      "Keyword",
+			TokenTypeAddress:    "LiteralNumber",
+			TokenTypeKey:        "NameAttribute",
+			TokenTypeURL:        "NameAttribute",
+			TokenTypeIndent:     "Text",
+			TokenTypeDedent:     "Text",
+			TokenTypeKeyword:    "Keyword",
+			TokenTypeRegion:     "CommentPreproc",
+		},
+		Text:    map[string]string{},
+		Default: "Error",
+	}
+}
+
+// Returns the type name for the token.
+func (m *HighlightMapping) type_name(t *Token) string {
+	if name, ok := m.Text[t.Text]; ok {
+		return name
+	}
+
+	if name, ok := m.Types[t.Type]; ok {
+		return name
+	}
+
+	return m.Default
+}
+
+// Tokenizes the source with a scanner configured by the profile (nil for
+// the default configuration) and returns highlighting tokens, using the
+// mapping (nil for ChromaMapping()). White space and comments are never
+// skipped, and unrecognized runes are kept, so the values of the returned
+// tokens concatenate to the source (after any normalization set by the
+// profile), taken verbatim, e.g., with escapes in strings intact. Input left over after a scan error is returned as a single
+// token of the mapping's Default type.
+func Highlight(src []byte, profile *Profile,
+	mapping *HighlightMapping) []HighlightToken {
+	if mapping == nil {
+		mapping = ChromaMapping()
+	}
+
+	keep_all := &Profile{
+		Name: profile.display_name(),
+		Configure: func(ts *TokenScanner) {
+			profile.Apply(ts)
+			ts.SkipWhitespace = false
+			ts.SkipComments = false
+			if ts.Unknown == UnknownStop || ts.Unknown == UnknownError {
+				ts.Unknown = UnknownRun
+			}
+		},
+	}
+
+	// Reading from memory cannot fail.
+	doc, _ := ScanDocument(bytes.NewReader(src), keep_all)
+
+	tokens := make([]HighlightToken, 0, len(doc.Tokens)+1)
+	offset := 0
+	for _, t := range doc.Tokens {
+		if t.Start.Offset > offset {
+			tokens = append(tokens, HighlightToken{Type: mapping.Default,
+				Value: string(doc.Source[offset:t.Start.Offset])})
+		}
+
+		tokens = append(tokens, HighlightToken{
+			Type:  mapping.type_name(t.Token),
+			Value: string(doc.Source[t.Start.Offset:t.End.Offset]),
+		})
+		offset = t.End.Offset
+	}
+
+	if offset < len(doc.Source) {
+		tokens = append(tokens, HighlightToken{Type: mapping.Default,
+			Value: string(doc.Source[offset:])})
+	}
+
+	return tokens
+}
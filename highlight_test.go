@@ -0,0 +1,53 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestHighlight(t *testing.T) {
+	src := "if x == \"a\\\"b\" { // yes\n\ty = 1.5 § }"
+
+	mapping := textparser.ChromaMapping()
+	mapping.Text["if"] = "Keyword"
+	mapping.Types[textparser.TokenTypeSymbol] = "Operator"
+
+	got := textparser.Highlight([]byte(src), textparser.ProfileGo(), mapping)
+
+	expected := []textparser.HighlightToken{
+		{"Keyword", "if"}, {"Text", " "}, {"Name", "x"}, {"Text", " "},
+		{"Operator", "=="}, {"Text", " "}, {"LiteralString", `"a\"b"`},
+		{"Text", " "}, {"Operator", "{"}, {"Text", " "},
+		{"Comment", "// yes\n"}, {"Text", "\t"}, {"Name", "y"},
+		{"Text", " "}, {"Operator", "="}, {"Text", " "},
+		{"LiteralNumberFloat", "1.5"}, {"Text", " "},
+		{"Operator", "§"}, {"Text", " "}, {"Operator", "}"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	var b strings.Builder
+	for _, token := range got {
+		b.WriteString(token.Value)
+	}
+	if b.String() != src {
+		t.Errorf("got %q, expected the source %q", b.String(), src)
+	}
+}
+
+func TestHighlightAfterError(t *testing.T) {
+	got := textparser.Highlight([]byte(`a "b`), nil, nil)
+
+	expected := []textparser.HighlightToken{
+		{"Name", "a"}, {"Text", " "}, {"Error", `"b`},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,197 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The deepest nesting of readers allowed by PushReader().
+const MaxIncludeDepth = 64
+
+// The state of a reader suspended by PushReader().
+type include_frame struct {
+	source       io.Reader
+	source_sizes *rune_sizes
+	input_ready  bool
+	reader       *rune_buffer
+	resume       Position // Position just past the last token read.
+	line_blank   bool
+	grapheme     grapheme_state
+}
+
+// Splices the reader into the input after the current token, e.g., for an
+// include directive, so that the following tokens are read from r, with
+// positions in the named file (as transformed by DisplayFilename()),
+// starting at line 1. At the end of r, scanning resumes where it left off,
+// in the previous file. Readers may be nested up to MaxIncludeDepth; the
+// caller is responsible for detecting cycles and for closing r. A token
+// cannot span the end of r.
+func (ts *TokenScanner) PushReader(r io.Reader, filename string) error {
+	if len(ts.includes) >= MaxIncludeDepth {
+		return fmt.Errorf("readers nested more than %d deep",
+			MaxIncludeDepth)
+	}
+
+	ts.includes = append(ts.includes, include_frame{
+		source:       ts.source,
+		source_sizes: ts.source_sizes,
+		input_ready:  ts.input_ready,
+		reader:       ts.reader,
+		resume:       ts.pending_end_pos(),
+		line_blank:   ts.line_blank,
+		grapheme:     ts.grapheme,
+	})
+
+	ts.source = r
+	ts.source_sizes = nil
+	ts.input_ready = false
+	ts.reader = new_rune_buffer(r)
+	ts.line_blank = true
+	ts.grapheme = grapheme_state{}
+
+	ts.pending_pos = &Position{
+		Filename: ts.DisplayFilename(filename),
+		Line:     1,
+		Column:   1,
+	}
+
+	return nil
+}
+
+// A reader queued by Append().
+type appended_source struct {
+	reader   io.Reader
+	filename string
+}
+
+// Queues the reader to be scanned after the current input ends, with
+// positions in the named file (as transformed by DisplayFilename()),
+// starting at line 1, so that several files scan as one stream while
+// positions still refer to each file, unlike with io.MultiReader(). Readers
+// are scanned in the order appended, each after the previous one and any
+// readers pushed with PushReader() have ended. A token cannot span the end
+// of a reader. The caller is responsible for closing r.
+func (ts *TokenScanner) Append(r io.Reader, filename string) {
+	ts.appended = append(ts.appended, appended_source{
+		reader:   r,
+		filename: filename,
+	})
+}
+
+// Returns a new TokenScanner over the contents of the named files, scanned
+// one after the other as if appended with Append(), with positions in each
+// file.
+func NewScannerFiles(names ...string) (*TokenScanner, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no files to scan")
+	}
+
+	var ts *TokenScanner
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if ts == nil {
+			ts = NewScannerBytes(data)
+			ts.SetFilename(name)
+			continue
+		}
+		ts.Append(&slice_reader{data: data}, name)
+	}
+
+	return ts, nil
+}
+
+// Returns the number of readers pushed with PushReader() that have not
+// ended yet.
+func (ts *TokenScanner) IncludeDepth() int {
+	return len(ts.includes)
+}
+
+// Resumes the reader suspended by the latest PushReader() at the end of the
+// current one, or, if there is none, moves on to the next reader queued by
+// Append(). Returns false if there is neither.
+func (ts *TokenScanner) pop_reader() bool {
+	if len(ts.includes) == 0 {
+		return ts.next_appended()
+	}
+
+	frame := ts.includes[len(ts.includes)-1]
+	ts.includes = ts.includes[:len(ts.includes)-1]
+
+	if ts.progress != nil {
+		ts.progress.popped += int64(ts.reader.consumed)
+	}
+
+	ts.source = frame.source
+	ts.source_sizes = frame.source_sizes
+	ts.input_ready = frame.input_ready
+	ts.reader = frame.reader
+	ts.line_blank = frame.line_blank
+	ts.grapheme = frame.grapheme
+
+	resume := frame.resume
+	ts.pending_pos = &resume
+
+	return true
+}
+
+// Moves on to the next reader queued by Append(). Returns false if there is
+// none.
+func (ts *TokenScanner) next_appended() bool {
+	if len(ts.appended) == 0 {
+		return false
+	}
+
+	next := ts.appended[0]
+	ts.appended = ts.appended[1:]
+
+	if ts.progress != nil {
+		ts.progress.popped += int64(ts.reader.consumed)
+	}
+
+	ts.source = next.reader
+	ts.source_sizes = nil
+	ts.input_ready = false
+	ts.reader = new_rune_buffer(next.reader)
+	ts.line_blank = true
+	ts.grapheme = grapheme_state{}
+
+	ts.pending_pos = &Position{
+		Filename: ts.DisplayFilename(next.filename),
+		Line:     1,
+		Column:   1,
+	}
+
+	return true
+}
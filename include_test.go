@@ -0,0 +1,161 @@
+package textparser_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestPushReader(t *testing.T) {
+	files := map[string]string{
+		"b.conf": "x = 1\ninclude \"c.conf\"\ny = 2",
+		"c.conf": "z\n",
+	}
+
+	p := textparser.NewScannerString("a = 0\ninclude \"b.conf\" w\nv")
+	p.SetFilename("a.conf")
+
+	var got []string
+	var depths []int
+	for p.Scan() {
+		pos := p.Position()
+		got = append(got, fmt.Sprintf("%s:%d:%d %s", pos.Filename, pos.Line,
+			pos.Column, p.TokenText()))
+
+		if p.TokenText() != "include" {
+			continue
+		}
+
+		if !p.Scan() {
+			t.Fatalf("expected a file name: %v", p.Err())
+		}
+		name := p.TokenTextNoQuotes()
+		err := p.PushReader(strings.NewReader(files[name]), name)
+		if err != nil {
+			t.Fatalf("couldn't push reader: %s", err)
+		}
+		depths = append(depths, p.IncludeDepth())
+	}
+
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"a.conf:1:1 a", "a.conf:1:3 =", "a.conf:1:5 0",
+		"a.conf:2:1 include", "b.conf:1:1 x", "b.conf:1:3 =", "b.conf:1:5 1",
+		"b.conf:2:1 include", "c.conf:1:1 z", "b.conf:3:1 y", "b.conf:3:3 =",
+		"b.conf:3:5 2", "a.conf:2:18 w", "a.conf:3:1 v"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if !reflect.DeepEqual(depths, []int{1, 2}) {
+		t.Errorf("got depths %v, expected [1 2]", depths)
+	}
+	if depth := p.IncludeDepth(); depth != 0 {
+		t.Errorf("got depth %d after the end, expected 0", depth)
+	}
+}
+
+func TestPushReaderDepth(t *testing.T) {
+	p := textparser.NewScannerString("")
+
+	for i := 0; i < textparser.MaxIncludeDepth; i++ {
+		if err := p.PushReader(strings.NewReader(""), "x"); err != nil {
+			t.Fatalf("couldn't push reader %d: %s", i, err)
+		}
+	}
+
+	if err := p.PushReader(strings.NewReader(""), "x"); err == nil {
+		t.Errorf("expected an error past MaxIncludeDepth")
+	}
+
+	if p.Scan() {
+		t.Errorf("got token %q, expected none", p.TokenText())
+	}
+	if depth := p.IncludeDepth(); depth != 0 {
+		t.Errorf("got depth %d after the end, expected 0", depth)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	p := textparser.NewScannerString("a\nb")
+	p.SetFilename("a.conf")
+	p.Append(strings.NewReader("c\n\n  d"), "b.conf")
+	p.Append(strings.NewReader(""), "empty.conf")
+	p.Append(strings.NewReader("e include f"), "c.conf")
+
+	var got []string
+	for p.Scan() {
+		pos := p.Position()
+		got = append(got, fmt.Sprintf("%s:%d:%d (%d) %s", pos.Filename,
+			pos.Line, pos.Column, pos.Offset, p.TokenText()))
+
+		if p.TokenText() == "include" {
+			err := p.PushReader(strings.NewReader("x"), "inc.conf")
+			if err != nil {
+				t.Fatalf("couldn't push reader: %s", err)
+			}
+		}
+	}
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"a.conf:1:1 (0) a", "a.conf:2:1 (2) b",
+		"b.conf:1:1 (0) c", "b.conf:3:3 (5) d", "c.conf:1:1 (0) e",
+		"c.conf:1:3 (2) include", "inc.conf:1:1 (0) x",
+		"c.conf:1:11 (10) f"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestNewScannerFiles(t *testing.T) {
+	dir := t.TempDir()
+	var names []string
+	for i, content := range []string{"a = 1\n", "b = 2"} {
+		name := filepath.Join(dir, fmt.Sprintf("%d.conf", i))
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatalf("couldn't write %s: %s", name, err)
+		}
+		names = append(names, name)
+	}
+
+	p, err := textparser.NewScannerFiles(names...)
+	if err != nil {
+		t.Fatalf("NewScannerFiles() failed: %s", err)
+	}
+	p.SetFileRoot(dir)
+
+	var got []string
+	for p.Scan() {
+		pos := p.Position()
+		got = append(got, fmt.Sprintf("%s:%d:%d %s",
+			filepath.Base(pos.Filename), pos.Line, pos.Column,
+			p.TokenText()))
+	}
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"0.conf:1:1 a", "0.conf:1:3 =", "0.conf:1:5 1",
+		"1.conf:1:1 b", "1.conf:1:3 =", "1.conf:1:5 2"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if _, err := textparser.NewScannerFiles(); err == nil {
+		t.Errorf("NewScannerFiles() succeeded with no files")
+	}
+	_, err = textparser.NewScannerFiles(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Errorf("NewScannerFiles() succeeded with a missing file")
+	}
+}
@@ -0,0 +1,304 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Controls whether Scan() tracks the indentation of lines, and which runes
+// may indent them (see SetIndentation()).
+type IndentPolicy int
+
+const (
+	// Indentation is not tracked. This is the default.
+	IndentOff IndentPolicy = iota
+
+	// Lines may only be indented with spaces.
+	IndentSpaces
+
+	// Lines may only be indented with tabs.
+	IndentTabs
+
+	// Lines may be indented with spaces or tabs, but not both: the rune
+	// indenting the first indented line must be used throughout.
+	IndentConsistent
+)
+
+// Returns a string representation of the indentation policy.
+func (p IndentPolicy) String() string {
+	switch p {
+	case IndentOff:
+		return "Off"
+	case IndentSpaces:
+		return "Spaces"
+	case IndentTabs:
+		return "Tabs"
+	case IndentConsistent:
+		return "Consistent"
+	}
+
+	return ""
+}
+
+// The state of indentation tracking (see SetIndentation()).
+type indent_state struct {
+	policy IndentPolicy
+	style  rune  // The indentation rune, once known.
+	levels []int // Widths of the enclosing indented blocks, innermost last.
+
+	line_start bool   // No token other than white space yet on the line.
+	indent     string // White space so far on the line.
+	depth      int    // Bracket nesting, in which lines are continued.
+	started    bool   // A line has been started.
+	done       bool   // The end of the input has been handled.
+}
+
+// Sets the indentation policy. Unless it is IndentOff, Scan() tracks the
+// indentation of each line like Python's tokenizer, synthesizing zero-width
+// tokens just before the first token of each line: a TokenTypeNewline token
+// ending the previous line, if any, then a TokenTypeIndent token, with the
+// new indentation as its text, if the line is indented further than the
+// previous one, or a TokenTypeDedent token for each enclosing block the line
+// returns from. At the end of the input, a TokenTypeNewline token and a
+// TokenTypeDedent token for each open block follow. Lines with nothing but
+// white space and comments are ignored, as are line breaks inside "()",
+// "[]", and "{}". Indentation breaking the policy, or returning to a width
+// that matches no enclosing block, results in a ScanError with
+// CodeIndentation. Indentation is not tracked in delimited or markdown mode.
+func (ts *TokenScanner) SetIndentation(policy IndentPolicy) {
+	if policy == IndentOff {
+		ts.indent = nil
+		return
+	}
+
+	ts.indent = &indent_state{policy: policy, line_start: true}
+}
+
+// Returns the indentation policy set with SetIndentation().
+func (ts *TokenScanner) Indentation() IndentPolicy {
+	if ts.indent == nil {
+		return IndentOff
+	}
+
+	return ts.indent.policy
+}
+
+// Returns true if indentation is being tracked.
+func (ts *TokenScanner) indent_enabled() bool {
+	return ts.indent != nil && ts.dsv == nil && ts.markdown == nil
+}
+
+// Tracks the indentation given the token just read. Returns the tokens
+// synthesized to be returned before it, if any.
+func (ts *TokenScanner) track_indent(
+	class rune_class,
+	token *Token,
+) ([]*ScannedToken, error) {
+	in := ts.indent
+	eol := string(ts.eol)
+
+	switch class {
+	case class_whitespace:
+		if i := strings.LastIndex(token.Text, eol); i >= 0 {
+			in.line_start = true
+			in.indent = strings.Clone(token.Text[i+len(eol):])
+		} else if in.line_start {
+			in.indent = strings.Clone(in.indent + token.Text)
+		}
+		return nil, nil
+
+	case class_continuation:
+		return nil, nil
+
+	case class_comment, class_directive:
+		// A comment ending a line, or ending on a line of its own.
+		if i := strings.LastIndex(token.Text, eol); i >= 0 {
+			rest := token.Text[i+len(eol):]
+			in.line_start = strings.Trim(rest, " \t") == ""
+			in.indent = strings.Clone(rest)
+		}
+		return nil, nil
+	}
+
+	line_start := in.line_start && in.depth == 0
+	in.line_start = false
+	ts.track_brackets(token)
+
+	if !line_start {
+		return nil, nil
+	}
+
+	start := *ts.pos
+	var synth []*ScannedToken
+	if in.started {
+		synth = append(synth, synth_token(TokenTypeNewline, "", start))
+	}
+	in.started = true
+
+	width, err := ts.indent_width(start)
+	if err != nil {
+		return nil, err
+	}
+
+	switch current := in.current(); {
+	case width > current:
+		in.levels = append(in.levels, width)
+		synth = append(synth, synth_token(TokenTypeIndent, in.indent, start))
+
+	case width < current:
+		for len(in.levels) > 0 && in.levels[len(in.levels)-1] > width {
+			in.levels = in.levels[:len(in.levels)-1]
+			synth = append(synth, synth_token(TokenTypeDedent, "", start))
+		}
+
+		if in.current() != width {
+			return nil, ts.indent_error(start, "Inconsistent dedent",
+				"The indentation matches no enclosing block.")
+		}
+	}
+
+	return synth, nil
+}
+
+// Returns the width of the innermost indented block, or 0 if there is none.
+func (in *indent_state) current() int {
+	if len(in.levels) == 0 {
+		return 0
+	}
+
+	return in.levels[len(in.levels)-1]
+}
+
+// Updates the bracket nesting with the brackets in a symbol token.
+func (ts *TokenScanner) track_brackets(token *Token) {
+	if token.Type != TokenTypeSymbol {
+		return
+	}
+
+	in := ts.indent
+	for _, ch := range token.Text {
+		switch ch {
+		case '(', '[', '{':
+			in.depth++
+		case ')', ']', '}':
+			if in.depth > 0 {
+				in.depth--
+			}
+		}
+	}
+}
+
+// Returns the width of the current line's indentation, checking it against
+// the policy.
+func (ts *TokenScanner) indent_width(start Position) (int, error) {
+	in := ts.indent
+	width := 0
+
+	for _, ch := range in.indent {
+		switch in.policy {
+		case IndentSpaces:
+			if ch != ' ' {
+				return 0, ts.indent_error(start, "Invalid indentation",
+					fmt.Sprintf("Found %q where only spaces are allowed.",
+						ch))
+			}
+		case IndentTabs:
+			if ch != '\t' {
+				return 0, ts.indent_error(start, "Invalid indentation",
+					fmt.Sprintf("Found %q where only tabs are allowed.", ch))
+			}
+		default:
+			if in.style == 0 {
+				in.style = ch
+			}
+			if ch != in.style {
+				return 0, ts.indent_error(start, "Inconsistent indentation",
+					fmt.Sprintf("Found %q after indenting with %q.", ch,
+						in.style))
+			}
+		}
+		width++
+	}
+
+	return width, nil
+}
+
+// Returns a ScanError with CodeIndentation for the line starting the token
+// at the position.
+func (ts *TokenScanner) indent_error(start Position, msg, detail string) error {
+	line_start := start
+	line_start.Offset -= len(ts.indent.indent)
+	line_start.Column = 1
+
+	return &ScanError{
+		Msg:    msg,
+		Detail: detail,
+		Start:  line_start,
+		End:    start,
+		Code:   CodeIndentation,
+	}
+}
+
+// Returns the tokens ending the input: a TokenTypeNewline token and a
+// TokenTypeDedent token for each open block, or none, if they were returned
+// already.
+func (ts *TokenScanner) indent_eof() []*ScannedToken {
+	in := ts.indent
+	if in.done || !in.started {
+		return nil
+	}
+	in.done = true
+
+	end := *ts.pos
+	synth := []*ScannedToken{synth_token(TokenTypeNewline, "", end)}
+	for range in.levels {
+		synth = append(synth, synth_token(TokenTypeDedent, "", end))
+	}
+	in.levels = nil
+
+	return synth
+}
+
+// Returns a zero-width token synthesized at the position.
+func synth_token(
+	token_type TokenType,
+	text string,
+	pos Position,
+) *ScannedToken {
+	first, _ := utf8.DecodeRuneInString(text)
+	if text == "" {
+		first = 0
+	}
+
+	return &ScannedToken{
+		Token: &Token{Text: text, FirstRune: first, Type: token_type},
+		Span:  Span{Start: pos, End: pos},
+	}
+}
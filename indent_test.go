@@ -0,0 +1,119 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestIndentation(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Policy   textparser.IndentPolicy
+		Input    string
+		Expected []string
+		Err      string
+	}
+
+	test_list := []TestData{
+		{Name: "blocks", Policy: textparser.IndentSpaces,
+			Input: "if x:\n  y = f(1,\n        2)\n\n  # note\n" +
+				"  while y:\n    z\nw\n",
+			Expected: []string{"if", "x", ":", "Newline", "Indent", "y", "=",
+				"f", "(", "1", ",", "2", ")", "Newline", "while", "y", ":",
+				"Newline", "Indent", "z", "Newline", "Dedent", "Dedent", "w",
+				"Newline"}},
+		{Name: "dedent at end", Policy: textparser.IndentTabs,
+			Input: "a\n\tb\n\t\tc",
+			Expected: []string{"a", "Newline", "Indent", "b", "Newline",
+				"Indent", "c", "Newline", "Dedent", "Dedent"}},
+		{Name: "consistent", Policy: textparser.IndentConsistent,
+			Input: "a\n\tb\nc\n",
+			Expected: []string{"a", "Newline", "Indent", "b", "Newline",
+				"Dedent", "c", "Newline"}},
+		{Name: "empty", Policy: textparser.IndentSpaces, Input: "\n  # x\n",
+			Expected: nil},
+		{Name: "tab with spaces", Policy: textparser.IndentSpaces,
+			Input: "a\n\tb\n",
+			Err: `Invalid indentation opened at :2:1 (2), unterminated ` +
+				`at :2:2 (3). Found '\t' where only spaces are allowed.`},
+		{Name: "mixed", Policy: textparser.IndentConsistent,
+			Input: "a\n  b\n \tc\n",
+			Err: `Inconsistent indentation opened at :3:1 (6), ` +
+				`unterminated at :3:3 (8). Found '\t' after indenting ` +
+				`with ' '.`},
+		{Name: "bad dedent", Policy: textparser.IndentSpaces,
+			Input: "a\n    b\n  c\n",
+			Err: `Inconsistent dedent opened at :3:1 (8), unterminated ` +
+				`at :3:3 (10). The indentation matches no enclosing block.`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetComments(textparser.CommentStyle{Start: "#"})
+			p.SetIndentation(test_data.Policy)
+
+			tokens, err := textparser.TokenizeAll(p)
+			if test_data.Err != "" {
+				if err == nil || err.Error() != test_data.Err {
+					st.Errorf("got error %v, expected %s", err, test_data.Err)
+				}
+				if code := textparser.ErrorCodeOf(err); code !=
+					textparser.CodeIndentation {
+					st.Errorf("got code %s, expected %s", code,
+						textparser.CodeIndentation)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, token := range tokens {
+				switch token.Type {
+				case textparser.TokenTypeNewline, textparser.TokenTypeIndent,
+					textparser.TokenTypeDedent:
+					got = append(got, token.Type.String())
+				default:
+					got = append(got, token.Text)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestIndentationSpans(t *testing.T) {
+	p := textparser.NewScannerString("a\n  b\n")
+	p.SetIndentation(textparser.IndentSpaces)
+
+	var got []string
+	for p.Scan() {
+		st := p.ScannedToken()
+		got = append(got, st.Type.String()+" "+
+			strings.TrimSpace(st.Span.String()))
+	}
+
+	expected := []string{
+		"Ident :1:1-1:2 (0-1)",
+		"Newline :2:3-2:3 (4-4)",
+		"Indent :2:3-2:3 (4-4)",
+		"Ident :2:3-2:4 (4-5)",
+		"Newline :3:1-3:1 (6-6)",
+		"Dedent :3:1-3:1 (6-6)",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if got := p.ConfigSummary().Indentation; got != textparser.IndentSpaces {
+		t.Errorf("got policy %s, expected Spaces", got)
+	}
+}
@@ -0,0 +1,217 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode"
+)
+
+// Reads a section header, e.g., "[server]" or "[[servers]]", through the
+// closing bracket on the same line.
+func (ts *TokenScanner) get_section() (*Token, error) {
+	var runes []rune
+
+	for {
+		ch, size, err := ts.get_one_rune()
+		if err != nil || ch == ts.eol {
+			if err == nil {
+				ts.unread_rune()
+			}
+			return nil, ts.unterminated_error("section header",
+				"Couldn't find end of section header (]).",
+				CodeUnterminatedSection, err)
+		}
+
+		ts.last_byte_len += size
+		ts.advance_position(ch, size)
+		runes = append(runes, ch)
+
+		if ch != ']' {
+			continue
+		}
+
+		// Take a second closing bracket for a "[[...]]" header.
+		if runes[1] == '[' && ts.check_next_rune_char(']') {
+			chars, _, err := ts.get_n_runes(1)
+			if err != nil {
+				return nil, err
+			}
+			runes = append(runes, chars...)
+		}
+
+		break
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  ts.last_byte_len,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeSection,
+	}
+
+	ts.set_token(token)
+
+	return token, nil
+}
+
+// Longest date/time with the default forms:
+// "1979-05-27T07:32:00.999999999-07:00". Matches with layouts set by
+// SetDateTimeLayouts() are limited to the same length.
+const max_datetime_runes = 64
+
+// Returns the number of runes of the date or time starting at the next rune,
+// or 0 if there is none, without consuming any input.
+func (ts *TokenScanner) datetime_len() int {
+	if len(ts.datetime_layouts) > 0 {
+		n, _ := ts.match_datetime_value()
+		return n
+	}
+
+	return match_datetime(ts.peek_upto(max_datetime_runes))
+}
+
+// Returns the number of runes at the start of s that form a date and
+// optional time, or a time, in the forms used by TOML, or 0 if there is no
+// match.
+func match_datetime(s []rune) int {
+	n := match_date(s)
+	if n > 0 {
+		// The time may be separated by 'T', 't', or a single space.
+		if len(s) > n && (s[n] == 'T' || s[n] == 't' || s[n] == ' ') {
+			if m := match_time(s[n+1:]); m > 0 {
+				n += 1 + m
+				n += match_zone(s[n:])
+			}
+		}
+	} else {
+		n = match_time(s)
+	}
+
+	// A date/time must not run into a longer number or identifier.
+	if n > 0 && n < len(s) && (is_ascii_digit(s[n]) ||
+		unicode.IsLetter(s[n])) {
+		return 0
+	}
+
+	return n
+}
+
+// Matches "YYYY-MM-DD".
+func match_date(s []rune) int {
+	if match_digits(s, 4) && len(s) > 4 && s[4] == '-' &&
+		match_digits(s[5:], 2) && len(s) > 7 && s[7] == '-' &&
+		match_digits(s[8:], 2) {
+		return 10
+	}
+
+	return 0
+}
+
+// Matches "HH:MM:SS" with an optional fraction, or "HH:MM".
+func match_time(s []rune) int {
+	if !(match_digits(s, 2) && len(s) > 2 && s[2] == ':' &&
+		match_digits(s[3:], 2)) {
+		return 0
+	}
+
+	n := 5
+	if len(s) > n && s[n] == ':' && match_digits(s[n+1:], 2) {
+		n += 3
+		if len(s) > n+1 && s[n] == '.' && is_ascii_digit(s[n+1]) {
+			n++
+			for n < len(s) && is_ascii_digit(s[n]) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// Matches "Z" or "+HH:MM".
+func match_zone(s []rune) int {
+	switch {
+	case len(s) > 0 && (s[0] == 'Z' || s[0] == 'z'):
+		return 1
+	case len(s) > 0 && (s[0] == '+' || s[0] == '-') &&
+		match_digits(s[1:], 2) && len(s) > 3 && s[3] == ':' &&
+		match_digits(s[4:], 2):
+		return 6
+	}
+
+	return 0
+}
+
+func match_digits(s []rune, n int) bool {
+	if len(s) < n {
+		return false
+	}
+
+	for _, ch := range s[:n] {
+		if !is_ascii_digit(ch) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func is_ascii_digit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// Reads a date/time matched by datetime_len().
+func (ts *TokenScanner) get_datetime() (*Token, error) {
+	n, value := ts.match_datetime_value()
+	if n == 0 {
+		return nil, nil
+	}
+
+	runes, total_size, err := ts.get_n_runes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeDateTime,
+	}
+	if !value.IsZero() {
+		token.Parsed = value
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
@@ -0,0 +1,87 @@
+package textparser_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestProfileINI(t *testing.T) {
+	input := `; top comment
+title = "TOML \"Example\""
+
+  [owner]
+name = 'Tom'   # trailing
+dob = 1979-05-27T07:32:00-08:00
+day = 1979-05-27
+at = 07:32:00.5
+spaced = 1979-05-27 07:32:00Z
+server.max-conns = 1_000
+ratio = 0.5e-3
+ports = [ 8000, 8001 ]
+
+[[servers]]
+`
+
+	p := textparser.ProfileINI().NewScanner(strings.NewReader(input))
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected := []string{
+		"Ident title", "Symbol =", `String "TOML "Example""`,
+		"Section [owner]",
+		"Ident name", "Symbol =", "String 'Tom'",
+		"Ident dob", "Symbol =", "DateTime 1979-05-27T07:32:00-08:00",
+		"Ident day", "Symbol =", "DateTime 1979-05-27",
+		"Ident at", "Symbol =", "DateTime 07:32:00.5",
+		"Ident spaced", "Symbol =", "DateTime 1979-05-27 07:32:00Z",
+		"Ident server.max-conns", "Symbol =", "Int 1_000",
+		"Ident ratio", "Symbol =", "Float 0.5e-3",
+		"Ident ports", "Symbol =", "Symbol [", "Int 8000", "Symbol ,",
+		"Int 8001", "Symbol ]",
+		"Section [[servers]]",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestSectionHeaderUnterminated(t *testing.T) {
+	p := textparser.ProfileINI().NewScanner(strings.NewReader(
+		"[owner\nname = 1"))
+
+	for p.Scan() {
+	}
+
+	var scan_err *textparser.ScanError
+	if !errors.As(p.Err(), &scan_err) {
+		t.Fatalf("got %v, expected a ScanError", p.Err())
+	}
+
+	expected := "Unterminated section header opened at :1:1 (0), " +
+		"unterminated at :1:7 (6). Couldn't find end of section header (])."
+	if scan_err.Error() != expected {
+		t.Errorf("got %q, expected %q", scan_err.Error(), expected)
+	}
+}
+
+func TestDateTimesOff(t *testing.T) {
+	p := textparser.NewScannerString("1979-05-27 07:32")
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"1979", "-05", "-27", "07", ":", "32"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,56 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import "sort"
+
+// Sets the words, e.g., "if" or "return", to be emitted as TokenTypeKeyword
+// tokens when scanned as identifiers, replacing any set before. Words are
+// matched exactly. Call with no words to switch this off.
+func (ts *TokenScanner) SetKeywords(words ...string) {
+	ts.keywords = nil
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if ts.keywords == nil {
+			ts.keywords = make(map[string]bool, len(words))
+		}
+		ts.keywords[word] = true
+	}
+}
+
+// Returns the words set with SetKeywords(), sorted.
+func (ts *TokenScanner) Keywords() []string {
+	words := make([]string, 0, len(ts.keywords))
+	for word := range ts.keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}
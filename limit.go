@@ -0,0 +1,100 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// Budget set by ScanLimit().
+type scan_limit struct {
+	tokens    int   // Maximum number of tokens, or 0 for no limit.
+	bytes     int64 // Maximum number of bytes, or 0 for no limit.
+	end       int64 // Offset at which to stop, or 0 for no limit.
+	count     int   // Number of tokens returned since ScanLimit().
+	truncated bool
+}
+
+// Limits further scanning to the next n_tokens tokens and to tokens starting
+// within the next n_bytes bytes of input, so that, e.g., a preview does not
+// pay to tokenize a whole file. Tokens are counted as Scan() returns them,
+// i.e., after middleware splits or drops them (see UseSplit()). A limit of 0
+// means no limit, so ScanLimit(0, 0) removes any limits. Once the budget is
+// used up, Scan() returns false with a nil Err(), and Truncated() reports
+// whether input remains. The last token may extend past the byte limit, as
+// tokens are never cut. Each call starts a new budget, e.g., to scan the
+// next page.
+func (ts *TokenScanner) ScanLimit(n_tokens int, n_bytes int64) {
+	ts.limit = scan_limit{tokens: n_tokens, bytes: n_bytes}
+	if n_bytes > 0 {
+		ts.limit.end = int64(ts.pending_end_pos().Offset) + n_bytes
+	}
+}
+
+// Returns true if the most recent call to Scan() returned false because the
+// budget set by ScanLimit() was used up while unscanned input remains.
+func (ts *TokenScanner) Truncated() bool {
+	return ts.limit.truncated
+}
+
+// Returns true if as many tokens as allowed by ScanLimit() were returned.
+func (ts *TokenScanner) token_limit_reached() bool {
+	l := &ts.limit
+	return l.tokens > 0 && l.count >= l.tokens
+}
+
+// Returns the number of tokens held back from Scan(), e.g., by
+// ConcatStrings, that are returned before any scanned next.
+func (ts *TokenScanner) held_tokens() int {
+	n := 0
+	if ts.string_run != nil {
+		n += 1 + len(ts.string_run.gap)
+	}
+	if ts.trailing != nil {
+		n += len(ts.trailing.tokens) + len(ts.trailing.gap)
+	}
+
+	return n
+}
+
+// Returns true, setting the truncated flag as needed, if the budget set by
+// ScanLimit() is used up, counting the tokens queued or held back as
+// returned. This must be called before update_pos().
+func (ts *TokenScanner) limit_reached() bool {
+	l := &ts.limit
+	l.truncated = false
+	pending := len(ts.queue) + ts.held_tokens()
+	reached := (l.tokens > 0 && l.count+pending >= l.tokens) ||
+		(l.end > 0 && int64(ts.pending_end_pos().Offset) >= l.end)
+
+	if reached {
+		l.truncated = len(ts.queue) > 0 || ts.string_run != nil ||
+			ts.trailing != nil
+		if !l.truncated {
+			_, err := ts.peek_rune()
+			l.truncated = err == nil
+		}
+	}
+
+	return reached
+}
@@ -0,0 +1,192 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestScanLimit(t *testing.T) {
+	type TestData struct {
+		Name      string
+		Input     string
+		NumTokens int
+		NumBytes  int64
+		Expected  []string
+		Truncated bool
+	}
+
+	test_list := []TestData{
+		{
+			Name:      "tokens",
+			Input:     "a b c d",
+			NumTokens: 2,
+			Expected:  []string{"a", "b"},
+			Truncated: true,
+		},
+		{
+			Name:      "bytes",
+			Input:     "alpha beta gamma",
+			NumBytes:  7,
+			Expected:  []string{"alpha", "beta"},
+			Truncated: true,
+		},
+		{
+			Name:      "both",
+			Input:     "a b c d",
+			NumTokens: 3,
+			NumBytes:  3,
+			Expected:  []string{"a", "b"},
+			Truncated: true,
+		},
+		{
+			Name:      "exact",
+			Input:     "a b",
+			NumTokens: 2,
+			Expected:  []string{"a", "b"},
+		},
+		{
+			Name:     "none",
+			Input:    "a b",
+			Expected: []string{"a", "b"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScanner(strings.NewReader(test_data.Input))
+			ts.ScanLimit(test_data.NumTokens, test_data.NumBytes)
+
+			var got []string
+			for ts.Scan() {
+				got = append(got, ts.TokenText())
+			}
+
+			if err := ts.Err(); err != nil && err != io.EOF {
+				st.Fatalf("scan failed: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+
+			if ts.Truncated() != test_data.Truncated {
+				st.Errorf("got truncated %t, expected %t", ts.Truncated(),
+					test_data.Truncated)
+			}
+		})
+	}
+}
+
+func TestScanLimitPages(t *testing.T) {
+	ts := textparser.NewScanner(strings.NewReader("a b c d e"))
+
+	var pages [][]string
+	for {
+		ts.ScanLimit(2, 0)
+
+		var page []string
+		for ts.Scan() {
+			page = append(page, ts.TokenText())
+		}
+		pages = append(pages, page)
+
+		if !ts.Truncated() {
+			break
+		}
+	}
+
+	expected := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("got %#v, expected %#v", pages, expected)
+	}
+}
+
+func TestScanLimitUnread(t *testing.T) {
+	ts := textparser.NewScanner(strings.NewReader("a b c"))
+	ts.ScanLimit(2, 0)
+
+	var got []string
+	for ts.Scan() {
+		got = append(got, ts.TokenText())
+		if ts.TokenText() == "b" && len(got) == 2 {
+			ts.UnreadToken()
+		}
+	}
+
+	expected := []string{"a", "b", "b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestScanLimitMiddleware(t *testing.T) {
+	split_runes := func(token *textparser.Token) []*textparser.Token {
+		var pieces []*textparser.Token
+		for _, ch := range token.Text {
+			piece := *token
+			piece.Text = string(ch)
+			pieces = append(pieces, &piece)
+		}
+		return pieces
+	}
+	drop_x := func(token *textparser.Token) (*textparser.Token, bool) {
+		return token, token.Text != "x"
+	}
+
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(ts *textparser.TokenScanner)
+		Expected [][]string
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "split",
+			Input: "ab cd",
+			Setup: func(ts *textparser.TokenScanner) {
+				ts.UseSplit(split_runes)
+			},
+			Expected: [][]string{{"a", "b", "c"}, {"d"}},
+		},
+		{
+			Name:  "dropped",
+			Input: "x a x b x c",
+			Setup: func(ts *textparser.TokenScanner) {
+				ts.Use(drop_x)
+			},
+			Expected: [][]string{{"a", "b", "c"}},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScanner(strings.NewReader(test_data.Input))
+			test_data.Setup(ts)
+
+			var pages [][]string
+			for {
+				ts.ScanLimit(3, 0)
+
+				var page []string
+				for ts.Scan() {
+					page = append(page, ts.TokenText())
+				}
+				pages = append(pages, page)
+
+				if !ts.Truncated() {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(pages, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", pages,
+					test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parses the arguments of a line directive, i.e., the rest of the line
+// following the directive prefix, with surrounding white space removed.
+// Returns the file name (empty to keep the current one) and the number of the
+// line following the directive. Returns false if the arguments are invalid.
+type LineDirectiveParser func(args string) (filename string, line int, ok bool)
+
+// Parses C-style line directive arguments: a line number, optionally followed
+// by a double-quoted file name, as in `#line 42 "orig.c"`.
+func ParseLineDirectiveC(args string) (string, int, bool) {
+	num, rest, _ := strings.Cut(args, " ")
+	line, err := strconv.Atoi(num)
+	if err != nil || line < 1 {
+		return "", 0, false
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return "", line, true
+	}
+
+	filename, err := strconv.Unquote(rest)
+	if err != nil || !strings.HasPrefix(rest, `"`) {
+		return "", 0, false
+	}
+
+	return filename, line, true
+}
+
+// Parses Go-style line directive arguments: a file name and a line number
+// separated by a colon, as in `//line orig.go:42`.
+func ParseLineDirectiveGo(args string) (string, int, bool) {
+	i := strings.LastIndexByte(args, ':')
+	if i < 1 {
+		return "", 0, false
+	}
+
+	line, err := strconv.Atoi(args[i+1:])
+	if err != nil || line < 1 {
+		return "", 0, false
+	}
+
+	return args[:i], line, true
+}
+
+// Enables recognition of line directives, which remap the file name and line
+// number reported in Position for the lines following them, e.g., when
+// scanning preprocessor or generated output. A directive must start at the
+// beginning of a line with the given prefix, followed by white space, and it
+// extends to the end of the line. The rest of the line is passed to parse.
+// Offsets and columns are not affected.
+//
+// For example, use "#line" with ParseLineDirectiveC for `#line 42 "orig.c"`,
+// or "//line" with ParseLineDirectiveGo for `//line orig.go:42`.
+//
+// Directives are returned as tokens of type TokenTypeComment, and skipped
+// along with comments if SkipComments is set. Directives that parse fails on
+// are returned the same way but have no effect. Pass an empty prefix to
+// disable recognition.
+func (ts *TokenScanner) SetLineDirective(
+	prefix string,
+	parse LineDirectiveParser,
+) {
+	if prefix == "" || parse == nil {
+		ts.line_directive_prefix = nil
+		ts.line_directive_parse = nil
+		return
+	}
+
+	ts.line_directive_prefix = []rune(prefix)
+	ts.line_directive_parse = parse
+}
+
+func (ts *TokenScanner) is_line_directive_start() bool {
+	prefix := ts.line_directive_prefix
+	if prefix == nil || ts.last_col != 1 {
+		return false
+	}
+
+	runes, err := ts.peek_multirune(len(prefix) + 1)
+	if err != nil || len(runes) < len(prefix)+1 {
+		return false
+	}
+
+	for i, ch := range prefix {
+		if runes[i] != ch {
+			return false
+		}
+	}
+
+	next := runes[len(prefix)]
+
+	return next != ts.eol && unicode.IsSpace(next)
+}
+
+func (ts *TokenScanner) get_line_directive() (*Token, error) {
+	runes, err := ts.read_until(ts.eol)
+	if err != nil {
+		return nil, err
+	}
+
+	text := runes_to_string(runes)
+	args := strings.TrimSpace(strings.TrimPrefix(text,
+		string(ts.line_directive_prefix)))
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      text,
+		NumBytes:  ts.last_byte_len,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeComment,
+	}
+
+	ts.set_token(token)
+
+	filename, line, ok := ts.line_directive_parse(args)
+	if !ok {
+		return token, nil
+	}
+
+	// The line following the directive becomes `line`.
+	next_line := ts.pos.Line + ts.last_line_addition
+	if runes[len(runes)-1] != ts.eol {
+		next_line++
+	}
+	ts.last_line_addition += line - next_line
+
+	if filename != "" {
+		filename = ts.DisplayFilename(filename)
+		ts.pending_filename = &filename
+	}
+
+	return token, nil
+}
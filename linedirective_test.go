@@ -0,0 +1,75 @@
+package textparser_test
+
+import (
+	"fmt"
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+)
+
+func TestLineDirective(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Prefix   string
+		Parse    textparser.LineDirectiveParser
+		Expected []string
+	}{
+		{
+			Name:   `C style`,
+			Input:  "a\n#line 42 \"orig.c\"\nb\nc\n#line 7\nd #line 1\ne",
+			Prefix: "#line",
+			Parse:  textparser.ParseLineDirectiveC,
+			Expected: []string{"gen.c:1:1 a", "orig.c:42:1 b",
+				"orig.c:43:1 c", "orig.c:7:1 d", "orig.c:7:3 #",
+				"orig.c:7:4 line", "orig.c:7:9 1", "orig.c:8:1 e"},
+		},
+		{
+			Name:   `Go style`,
+			Input:  "a\n//line orig.go:10\nb // c\n//line bad\nd",
+			Prefix: "//line",
+			Parse:  textparser.ParseLineDirectiveGo,
+			Expected: []string{"gen.c:1:1 a", "orig.go:10:1 b",
+				"orig.go:12:1 d"},
+		},
+		{
+			Name:     `disabled`,
+			Input:    "a\n//line orig.go:10\nb",
+			Prefix:   "",
+			Parse:    textparser.ParseLineDirectiveGo,
+			Expected: []string{"gen.c:1:1 a", "gen.c:3:1 b"},
+		},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetFilename("gen.c")
+			p.SetLineDirective(test_data.Prefix, test_data.Parse)
+
+			token_list := make([]string, 0, len(test_data.Expected))
+			for p.Scan() {
+				pos := p.Position()
+				token_list = append(token_list, fmt.Sprintf("%s:%d:%d %s",
+					pos.Filename, pos.Line, pos.Column, p.TokenText()))
+			}
+
+			if !reflect.DeepEqual(token_list, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", token_list,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestLineDirectiveToken(t *testing.T) {
+	p := textparser.NewScannerString("#line 5 \"x.c\"\nfoo")
+	p.SkipComments = false
+	p.SetLineDirective("#line", textparser.ParseLineDirectiveC)
+
+	p.Scan()
+	if p.TokenText() != "#line 5 \"x.c\"\n" ||
+		p.Token().Type != textparser.TokenTypeComment {
+		t.Errorf("unexpected directive token %s", p.Token())
+	}
+}
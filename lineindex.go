@@ -0,0 +1,173 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// An index of the lines in a text, for converting between byte offsets and
+// line/column positions without rescanning, e.g., for tools that store only
+// byte offsets. Columns are counted the same way as by a TokenScanner with
+// the same ColumnMode.
+type LineIndex struct {
+	Filename   string     // Filename reported in positions, if any.
+	ColumnMode ColumnMode // How columns are counted.
+
+	src   []byte
+	eol   rune
+	lines []int // Byte offset of the start of each line.
+}
+
+// Returns a LineIndex over the text, with lines terminated by '\n'. The
+// index refers to src, which must not be modified afterward.
+func NewLineIndex(src []byte) *LineIndex {
+	return NewLineIndexEOL(src, '\n')
+}
+
+// Returns a LineIndex over the text, with lines terminated by the eol rune,
+// as set on a scanner with SetEOL(). The index refers to src, which must not
+// be modified afterward.
+func NewLineIndexEOL(src []byte, eol rune) *LineIndex {
+	li := &LineIndex{src: src, eol: eol, lines: []int{0}}
+
+	sep := []byte(string(eol))
+	for offset := 0; ; {
+		i := bytes.Index(src[offset:], sep)
+		if i < 0 {
+			break
+		}
+		offset += i + len(sep)
+		li.lines = append(li.lines, offset)
+	}
+
+	return li
+}
+
+// Returns the number of lines in the text.
+func (li *LineIndex) NumLines() int {
+	return len(li.lines)
+}
+
+// Returns the byte offset of the start of the given line (starting at 1), or
+// -1 if there is no such line.
+func (li *LineIndex) LineOffset(line int) int {
+	if line < 1 || line > len(li.lines) {
+		return -1
+	}
+
+	return li.lines[line-1]
+}
+
+// Returns the byte offset just past the end of the given line, including
+// its end-of-line rune.
+func (li *LineIndex) line_end(line int) int {
+	if line < len(li.lines) {
+		return li.lines[line]
+	}
+
+	return len(li.src)
+}
+
+// Returns the position of the byte offset, which must fall on a rune
+// boundary between 0 and the length of the text, inclusive.
+func (li *LineIndex) OffsetToPosition(offset int) (Position, error) {
+	if offset < 0 || offset > len(li.src) {
+		return Position{}, fmt.Errorf("Offset %d out of range [0, %d].",
+			offset, len(li.src))
+	}
+
+	if offset < len(li.src) && !utf8.RuneStart(li.src[offset]) {
+		return Position{}, fmt.Errorf("Offset %d is not at a rune boundary.",
+			offset)
+	}
+
+	line := sort.Search(len(li.lines), func(i int) bool {
+		return li.lines[i] > offset
+	})
+
+	pos := Position{Filename: li.Filename, Offset: offset, Line: line,
+		Column: 1}
+	li.walk_line(line, func(o, col int) bool {
+		pos.Column = col
+		return o < offset
+	})
+
+	return pos, nil
+}
+
+// Returns the byte offset of the line and column of the position. The
+// Offset and Filename of the position are ignored. In ColumnGraphemes mode,
+// where the runes of a grapheme cluster share a column, the offset is that
+// of the start of the following cluster, as for the positions of tokens.
+func (li *LineIndex) PositionToOffset(pos Position) (int, error) {
+	if pos.Line < 1 || pos.Line > len(li.lines) {
+		return 0, fmt.Errorf("Line %d out of range [1, %d].", pos.Line,
+			len(li.lines))
+	}
+
+	offset := -1
+	li.walk_line(pos.Line, func(o, col int) bool {
+		if col == pos.Column {
+			offset = o
+		}
+		return col <= pos.Column
+	})
+
+	if offset < 0 {
+		return 0, fmt.Errorf("Column %d out of range on line %d.",
+			pos.Column, pos.Line)
+	}
+
+	return offset, nil
+}
+
+// Calls visit with the byte offset and column of each rune on the line,
+// followed by the offset and column just past the end of the text if it is
+// on the line, until visit returns false.
+func (li *LineIndex) walk_line(line int, visit func(offset, col int) bool) {
+	var g grapheme_state
+
+	start, end := li.lines[line-1], li.line_end(line)
+	col := 1
+	for offset := start; offset < end; {
+		if !visit(offset, col) {
+			return
+		}
+
+		ch, size := utf8.DecodeRune(li.src[offset:])
+		offset += size
+		col += column_width(li.ColumnMode, ch, size, &g)
+	}
+
+	if end == len(li.src) {
+		visit(end, col)
+	}
+}
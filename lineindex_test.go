@@ -0,0 +1,120 @@
+package textparser_test
+
+import (
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestLineIndexMatchesScanner(t *testing.T) {
+	type TestData struct {
+		Name       string
+		Input      string
+		ColumnMode textparser.ColumnMode
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "ascii",
+			Input: "foo = bar\n  baz(1, 2.5)\n\n\"str\" // end",
+		},
+		{
+			Name:  "unicode",
+			Input: "héllo wörld\n日本 = \"語\"\n",
+		},
+		{
+			Name:       "graphemes",
+			Input:      "e\u0301te\u0301 = x\n\"e\u0301\" y",
+			ColumnMode: textparser.ColumnGraphemes,
+		},
+		{
+			Name:       "bytes",
+			Input:      "h\u00e9llo \u65e5\n\"\U0001F44D\" x",
+			ColumnMode: textparser.ColumnBytes,
+		},
+		{
+			Name:       "utf16",
+			Input:      "h\u00e9llo \u65e5\n\"\U0001F44D\" x",
+			ColumnMode: textparser.ColumnUTF16,
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScanner(strings.NewReader(test_data.Input))
+			ts.SkipWhitespace = false
+			ts.SkipComments = false
+			ts.ColumnMode = test_data.ColumnMode
+
+			tokens, err := textparser.TokenizeAll(ts)
+			if err != nil {
+				st.Fatalf("scan failed: %s", err)
+			}
+
+			index := textparser.NewLineIndex([]byte(test_data.Input))
+			index.ColumnMode = test_data.ColumnMode
+
+			for _, token := range tokens {
+				for _, pos := range []textparser.Position{token.Start,
+					token.End} {
+					got, err := index.OffsetToPosition(pos.Offset)
+					if err != nil {
+						st.Errorf("offset %d: %s", pos.Offset, err)
+						continue
+					}
+					if got != pos {
+						st.Errorf("offset %d: got %#v, expected %#v",
+							pos.Offset, got, pos)
+					}
+
+					offset, err := index.PositionToOffset(pos)
+					if err != nil {
+						st.Errorf("position %s: %s", &pos, err)
+						continue
+					}
+					if offset != pos.Offset {
+						st.Errorf("position %s: got %d, expected %d", &pos,
+							offset, pos.Offset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLineIndexErrors(t *testing.T) {
+	index := textparser.NewLineIndex([]byte("ab\né\n"))
+
+	if n := index.NumLines(); n != 3 {
+		t.Errorf("got %d lines, expected 3", n)
+	}
+
+	if offset := index.LineOffset(2); offset != 3 {
+		t.Errorf("got line offset %d, expected 3", offset)
+	}
+
+	for _, offset := range []int{-1, 4, 7} {
+		if _, err := index.OffsetToPosition(offset); err == nil {
+			t.Errorf("offset %d: expected an error", offset)
+		}
+	}
+
+	for _, pos := range []textparser.Position{
+		{Line: 0, Column: 1},
+		{Line: 4, Column: 1},
+		{Line: 1, Column: 4},
+		{Line: 3, Column: 2},
+	} {
+		if _, err := index.PositionToOffset(pos); err == nil {
+			t.Errorf("line %d, column %d: expected an error", pos.Line,
+				pos.Column)
+		}
+	}
+
+	pos, err := index.OffsetToPosition(6)
+	expected := textparser.Position{Offset: 6, Line: 3, Column: 1}
+	if err != nil || pos != expected {
+		t.Errorf("got %#v (%v), expected %#v", pos, err, expected)
+	}
+}
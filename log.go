@@ -0,0 +1,79 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Sets the logger used to report scanner events: the recognizer chosen for
+// each token, each token emitted, and scan errors. Each event includes the
+// current position, so a logger carrying request-scoped attributes (e.g.,
+// from slog.Logger.With()) can correlate scanner behavior with the caller.
+// Recognizer and token events are logged at LogLevel, and errors at
+// LogErrorLevel. Pass nil to disable logging, which is the default.
+func (ts *TokenScanner) SetLogger(l *slog.Logger) {
+	ts.logger = l
+}
+
+func (ts *TokenScanner) log_enabled(level slog.Level) bool {
+	return ts.logger != nil && ts.logger.Enabled(context.Background(), level)
+}
+
+func (ts *TokenScanner) log_pos_attr() slog.Attr {
+	return slog.String("pos", ts.pos.String())
+}
+
+func (ts *TokenScanner) log_recognizer(name string, token *Token) {
+	if !ts.log_enabled(ts.LogLevel) {
+		return
+	}
+
+	ts.logger.LogAttrs(context.Background(), ts.LogLevel,
+		"recognizer chosen", slog.String("recognizer", name),
+		slog.String("type", token.Type.String()), ts.log_pos_attr())
+}
+
+func (ts *TokenScanner) log_token(token *Token) {
+	if !ts.log_enabled(ts.LogLevel) {
+		return
+	}
+
+	ts.logger.LogAttrs(context.Background(), ts.LogLevel, "token emitted",
+		slog.String("type", token.Type.String()),
+		slog.String("text", log_text(token.Text)), ts.log_pos_attr())
+}
+
+func (ts *TokenScanner) log_error(err error) {
+	if !ts.log_enabled(ts.LogErrorLevel) {
+		return
+	}
+
+	ts.logger.LogAttrs(context.Background(), ts.LogErrorLevel,
+		"scan error", slog.String("error", err.Error()), ts.log_pos_attr())
+}
@@ -0,0 +1,55 @@
+package textparser_test
+
+import (
+	"bytes"
+	textparser "github.com/cuberat/go-textparser"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := textparser.NewScannerString(`foo "bar`)
+	p.SetLogger(logger.With("request_id", "abc123"))
+	for p.Scan() {
+	}
+
+	out := buf.String()
+	for _, expected := range []string{
+		`msg="recognizer chosen" request_id=abc123 recognizer=ident`,
+		`msg="token emitted" request_id=abc123 type=Ident text=foo`,
+		`level=WARN msg="scan error" request_id=abc123`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in log output:\n%s", expected, out)
+		}
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+
+	p := textparser.NewScannerString(`foo bar`)
+	p.SetLogger(logger)
+	for p.Scan() {
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected debug events to be filtered, got:\n%s", buf)
+	}
+
+	p = textparser.NewScannerString(`foo bar`)
+	p.SetLogger(logger)
+	p.LogLevel = slog.LevelInfo
+	for p.Scan() {
+	}
+
+	if !strings.Contains(buf.String(), `level=INFO msg="token emitted"`) {
+		t.Errorf("expected info events, got:\n%s", buf)
+	}
+}
@@ -0,0 +1,203 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"net/netip"
+	"strings"
+	"unicode"
+)
+
+// Log level words recognized by ProfileLog(), matched regardless of case.
+var DefaultLevelWords = []string{"TRACE", "DEBUG", "INFO", "NOTICE", "WARN",
+	"WARNING", "ERROR", "ERR", "CRIT", "CRITICAL", "ALERT", "EMERG", "FATAL",
+	"PANIC"}
+
+// Sets the words, e.g., "INFO" or "error", to be emitted as TokenTypeLevel
+// tokens when scanned as identifiers, replacing any set before. Words are
+// matched regardless of case. Call with no words to switch this off.
+func (ts *TokenScanner) SetLevelWords(words ...string) {
+	ts.level_words = ts.level_words[:0]
+	for _, word := range words {
+		if word != "" {
+			ts.level_words = append(ts.level_words, word)
+		}
+	}
+}
+
+// Returns the words set with SetLevelWords(), in order.
+func (ts *TokenScanner) LevelWords() []string {
+	return append([]string(nil), ts.level_words...)
+}
+
+// Returns true if the text is one of the words set with SetLevelWords().
+func (ts *TokenScanner) is_level_word(text string) bool {
+	for _, word := range ts.level_words {
+		if strings.EqualFold(text, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the type of an identifier token with the given text, taking
+// KeyValuePairs, SetKeywords(), and SetLevelWords() into account.
+func (ts *TokenScanner) ident_type(text string) TokenType {
+	if ts.KeyValuePairs {
+		next := ts.peek_upto(2)
+		if len(next) > 0 && next[0] == '=' &&
+			(len(next) == 1 || next[1] != '=') {
+			return TokenTypeKey
+		}
+	}
+
+	if ts.keywords[text] {
+		return TokenTypeKeyword
+	}
+
+	if ts.is_level_word(text) {
+		return TokenTypeLevel
+	}
+
+	return TokenTypeIdent
+}
+
+// Longest address: "ffff:ffff:ffff:ffff:ffff:ffff:255.255.255.255".
+const max_address_runes = 45
+
+// Returns true for runes that may appear in an IP address.
+func is_address_rune(ch rune) bool {
+	return is_ascii_digit(ch) || (ch >= 'a' && ch <= 'f') ||
+		(ch >= 'A' && ch <= 'F') || ch == '.' || ch == ':'
+}
+
+// Returns the number of runes of the IPv4 or IPv6 address starting at the
+// next rune, or 0 if there is none, without consuming any input.
+func (ts *TokenScanner) address_len() int {
+	n, _ := ts.match_address()
+	return n
+}
+
+// Returns the number of runes of the IPv4 or IPv6 address or CIDR prefix
+// starting at the next rune, and its parsed value, or 0 if there is none,
+// without consuming any input. The longest prefix of the upcoming address
+// runes that parses is taken, e.g., the address in "10.0.0.1:8080", as long
+// as it is not followed by a letter or digit. A plain address is returned as
+// a prefix covering just the address.
+func (ts *TokenScanner) match_address() (int, netip.Prefix) {
+	if !ts.Addresses {
+		return 0, netip.Prefix{}
+	}
+
+	next := ts.peek_upto(max_address_runes + max_prefix_len_runes + 1)
+
+	n := 0
+	for n < len(next) && is_address_rune(next[n]) {
+		n++
+	}
+	if n > max_address_runes {
+		return 0, netip.Prefix{}
+	}
+
+	for ; n > 1; n-- {
+		if n < len(next) && (unicode.IsLetter(next[n]) ||
+			unicode.IsDigit(next[n])) {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(string(next[:n]))
+		if err != nil {
+			continue
+		}
+
+		if m, prefix := match_prefix_len(next[:n], next[n:]); m > 0 {
+			return n + m, prefix
+		}
+
+		return n, netip.PrefixFrom(addr, addr.BitLen())
+	}
+
+	return 0, netip.Prefix{}
+}
+
+// Longest prefix length, including the slash: "/128".
+const max_prefix_len_runes = 4
+
+// Returns the number of runes of the prefix length, e.g., "/8", at the start
+// of rest, following the address addr, and the parsed prefix, or 0 if there
+// is no valid prefix length.
+func match_prefix_len(addr, rest []rune) (int, netip.Prefix) {
+	if len(rest) < 2 || rest[0] != '/' {
+		return 0, netip.Prefix{}
+	}
+
+	m := 1
+	for m < len(rest) && m < max_prefix_len_runes && is_ascii_digit(rest[m]) {
+		m++
+	}
+	if m < len(rest) && (unicode.IsLetter(rest[m]) ||
+		unicode.IsDigit(rest[m])) {
+		return 0, netip.Prefix{}
+	}
+
+	text := string(addr) + string(rest[:m])
+	prefix, err := netip.ParsePrefix(text)
+	if err != nil {
+		return 0, netip.Prefix{}
+	}
+
+	return m, prefix
+}
+
+// Reads an IP address or CIDR prefix matched by match_address().
+func (ts *TokenScanner) get_address() (*Token, error) {
+	n, value := ts.match_address()
+	if n == 0 {
+		return nil, nil
+	}
+
+	runes, total_size, err := ts.get_n_runes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeAddress,
+		Parsed:    value,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
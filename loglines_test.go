@@ -0,0 +1,148 @@
+package textparser_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestProfileLog(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"key=value", `2024-01-02T03:04:05.123Z INFO [web] ` +
+			`msg="GET /a" status=200`,
+			[]string{`DateTime "2024-01-02T03:04:05.123Z"`, `Level "INFO"`,
+				`Symbol "["`, `Ident "web"`, `Symbol "]"`, `Key "msg"`,
+				`Symbol "="`, `String "\"GET /a\""`, `Key "status"`,
+				`Symbol "="`, `Int "200"`}},
+		{"syslog", "Jan  2 03:04:05 sshd[42]: error: from ::1 level=warn",
+			[]string{`Ident "Jan"`, `Int "2"`, `DateTime "03:04:05"`,
+				`Ident "sshd"`, `Symbol "["`, `Int "42"`, `Symbol "]"`,
+				`Symbol ":"`, `Level "error"`, `Symbol ":"`, `Ident "from"`,
+				`Address "::1"`, `Key "level"`, `Symbol "="`,
+				`Level "warn"`}},
+		{"addresses", "10.0.0.1:8080 fe80::1 1.2.3.4.5 1.2.3.4x http.req-id",
+			[]string{`Address "10.0.0.1"`, `Symbol ":"`, `Int "8080"`,
+				`Address "fe80::1"`, `Address "1.2.3.4"`, `Symbol "."`,
+				`Int "5"`, `Float "1.2"`, `Symbol "."`, `Float "3.4"`,
+				`Ident "x"`, `Ident "http.req-id"`}},
+		{"comparison is not a pair", "x==y",
+			[]string{`Ident "x"`, `Symbol "="`, `Symbol "="`, `Ident "y"`}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.ProfileLog().NewScanner(
+				strings.NewReader(test_data.Input))
+			p.SkipWhitespace = true
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+
+					strconv.Quote(token.Text))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestLevelWords(t *testing.T) {
+	p := textparser.NewScannerString("info Notice")
+	p.SkipWhitespace = true
+	p.SetLevelWords("", "NOTICE")
+
+	if got, expected := p.LevelWords(), []string{"NOTICE"}; !reflect.DeepEqual(
+		got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	var got []textparser.TokenType
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type)
+	}
+
+	expected := []textparser.TokenType{textparser.TokenTypeIdent,
+		textparser.TokenTypeLevel}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestAddressPrefixes(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+		Prefixes []string
+	}
+
+	test_list := []TestData{
+		{"addresses", "10.0.0.1 ::1", []string{"10.0.0.1", "::1"},
+			[]string{"10.0.0.1/32", "::1/128"}},
+		{"cidr", "10.0.0.0/8 fe80::/10 10.1.2.3/24",
+			[]string{"10.0.0.0/8", "fe80::/10", "10.1.2.3/24"},
+			[]string{"10.0.0.0/8", "fe80::/10", "10.1.2.3/24"}},
+		{"invalid prefix length", "10.0.0.0/33 10.0.0.0/8x",
+			[]string{"10.0.0.0", "/", "33", "10.0.0.0", "/", "8", "x"},
+			[]string{"10.0.0.0/32", "", "", "10.0.0.0/32", "", "", ""}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SkipWhitespace = true
+			p.Addresses = true
+
+			var got, prefixes []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Text)
+				prefix := ""
+				if token.Prefix().IsValid() {
+					prefix = token.Prefix().String()
+				}
+				prefixes = append(prefixes, prefix)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+			if !reflect.DeepEqual(prefixes, test_data.Prefixes) {
+				st.Errorf("got %#v, expected %#v", prefixes,
+					test_data.Prefixes)
+			}
+		})
+	}
+}
+
+func TestAddressGob(t *testing.T) {
+	p := textparser.NewScannerString("10.0.0.0/8")
+	p.Addresses = true
+
+	tokens := scan_all(t, p)
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(tokens[0]); err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	got := new(textparser.Token)
+	if err := gob.NewDecoder(buf).Decode(got); err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	if got.Prefix() != tokens[0].Prefix() || got.Text != tokens[0].Text {
+		t.Errorf("got %#v, expected %#v", got, tokens[0])
+	}
+}
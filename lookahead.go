@@ -0,0 +1,268 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// The most runes the scanner looks ahead at once.
+const max_lookahead = 1 << 16
+
+// Error returned when more than max_lookahead runes are peeked at.
+var errLookaheadLimit = errors.New("lookahead limit exceeded")
+
+// A rune read from the input, along with its size in bytes.
+type sized_rune struct {
+	ch   rune
+	size int
+}
+
+// Reads runes from a reader with arbitrary lookahead, up to max_lookahead
+// runes, keeping peeked runes in a ring buffer until they are read.
+type rune_buffer struct {
+	src io.RuneReader
+
+	// Peeked runes, starting at head. The length is a power of two.
+	ring  []sized_rune
+	head  int
+	count int
+
+	last     sized_rune // The last rune read, for unread_rune().
+	has_last bool
+
+	// The whole input, if it is held in memory (see slice_reader), and the
+	// number of bytes of it read so far.
+	data     []byte
+	consumed int
+
+	// The source, if it is the caller's io.RuneScanner, read directly.
+	shared io.RuneScanner
+}
+
+// Returns a rune buffer reading from the reader. Input held in memory is
+// decoded in place, and an io.RuneScanner is read directly, instead of being
+// copied through a bufio.Reader.
+func new_rune_buffer(r io.Reader) *rune_buffer {
+	switch src := r.(type) {
+	case *slice_reader:
+		return &rune_buffer{src: src, data: src.data}
+	case io.RuneScanner:
+		return &rune_buffer{src: src, shared: src}
+	}
+
+	return &rune_buffer{src: bufio.NewReader(r)}
+}
+
+// Gives runes peeked at but not yet read back to the caller's io.RuneScanner,
+// if possible, so that the caller may go on reading where the scanner left
+// off: a single rune through UnreadRune(), or any number if the source is
+// also an io.Seeker. Otherwise, they stay buffered.
+func (b *rune_buffer) release() {
+	if b.shared == nil || b.count == 0 {
+		return
+	}
+
+	// The peeked runes are the last ones read from the source.
+	if b.count == 1 {
+		if b.shared.UnreadRune() == nil {
+			b.count = 0
+		}
+		return
+	}
+
+	seeker, ok := b.shared.(io.Seeker)
+	if !ok {
+		return
+	}
+
+	size := 0
+	for i := 0; i < b.count; i++ {
+		size += b.ring[(b.head+i)&(len(b.ring)-1)].size
+	}
+
+	if _, err := seeker.Seek(int64(-size), io.SeekCurrent); err == nil {
+		b.count = 0
+	}
+}
+
+// Reads the next rune, as io.RuneReader.
+func (b *rune_buffer) ReadRune() (rune, int, error) {
+	var r sized_rune
+
+	if b.count > 0 {
+		r = b.ring[b.head]
+		b.head = (b.head + 1) & (len(b.ring) - 1)
+		b.count--
+	} else {
+		ch, size, err := b.src.ReadRune()
+		if err != nil {
+			b.has_last = false
+			return 0, 0, err
+		}
+		r = sized_rune{ch, size}
+	}
+
+	b.last, b.has_last = r, true
+	b.consumed += r.size
+
+	return r.ch, r.size, nil
+}
+
+// Unreads the last rune read, as io.RuneScanner. Only one rune may be unread
+// after each ReadRune().
+func (b *rune_buffer) UnreadRune() error {
+	if !b.has_last {
+		return bufio.ErrInvalidUnreadRune
+	}
+	b.has_last = false
+	b.consumed -= b.last.size
+
+	b.grow(b.count + 1)
+	b.head = (b.head - 1) & (len(b.ring) - 1)
+	b.ring[b.head] = b.last
+	b.count++
+
+	return nil
+}
+
+// Returns the next n runes without consuming them. If the input ends or a
+// read fails first, returns the runes available along with the error, e.g.,
+// io.EOF.
+func (b *rune_buffer) peek(n int) ([]rune, error) {
+	if n > max_lookahead {
+		return nil, errLookaheadLimit
+	}
+
+	err := b.fill(n)
+	if n > b.count {
+		n = b.count
+	}
+
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = b.ring[(b.head+i)&(len(b.ring)-1)].ch
+	}
+
+	// Peeking doesn't change what may be unread, since the peeked runes
+	// follow the last rune read.
+	return runes, err
+}
+
+// Returns the rune i runes ahead, counting from 0, without consuming any,
+// or an error, e.g., io.EOF, if the input ends first.
+func (b *rune_buffer) peek_at(i int) (sized_rune, error) {
+	if i >= max_lookahead {
+		return sized_rune{}, errLookaheadLimit
+	}
+
+	if err := b.fill(i + 1); err != nil && i >= b.count {
+		return sized_rune{}, err
+	}
+
+	return b.ring[(b.head+i)&(len(b.ring)-1)], nil
+}
+
+// Reads ahead until n runes are peeked, or the input ends or a read fails,
+// in which case the error is returned.
+func (b *rune_buffer) fill(n int) error {
+	if b.count >= n {
+		return nil
+	}
+
+	b.grow(n)
+	for b.count < n {
+		var (
+			r   sized_rune
+			err error
+		)
+		r.ch, r.size, err = b.src.ReadRune()
+		if err != nil {
+			return err
+		}
+		b.ring[(b.head+b.count)&(len(b.ring)-1)] = r
+		b.count++
+	}
+
+	return nil
+}
+
+// Makes room for at least n runes in the ring buffer.
+func (b *rune_buffer) grow(n int) {
+	if n <= len(b.ring) {
+		return
+	}
+
+	size := 16
+	for size < n {
+		size *= 2
+	}
+
+	ring := make([]sized_rune, size)
+	for i := 0; i < b.count; i++ {
+		ring[i] = b.ring[(b.head+i)&(len(b.ring)-1)]
+	}
+	b.ring, b.head = ring, 0
+}
+
+// A reader over input held in memory, which the scanner decodes in place.
+type slice_reader struct {
+	data []byte
+	off  int
+}
+
+// Reads bytes, as io.Reader, e.g., for Normalize().
+func (r *slice_reader) Read(p []byte) (int, error) {
+	if r.off >= len(r.data) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.data[r.off:])
+	r.off += n
+
+	return n, nil
+}
+
+// Reads the next rune, as io.RuneReader.
+func (r *slice_reader) ReadRune() (rune, int, error) {
+	if r.off >= len(r.data) {
+		return 0, 0, io.EOF
+	}
+
+	if ch := r.data[r.off]; ch < utf8.RuneSelf {
+		r.off++
+		return rune(ch), 1, nil
+	}
+
+	ch, size := utf8.DecodeRune(r.data[r.off:])
+	r.off += size
+
+	return ch, size, nil
+}
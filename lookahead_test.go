@@ -0,0 +1,108 @@
+package textparser_test
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestLongLookahead(t *testing.T) {
+	// Longer than the default bufio buffer, in bytes.
+	marker := strings.Repeat("é", 3000)
+	input := "a " + marker + " comment\nb " + marker[:10] + " c"
+
+	p := textparser.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+	p.SetComments(textparser.CommentStyle{Start: marker})
+	p.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+		return ch >= 'a' && ch <= 'z' || ch == 'é'
+	}
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, token := range tokens {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"a", "b", marker[:10], "c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %d tokens %.40q, expected %q", len(got), got, expected)
+	}
+}
+
+func TestLookaheadAtEnd(t *testing.T) {
+	p := textparser.NewScanner(iotest.OneByteReader(strings.NewReader(
+		"x <=")))
+	p.SetOperators("<==>", "<=")
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.TokenText())
+	}
+
+	expected := []string{"x", "<="}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestRuneScannerInterleaved(t *testing.T) {
+	type TestData struct {
+		Name   string
+		Source func(input string) io.RuneScanner
+	}
+
+	test_list := []TestData{
+		{
+			Name: "strings.Reader",
+			Source: func(input string) io.RuneScanner {
+				return strings.NewReader(input)
+			},
+		},
+		{
+			Name: "bufio.Reader",
+			Source: func(input string) io.RuneScanner {
+				return bufio.NewReader(strings.NewReader(input))
+			},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			r := test_data.Source("key: raw text\nnext 42")
+			p := textparser.NewScanner(r.(io.Reader))
+
+			var got []string
+			for p.Scan() {
+				got = append(got, p.TokenText())
+				if p.TokenText() != ":" {
+					continue
+				}
+
+				// Read the rest of the line past the scanner.
+				var line []rune
+				for {
+					ch, _, err := r.ReadRune()
+					if err != nil || ch == '\n' {
+						break
+					}
+					line = append(line, ch)
+				}
+				got = append(got, string(line))
+			}
+
+			expected := []string{"key", ":", " raw text", "next", "42"}
+			if !reflect.DeepEqual(got, expected) {
+				st.Errorf("got %#v, expected %#v", got, expected)
+			}
+		})
+	}
+}
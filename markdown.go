@@ -0,0 +1,434 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// State of markdown inline mode, set by SetMarkdownInline().
+type markdown_state struct {
+	prev        rune // Last rune consumed, or 0 at the start.
+	after_close bool // The last token was a "]" link bracket.
+	link_depth  int  // Parenthesis depth inside a link destination.
+}
+
+// Code spans and autolinks longer than this many runes are not recognized.
+const max_markdown_lookahead = 1024
+
+// Runes that may start an inline construct, and so end a text run.
+const markdown_special_runes = "\\`*_[]!<()"
+
+// Switches the scanner to markdown inline mode, where Scan() emits typed
+// tokens for inline markdown constructs and leaves the text between them
+// intact:
+//
+//   - TokenTypeEmphasis for a run of "*" or "_" that can open or close
+//     emphasis, e.g., "**", following the CommonMark flanking rules;
+//   - TokenTypeCode for a code span, e.g., "`x`", including its backticks;
+//   - TokenTypeSymbol for link and image brackets, "[", "![", and "]", and
+//     for the parentheses around a link destination directly following
+//     "]", with Bracket set to BracketOpen or BracketClose;
+//   - TokenTypeAutolink for an autolink, e.g., "<https://example.com>" or
+//     "<me@example.com>", including the angle brackets;
+//   - TokenTypeText for everything else, including whitespace, line endings,
+//     and backslash escapes, e.g., "\*".
+//
+// Block structure, e.g., headings and lists, is not recognized, and emphasis
+// runs are not paired, so that callers can apply their own rules. Scanning
+// never fails on well-formed UTF-8.
+//
+// Switching markdown inline mode on switches delimited mode (see
+// SetDelimited()) off.
+func (ts *TokenScanner) SetMarkdownInline(enabled bool) {
+	if !enabled {
+		ts.markdown = nil
+		return
+	}
+
+	ts.dsv = nil
+	ts.markdown = &markdown_state{}
+}
+
+// Reads the next inline construct or text run.
+func (ts *TokenScanner) get_markdown() (*Token, error) {
+	m := ts.markdown
+	next := ts.peek_upto(max_markdown_lookahead)
+	if len(next) == 0 {
+		return nil, io.EOF
+	}
+
+	token_type, bracket, n := m.match(next)
+	if n == 0 {
+		return ts.get_markdown_text()
+	}
+
+	runes, total_size, err := ts.get_n_runes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bracket == BracketClose && runes[0] == ']':
+		m.after_close = true
+	case runes[0] == '(':
+		m.after_close = false
+		m.link_depth = 1
+	case runes[0] == ')':
+		m.after_close = false
+		m.link_depth = 0
+	default:
+		m.after_close = false
+	}
+
+	return ts.markdown_token(token_type, bracket, runes, total_size), nil
+}
+
+// Returns the type, bracket kind, and length in runes of the inline
+// construct at the start of next, or a length of 0 if there is none.
+func (m *markdown_state) match(next []rune) (TokenType, BracketKind, int) {
+	if m.link_depth > 0 {
+		// A link destination is text up to its closing parenthesis.
+		if next[0] == ')' && m.link_depth == 1 {
+			return TokenTypeSymbol, BracketClose, 1
+		}
+		return TokenTypeText, BracketNone, 0
+	}
+
+	switch next[0] {
+	case '`':
+		if n := match_code_span(next); n > 0 {
+			return TokenTypeCode, BracketNone, n
+		}
+	case '*', '_':
+		if n := emphasis_len(m.prev, next); n > 0 {
+			return TokenTypeEmphasis, BracketNone, n
+		}
+	case '[':
+		return TokenTypeSymbol, BracketOpen, 1
+	case '!':
+		if len(next) > 1 && next[1] == '[' {
+			return TokenTypeSymbol, BracketOpen, 2
+		}
+	case ']':
+		return TokenTypeSymbol, BracketClose, 1
+	case '(':
+		if m.after_close {
+			return TokenTypeSymbol, BracketOpen, 1
+		}
+	case '<':
+		if n := match_autolink(next); n > 0 {
+			return TokenTypeAutolink, BracketNone, n
+		}
+	}
+
+	return TokenTypeText, BracketNone, 0
+}
+
+// Reads a run of plain text, up to the next inline construct.
+func (ts *TokenScanner) get_markdown_text() (*Token, error) {
+	m := ts.markdown
+
+	var (
+		runes      []rune
+		total_size int
+	)
+
+	for {
+		next := ts.peek_upto(max_markdown_lookahead)
+		n := m.text_len(next, len(runes) == 0)
+		if n == 0 {
+			break
+		}
+
+		chars, size, err := ts.get_n_runes(n)
+		if err != nil {
+			return nil, err
+		}
+		runes = append(runes, chars...)
+		total_size += size
+
+		if n < len(next) || len(next) < max_markdown_lookahead {
+			break
+		}
+	}
+
+	m.after_close = false
+
+	return ts.markdown_token(TokenTypeText, BracketNone, runes, total_size),
+		nil
+}
+
+// Returns the number of runes of plain text at the start of next, updating
+// the link destination depth for the parentheses in it. At the start of a
+// token, the first rune is taken as text even if it could start a construct,
+// since match() has already rejected it.
+func (m *markdown_state) text_len(next []rune, first bool) int {
+	prev := m.prev
+	i := 0
+
+	for i < len(next) {
+		ch := next[i]
+		n := 1
+
+		switch {
+		case m.link_depth > 0:
+			switch ch {
+			case '(':
+				m.link_depth++
+			case ')':
+				if m.link_depth == 1 {
+					return i
+				}
+				m.link_depth--
+			case '\n':
+				// A link destination cannot span lines.
+				m.link_depth = 0
+			}
+		case ch == '\\' && i+1 < len(next) && is_ascii_punct(next[i+1]):
+			n = 2
+		case first && i == 0:
+			if ch == '`' || ch == '*' || ch == '_' {
+				// A run that match() rejected is literal as a whole.
+				n = count_run(next, ch)
+			}
+		case !strings.ContainsRune(markdown_special_runes, ch):
+		case ch == '(' || ch == ')':
+		case ch == '!':
+			if i+1 < len(next) && next[i+1] == '[' {
+				return i
+			}
+		case ch == '_' || ch == '*':
+			if emphasis_len(prev, next[i:]) > 0 {
+				return i
+			}
+			n = count_run(next[i:], ch)
+		case ch == '`':
+			if match_code_span(next[i:]) > 0 {
+				return i
+			}
+			n = count_run(next[i:], '`')
+		case ch == '<':
+			if match_autolink(next[i:]) > 0 {
+				return i
+			}
+		default:
+			// "[" or "]".
+			return i
+		}
+
+		i += n
+		prev = next[i-1]
+	}
+
+	return i
+}
+
+// Returns the length of the emphasis delimiter run at the start of next, or
+// 0 if the run can neither open nor close emphasis. The before rune is the
+// one preceding the run, or 0 at the start of the input.
+func emphasis_len(before rune, next []rune) int {
+	ch := next[0]
+	n := count_run(next, ch)
+
+	after := ' '
+	if before == 0 {
+		before = ' '
+	}
+	if n < len(next) {
+		after = next[n]
+	}
+
+	left := !unicode.IsSpace(after) && (!is_markdown_punct(after) ||
+		unicode.IsSpace(before) || is_markdown_punct(before))
+	right := !unicode.IsSpace(before) && (!is_markdown_punct(before) ||
+		unicode.IsSpace(after) || is_markdown_punct(after))
+
+	if ch == '_' {
+		// Intraword underscores, e.g., in "snake_case", are literal.
+		left, right = left && (!right || is_markdown_punct(before)),
+			right && (!left || is_markdown_punct(after))
+	}
+
+	if !left && !right {
+		return 0
+	}
+
+	return n
+}
+
+// Returns the number of consecutive ch runes at the start of s.
+func count_run(s []rune, ch rune) int {
+	n := 0
+	for n < len(s) && s[n] == ch {
+		n++
+	}
+
+	return n
+}
+
+// Returns the length of the code span at the start of s, or 0 if the opening
+// backtick run has no closing run of the same length before a blank line.
+func match_code_span(s []rune) int {
+	open := count_run(s, '`')
+
+	for i := open; i < len(s); {
+		switch {
+		case s[i] == '`':
+			n := count_run(s[i:], '`')
+			if n == open {
+				return i + n
+			}
+			i += n
+		case s[i] == '\n' && is_blank_line_after(s[i+1:]):
+			return 0
+		default:
+			i++
+		}
+	}
+
+	return 0
+}
+
+// Returns true if s starts with a line containing only spaces and tabs.
+func is_blank_line_after(s []rune) bool {
+	for _, ch := range s {
+		switch ch {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		}
+		return false
+	}
+
+	return true
+}
+
+// Returns the length of the autolink at the start of s, e.g.,
+// "<https://example.com>" or "<me@example.com>", or 0 if there is none.
+func match_autolink(s []rune) int {
+	if n := match_uri_autolink(s); n > 0 {
+		return n
+	}
+
+	return match_email_autolink(s)
+}
+
+// Matches "<scheme:...>" with a scheme of 2 to 32 characters.
+func match_uri_autolink(s []rune) int {
+	i := 1
+	for i < len(s) && (is_ascii_letter(s[i]) ||
+		(i > 1 && (is_ascii_digit(s[i]) || s[i] == '+' || s[i] == '.' ||
+			s[i] == '-'))) {
+		i++
+	}
+	if i-1 < 2 || i-1 > 32 || i >= len(s) || s[i] != ':' {
+		return 0
+	}
+
+	for i++; i < len(s); i++ {
+		switch {
+		case s[i] == '>':
+			return i + 1
+		case s[i] == '<' || s[i] <= ' ' || s[i] == 0x7f:
+			return 0
+		}
+	}
+
+	return 0
+}
+
+// Matches "<local@domain>".
+func match_email_autolink(s []rune) int {
+	i := 1
+	for i < len(s) && (is_ascii_letter(s[i]) || is_ascii_digit(s[i]) ||
+		strings.ContainsRune(".!#$%&'*+/=?^_`{|}~-", s[i])) {
+		i++
+	}
+	if i == 1 || i >= len(s) || s[i] != '@' {
+		return 0
+	}
+
+	// Labels of letters, digits, and dashes, separated by dots.
+	label := 0
+	for i++; i < len(s); i++ {
+		switch ch := s[i]; {
+		case is_ascii_letter(ch) || is_ascii_digit(ch) || ch == '-':
+			label++
+		case ch == '.' && label > 0:
+			label = 0
+		case ch == '>' && label > 0:
+			return i + 1
+		default:
+			return 0
+		}
+	}
+
+	return 0
+}
+
+func is_ascii_letter(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// Returns true for ASCII punctuation, which a backslash may escape.
+func is_ascii_punct(ch rune) bool {
+	return ch < 0x80 && (unicode.IsPunct(ch) || unicode.IsSymbol(ch))
+}
+
+// Returns true for punctuation in the sense of the CommonMark flanking rules.
+func is_markdown_punct(ch rune) bool {
+	return unicode.IsPunct(ch) || unicode.IsSymbol(ch)
+}
+
+// Returns a new token of the given type for the runes read.
+func (ts *TokenScanner) markdown_token(
+	token_type TokenType,
+	bracket BracketKind,
+	runes []rune,
+	total_size int,
+) *Token {
+	ts.markdown.prev = runes[len(runes)-1]
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      token_type,
+		Bracket:   bracket,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token
+}
@@ -0,0 +1,117 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestMarkdownInline(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"emphasis", "Some *emphasis* and __strong__ text.",
+			[]string{`Text "Some "`, `Emphasis "*"`, `Text "emphasis"`,
+				`Emphasis "*"`, `Text " and "`, `Emphasis "__"`,
+				`Text "strong"`, `Emphasis "__"`, `Text " text."`}},
+		{"literal delimiters", "snake_case_name and a * b ** c",
+			[]string{`Text "snake_case_name and a * b ** c"`}},
+		{"code spans", "Use `x := 1` or ``a ` b`` here, `unmatched",
+			[]string{`Text "Use "`, "Code \"`x := 1`\"", `Text " or "`,
+				"Code \"``a ` b``\"", "Text \" here, `unmatched\""}},
+		{"code span ends at blank line", "`a\n\nb`",
+			[]string{"Text \"`a\\n\\nb`\""}},
+		{"links", "See [docs](https://x.org/a_(b)) and ![img](p.png).",
+			[]string{`Text "See "`, `Symbol "[" open`, `Text "docs"`,
+				`Symbol "]" close`, `Symbol "(" open`,
+				`Text "https://x.org/a_(b)"`, `Symbol ")" close`,
+				`Text " and "`, `Symbol "![" open`, `Text "img"`,
+				`Symbol "]" close`, `Symbol "(" open`, `Text "p.png"`,
+				`Symbol ")" close`, `Text "."`}},
+		{"autolinks", "Visit <https://example.com> or <me@example.com>, " +
+			"not <b>.",
+			[]string{`Text "Visit "`, `Autolink "<https://example.com>"`,
+				`Text " or "`, `Autolink "<me@example.com>"`,
+				`Text ", not <b>."`}},
+		{"escapes and parentheses", "\\*a\\* (b) [c] (d)",
+			[]string{`Text "\\*a\\* (b) "`, `Symbol "[" open`, `Text "c"`,
+				`Symbol "]" close`, `Text " (d)"`}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.ProfileMarkdown().NewScanner(
+				strings.NewReader(test_data.Input))
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				s := token.Type.String() + " " + strconv.Quote(token.Text)
+				if token.Bracket != textparser.BracketNone {
+					s += " " + token.Bracket.String()
+				}
+				got = append(got, s)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestMarkdownInlinePositions(t *testing.T) {
+	p := textparser.ProfileMarkdown().NewScanner(
+		strings.NewReader("a\n**b** `c`"))
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	type Span struct {
+		Text   string
+		Line   int
+		Column int
+	}
+
+	var got []Span
+	for _, token := range tokens {
+		got = append(got, Span{token.Text, token.Start.Line,
+			token.Start.Column})
+	}
+
+	expected := []Span{{"a\n", 1, 1}, {"**", 2, 1}, {"b", 2, 3},
+		{"**", 2, 4}, {" ", 2, 6}, {"`c`", 2, 7}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestMarkdownInlineSwitch(t *testing.T) {
+	p := textparser.NewScannerString("*a*")
+	p.SetDelimited(',', '"')
+	p.SetMarkdownInline(true)
+
+	summary := p.ConfigSummary()
+	if !reflect.DeepEqual(summary.Recognizers, []string{"markdown"}) {
+		t.Errorf("got %#v, expected markdown only", summary.Recognizers)
+	}
+
+	p.SetMarkdownInline(false)
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"*", "a", "*"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,262 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+	utf8 "unicode/utf8"
+)
+
+func init() {
+	// Allow tokens and positions to be sent as interface values.
+	gob.Register(&Token{})
+	gob.Register(&Position{})
+
+	// Parsed values of tokens.
+	gob.Register(time.Time{})
+	gob.Register(netip.Prefix{})
+}
+
+// Returns the TokenType with the given name, as returned by the String()
+// method of TokenType.
+func ParseTokenType(name string) (TokenType, error) {
+	for i, n := range token_type_names {
+		if n == name {
+			return TokenType(i), nil
+		}
+	}
+
+	// Fallback for types without a name.
+	if strings.HasPrefix(name, "TokenType(") && strings.HasSuffix(name, ")") {
+		num, err := strconv.Atoi(name[len("TokenType(") : len(name)-1])
+		if err == nil {
+			return TokenType(num), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown token type %q", name)
+}
+
+func (t TokenType) name() string {
+	if s := t.String(); s != "" {
+		return s
+	}
+
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
+// Implements the encoding.TextMarshaler interface. The token type is
+// serialized by name.
+func (t TokenType) MarshalText() ([]byte, error) {
+	return []byte(t.name()), nil
+}
+
+// Implements the encoding.TextUnmarshaler interface.
+func (t *TokenType) UnmarshalText(text []byte) error {
+	tt, err := ParseTokenType(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = tt
+
+	return nil
+}
+
+// Implements the gob.GobEncoder interface. The token type is serialized by
+// name, so that persisted token streams survive renumbering.
+func (t TokenType) GobEncode() ([]byte, error) {
+	return t.MarshalText()
+}
+
+// Implements the gob.GobDecoder interface.
+func (t *TokenType) GobDecode(data []byte) error {
+	return t.UnmarshalText(data)
+}
+
+type json_position struct {
+	Filename string `json:"filename"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// Implements the json.Marshaler interface.
+func (p *Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal(json_position(*p))
+}
+
+// Implements the json.Unmarshaler interface.
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var jp json_position
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return err
+	}
+
+	*p = Position(jp)
+
+	return nil
+}
+
+type json_token struct {
+	Type      TokenType `json:"type"`
+	Text      string    `json:"text"`
+	NumBytes  int       `json:"num_bytes"`
+	NumChars  int       `json:"num_chars"`
+	FirstRune string    `json:"first_rune"`
+	Raw       string    `json:"raw,omitempty"`
+	Bracket   string    `json:"bracket,omitempty"`
+	Time      string    `json:"time,omitempty"`
+	Prefix    string    `json:"prefix,omitempty"`
+
+	Whitespace *WhitespaceInfo `json:"whitespace,omitempty"`
+	Value      string          `json:"value,omitempty"`
+	Suffix     string          `json:"suffix,omitempty"`
+	Subtype    string          `json:"subtype,omitempty"`
+	Parts      []Span          `json:"parts,omitempty"`
+	Segments   []string        `json:"segments,omitempty"`
+	Region     string          `json:"region,omitempty"`
+	Continued  bool            `json:"continued,omitempty"`
+
+	TrailingComment *Token `json:"trailing_comment,omitempty"`
+}
+
+// Implements the json.Marshaler interface. The token type is serialized by
+// name and the first rune as a string.
+func (t *Token) MarshalJSON() ([]byte, error) {
+	jt := &json_token{
+		Type:     t.Type,
+		Text:     t.Text,
+		NumBytes: t.NumBytes,
+		NumChars: t.NumChars,
+		Raw:      t.Raw,
+		Bracket:  t.Bracket.String(),
+		Value:    t.Value,
+		Suffix:   t.Suffix,
+		Subtype:  t.Subtype,
+		Parts:    t.Parts,
+		Segments: t.Segments,
+		Region:   t.Region,
+
+		Continued:       t.Continued,
+		TrailingComment: t.TrailingComment,
+	}
+
+	if value := t.Time(); !value.IsZero() {
+		jt.Time = value.Format(time.RFC3339Nano)
+	}
+
+	if prefix := t.Prefix(); prefix.IsValid() {
+		jt.Prefix = prefix.String()
+	}
+
+	if t.FirstRune != 0 {
+		jt.FirstRune = string(t.FirstRune)
+	}
+
+	if t.Whitespace != (WhitespaceInfo{}) {
+		jt.Whitespace = &t.Whitespace
+	}
+
+	return json.Marshal(jt)
+}
+
+// Implements the json.Unmarshaler interface.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	jt := new(json_token)
+	if err := json.Unmarshal(data, jt); err != nil {
+		return err
+	}
+
+	var first_rune rune
+	if jt.FirstRune != "" {
+		r, size := utf8.DecodeRuneInString(jt.FirstRune)
+		if size != len(jt.FirstRune) {
+			return fmt.Errorf("invalid first rune %q", jt.FirstRune)
+		}
+		first_rune = r
+	}
+
+	var bracket BracketKind
+	switch jt.Bracket {
+	case "":
+	case "open":
+		bracket = BracketOpen
+	case "close":
+		bracket = BracketClose
+	default:
+		return fmt.Errorf("invalid bracket %q", jt.Bracket)
+	}
+
+	var parsed any
+	if jt.Time != "" {
+		value, err := time.Parse(time.RFC3339Nano, jt.Time)
+		if err != nil {
+			return fmt.Errorf("invalid time %q", jt.Time)
+		}
+		parsed = value
+	}
+
+	if jt.Prefix != "" {
+		prefix, err := netip.ParsePrefix(jt.Prefix)
+		if err != nil {
+			return fmt.Errorf("invalid prefix %q", jt.Prefix)
+		}
+		parsed = prefix
+	}
+
+	*t = Token{
+		Text:      jt.Text,
+		NumBytes:  jt.NumBytes,
+		NumChars:  jt.NumChars,
+		FirstRune: first_rune,
+		Type:      jt.Type,
+		Raw:       jt.Raw,
+		Bracket:   bracket,
+		Parsed:    parsed,
+		Value:     jt.Value,
+		Suffix:    jt.Suffix,
+		Subtype:   jt.Subtype,
+		Parts:     jt.Parts,
+		Segments:  jt.Segments,
+		Region:    jt.Region,
+
+		Continued:       jt.Continued,
+		TrailingComment: jt.TrailingComment,
+	}
+	if jt.Whitespace != nil {
+		t.Whitespace = *jt.Whitespace
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package textparser_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	textparser "github.com/cuberat/go-textparser"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func scan_all(t *testing.T, p *textparser.TokenScanner) []*textparser.Token {
+	token_list := make([]*textparser.Token, 0)
+	for p.Scan() {
+		token_list = append(token_list, p.Token())
+	}
+
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("error from scanner: %s", err)
+	}
+
+	return token_list
+}
+
+func TestTokenJSON(t *testing.T) {
+	p := textparser.NewScannerString(`foo = "bar" + 4.2 // done`)
+	p.SkipWhitespace = false
+	p.SkipComments = false
+	token_list := scan_all(t, p)
+
+	data, err := json.Marshal(token_list)
+	if err != nil {
+		t.Fatalf("couldn't marshal tokens: %s", err)
+	}
+
+	if !bytes.Contains(data, []byte(`"type":"Ident"`)) {
+		t.Errorf("token type not serialized by name: %s", data)
+	}
+
+	var got []*textparser.Token
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("couldn't unmarshal tokens: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, token_list) {
+		t.Errorf("got %+v, expected %+v", got, token_list)
+	}
+}
+
+func TestPositionJSON(t *testing.T) {
+	pos := &textparser.Position{Filename: "foo.txt", Offset: 12, Line: 2,
+		Column: 3}
+
+	data, err := json.Marshal(pos)
+	if err != nil {
+		t.Fatalf("couldn't marshal position: %s", err)
+	}
+
+	expected := `{"filename":"foo.txt","offset":12,"line":2,"column":3}`
+	if string(data) != expected {
+		t.Errorf("got %s, expected %s", data, expected)
+	}
+
+	got := new(textparser.Position)
+	if err = json.Unmarshal(data, got); err != nil {
+		t.Fatalf("couldn't unmarshal position: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, pos) {
+		t.Errorf("got %s, expected %s", got, pos)
+	}
+}
+
+func TestTokenTypeText(t *testing.T) {
+	for _, tt := range []textparser.TokenType{textparser.TokenTypeIdent,
+		textparser.TokenTypeSymbol, textparser.TokenType(1000)} {
+		text, err := tt.MarshalText()
+		if err != nil {
+			t.Fatalf("couldn't marshal %d: %s", tt, err)
+		}
+
+		var got textparser.TokenType
+		if err = got.UnmarshalText(text); err != nil {
+			t.Fatalf("couldn't unmarshal %s: %s", text, err)
+		}
+
+		if got != tt {
+			t.Errorf("got %d, expected %d", got, tt)
+		}
+	}
+
+	if _, err := textparser.ParseTokenType("Bogus"); err == nil {
+		t.Errorf("expected error for unknown token type")
+	}
+}
+
+func TestTokenGob(t *testing.T) {
+	p := textparser.NewScannerString(`name, del=',', usage='stuff'`)
+	token_list := scan_all(t, p)
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(token_list); err != nil {
+		t.Fatalf("couldn't encode tokens: %s", err)
+	}
+
+	var got []*textparser.Token
+	if err := gob.NewDecoder(buf).Decode(&got); err != nil {
+		t.Fatalf("couldn't decode tokens: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, token_list) {
+		t.Errorf("got %+v, expected %+v", got, token_list)
+	}
+}
@@ -0,0 +1,225 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Recognizes one kind of token. Scan() tries the matchers in its chain in
+// order (see SetMatchers()), and the first one matching the start of the
+// input reads the token.
+type TokenMatcher interface {
+	// Returns the name of the matcher, e.g., "quoted", which identifies it
+	// in the chain, in ConfigSummary.Recognizers, and in log records.
+	Name() string
+
+	// Returns true if a token of this kind starts with ch, the next rune in
+	// the input, which has not been consumed yet. Use PeekRunes() to look
+	// further ahead. Must not consume any input.
+	Match(ts *TokenScanner, ch rune) bool
+
+	// Reads the token that Match() reported, e.g., with ReadToken().
+	Read(ts *TokenScanner) (*Token, error)
+}
+
+// One of the built-in recognizers, as a matcher.
+type class_matcher rune_class
+
+func (m class_matcher) Name() string {
+	return rune_class(m).String()
+}
+
+func (m class_matcher) Match(ts *TokenScanner, ch rune) bool {
+	return ts.class_matches(rune_class(m), ch)
+}
+
+func (m class_matcher) Read(ts *TokenScanner) (*Token, error) {
+	return ts.read_class(rune_class(m))
+}
+
+// The built-in matchers, in the order given by class_order.
+var default_matchers = func() []TokenMatcher {
+	matchers := make([]TokenMatcher, len(class_order))
+	for i, class := range class_order {
+		matchers[i] = class_matcher(class)
+	}
+	return matchers
+}()
+
+// Returns the built-in matchers, in the default order documented for Scan().
+// Their names are "directive", "continuation", "whitespace", "comment",
+// "section", "quoted", "parameter", "variable", "url", "address",
+// "datetime", "ident", "number", and "symbol". A built-in matcher only
+// matches when the scanner is configured for its kind of token, e.g.,
+// "datetime" requires DateTimes.
+func DefaultMatchers() []TokenMatcher {
+	return append([]TokenMatcher(nil), default_matchers...)
+}
+
+// Sets the chain of matchers tried, in order, at the start of each token,
+// replacing the current one. The first matcher that matches reads the token.
+// If none does, the Unknown setting decides what happens. Built-in matchers
+// left out of the chain are never used, e.g., without "number", digits are
+// unknown runes (or part of identifiers, if IsIdentRune accepts them).
+//
+// Delimited mode (see SetDelimited()) and markdown inline mode (see
+// SetMarkdownInline()) do not use the chain.
+func (ts *TokenScanner) SetMatchers(matchers ...TokenMatcher) {
+	ts.matchers = append([]TokenMatcher(nil), matchers...)
+}
+
+// Returns the chain of matchers, in order. See SetMatchers().
+func (ts *TokenScanner) Matchers() []TokenMatcher {
+	return append([]TokenMatcher(nil), ts.matchers...)
+}
+
+// Inserts the matcher into the chain just before the matcher named before,
+// or at the end of the chain, if before is empty. Returns an error if there
+// is no matcher named before.
+func (ts *TokenScanner) InsertMatcher(before string, m TokenMatcher) error {
+	i := len(ts.matchers)
+	if before != "" {
+		if i = ts.matcher_index(before); i < 0 {
+			return fmt.Errorf("no matcher named %q", before)
+		}
+	}
+
+	matchers := make([]TokenMatcher, 0, len(ts.matchers)+1)
+	matchers = append(matchers, ts.matchers[:i]...)
+	matchers = append(matchers, m)
+	ts.matchers = append(matchers, ts.matchers[i:]...)
+
+	return nil
+}
+
+// Removes the first matcher with the name from the chain. Returns false if
+// there is none.
+func (ts *TokenScanner) RemoveMatcher(name string) bool {
+	i := ts.matcher_index(name)
+	if i < 0 {
+		return false
+	}
+
+	matchers := make([]TokenMatcher, 0, len(ts.matchers)-1)
+	matchers = append(matchers, ts.matchers[:i]...)
+	ts.matchers = append(matchers, ts.matchers[i+1:]...)
+
+	return true
+}
+
+// Returns the index of the first matcher with the name in the chain, or -1.
+func (ts *TokenScanner) matcher_index(name string) int {
+	for i, m := range ts.matchers {
+		if m.Name() == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Reads a token with the matcher, or with the recognizer for unknown runes if
+// it is nil. Returns the class of the token, for the processing following
+// every token, and the name of the recognizer.
+func (ts *TokenScanner) read_match(
+	m TokenMatcher,
+) (
+	class rune_class,
+	name string,
+	token *Token,
+	err error,
+) {
+	if m == nil {
+		token, err = ts.get_unknown()
+		return class_unknown, class_unknown.String(), token, err
+	}
+
+	if c, ok := m.(class_matcher); ok {
+		class = rune_class(c)
+		token, err = ts.read_class(class)
+		return class, class.String(), token, err
+	}
+
+	token, err = m.Read(ts)
+	if token == nil {
+		return class_custom, m.Name(), token, err
+	}
+
+	// Whitespace and comments from a custom matcher are treated like those
+	// of the built-in recognizers, e.g., skipped by default.
+	switch token.Type {
+	case TokenTypeWhitespace:
+		class = class_whitespace
+	case TokenTypeComment:
+		class = class_comment
+	default:
+		class = class_custom
+	}
+
+	return class, m.Name(), token, err
+}
+
+// Returns up to the next n runes of the input without consuming them. Fewer
+// are returned at the end of the input. Meant for the Match() method of a
+// TokenMatcher.
+func (ts *TokenScanner) PeekRunes(n int) []rune {
+	runes, _ := ts.reader.peek(n)
+	return runes
+}
+
+// Consumes the next n runes of the input as a token of the given type, and
+// returns the token. Meant for the Read() method of a TokenMatcher. Returns
+// an error if there are fewer than n runes left.
+func (ts *TokenScanner) ReadToken(n int, token_type TokenType) (*Token, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid token length %d", n)
+	}
+
+	runes, total_size, err := ts.get_n_runes(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      token_type,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
@@ -0,0 +1,203 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"unicode"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// Matches ticket IDs, e.g., "ABC-123".
+type ticket_matcher struct{}
+
+func (ticket_matcher) Name() string {
+	return "ticket"
+}
+
+func (ticket_matcher) Match(ts *textparser.TokenScanner, ch rune) bool {
+	return ticket_len(ts) > 0
+}
+
+func (ticket_matcher) Read(
+	ts *textparser.TokenScanner,
+) (*textparser.Token, error) {
+	return ts.ReadToken(ticket_len(ts), textparser.TokenTypeKeyword)
+}
+
+func ticket_len(ts *textparser.TokenScanner) int {
+	runes := ts.PeekRunes(16)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i == 0 || i == len(runes) || runes[i] != '-' {
+		return 0
+	}
+	i++
+	start := i
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	if i == start {
+		return 0
+	}
+
+	return i
+}
+
+// Reads runs of dots as whitespace.
+type dots_matcher struct{}
+
+func (dots_matcher) Name() string {
+	return "dots"
+}
+
+func (dots_matcher) Match(ts *textparser.TokenScanner, ch rune) bool {
+	return ch == '.'
+}
+
+func (dots_matcher) Read(
+	ts *textparser.TokenScanner,
+) (*textparser.Token, error) {
+	n := 0
+	for _, ch := range ts.PeekRunes(64) {
+		if ch != '.' {
+			break
+		}
+		n++
+	}
+	return ts.ReadToken(n, textparser.TokenTypeWhitespace)
+}
+
+func TestMatchers(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner) error
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "default chain",
+			Input:    "ABC-12 x",
+			Expected: []string{"Ident ABC", "Int -12", "Ident x"},
+		},
+		{
+			Name:  "inserted before ident",
+			Input: "see ABC-12, AB-x",
+			Setup: func(p *textparser.TokenScanner) error {
+				return p.InsertMatcher("ident", ticket_matcher{})
+			},
+			Expected: []string{"Ident see", "Keyword ABC-12", "Symbol ,",
+				"Ident AB", "Symbol -", "Ident x"},
+		},
+		{
+			Name:  "inserted after ident",
+			Input: "ABC-12",
+			Setup: func(p *textparser.TokenScanner) error {
+				return p.InsertMatcher("", ticket_matcher{})
+			},
+			Expected: []string{"Ident ABC", "Int -12"},
+		},
+		{
+			Name:  "custom whitespace is skipped",
+			Input: "a...b",
+			Setup: func(p *textparser.TokenScanner) error {
+				return p.InsertMatcher("symbol", dots_matcher{})
+			},
+			Expected: []string{"Ident a", "Ident b"},
+		},
+		{
+			Name:  "removed",
+			Input: "a # b",
+			Setup: func(p *textparser.TokenScanner) error {
+				p.RemoveMatcher("comment")
+				return nil
+			},
+			Expected: []string{"Ident a", "Symbol #", "Ident b"},
+		},
+		{
+			Name:  "reordered",
+			Input: "x1 1x",
+			Setup: func(p *textparser.TokenScanner) error {
+				p.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+					return textparser.IsIdentRune(ch, 1, runes)
+				}
+				for _, m := range p.Matchers() {
+					if m.Name() == "number" {
+						p.RemoveMatcher("number")
+						return p.InsertMatcher("ident", m)
+					}
+				}
+				return nil
+			},
+			Expected: []string{"Ident x1", "Int 1", "Ident x"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			if test_data.Setup != nil {
+				if err := test_data.Setup(p); err != nil {
+					st.Fatalf("unexpected error: %s", err)
+				}
+			}
+
+			var got []string
+			for p.Scan() {
+				got = append(got,
+					p.Token().Type.String()+" "+p.TokenText())
+			}
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Errorf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestMatcherChain(t *testing.T) {
+	p := textparser.NewScannerString("")
+
+	var names []string
+	for _, m := range textparser.DefaultMatchers() {
+		names = append(names, m.Name())
+	}
+	expected := []string{"directive", "continuation", "whitespace",
+		"comment", "section", "quoted", "parameter", "variable", "url",
+		"address", "datetime", "ident", "number", "symbol"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got %#v, expected %#v", names, expected)
+	}
+
+	if err := p.InsertMatcher("nope", ticket_matcher{}); err == nil {
+		t.Errorf("expected an error inserting before an unknown matcher")
+	}
+	if p.RemoveMatcher("ticket") {
+		t.Errorf("removed a matcher not in the chain")
+	}
+
+	if err := p.InsertMatcher("quoted", ticket_matcher{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	p.RemoveMatcher("symbol")
+
+	got := p.ConfigSummary().Recognizers
+	expected = []string{"whitespace (skipped)", "comment (skipped)",
+		"ticket", "quoted", "ident", "number", "unknown (Stop)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if len(textparser.NewScannerString("").Matchers()) != len(names) {
+		t.Errorf("changing one scanner's chain changed the default")
+	}
+}
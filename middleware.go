@@ -0,0 +1,158 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode/utf8"
+)
+
+// A function applied to each token scanned, before Scan() returns it. It
+// returns the token to return instead, e.g., the same token with its text
+// changed, and true, or false to drop the token.
+type Middleware func(token *Token) (*Token, bool)
+
+// A function applied to each token scanned, before Scan() returns it,
+// returning the tokens to return instead, in order. It may return the token
+// itself, a different one, several tokens splitting it, or none, to drop it.
+type SplitMiddleware func(token *Token) []*Token
+
+// The general form of middleware.
+type split_func func(token *Token) []*Token
+
+// Adds middleware applied, in the order added, to each token scanned and not
+// skipped, including those synthesized by indentation tracking (see
+// SetIndentation()), before emit transforms (see SetTransform()). Middleware
+// may change the token in place, but the token may be reused if ReuseToken
+// is set, so a token kept by middleware for later must be copied.
+func (ts *TokenScanner) Use(middleware ...Middleware) {
+	for _, mw := range middleware {
+		mw := mw
+		ts.middleware = append(ts.middleware, func(token *Token) []*Token {
+			if token, ok := mw(token); ok && token != nil {
+				return []*Token{token}
+			}
+			return nil
+		})
+	}
+}
+
+// Adds middleware that may split a token into several, applied in order with
+// middleware added by Use(). Each of the tokens replacing a token is given
+// the position following the raw text of those before it (the Raw field, if
+// set, or else Text), and the last one ends where the original token ends.
+func (ts *TokenScanner) UseSplit(middleware ...SplitMiddleware) {
+	for _, mw := range middleware {
+		ts.middleware = append(ts.middleware, split_func(mw))
+	}
+}
+
+// Runs the tokens through the middleware, dropping tokens of skipped types
+// (see SkipTypes()), and adds the result to the queue of tokens to return.
+func (ts *TokenScanner) enqueue(tokens ...*ScannedToken) {
+	for _, st := range tokens {
+		if ts.skip_type(st.Token.Type) {
+			continue
+		}
+
+		pieces := []*ScannedToken{st}
+		for _, mw := range ts.middleware {
+			pieces = ts.apply_middleware(mw, pieces)
+		}
+		ts.queue = append(ts.queue, pieces...)
+	}
+}
+
+// Returns the tokens resulting from applying the middleware to each token.
+func (ts *TokenScanner) apply_middleware(
+	mw split_func,
+	tokens []*ScannedToken,
+) []*ScannedToken {
+	var out []*ScannedToken
+
+	for _, st := range tokens {
+		results := mw(st.Token)
+		if len(results) == 1 {
+			out = append(out, &ScannedToken{Token: results[0], Span: st.Span})
+			continue
+		}
+
+		pos := st.Span.Start
+		for i, token := range results {
+			start := pos
+			if i == len(results)-1 {
+				pos = st.Span.End
+			} else {
+				raw := token.Raw
+				if raw == "" {
+					raw = token.Text
+				}
+				pos = ts.advance_over(pos, raw)
+			}
+			out = append(out, &ScannedToken{
+				Token: token,
+				Span:  Span{Start: start, End: pos},
+			})
+		}
+	}
+
+	return out
+}
+
+// Returns the position following the text, starting at pos.
+func (ts *TokenScanner) advance_over(pos Position, text string) Position {
+	var g grapheme_state
+	for len(text) > 0 {
+		ch, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		pos.Offset += size
+
+		if ch == ts.eol {
+			pos.Line++
+			pos.Column = 1
+			g = grapheme_state{}
+			continue
+		}
+		pos.Column += column_width(ts.ColumnMode, ch, size, &g)
+	}
+
+	return pos
+}
+
+// Returns the next queued token from Scan().
+func (ts *TokenScanner) emit_queued() bool {
+	st := ts.queue[0]
+	ts.queue = ts.queue[1:]
+
+	ts.old_token = ts.LastToken
+	ts.LastToken = st.Token
+	*ts.old_pos = *ts.pos
+	*ts.pos = st.Start
+	ts.old_end_pos = ts.end_pos
+	ts.end_pos = st.End
+
+	return ts.emit(st.Token)
+}
@@ -0,0 +1,150 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestMiddleware(t *testing.T) {
+	lower := func(token *textparser.Token) (*textparser.Token, bool) {
+		if token.Type == textparser.TokenTypeIdent {
+			token.Text = strings.ToLower(token.Text)
+		}
+		return token, true
+	}
+	unquote := func(token *textparser.Token) (*textparser.Token, bool) {
+		if token.Type == textparser.TokenTypeString {
+			token.Text = token.Text[1 : len(token.Text)-1]
+		}
+		return token, true
+	}
+	drop_symbols := func(token *textparser.Token) (*textparser.Token, bool) {
+		return token, token.Type != textparser.TokenTypeSymbol
+	}
+
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "transform",
+			Input: `SELECT "Name" FROM T`,
+			Setup: func(p *textparser.TokenScanner) {
+				p.Use(lower, unquote)
+			},
+			Expected: []string{"Ident select", "String Name", "Ident from",
+				"Ident t"},
+		},
+		{
+			Name:  "drop",
+			Input: "f(a, b);",
+			Setup: func(p *textparser.TokenScanner) {
+				p.Use(drop_symbols)
+			},
+			Expected: []string{"Ident f", "Ident a", "Ident b"},
+		},
+		{
+			Name:  "drop all",
+			Input: "; ;",
+			Setup: func(p *textparser.TokenScanner) {
+				p.Use(drop_symbols)
+			},
+		},
+		{
+			Name:  "split then transform",
+			Input: "Foo_Bar baz",
+			Setup: func(p *textparser.TokenScanner) {
+				p.UseSplit(split_words)
+				p.Use(lower)
+			},
+			Expected: []string{"Ident foo", "Symbol _", "Ident bar",
+				"Ident baz"},
+		},
+		{
+			Name:  "indentation",
+			Input: "a\n\tb\n",
+			Setup: func(p *textparser.TokenScanner) {
+				p.SetIndentation(textparser.IndentTabs)
+				p.Use(func(token *textparser.Token) (*textparser.Token,
+					bool) {
+					return token, token.Type != textparser.TokenTypeNewline
+				})
+			},
+			Expected: []string{"Ident a", "Indent \t", "Ident b",
+				"Dedent "},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			test_data.Setup(p)
+
+			var got []string
+			for p.Scan() {
+				got = append(got,
+					p.Token().Type.String()+" "+p.TokenText())
+			}
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Errorf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+// Splits identifiers at underscores.
+func split_words(token *textparser.Token) []*textparser.Token {
+	if token.Type != textparser.TokenTypeIdent {
+		return []*textparser.Token{token}
+	}
+
+	var tokens []*textparser.Token
+	for i, word := range strings.Split(token.Text, "_") {
+		if i > 0 {
+			tokens = append(tokens, &textparser.Token{Text: "_",
+				NumBytes: 1, NumChars: 1, FirstRune: '_',
+				Type: textparser.TokenTypeSymbol})
+		}
+		tokens = append(tokens, &textparser.Token{Text: word,
+			NumBytes: len(word), NumChars: len(word),
+			FirstRune: rune(word[0]), Type: textparser.TokenTypeIdent})
+	}
+
+	return tokens
+}
+
+// Test that tokens split by middleware get the positions of their text.
+func TestMiddlewareSplitSpans(t *testing.T) {
+	p := textparser.NewScannerString("x\n  ab_cd_e")
+	p.UseSplit(split_words)
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, st := range tokens {
+		got = append(got, st.Token.Text+" "+st.Span.String())
+	}
+
+	expected := []string{"x :1:1-1:2 (0-1)", "ab :2:3-2:5 (4-6)",
+		"_ :2:5-2:6 (6-7)", "cd :2:6-2:8 (7-9)", "_ :2:8-2:9 (9-10)",
+		"e :2:9-2:10 (10-11)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
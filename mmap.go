@@ -0,0 +1,83 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"os"
+)
+
+// Returns a new TokenScanner over the contents of the named file, mapped
+// into memory where supported instead of being read through a buffer, with
+// the file name set (see SetFilename()). In byte mode (see ByteTokens), the
+// text of tokens refers to the mapped file itself rather than to a copy, so
+// it stays valid after later calls to Scan(), until Close() is called.
+// Offsets are byte offsets in the file, as for other readers.
+func NewScannerFile(path string) (*TokenScanner, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("%s: file too large to map (%d bytes)", path,
+			size)
+	}
+
+	var data []byte
+	if size > 0 {
+		if data, err = map_file(f, int(size)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	ts := NewScanner(&slice_reader{data: data})
+	ts.mapped = data
+	ts.SetFilename(path)
+
+	return ts, nil
+}
+
+// Releases the file mapped by NewScannerFile(), after which neither the
+// scanner nor the text of tokens referring to the file may be used. Does
+// nothing for other scanners.
+func (ts *TokenScanner) Close() error {
+	data := ts.mapped
+	if data == nil {
+		return nil
+	}
+	ts.mapped = nil
+
+	return unmap_file(data)
+}
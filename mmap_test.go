@@ -0,0 +1,90 @@
+package textparser_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestNewScannerFile(t *testing.T) {
+	type TestData struct {
+		Name  string
+		Input string
+	}
+
+	test_list := []TestData{
+		{Name: "empty", Input: ""},
+		{Name: "ascii", Input: "foo = 'bar\nbaz' + 42 // note\n"},
+		{Name: "unicode", Input: "été <= \"ünï\" [1, 2.5]\n"},
+		{Name: "invalid utf-8", Input: "ab\xffcd = 'x\xfe'\n"},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			path := filepath.Join(st.TempDir(), "input.txt")
+			err := os.WriteFile(path, []byte(test_data.Input), 0o644)
+			if err != nil {
+				st.Fatalf("couldn't write input: %s", err)
+			}
+
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetFilename(path)
+			expected, err := textparser.TokenizeAll(p)
+			if err != nil {
+				st.Fatalf("error from scanner: %s", err)
+			}
+
+			p, err = textparser.NewScannerFile(path)
+			if err != nil {
+				st.Fatalf("couldn't open file: %s", err)
+			}
+			defer p.Close()
+
+			got, err := textparser.TokenizeAll(p)
+			if err != nil {
+				st.Fatalf("error from scanner: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				st.Errorf("got %#v, expected %#v", got, expected)
+			}
+		})
+	}
+}
+
+func TestNewScannerFileByteTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	input := "one 'two' three\xff four"
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatalf("couldn't write input: %s", err)
+	}
+
+	p, err := textparser.NewScannerFile(path)
+	if err != nil {
+		t.Fatalf("couldn't open file: %s", err)
+	}
+	defer p.Close()
+	p.ByteTokens = true
+	p.ReuseToken = true
+
+	// Text referring to the mapped file stays valid across calls to Scan().
+	var got []string
+	for p.Scan() {
+		got = append(got, p.TokenText())
+	}
+
+	expected := []string{"one", "'two'", "three", "�", "four"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestNewScannerFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := textparser.NewScannerFile(path); !os.IsNotExist(err) {
+		t.Errorf("got error %v, expected a missing file", err)
+	}
+}
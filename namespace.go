@@ -0,0 +1,96 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"sort"
+)
+
+// Sets the separators, e.g., "::" or "->", joining identifiers into a single
+// qualified name, replacing any set before, so that, e.g., "std::vector" is
+// one TokenTypeIdent token, with the identifiers joined, "std" and "vector",
+// as its Segments, rather than two identifiers and a symbol. A separator is
+// only taken when an identifier directly precedes it and a rune starting an
+// identifier directly follows it, so that "a::" is still "a" followed by a
+// symbol. The longest separator matching wins. Call with no separators to
+// switch this off.
+func (ts *TokenScanner) SetNamespaceSeparators(separators ...string) {
+	ts.name_separators = ts.name_separators[:0]
+	for _, sep := range separators {
+		if sep != "" {
+			ts.name_separators = append(ts.name_separators,
+				[]rune(sep))
+		}
+	}
+
+	sort.SliceStable(ts.name_separators, func(i, j int) bool {
+		return len(ts.name_separators[i]) >
+			len(ts.name_separators[j])
+	})
+}
+
+// Returns the separators set with SetNamespaceSeparators(), longest first.
+func (ts *TokenScanner) NamespaceSeparators() []string {
+	list := make([]string, 0, len(ts.name_separators))
+	for _, sep := range ts.name_separators {
+		list = append(list, string(sep))
+	}
+
+	return list
+}
+
+// Returns the number of runes in the namespace separator starting at the
+// next rune, if an identifier rune follows it, or 0 if there is none.
+func (ts *TokenScanner) namespace_separator_len() int {
+	if len(ts.name_separators) == 0 {
+		return 0
+	}
+
+	p := ts.peek_upto(len(ts.name_separators[0]) + 1)
+	for _, sep := range ts.name_separators {
+		n := len(sep)
+		if len(p) > n && has_rune_prefix(p, sep) &&
+			ts.IsIdentRune(p[n], 0, nil) {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// Returns the identifiers of a qualified name, given the rune ranges of the
+// separators between them.
+func namespace_segments(runes []rune, separators [][2]int) []string {
+	segments := make([]string, 0, len(separators)+1)
+	start := 0
+	for _, sep := range separators {
+		segments = append(segments, string(runes[start:sep[0]]))
+		start = sep[1]
+	}
+
+	return append(segments, string(runes[start:]))
+}
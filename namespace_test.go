@@ -0,0 +1,62 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestNamespaceSeparators(t *testing.T) {
+	type TestData struct {
+		Name       string
+		Input      string
+		Separators []string
+		Expected   []string // Type, text, and segments of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:       "c++",
+			Input:      "std::vector<int> a:b ::c d:: e::1",
+			Separators: []string{"::"},
+			Expected: []string{"Ident std::vector std|vector", "Symbol <",
+				"Ident int", "Symbol >", "Ident a", "Symbol :", "Ident b",
+				"Symbol :", "Symbol :", "Ident c", "Ident d", "Symbol :",
+				"Symbol :", "Ident e", "Symbol :", "Symbol :", "Int 1"},
+		},
+		{
+			Name:       "longest wins",
+			Input:      "Foo::Bar->baz->qux Foo:Bar",
+			Separators: []string{":", "->", "::"},
+			Expected: []string{"Ident Foo::Bar->baz->qux Foo|Bar|baz|qux",
+				"Ident Foo:Bar Foo|Bar"},
+		},
+		{
+			Name:     "none",
+			Input:    "a::b",
+			Expected: []string{"Ident a", "Symbol :", "Symbol :", "Ident b"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetNamespaceSeparators(test_data.Separators...)
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				s := token.Type.String() + " " + token.Text
+				if token.Segments != nil {
+					s += " " + strings.Join(token.Segments, "|")
+				}
+				got = append(got, s)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import "golang.org/x/text/unicode/norm"
+
+// A Unicode normalization form.
+type Normalization int
+
+// Supported normalization forms. See https://unicode.org/reports/tr15/.
+const (
+	NoNormalization Normalization = iota
+	NFC                           // Canonical composition.
+	NFD                           // Canonical decomposition.
+	NFKC                          // Compatibility composition.
+	NFKD                          // Compatibility decomposition.
+)
+
+// Returns a string representation of the normalization form.
+func (n Normalization) String() string {
+	names := [...]string{"None", "NFC", "NFD", "NFKC", "NFKD"}
+	if n < 0 || int(n) > len(names)-1 {
+		return ""
+	}
+
+	return names[n]
+}
+
+func (n Normalization) form() (norm.Form, bool) {
+	switch n {
+	case NFC:
+		return norm.NFC, true
+	case NFD:
+		return norm.NFD, true
+	case NFKC:
+		return norm.NFKC, true
+	case NFKD:
+		return norm.NFKD, true
+	}
+
+	return 0, false
+}
+
+// Sets up the input according to the scanner options on the first call to
+// Scan(), since options may be set after Init().
+func (ts *TokenScanner) prepare_input() {
+	if ts.input_ready {
+		return
+	}
+	ts.input_ready = true
+
+	if form, ok := ts.Normalize.form(); ok {
+		ts.reader = new_rune_buffer(form.Reader(ts.source))
+
+		// Normalization changes the runes, so sizes in the original
+		// encoding no longer line up.
+		ts.source_sizes = nil
+	}
+}
@@ -0,0 +1,41 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	decomposed := "café"
+	composed := "café"
+	input := decomposed + " = " + composed
+
+	tests := []struct {
+		Name      string
+		Normalize textparser.Normalization
+		Expected  []string
+	}{
+		{"none", textparser.NoNormalization,
+			[]string{decomposed, "=", composed}},
+		{"NFC", textparser.NFC, []string{composed, "=", composed}},
+		{"NFD", textparser.NFD, []string{decomposed, "=", decomposed}},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(input)
+			p.Normalize = test_data.Normalize
+
+			token_list := make([]string, 0, len(test_data.Expected))
+			for p.Scan() {
+				token_list = append(token_list, p.TokenText())
+			}
+
+			if !reflect.DeepEqual(token_list, test_data.Expected) {
+				st.Errorf("got %+q, expected %+q", token_list,
+					test_data.Expected)
+			}
+		})
+	}
+}
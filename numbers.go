@@ -0,0 +1,540 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Extensions to the number syntax, which by default is an optional minus
+// sign, digits, and an optional fraction, e.g., "-12.5". Combine with |.
+type NumberSyntax uint
+
+const (
+	// Exponents, e.g., "1e10" or "2.5E-3", making the token a float.
+	NumberExponent NumberSyntax = 1 << iota
+
+	// Base prefixes "0x", "0o", and "0b" (in either case), e.g., "0xFF",
+	// along with hex floats with a binary exponent, e.g., "0x1.8p3", which
+	// are floats.
+	NumberPrefixes
+
+	// Underscores between digits, e.g., "1_000_000".
+	NumberUnderscores
+)
+
+// Number syntax suited to Go and similar languages.
+const NumberSyntaxGo = NumberExponent | NumberPrefixes | NumberUnderscores
+
+// Returns a string representation of the syntax, e.g.,
+// "Exponent|Underscores".
+func (n NumberSyntax) String() string {
+	if n == 0 {
+		return "Default"
+	}
+
+	s := ""
+	for i, name := range []string{"Exponent", "Prefixes", "Underscores"} {
+		if n&(1<<i) != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += name
+		}
+	}
+
+	return s
+}
+
+// Returns up to n upcoming runes, without consuming any input. Fewer are
+// returned near the end of the input.
+func (ts *TokenScanner) peek_upto(n int) []rune {
+	runes, _ := ts.reader.peek(n)
+
+	return runes
+}
+
+// Returns the base for a prefix rune following "0", or 0 if the rune is not
+// a base prefix.
+func number_base(ch rune) int {
+	switch ch {
+	case 'x', 'X':
+		return 16
+	case 'o', 'O':
+		return 8
+	case 'b', 'B':
+		return 2
+	}
+
+	return 0
+}
+
+// Returns true if the rune is a digit in the base.
+func is_base_digit(ch rune, base int) bool {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch-'0') < base
+	case ch >= 'a' && ch <= 'f':
+		return base == 16
+	case ch >= 'A' && ch <= 'F':
+		return base == 16
+	}
+
+	return false
+}
+
+// Recognizes numbers with a grammar of the caller's own in place of the
+// built-in one, e.g., "#x1F" for hex, without the built-in handling of signs
+// and decimal points. See SetNumberMatcher().
+type NumberMatcher struct {
+	// Returns true if a number starts with ch, the next rune in the input,
+	// which has not been consumed yet. Use PeekRunes() to look further
+	// ahead. Must not consume any input.
+	IsNumberStart func(ts *TokenScanner, ch rune) bool
+
+	// Reads the number that IsNumberStart reported, e.g., with ReadToken().
+	ScanNumber func(ts *TokenScanner) (*Token, error)
+}
+
+// Returns "number", the name of the built-in matcher it replaces.
+func (m NumberMatcher) Name() string {
+	return class_number.String()
+}
+
+// Returns true if IsNumberStart does, unless DisableNumbers is set.
+func (m NumberMatcher) Match(ts *TokenScanner, ch rune) bool {
+	return !ts.DisableNumbers && m.IsNumberStart(ts, ch)
+}
+
+// Reads the number with ScanNumber.
+func (m NumberMatcher) Read(ts *TokenScanner) (*Token, error) {
+	return m.ScanNumber(ts)
+}
+
+// Replaces the "number" matcher in the chain with m, moving it just before
+// "ident", so that numbers such as "inf" are not taken for identifiers. None
+// of the number settings (e.g., Numbers, Signs, and NumberLocale) apply to
+// the numbers it reads. A NumberMatcher with a nil function restores the
+// built-in matcher, just before "symbol".
+func (ts *TokenScanner) SetNumberMatcher(m NumberMatcher) {
+	ts.RemoveMatcher(class_number.String())
+
+	var (
+		matcher TokenMatcher = class_matcher(class_number)
+		before               = class_symbol.String()
+	)
+	if m.IsNumberStart != nil && m.ScanNumber != nil {
+		matcher = m
+		before = class_ident.String()
+	}
+	if ts.matcher_index(before) < 0 {
+		before = ""
+	}
+
+	// Cannot fail, as before is in the chain or empty.
+	_ = ts.InsertMatcher(before, matcher)
+}
+
+// Reads a number with a base prefix, if NumberPrefixes is set and one
+// starts at the next rune. Returns false if there is none, without
+// consuming any input.
+func (ts *TokenScanner) get_prefixed_number(n *number_state) (bool, error) {
+	if ts.Numbers&NumberPrefixes == 0 {
+		return false, nil
+	}
+
+	p := ts.peek_upto(5)
+	k := 0
+	if len(p) > 0 && ts.is_number_sign(p[0]) {
+		k = 1
+	}
+
+	if len(p) < k+3 || p[k] != '0' {
+		return false, nil
+	}
+
+	base := number_base(p[k+1])
+	if base == 0 {
+		return false, nil
+	}
+
+	// The first digit may follow an underscore, e.g., "0x_FF".
+	first := p[k+2]
+	if first == '_' && ts.Numbers&NumberUnderscores != 0 && len(p) > k+3 {
+		first = p[k+3]
+	}
+
+	if !is_base_digit(first, base) {
+		// A hex float may have no integer digits, e.g., "0x.8p1".
+		if base != 16 || first != '.' || len(p) < k+4 ||
+			!is_base_digit(p[k+3], 16) || ts.hex_float_end(k+2) < 0 {
+			return false, nil
+		}
+
+		if err := n.take(k + 2); err != nil {
+			return true, err
+		}
+
+		return true, ts.get_hex_float(n)
+	}
+
+	if err := n.take(k + 2); err != nil {
+		return true, err
+	}
+
+	err := n.take_digits(true, func(ch rune) bool {
+		return is_base_digit(ch, base)
+	})
+	if err != nil || base != 16 {
+		return true, err
+	}
+
+	return true, ts.get_hex_float(n)
+}
+
+// Reads the rest of a hex float, e.g., the ".8p3" of "0x1.8p3", if one
+// starts at the next rune, marking the number as a float. The fraction is
+// optional but the binary exponent is not, so that "0x1.8" is still an int
+// followed by ".8".
+func (ts *TokenScanner) get_hex_float(n *number_state) error {
+	end := ts.hex_float_end(0)
+	if end < 0 {
+		return nil
+	}
+
+	n.is_float = true
+
+	return n.take(end)
+}
+
+// Returns the offset just past the rest of a hex float starting at offset i
+// from the next rune, or -1 if there is none.
+func (ts *TokenScanner) hex_float_end(i int) int {
+	underscores := ts.Numbers&NumberUnderscores != 0
+	peek := func(i int) rune {
+		sr, err := ts.reader.peek_at(i)
+		if err != nil {
+			return -1
+		}
+		return sr.ch
+	}
+
+	// Counts the digits, and underscores between them, starting at i.
+	skip_digits := func(i int, is_digit func(rune) bool) int {
+		for k := i; ; k++ {
+			ch := peek(k)
+			switch {
+			case is_digit(ch):
+			case ch == '_' && underscores && k > i && is_digit(peek(k+1)):
+			default:
+				return k
+			}
+		}
+	}
+
+	if peek(i) == '.' {
+		i = skip_digits(i+1, func(ch rune) bool {
+			return is_base_digit(ch, 16)
+		})
+	}
+
+	if ch := peek(i); ch != 'p' && ch != 'P' {
+		return -1
+	}
+	i++
+
+	if ch := peek(i); ch == '+' || ch == '-' {
+		i++
+	}
+
+	end := skip_digits(i, func(ch rune) bool {
+		return is_base_digit(ch, 10)
+	})
+	if end == i {
+		return -1
+	}
+
+	return end
+}
+
+// Reads an exponent, if NumberExponent is set and one starts at the next
+// rune. Returns true if one was read.
+func (ts *TokenScanner) get_exponent(n *number_state) (bool, error) {
+	if ts.Numbers&NumberExponent == 0 {
+		return false, nil
+	}
+
+	p := ts.peek_upto(3)
+	if len(p) < 2 || (p[0] != 'e' && p[0] != 'E') {
+		return false, nil
+	}
+
+	k := 1
+	if p[1] == '+' || p[1] == '-' {
+		k = 2
+	}
+
+	if len(p) <= k || !ts.IsDigitRune(p[k], 0, nil) {
+		return false, nil
+	}
+
+	if err := n.take(k); err != nil {
+		return true, err
+	}
+
+	return true, n.take_digits(false, func(ch rune) bool {
+		return ts.IsDigitRune(ch, 0, nil)
+	})
+}
+
+// Runes accepted so far for a number token.
+type number_state struct {
+	ts         *TokenScanner
+	runes      []rune
+	total_size int
+	is_float   bool
+}
+
+// Accepts the next n runes.
+func (n *number_state) take(count int) error {
+	for i := 0; i < count; i++ {
+		ch, size, err := n.ts.get_one_rune()
+		if err != nil {
+			return err
+		}
+
+		n.total_size += size
+		n.ts.advance_position(ch, size)
+		n.runes = append(n.runes, ch)
+	}
+
+	return nil
+}
+
+// Accepts a run of digits, with underscores between them if
+// NumberUnderscores is set. A leading underscore is accepted if after_prefix
+// is set.
+func (n *number_state) take_digits(after_prefix bool,
+	is_digit func(rune) bool) error {
+	for i := 0; ; i++ {
+		p := n.ts.peek_upto(2)
+		switch {
+		case len(p) > 0 && is_digit(p[0]):
+		case len(p) > 1 && p[0] == '_' && is_digit(p[1]) &&
+			n.ts.Numbers&NumberUnderscores != 0 && (i > 0 || after_prefix):
+		default:
+			return nil
+		}
+
+		if err := n.take(1); err != nil {
+			return err
+		}
+	}
+}
+
+// Sets the suffixes, e.g., "u8", "f", or "L", taken as part of a number token
+// they directly follow, replacing any set before, so that, e.g., "10u8" is a
+// single TokenTypeInt token with Suffix "u8" rather than "10" followed by an
+// identifier. Suffixes are case-sensitive, and the longest one matching
+// wins, but not if an identifier rune directly follows it, so that "10units"
+// is still "10" followed by "units". Call with no suffixes to switch this
+// off.
+func (ts *TokenScanner) SetNumberSuffixes(suffixes ...string) {
+	ts.number_suffixes = ts.number_suffixes[:0]
+	for _, suffix := range suffixes {
+		if suffix != "" {
+			ts.number_suffixes = append(ts.number_suffixes, []rune(suffix))
+		}
+	}
+
+	sort.SliceStable(ts.number_suffixes, func(i, j int) bool {
+		return len(ts.number_suffixes[i]) > len(ts.number_suffixes[j])
+	})
+}
+
+// Returns the suffixes set with SetNumberSuffixes(), longest first.
+func (ts *TokenScanner) NumberSuffixes() []string {
+	list := make([]string, 0, len(ts.number_suffixes))
+	for _, suffix := range ts.number_suffixes {
+		list = append(list, string(suffix))
+	}
+
+	return list
+}
+
+// Reads a suffix set with SetNumberSuffixes(), if one starts at the next
+// rune, and returns it, or the empty string if there is none.
+func (ts *TokenScanner) get_number_suffix(n *number_state) (string, error) {
+	if len(ts.number_suffixes) == 0 {
+		return "", nil
+	}
+
+	p := ts.peek_upto(len(ts.number_suffixes[0]) + 1)
+	for _, suffix := range ts.number_suffixes {
+		if !has_rune_prefix(p, suffix) {
+			continue
+		}
+		if len(p) > len(suffix) && ts.IsIdentRune(p[len(suffix)],
+			len(suffix), suffix) {
+			continue
+		}
+
+		if err := n.take(len(suffix)); err != nil {
+			return "", err
+		}
+
+		return string(suffix), nil
+	}
+
+	return "", nil
+}
+
+// The digit grouping and decimal separators of numbers in a locale, e.g.,
+// "1.234,56" in much of Europe. The zero value means the default syntax,
+// with no grouping and a decimal point.
+type NumberLocale struct {
+	Group   rune // Thousands separator, e.g., ',', or 0 for none.
+	Decimal rune // Decimal separator, e.g., '.', or 0 for '.'.
+}
+
+// Common number locales.
+var (
+	NumberLocaleEnglish  = NumberLocale{Group: ',', Decimal: '.'}  // 1,234.56
+	NumberLocaleEuropean = NumberLocale{Group: '.', Decimal: ','}  // 1.234,56
+	NumberLocaleSwiss    = NumberLocale{Group: '\'', Decimal: '.'} // 1'234.56
+)
+
+// Returns a sample number in the locale, e.g., "1,234.56", or "Default"
+// for the zero value.
+func (l NumberLocale) String() string {
+	if l == (NumberLocale{}) {
+		return "Default"
+	}
+
+	s := "1234"
+	if l.Group != 0 {
+		s = fmt.Sprintf("1%c234", l.Group)
+	}
+
+	return fmt.Sprintf("%s%c56", s, l.decimal())
+}
+
+// Returns the decimal separator.
+func (l NumberLocale) decimal() rune {
+	if l.Decimal == 0 {
+		return '.'
+	}
+
+	return l.Decimal
+}
+
+// Reads a number in the syntax of NumberLocale: an optional sign, digits
+// grouped in threes by the group separator after a first group of one to
+// three, an optional fraction after the decimal separator, and an optional
+// exponent. The Value of the token is the number without group separators
+// and with a decimal point, e.g., "1234.56" for "1.234,56", so that it
+// parses with strconv.
+func (ts *TokenScanner) get_locale_number(n *number_state) (*Token, error) {
+	locale := ts.NumberLocale
+	is_digit := func(ch rune) bool {
+		return ts.IsDigitRune(ch, 0, nil)
+	}
+
+	p := ts.peek_upto(2)
+	if len(p) > 1 && ts.is_number_sign(p[0]) && is_digit(p[1]) {
+		if err := n.take(1); err != nil {
+			return nil, err
+		}
+	}
+
+	start := len(n.runes)
+	if err := n.take_digits(false, is_digit); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(n.runes) == start {
+		return nil, nil
+	}
+
+	// A group separator is followed by exactly three digits.
+	grouped := false
+	for locale.Group != 0 && (grouped || len(n.runes)-start <= 3) {
+		p = ts.peek_upto(5)
+		if len(p) < 4 || p[0] != locale.Group || !is_digit(p[1]) ||
+			!is_digit(p[2]) || !is_digit(p[3]) ||
+			(len(p) > 4 && is_digit(p[4])) {
+			break
+		}
+		if err := n.take(4); err != nil && err != io.EOF {
+			return nil, err
+		}
+		grouped = true
+	}
+
+	token_type := TokenTypeInt
+	p = ts.peek_upto(2)
+	if len(p) > 1 && p[0] == locale.decimal() && is_digit(p[1]) {
+		if err := n.take(1); err != nil {
+			return nil, err
+		}
+		err := n.take_digits(false, is_digit)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		token_type = TokenTypeFloat
+	}
+
+	if found, err := ts.get_exponent(n); found {
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		token_type = TokenTypeFloat
+	}
+
+	text := string(n.runes)
+	token, err := ts.suffixed_number_token(n, token_type)
+	if err != nil {
+		return nil, err
+	}
+
+	value := strings.Map(func(ch rune) rune {
+		switch ch {
+		case locale.Group:
+			return -1
+		case locale.decimal():
+			return '.'
+		}
+		return ch
+	}, text)
+	if value != text {
+		token.Value = value + token.Suffix
+	}
+
+	return token, nil
+}
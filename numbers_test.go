@@ -0,0 +1,315 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestNumberSyntax(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Syntax   textparser.NumberSyntax
+		Input    string
+		Expected []string // Type and text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "default",
+			Input: "1e5 0x1F 1_000",
+			Expected: []string{"Int 1", "Ident e5", "Int 0", "Ident x1F",
+				"Int 1", "Ident _000"},
+		},
+		{
+			Name:   "exponent",
+			Syntax: textparser.NumberExponent,
+			Input:  "1e5 2.5E-3 -4e+2 3e 7.",
+			Expected: []string{"Float 1e5", "Float 2.5E-3", "Float -4e+2",
+				"Int 3", "Ident e", "Int 7", "Symbol ."},
+		},
+		{
+			Name:   "prefixes",
+			Syntax: textparser.NumberPrefixes,
+			Input:  "0x1F 0o17 0B101 -0xa 0x 0b2",
+			Expected: []string{"Int 0x1F", "Int 0o17", "Int 0B101",
+				"Int -0xa", "Int 0", "Ident x", "Int 0", "Ident b2"},
+		},
+		{
+			Name:   "underscores",
+			Syntax: textparser.NumberUnderscores | textparser.NumberPrefixes,
+			Input:  "1_000 1__0 2_ 0x_F_F 3.1_4",
+			Expected: []string{"Int 1_000", "Int 1", "Ident __0", "Int 2",
+				"Ident _", "Int 0x_F_F", "Float 3.1_4"},
+		},
+		{
+			Name:   "hex floats",
+			Syntax: textparser.NumberUnderscores | textparser.NumberPrefixes,
+			Input:  "0x1.8p3 -0x1p-2 0X1.P+4 0xA_B.C_Dp1_0 0xFp 0o1p3 0x1.8",
+			Expected: []string{"Float 0x1.8p3", "Float -0x1p-2",
+				"Float 0X1.P+4", "Float 0xA_B.C_Dp1_0", "Int 0xF", "Ident p",
+				"Int 0o1", "Ident p3", "Int 0x1", "Symbol .", "Int 8"},
+		},
+		{
+			Name:   "hex floats without integer digits",
+			Syntax: textparser.NumberUnderscores | textparser.NumberPrefixes,
+			Input:  "0x.8p1 -0x.C_8P-2 0x.8 0x.p1",
+			Expected: []string{"Float 0x.8p1", "Float -0x.C_8P-2", "Int 0",
+				"Ident x", "Symbol .", "Int 8", "Int 0", "Ident x",
+				"Symbol .", "Ident p1"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.Numbers = test_data.Syntax
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestDisableNumbers(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string // Type and text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "part numbers",
+			Input: "AB-12.5 -7 x9 4a",
+			Expected: []string{"Ident AB", "Symbol -", "Ident 12",
+				"Symbol .", "Ident 5", "Symbol -", "Ident 7", "Ident x9",
+				"Ident 4a"},
+		},
+		{
+			Name:  "phone number",
+			Input: "+1 (555) 010-9999",
+			Expected: []string{"Symbol +", "Ident 1", "Symbol (",
+				"Ident 555", "Symbol )", "Ident 010", "Symbol -",
+				"Ident 9999"},
+		},
+		{
+			Name:  "number syntax ignored",
+			Input: "0x1F 1e5",
+			Setup: func(p *textparser.TokenScanner) {
+				p.Numbers = textparser.NumberSyntaxGo
+			},
+			Expected: []string{"Ident 0x1F", "Ident 1e5"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.DisableNumbers = true
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestNumberSuffixes(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Numbers  textparser.NumberSyntax
+		Expected []string // Type, text, and suffix of each token.
+	}
+
+	test_list := []TestData{
+		{"rust", "10u8 255u16 7i32", 0,
+			[]string{"Int 10u8 u8", "Int 255u16 u16", "Int 7i32 i32"}},
+		{"c", "3.5f 42L 7UL 1e10f", textparser.NumberExponent,
+			[]string{"Float 3.5f f", "Int 42L L", "Int 7UL UL",
+				"Float 1e10f f"}},
+		{"hex", "0xFFu8", textparser.NumberPrefixes,
+			[]string{"Int 0xFFu8 u8"}},
+		{"followed by identifier", "10units 3fx", 0,
+			[]string{"Int 10 ", "Ident units ", "Int 3 ", "Ident fx "}},
+		{"longest wins", "1u16", 0, []string{"Int 1u16 u16"}},
+		{"case-sensitive", "42l", 0, []string{"Int 42 ", "Ident l "}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.Numbers = test_data.Numbers
+			p.SetNumberSuffixes("u", "u8", "u16", "i32", "f", "L", "UL")
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text+" "+
+					token.Suffix)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+
+	p := textparser.NewScannerString("")
+	p.SetNumberSuffixes("f", "", "u16")
+	expected := []string{"u16", "f"}
+	if got := p.NumberSuffixes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got suffixes %#v, expected %#v", got, expected)
+	}
+}
+
+func TestNumberLocale(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Locale   textparser.NumberLocale
+		Input    string
+		Expected []string // Type, text, and value of each token.
+	}
+
+	test_list := []TestData{
+		{"european", textparser.NumberLocaleEuropean,
+			"1.234,56 -1.234.567 12,5 3.14 1.2345",
+			[]string{"Float 1.234,56 1234.56", "Int -1.234.567 -1234567",
+				"Float 12,5 12.5", "Int 3 3", "Symbol . .", "Int 14 14",
+				"Int 1 1", "Symbol . .", "Int 2345 2345"}},
+		{"english", textparser.NumberLocaleEnglish,
+			"1,234.56 f(1,2) 1234,567",
+			[]string{"Float 1,234.56 1234.56", "Ident f f", "Symbol ( (",
+				"Int 1 1", "Symbol , ,", "Int 2 2", "Symbol ) )",
+				"Int 1234 1234", "Symbol , ,", "Int 567 567"}},
+		{"swiss", textparser.NumberLocaleSwiss, "1'234'567.5",
+			[]string{"Float 1'234'567.5 1234567.5"}},
+		{"space", textparser.NumberLocale{Group: ' ', Decimal: ','},
+			"1 234,5", []string{"Float 1 234,5 1234.5"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.NumberLocale = test_data.Locale
+			p.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"', ch
+			}
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text+" "+
+					token.ValueText())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+
+	if got := textparser.NumberLocaleEuropean.String(); got != "1.234,56" {
+		t.Errorf("got %q, expected %q", got, "1.234,56")
+	}
+}
+
+// Reads digits, radix numbers such as "16r1F", and "inf".
+func radix_number_len(p *textparser.TokenScanner) int {
+	runes := p.PeekRunes(64)
+	if len(runes) >= 3 && string(runes[:3]) == "inf" &&
+		(len(runes) == 3 || !textparser.IsIdentRune(runes[3], 3, runes)) {
+		return 3
+	}
+
+	n := 0
+	for n < len(runes) && runes[n] >= '0' && runes[n] <= '9' {
+		n++
+	}
+	if n > 0 && n < len(runes)-1 && runes[n] == 'r' {
+		k := n + 1
+		for k < len(runes) && strings.ContainsRune(
+			"0123456789ABCDEF", runes[k]) {
+			k++
+		}
+		if k > n+1 {
+			n = k
+		}
+	}
+
+	return n
+}
+
+func TestNumberMatcher(t *testing.T) {
+	m := textparser.NumberMatcher{
+		IsNumberStart: func(p *textparser.TokenScanner, ch rune) bool {
+			return radix_number_len(p) > 0
+		},
+		ScanNumber: func(p *textparser.TokenScanner) (*textparser.Token,
+			error) {
+			n := radix_number_len(p)
+			if p.PeekRunes(1)[0] == 'i' {
+				return p.ReadToken(n, textparser.TokenTypeFloat)
+			}
+			return p.ReadToken(n, textparser.TokenTypeInt)
+		},
+	}
+
+	input := "-2 1.5 16r1F inf info"
+	p := textparser.NewScannerString(input)
+	default_names := []string{}
+	for _, matcher := range p.Matchers() {
+		default_names = append(default_names, matcher.Name())
+	}
+
+	p.Numbers = textparser.NumberSyntaxGo
+	p.SetNumberMatcher(m)
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected := []string{"Symbol -", "Int 2", "Int 1", "Symbol .", "Int 5",
+		"Int 16r1F", "Float inf", "Ident info"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	p.SetNumberMatcher(textparser.NumberMatcher{})
+	names := []string{}
+	for _, matcher := range p.Matchers() {
+		names = append(names, matcher.Name())
+	}
+	if !reflect.DeepEqual(names, default_names) {
+		t.Errorf("got %#v, expected %#v", names, default_names)
+	}
+
+	p.Init(strings.NewReader(input))
+	got = nil
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected = []string{"Int -2", "Float 1.5", "Int 16", "Ident r1F",
+		"Ident inf", "Ident info"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,93 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"sort"
+)
+
+// Sets multi-rune operators, e.g., "==", "&&", or "...", to be emitted as
+// single symbol tokens, replacing any set before. Operators are matched
+// longest first wherever a symbol token starts; elsewhere, symbols are read
+// with IsSymbolRune as usual.
+func (ts *TokenScanner) SetOperators(operators ...string) {
+	ts.operators = ts.operators[:0]
+	for _, op := range operators {
+		if op != "" {
+			ts.operators = append(ts.operators, []rune(op))
+		}
+	}
+
+	sort.SliceStable(ts.operators, func(i, j int) bool {
+		return len(ts.operators[i]) > len(ts.operators[j])
+	})
+}
+
+// Returns the operators set with SetOperators(), longest first.
+func (ts *TokenScanner) Operators() []string {
+	operators := make([]string, 0, len(ts.operators))
+	for _, op := range ts.operators {
+		operators = append(operators, string(op))
+	}
+
+	return operators
+}
+
+// Reads an operator set with SetOperators(), if one starts at the next rune.
+// Returns a nil token if there is none.
+func (ts *TokenScanner) get_operator() (*Token, error) {
+	for _, op := range ts.operators {
+		if ts.Signs != SignNumber && is_sign_run(op) {
+			continue
+		}
+
+		if !ts.peek_prefix(op) {
+			continue
+		}
+
+		runes, total_size, err := ts.get_n_runes(len(op))
+		if err != nil {
+			return nil, err
+		}
+
+		token := ts.new_token()
+		*token = Token{
+			Text:      ts.token_text(runes),
+			NumBytes:  total_size,
+			NumChars:  len(runes),
+			FirstRune: runes[0],
+			Type:      TokenTypeSymbol,
+		}
+
+		ts.last_byte_len = total_size
+		ts.set_token(token)
+
+		return token, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,29 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSetOperators(t *testing.T) {
+	p := textparser.NewScannerString("a <<= b <- c < d ... e")
+	p.SetOperators("<", "<<=", "<-", "...")
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"a", "<<=", "b", "<-", "c", "<", "d", "...", "e"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	ops := p.Operators()
+	expected_ops := []string{"<<=", "...", "<-", "<"}
+	if !reflect.DeepEqual(ops, expected_ops) {
+		t.Errorf("got %#v, expected %#v", ops, expected_ops)
+	}
+}
@@ -0,0 +1,58 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// A token cut at MaxTokenBytes, the rest of which is scanned by the next
+// call to Scan().
+type cut_token struct {
+	class  rune_class
+	resume func() (*Token, error)
+}
+
+// Returns true if a token of n bytes is too long to deliver in one piece.
+func (ts *TokenScanner) cut_at(n int) bool {
+	return ts.MaxTokenBytes > 0 && n > ts.MaxTokenBytes
+}
+
+// Arranges for the next call to Scan() to continue the current token, of
+// the class, with resume.
+func (ts *TokenScanner) cut_token(
+	class rune_class,
+	resume func() (*Token, error),
+) {
+	ts.cut = &cut_token{class: class, resume: resume}
+}
+
+// Scans the next piece of a token cut at MaxTokenBytes.
+func (ts *TokenScanner) resume_cut() (rune_class, *Token, error) {
+	cut := ts.cut
+	ts.cut = nil
+
+	token, err := cut.resume()
+
+	return cut.class, token, err
+}
@@ -0,0 +1,191 @@
+package textparser_test
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestMaxTokenBytes(t *testing.T) {
+	type Piece struct {
+		Text      string
+		Type      textparser.TokenType
+		Continued bool
+	}
+
+	type TestData struct {
+		Name     string
+		Input    string
+		Max      int
+		Expected []Piece
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "string",
+			Input: `x = "abcdefghij"`,
+			Max:   4,
+			Expected: []Piece{
+				{"x", textparser.TokenTypeIdent, false},
+				{"=", textparser.TokenTypeSymbol, false},
+				{`"abc`, textparser.TokenTypeString, true},
+				{"defg", textparser.TokenTypeString, true},
+				{`hij"`, textparser.TokenTypeString, false},
+			},
+		},
+		{
+			Name:  "escaped quote",
+			Input: `"ab\"cd"`,
+			Max:   3,
+			Expected: []Piece{
+				{`"ab`, textparser.TokenTypeString, true},
+				{`"c`, textparser.TokenTypeString, true},
+				{`d"`, textparser.TokenTypeString, false},
+			},
+		},
+		{
+			Name:  "ident",
+			Input: "abcdefghij + k",
+			Max:   4,
+			Expected: []Piece{
+				{"abcd", textparser.TokenTypeIdent, true},
+				{"efgh", textparser.TokenTypeIdent, true},
+				{"ij", textparser.TokenTypeIdent, false},
+				{"+", textparser.TokenTypeSymbol, false},
+				{"k", textparser.TokenTypeIdent, false},
+			},
+		},
+		{
+			Name:  "exact length",
+			Input: `"ab" abcd`,
+			Max:   4,
+			Expected: []Piece{
+				{`"ab"`, textparser.TokenTypeString, false},
+				{"abcd", textparser.TokenTypeIdent, false},
+			},
+		},
+		{
+			Name:  "multibyte runes",
+			Input: "'ééé'",
+			Max:   3,
+			Expected: []Piece{
+				{"'é", textparser.TokenTypeString, true},
+				{"é", textparser.TokenTypeString, true},
+				{"é'", textparser.TokenTypeString, false},
+			},
+		},
+		{
+			Name:  "skipped white space",
+			Input: "a          b",
+			Max:   2,
+			Expected: []Piece{
+				{"a", textparser.TokenTypeIdent, false},
+				{"b", textparser.TokenTypeIdent, false},
+			},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.MaxTokenBytes = test_data.Max
+
+			got := []Piece{}
+			for p.Scan() {
+				token := p.Token()
+				got = append(got,
+					Piece{token.Text, token.Type, token.Continued})
+			}
+
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Fatalf("error from scanner: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestMaxTokenBytesNoQuotes(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Max      int
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"two pieces", `"abcd"`, 3, []string{"ab", "cd"}},
+		{"one-rune pieces", `"abcd"`, 1,
+			[]string{"", "a", "b", "c", "d"}},
+		{"multibyte runes", `"abcdéé"`, 4, []string{"abc", "dé", "é"}},
+		{"not cut", `"ab" "cd"`, 8, []string{"ab", "cd"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.MaxTokenBytes = test_data.Max
+
+			got := []string{}
+			for p.Scan() {
+				got = append(got, p.TokenTextNoQuotes())
+			}
+
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Fatalf("error from scanner: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestMaxTokenBytesUnterminated(t *testing.T) {
+	p := textparser.NewScannerString(`"abcdefgh`)
+	p.MaxTokenBytes = 4
+
+	for p.Scan() {
+		if !p.Token().Continued {
+			t.Errorf("got final piece %q of an unterminated string",
+				p.TokenText())
+		}
+	}
+
+	code := textparser.ErrorCodeOf(p.Err())
+	if code != textparser.CodeUnterminatedString {
+		t.Errorf("got code %s, expected %s", code,
+			textparser.CodeUnterminatedString)
+	}
+}
+
+func TestMaxTokenBytesJSON(t *testing.T) {
+	p := textparser.NewScannerString(`"abcdefgh"`)
+	p.MaxTokenBytes = 4
+	token_list := scan_all(t, p)
+
+	data, err := json.Marshal(token_list)
+	if err != nil {
+		t.Fatalf("couldn't marshal tokens: %s", err)
+	}
+
+	var got []*textparser.Token
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("couldn't unmarshal tokens: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, token_list) {
+		t.Errorf("got %+v, expected %+v", got, token_list)
+	}
+
+	if !got[0].Continued {
+		t.Errorf("expected the first piece to be continued")
+	}
+}
@@ -0,0 +1,217 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// The smallest chunk of input TokenizeParallel() scans on its own.
+const min_parallel_chunk = 1 << 16
+
+// The tokens scanned from a range of the input.
+type chunk_result struct {
+	tokens []*ScannedToken
+
+	// Offset of the first token at or past the end of the range, or -1 if
+	// the input ended first.
+	next int
+
+	err error
+}
+
+// Scans all of data with scanners configured by the profile (nil for the
+// default configuration) and returns the tokens, as TokenizeAll() would, but
+// splitting the input into chunks at line starts and scanning them
+// concurrently with up to workers goroutines (GOMAXPROCS if workers is not
+// positive). Positions are corrected to refer to all of data, with filename
+// as the file name. A chunk may start inside a string or comment spanning
+// several lines; the tokens scanned from there are discarded up to the point
+// where they agree with those of the previous chunk, and scanned again if
+// they never do. The configuration must not carry state across lines, e.g.,
+// SetIndentation(), and must keep offsets exact, i.e., no Normalize and no
+// line directives. Lines are counted by '\n'.
+func TokenizeParallel(
+	data []byte,
+	filename string,
+	profile *Profile,
+	workers int,
+) ([]*ScannedToken, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if n := len(data) / min_parallel_chunk; n < workers {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Chunk k covers starts[k] up to starts[k+1], at line starts.
+	starts := []int{0}
+	for k := 1; k < workers; k++ {
+		start := len(data) * k / workers
+		if start < starts[len(starts)-1] {
+			continue
+		}
+		i := bytes.IndexByte(data[start:], '\n')
+		if i < 0 {
+			break
+		}
+		if start += i + 1; start < len(data) &&
+			start > starts[len(starts)-1] {
+			starts = append(starts, start)
+		}
+	}
+	starts = append(starts, len(data))
+
+	lines := make([]int, len(starts))
+	for k := 1; k < len(starts); k++ {
+		lines[k] = lines[k-1] + bytes.Count(data[starts[k-1]:starts[k]],
+			[]byte{'\n'})
+	}
+
+	results := make([]chunk_result, len(starts)-1)
+	var wg sync.WaitGroup
+	for k := range results {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			results[k] = scan_chunk(data, starts[k], starts[k], starts[k+1],
+				lines[k], filename, profile)
+		}(k)
+	}
+	wg.Wait()
+
+	tokens := results[0].tokens
+	if results[0].err != nil {
+		return tokens, results[0].err
+	}
+
+	next := results[0].next
+	for k := 1; k < len(results) && next >= 0; k++ {
+		if next >= starts[k+1] {
+			// A token from an earlier chunk spans this one.
+			continue
+		}
+
+		r := results[k]
+		j := sort.Search(len(r.tokens), func(i int) bool {
+			return r.tokens[i].Start.Offset >= next
+		})
+		if j == len(r.tokens) || r.tokens[j].Start.Offset != next {
+			// The chunk started inside a token, e.g., a multi-line string,
+			// and never caught up, so scan it again from where the
+			// previous chunk left off.
+			line_start := bytes.LastIndexByte(data[:next], '\n') + 1
+			r = scan_chunk(data, line_start, next, starts[k+1],
+				lines[k]+bytes.Count(data[starts[k]:next], []byte{'\n'}),
+				filename, profile)
+			j = 0
+		}
+
+		tokens = append(tokens, r.tokens[j:]...)
+		if r.err != nil {
+			return tokens, r.err
+		}
+		next = r.next
+	}
+
+	return tokens, nil
+}
+
+// Reads the named file and tokenizes it with TokenizeParallel().
+func TokenizeFileParallel(
+	filename string,
+	profile *Profile,
+	workers int,
+) ([]*ScannedToken, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return TokenizeParallel(data, filename, profile, workers)
+}
+
+// Scans data from start, on the line beginning at line_start after the given
+// number of lines, up to the first token at or past stop, returning tokens
+// with positions relative to all of data.
+func scan_chunk(
+	data []byte,
+	line_start, start, stop, lines int,
+	filename string,
+	profile *Profile,
+) chunk_result {
+	ts := profile.NewScanner(bytes.NewReader(data[start:]))
+	ts.SetFilename(filename)
+
+	// Count the columns of the line up to start.
+	for i := line_start; i < start; {
+		ch, size := utf8.DecodeRune(data[i:start])
+		ts.advance_position(ch, size)
+		i += size
+	}
+
+	shift := func(pos *Position) {
+		pos.Offset += start
+		pos.Line += lines
+	}
+
+	r := chunk_result{next: -1}
+	for ts.Scan() {
+		st := ts.owned_token()
+		if start+st.Start.Offset >= stop {
+			r.next = start + st.Start.Offset
+			return r
+		}
+
+		shift(&st.Start)
+		shift(&st.End)
+		r.tokens = append(r.tokens, st)
+	}
+
+	if err := ts.Err(); err != nil && err != io.EOF {
+		var scan_err *ScanError
+		if errors.As(err, &scan_err) {
+			shifted := *scan_err
+			shift(&shifted.Start)
+			shift(&shifted.End)
+			err = &shifted
+		}
+		r.err = err
+	}
+
+	return r
+}
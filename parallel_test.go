@@ -0,0 +1,96 @@
+package textparser_test
+
+import (
+	"bytes"
+	"fmt"
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parallel_input(lines int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < lines; i++ {
+		switch i % 7 {
+		case 0:
+			fmt.Fprintf(&buf, "name_%d = 'multi\nline\n\nstring %d'\n", i, i)
+		case 3:
+			fmt.Fprintf(&buf, "/* comment %d\n  spanning\n  lines */\n", i)
+		default:
+			fmt.Fprintf(&buf, "value_%d = %d.5 + foo(\"bar\", %d)\n", i, i, i)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Returns input with a string spanning several chunks, followed by tokens
+// on the line where it ends.
+func spanning_string_input() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(strings.Repeat("a = 1\n", 20000))
+	buf.WriteString("s = 'start\n")
+	buf.WriteString(strings.Repeat("line\n", 30000))
+	buf.WriteString("end' y = 2 + z\n")
+	buf.WriteString(strings.Repeat("b = 3\n", 20000))
+
+	return buf.Bytes()
+}
+
+func TestTokenizeParallel(t *testing.T) {
+	profile := &textparser.Profile{
+		Name: "default_comments",
+		Configure: func(ts *textparser.TokenScanner) {
+			ts.SetComments(textparser.DefaultCommentStyles...)
+		},
+	}
+
+	type TestData struct {
+		Name    string
+		Data    []byte
+		Workers int
+	}
+
+	data := parallel_input(20000)
+	test_list := []TestData{
+		{Name: "single worker", Data: data, Workers: 1},
+		{Name: "many workers", Data: data, Workers: 16},
+		{Name: "default workers", Data: data, Workers: 0},
+		{Name: "small input", Data: parallel_input(10), Workers: 8},
+		{
+			Name:    "unterminated string",
+			Data:    append(parallel_input(10000), "x = 'open\n"...),
+			Workers: 16,
+		},
+		{
+			Name:    "tokens after a string across chunks",
+			Data:    spanning_string_input(),
+			Workers: 8,
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := profile.NewScanner(bytes.NewReader(test_data.Data))
+			ts.SetFilename("input")
+			expected, expected_err := textparser.TokenizeAll(ts)
+
+			got, err := textparser.TokenizeParallel(test_data.Data, "input",
+				profile, test_data.Workers)
+			if !reflect.DeepEqual(err, expected_err) {
+				st.Fatalf("got error %#v, expected %#v", err, expected_err)
+			}
+			if len(got) != len(expected) {
+				st.Fatalf("got %d tokens, expected %d", len(got),
+					len(expected))
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], expected[i]) {
+					st.Fatalf("token %d: got %s, expected %s", i, got[i],
+						expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"strings"
+)
+
+// Returns true if the rune may be part of the name of a bind parameter.
+// `runes` starts with the prefix.
+func (ts *TokenScanner) is_parameter_rune(ch rune, runes []rune) bool {
+	return ts.IsIdentRune(ch, len(runes), runes) ||
+		ts.IsDigitRune(ch, len(runes), runes)
+}
+
+// Returns true if the upcoming input starts a bind parameter, without
+// consuming any input.
+func (ts *TokenScanner) is_parameter_start() bool {
+	if ts.ParameterPrefixes == "" {
+		return false
+	}
+
+	runes := ts.peek_upto(2)
+	if len(runes) == 0 || !strings.ContainsRune(ts.ParameterPrefixes,
+		runes[0]) {
+		return false
+	}
+
+	if runes[0] == '?' {
+		return true
+	}
+
+	return len(runes) == 2 && ts.is_parameter_rune(runes[1], runes[:1])
+}
+
+// Reads a bind parameter: a prefix rune followed by identifier runes or
+// digits.
+func (ts *TokenScanner) get_parameter() (*Token, error) {
+	if !ts.is_parameter_start() {
+		return nil, nil
+	}
+
+	var (
+		runes      []rune
+		total_size int
+	)
+
+	for {
+		ch, size, err := ts.get_one_rune()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if len(runes) > 0 && !ts.is_parameter_rune(ch, runes) {
+			if err = ts.unread_rune(); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		total_size += size
+		ts.advance_position(ch, size)
+		runes = append(runes, ch)
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeParameter,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
@@ -0,0 +1,153 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+)
+
+// Statistics for a PredicateCache.
+type PredicateCacheStats struct {
+	Hits   int64 // Number of calls answered from the cache.
+	Misses int64 // Number of calls passed on to the predicate.
+	Size   int   // Number of cached results.
+}
+
+// Returns a string representation of the statistics.
+func (s PredicateCacheStats) String() string {
+	return fmt.Sprintf("hits=%d misses=%d size=%d", s.Hits, s.Misses, s.Size)
+}
+
+type predicate_key struct {
+	ch     rune
+	bucket int
+}
+
+// A PredicateCache memoizes the results of an expensive rune predicate, such
+// as an IsIdentRune that consults a large table. Results are keyed by the rune
+// and by an index bucket: the index `i` passed to the predicate is capped at
+// the number of buckets minus one, so with 2 buckets, results for the first
+// rune of a token are kept separately from results for the rest. The
+// predicate must therefore not depend on `runes`, nor distinguish between
+// indexes that share a bucket. A PredicateCache is not safe for concurrent
+// use.
+type PredicateCache struct {
+	pred    func(ch rune, i int, runes []rune) bool
+	buckets int
+	results map[predicate_key]bool
+	stats   PredicateCacheStats
+}
+
+// Returns a new PredicateCache wrapping the predicate. If index_buckets is
+// less than 1, a single bucket is used, i.e., the index is ignored.
+func NewPredicateCache(
+	pred func(ch rune, i int, runes []rune) bool,
+	index_buckets int,
+) *PredicateCache {
+	if index_buckets < 1 {
+		index_buckets = 1
+	}
+
+	return &PredicateCache{
+		pred:    pred,
+		buckets: index_buckets,
+		results: make(map[predicate_key]bool),
+	}
+}
+
+// Calls the wrapped predicate, or returns its cached result.
+func (c *PredicateCache) Check(ch rune, i int, runes []rune) bool {
+	bucket := i
+	if bucket > c.buckets-1 {
+		bucket = c.buckets - 1
+	}
+
+	key := predicate_key{ch: ch, bucket: bucket}
+	if result, ok := c.results[key]; ok {
+		c.stats.Hits++
+		return result
+	}
+
+	c.stats.Misses++
+	result := c.pred(ch, i, runes)
+	c.results[key] = result
+
+	return result
+}
+
+// Returns the cache statistics.
+func (c *PredicateCache) Stats() PredicateCacheStats {
+	stats := c.stats
+	stats.Size = len(c.results)
+
+	return stats
+}
+
+// Discards all cached results and statistics.
+func (c *PredicateCache) Reset() {
+	c.results = make(map[predicate_key]bool)
+	c.stats = PredicateCacheStats{}
+}
+
+// Wraps the scanner's current IsIdentRune, IsSpaceRune, IsSymbolRune, and
+// IsDigitRune predicates in PredicateCaches with the given number of index
+// buckets (see NewPredicateCache()). At least 2 buckets are used, as the
+// default predicates treat the first rune of a token differently from the
+// rest. Call this after installing custom predicates. Each rune is otherwise
+// classified several times as recognizers fall through to one another.
+// Statistics are available from PredicateCacheStats().
+func (ts *TokenScanner) CachePredicates(index_buckets int) {
+	ts.predicate_caches = make(map[string]*PredicateCache)
+	if index_buckets < 2 {
+		index_buckets = 2
+	}
+
+	wrap := func(name string, pred *func(rune, int, []rune) bool) {
+		if *pred == nil {
+			return
+		}
+		c := NewPredicateCache(*pred, index_buckets)
+		ts.predicate_caches[name] = c
+		*pred = c.Check
+	}
+
+	wrap("IsIdentRune", &ts.IsIdentRune)
+	wrap("IsSpaceRune", &ts.IsSpaceRune)
+	wrap("IsSymbolRune", &ts.IsSymbolRune)
+	wrap("IsDigitRune", &ts.IsDigitRune)
+}
+
+// Returns the statistics for the caches installed by CachePredicates(),
+// keyed by the name of the predicate field, e.g., "IsIdentRune".
+func (ts *TokenScanner) PredicateCacheStats() map[string]PredicateCacheStats {
+	stats := make(map[string]PredicateCacheStats, len(ts.predicate_caches))
+	for name, c := range ts.predicate_caches {
+		stats[name] = c.Stats()
+	}
+
+	return stats
+}
@@ -0,0 +1,94 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+)
+
+func TestPredicateCache(t *testing.T) {
+	calls := 0
+	pred := func(ch rune, i int, runes []rune) bool {
+		calls++
+		return textparser.IsIdentRune(ch, i, runes)
+	}
+
+	c := textparser.NewPredicateCache(pred, 2)
+	for i := 0; i < 3; i++ {
+		if c.Check('a', i, nil) != true || c.Check('1', i, nil) != (i > 0) {
+			t.Errorf("[%d] unexpected result", i)
+		}
+	}
+
+	// 'a' and '1' at index 0, then at index > 0.
+	if calls != 4 {
+		t.Errorf("got %d calls, expected 4", calls)
+	}
+
+	expected := textparser.PredicateCacheStats{Hits: 2, Misses: 4, Size: 4}
+	if c.Stats() != expected {
+		t.Errorf("got %s, expected %s", c.Stats(), expected)
+	}
+
+	c.Reset()
+	if c.Stats() != (textparser.PredicateCacheStats{}) {
+		t.Errorf("got %s after reset", c.Stats())
+	}
+}
+
+func TestCachePredicates(t *testing.T) {
+	input := `foo = bar + baz1 * 42; foo = bar`
+
+	p := textparser.NewScannerString(input)
+	expected := make([]string, 0)
+	for p.Scan() {
+		expected = append(expected, p.TokenText())
+	}
+
+	p = textparser.NewScannerString(input)
+	p.CachePredicates(2)
+	token_list := make([]string, 0, len(expected))
+	for p.Scan() {
+		token_list = append(token_list, p.TokenText())
+	}
+
+	if !reflect.DeepEqual(token_list, expected) {
+		t.Errorf("got %#v, expected %#v", token_list, expected)
+	}
+
+	stats := p.PredicateCacheStats()
+	ident := stats["IsIdentRune"]
+	if ident.Hits == 0 || ident.Misses == 0 {
+		t.Errorf("unexpected IsIdentRune stats: %s", ident)
+	}
+
+	if _, ok := stats["IsDigitRune"]; !ok {
+		t.Errorf("expected stats for IsDigitRune: %v", stats)
+	}
+}
+
+func TestCachePredicatesOneBucket(t *testing.T) {
+	input := `a1 12 ++ x`
+
+	p := textparser.NewScannerString(input)
+	expected := make([]string, 0)
+	for p.Scan() {
+		expected = append(expected, p.Token().Type.String()+" "+
+			p.TokenText())
+	}
+
+	for _, buckets := range []int{0, 1} {
+		p = textparser.NewScannerString(input)
+		p.CachePredicates(buckets)
+		token_list := make([]string, 0, len(expected))
+		for p.Scan() {
+			token_list = append(token_list, p.Token().Type.String()+" "+
+				p.TokenText())
+		}
+
+		if !reflect.DeepEqual(token_list, expected) {
+			t.Errorf("buckets %d: got %#v, expected %#v", buckets,
+				token_list, expected)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Ready-made profiles for common languages. Each call returns a new Profile,
+// which may be modified freely.
+
+// Operators of the Go language.
+var go_operators = []string{
+	"<<=", ">>=", "&^=", "...", "&&", "||", "<-", "++", "--", "==", "!=",
+	"<=", ">=", ":=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<",
+	">>", "&^",
+}
+
+// Returns a profile for Go source code: "//" and "/* */" comments;
+// double-quoted, rune, and raw (back-quoted) string literals, with no escapes
+// in raw strings; Go operators; and Go number syntax, e.g., "0x_FF" and
+// "1e-9".
+func ProfileGo() *Profile {
+	return &Profile{
+		Name: "go",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = IsQuoteRune
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return ch == '\\' && runes[0] != '`'
+			}
+			ts.SetComments(DefaultCommentStyles...)
+			ts.SetOperators(go_operators...)
+			ts.Numbers = NumberSyntaxGo
+		},
+	}
+}
+
+// Returns a profile for JSON: double-quoted strings only, no comments,
+// literal names (true, false, and null) as identifiers, the structural
+// characters as symbols, and numbers with exponents.
+func ProfileJSON() *Profile {
+	return &Profile{
+		Name: "json",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"', '"'
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+			}
+			ts.IsSymbolRune = func(ch rune, i int, runes []rune) bool {
+				return i == 0 && strings.ContainsRune("{}[]:,", ch)
+			}
+			ts.SetComments()
+			ts.SetOperators()
+			ts.Numbers = NumberExponent
+		},
+	}
+}
+
+// Returns a profile for SQL: "--" and "/* */" comments; single-quoted
+// strings, and double-quoted, back-quoted, and bracketed identifiers (as
+// TokenTypeIdent tokens), where a doubled quote stands for one quote and
+// backslashes are literal; ":name", "@name", "$1", and "?" bind parameters as
+// TokenTypeParameter tokens; comparison, concatenation, and cast operators;
+// and numbers with exponents. Keywords are identifiers; see IsSQLKeyword().
+func ProfileSQL() *Profile {
+	return &Profile{
+		Name: "sql",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				switch ch {
+				case '\'', '"', '`':
+					return true, ch
+				case '[':
+					return true, ']'
+				}
+				return false, 0
+			}
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return false
+			}
+			ts.IsIdentQuoteRune = func(ch rune) bool {
+				return ch != '\''
+			}
+			ts.DoubledQuotes = true
+			ts.ParameterPrefixes = ":@$?"
+			ts.SetComments(CommentStyle{Start: "--"},
+				CommentStyle{Start: "/*", End: "*/"})
+			ts.SetOperators("<>", "!=", "<=", ">=", "||", "::")
+			ts.Numbers = NumberExponent
+		},
+	}
+}
+
+// Reserved words of standard SQL and common dialects, in upper case.
+var SQLKeywords = []string{
+	"ADD", "ALL", "ALTER", "AND", "ANY", "AS", "ASC", "BETWEEN", "BY",
+	"CASE", "CAST", "CHECK", "COLUMN", "CONSTRAINT", "CREATE", "CROSS",
+	"DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "END",
+	"EXCEPT", "EXISTS", "FALSE", "FOREIGN", "FROM", "FULL", "GROUP",
+	"HAVING", "IN", "INDEX", "INNER", "INSERT", "INTERSECT", "INTO", "IS",
+	"JOIN", "KEY", "LEFT", "LIKE", "LIMIT", "NOT", "NULL", "OFFSET", "ON",
+	"OR", "ORDER", "OUTER", "PRIMARY", "REFERENCES", "RIGHT", "SELECT",
+	"SET", "TABLE", "THEN", "TRUE", "UNION", "UNIQUE", "UPDATE", "USING",
+	"VALUES", "VIEW", "WHEN", "WHERE", "WITH",
+}
+
+// SQLKeywords folded with FoldCase().
+var sql_keyword_set = func() map[string]bool {
+	set := make(map[string]bool, len(SQLKeywords))
+	for _, keyword := range SQLKeywords {
+		set[FoldCase(keyword)] = true
+	}
+	return set
+}()
+
+// Returns true if the token is an unquoted identifier, or a keyword set with
+// SetKeywords(), that is one of SQLKeywords, ignoring case, e.g., "select" or
+// "Select".
+func IsSQLKeyword(t *Token) bool {
+	return is_word_token(t) && sql_keyword_set[FoldCase(t.Text)]
+}
+
+// Runes other than letters and digits allowed in shell words.
+const shell_word_runes = "_-./~+%,@:=^"
+
+// Returns a profile for POSIX shell command lines: "#" comments at the start
+// of a word; single-quoted strings without escapes, and double-quoted and
+// back-quoted strings with backslash escapes; words, including numbers,
+// options, paths, and runes escaped with a backslash, as identifiers; and
+// control and redirection operators. See SplitShellWords() to split a command
+// line into words.
+func ProfileShell() *Profile {
+	return &Profile{
+		Name: "shell",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = IsQuoteRune
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return ch == '\\' && runes[0] != '\''
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return unicode.IsLetter(ch) || unicode.IsDigit(ch) ||
+					strings.ContainsRune(shell_word_runes, ch) ||
+					(i > 0 && ch == '#') || ch == '\\' ||
+					shell_escaped(runes)
+			}
+			ts.SetComments(CommentStyle{Start: "#"})
+			ts.SetOperators("&&", "||", ";;", ">>", "<<", ">&", "<&", "&>",
+				"|&", "$(", "${")
+		},
+	}
+}
+
+// Returns true if the runes end with an unescaped backslash, which escapes the
+// next rune.
+func shell_escaped(runes []rune) bool {
+	n := 0
+	for i := len(runes) - 1; i >= 0 && runes[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// Runes other than letters and digits allowed in s-expression symbols.
+const sexp_symbol_runes = "-*/+?!<>=._:&%$"
+
+// Returns a profile for s-expressions, as in Lisp, Clojure, and EDN: ";"
+// comments; commas as white space; double-quoted strings with backslash
+// escapes; symbols and keywords, e.g., "list->vector", "*out*", or
+// ":key", as identifiers; numbers with exponents; and "(", ")", "[", "]",
+// "{", and "}" as brackets (see SetBrackets()). A symbol starting with "+" or
+// "-", e.g., "+" or "->", is a symbol token, unless a digit follows a "-",
+// making it a number. Other punctuation, e.g., the quote "'", is a symbol
+// token on its own. See ParseSexp() to build the nested lists.
+func ProfileSexp() *Profile {
+	return &Profile{
+		Name: "sexp",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"', ch
+			}
+			ts.IsSpaceRune = func(ch rune, i int, runes []rune) bool {
+				return unicode.IsSpace(ch) || ch == ','
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				if i == 0 && (ch == '+' || ch == '-') {
+					return false
+				}
+				return unicode.IsLetter(ch) ||
+					(i > 0 && unicode.IsDigit(ch)) ||
+					strings.ContainsRune(sexp_symbol_runes, ch)
+			}
+			ts.IsSymbolRune = func(ch rune, i int, runes []rune) bool {
+				if i == 0 {
+					return strings.ContainsRune("()[]{}'`~@^#+-", ch)
+				}
+				return (runes[0] == '+' || runes[0] == '-') &&
+					(unicode.IsLetter(ch) || unicode.IsDigit(ch) ||
+						strings.ContainsRune(sexp_symbol_runes, ch))
+			}
+			ts.SetComments(CommentStyle{Start: ";"})
+			ts.SetOperators("~@")
+			ts.SetBrackets("()", "[]", "{}")
+			ts.Numbers = NumberExponent
+		},
+	}
+}
+
+// Runes that end an unquoted dotenv key or value.
+const dotenv_special_runes = "\"'=:$"
+
+// Returns a profile for dotenv files and Java properties files: "#"
+// comments; keys and unquoted words of values as identifiers, which may
+// contain any runes other than white space, quotes, "=", ":", and "$"; "="
+// and ":" separators as symbols; single-quoted strings without escapes and
+// double-quoted strings with backslash escapes, both of which may span
+// lines; and variable references, e.g., "${OTHER}", as TokenTypeVariable
+// tokens (see Variables). An "export" prefix is an identifier. See
+// ParseDotenv() to read the entries.
+func ProfileDotenv() *Profile {
+	return &Profile{
+		Name: "dotenv",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"' || ch == '\'', ch
+			}
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return ch == '\\' && runes[0] == '"'
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return !unicode.IsSpace(ch) && (i > 0 || ch != '#') &&
+					!strings.ContainsRune(dotenv_special_runes, ch)
+			}
+			ts.SetComments(CommentStyle{Start: "#"})
+			ts.SetOperators()
+			ts.Variables = true
+		},
+	}
+}
+
+// Returns a profile for log lines: ISO-8601 timestamps, e.g.,
+// "2024-01-02T03:04:05.123Z", as TokenTypeDateTime tokens; the log levels in
+// DefaultLevelWords, e.g., "INFO" or "warn", as TokenTypeLevel tokens; IPv4
+// and IPv6 addresses and CIDR prefixes as TokenTypeAddress tokens; URLs as
+// TokenTypeURL tokens; single- and double-quoted strings with backslash
+// escapes; and the keys of key=value pairs as TokenTypeKey tokens.
+// Identifiers may contain dots and dashes, e.g., "http.status" or "req-id".
+// There are no comments.
+func ProfileLog() *Profile {
+	return &Profile{
+		Name: "log",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"' || ch == '\'', ch
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return unicode.IsLetter(ch) || ch == '_' ||
+					(i > 0 && (unicode.IsDigit(ch) || ch == '.' ||
+						ch == '-'))
+			}
+			ts.SetComments()
+			ts.SetLevelWords(DefaultLevelWords...)
+			ts.DateTimes = true
+			ts.Addresses = true
+			ts.KeyValuePairs = true
+			ts.URLs = true
+		},
+	}
+}
+
+// Returns a profile for configuration files of key/value pairs and blocks in
+// braces, as in nginx or HCL, to be parsed with ParseConfig(): "#", "//",
+// and "/* */" comments; single- and double-quoted strings with backslash
+// escapes; keys that may contain dots and dashes, e.g., "max-conns", as
+// identifiers; and Go number syntax.
+func ProfileConfig() *Profile {
+	return &Profile{
+		Name: "config",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				return ch == '"' || ch == '\'', ch
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return unicode.IsLetter(ch) || ch == '_' ||
+					(i > 0 && (unicode.IsDigit(ch) || ch == '-' ||
+						ch == '.'))
+			}
+			ts.SetComments(CommentStyle{Start: "#"},
+				CommentStyle{Start: "//"},
+				CommentStyle{Start: "/*", End: "*/"})
+			ts.SetOperators()
+			ts.Numbers = NumberSyntaxGo
+		},
+	}
+}
+
+// Returns a profile for INI and TOML-style configuration files: "#" and ";"
+// comments; section headers, e.g., "[server]" or "[[servers]]", as
+// TokenTypeSection tokens; bare keys that may contain dots and dashes, e.g.,
+// "server.max-conns", as identifiers; single-quoted literal strings without
+// escapes and double-quoted strings with backslash escapes; dates and times
+// as TokenTypeDateTime tokens; and TOML number syntax.
+func ProfileINI() *Profile {
+	return &Profile{
+		Name: "ini",
+		Configure: func(ts *TokenScanner) {
+			ts.IsQuoteRune = func(ch rune) (bool, rune) {
+				if ch == '"' || ch == '\'' {
+					return true, ch
+				}
+				return false, 0
+			}
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return ch == '\\' && runes[0] == '"'
+			}
+			ts.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+				return unicode.IsLetter(ch) || ch == '_' ||
+					(i > 0 && (unicode.IsDigit(ch) || ch == '-' ||
+						ch == '.'))
+			}
+			ts.SetComments(CommentStyle{Start: "#"}, CommentStyle{Start: ";"})
+			ts.SetOperators()
+			ts.SectionHeaders = true
+			ts.DateTimes = true
+			ts.Numbers = NumberSyntaxGo
+		},
+	}
+}
+
+// Returns a profile for comma-separated values (RFC 4180), in delimited mode
+// (see SetDelimited()) with double-quoted fields.
+func ProfileCSV() *Profile {
+	return &Profile{
+		Name: "csv",
+		Configure: func(ts *TokenScanner) {
+			ts.SetDelimited(',', '"')
+		},
+	}
+}
+
+// Returns a profile for inline markdown, in markdown inline mode (see
+// SetMarkdownInline()), e.g., for linters that need the positions of
+// emphasis markers, code spans, and links.
+func ProfileMarkdown() *Profile {
+	return &Profile{
+		Name: "markdown",
+		Configure: func(ts *TokenScanner) {
+			ts.SetMarkdownInline(true)
+		},
+	}
+}
+
+// Returns a profile for tab-separated values, in delimited mode (see
+// SetDelimited()) without quoting.
+func ProfileTSV() *Profile {
+	return &Profile{
+		Name: "tsv",
+		Configure: func(ts *TokenScanner) {
+			ts.SetDelimited('\t', 0)
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestPresets(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Profile  *textparser.Profile
+		Input    string
+		Expected []string // Type and text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:    "go",
+			Profile: textparser.ProfileGo(),
+			Input: "x := 0x_FF + 1_000 // c\n" +
+				"y <<= 2.5e-3 /* d */ s := `a\\` + \"b\\\"\"",
+			Expected: []string{"Ident x", "Symbol :=", "Int 0x_FF",
+				"Symbol +", "Int 1_000", "Ident y", "Symbol <<=",
+				"Float 2.5e-3", "Ident s", "Symbol :=", "String `a\\`",
+				"Symbol +", "String \"b\"\""},
+		},
+		{
+			Name:    "json",
+			Profile: textparser.ProfileJSON(),
+			Input:   `{"a": [true, -1.5E+2, null], "b": {}}`,
+			Expected: []string{"Symbol {", `String "a"`, "Symbol :",
+				"Symbol [", "Ident true", "Symbol ,", "Float -1.5E+2",
+				"Symbol ,", "Ident null", "Symbol ]", "Symbol ,",
+				`String "b"`, "Symbol :", "Symbol {", "Symbol }",
+				"Symbol }"},
+		},
+		{
+			Name:    "sql",
+			Profile: textparser.ProfileSQL(),
+			Input: "SELECT \"a\" FROM t -- all\n" +
+				"WHERE b <> 'c\\' AND d >= 1e3 /* e */",
+			Expected: []string{"Ident SELECT", `Ident "a"`, "Ident FROM",
+				"Ident t", "Ident WHERE", "Ident b", "Symbol <>",
+				`String 'c\'`, "Ident AND", "Ident d", "Symbol >=",
+				"Float 1e3"},
+		},
+		{
+			Name:    "shell",
+			Profile: textparser.ProfileShell(),
+			Input: "ls -la ~/x#1 && echo 'a\\' \"b\\\"\" >> out.txt # c\n" +
+				"cat $(pwd)",
+			Expected: []string{"Ident ls", "Ident -la", "Ident ~/x#1",
+				"Symbol &&", "Ident echo", `String 'a\'`,
+				`String "b""`, "Symbol >>", "Ident out.txt", "Ident cat",
+				"Symbol $(", "Ident pwd", "Symbol )"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := test_data.Profile.NewScanner(
+				strings.NewReader(test_data.Input))
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
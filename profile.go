@@ -0,0 +1,62 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+)
+
+// A Profile is a named scanner configuration that can be applied to any
+// number of scanners.
+type Profile struct {
+	// Name of the profile, for display purposes.
+	Name string
+
+	// Function that configures a scanner that has already been initialized
+	// with the default settings.
+	Configure func(ts *TokenScanner)
+}
+
+// Applies the profile to the scanner. A nil profile leaves the scanner
+// unchanged.
+func (p *Profile) Apply(ts *TokenScanner) {
+	if p == nil || p.Configure == nil {
+		return
+	}
+
+	p.Configure(ts)
+}
+
+// Returns a new TokenScanner initialized with the provided reader and
+// configured according to the profile. A nil profile results in the default
+// configuration.
+func (p *Profile) NewScanner(r io.Reader) *TokenScanner {
+	ts := NewScanner(r)
+	p.Apply(ts)
+
+	return ts
+}
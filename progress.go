@@ -0,0 +1,96 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// The default number of bytes between calls to the progress function.
+const default_progress_interval = 1 << 20
+
+// The state of progress reporting (see OnProgress()).
+type progress_state struct {
+	fn       func(bytes_consumed int64)
+	interval int64
+	next     int64 // Byte count at which to report next.
+	reported int64 // Byte count last reported, or -1.
+	popped   int64 // Bytes read from readers that have ended.
+}
+
+// Sets a function called with the number of bytes of input consumed so far,
+// once at least interval bytes were consumed since the previous call, and
+// once more at the end of the input, so that, e.g., a UI can show progress
+// while scanning a large file. The count includes readers pushed with
+// PushReader(). An interval of 0 means 1 MiB. The function is called from
+// Scan(), so it should return quickly. Call with a nil function to stop
+// reporting.
+func (ts *TokenScanner) OnProgress(
+	interval int64,
+	fn func(bytes_consumed int64),
+) {
+	if fn == nil {
+		ts.progress = nil
+		return
+	}
+
+	if interval <= 0 {
+		interval = default_progress_interval
+	}
+
+	consumed := ts.bytes_consumed()
+	ts.progress = &progress_state{
+		fn:       fn,
+		interval: interval,
+		next:     consumed + interval,
+		reported: -1,
+	}
+}
+
+// Returns the number of bytes of input consumed so far, including readers
+// pushed with PushReader().
+func (ts *TokenScanner) bytes_consumed() int64 {
+	n := int64(ts.reader.consumed)
+	for _, frame := range ts.includes {
+		n += int64(frame.reader.consumed)
+	}
+	if ts.progress != nil {
+		n += ts.progress.popped
+	}
+
+	return n
+}
+
+// Calls the progress function if enough input was consumed since the last
+// call, or if the input ended.
+func (ts *TokenScanner) report_progress(ended bool) {
+	p := ts.progress
+	n := ts.bytes_consumed()
+	if n < p.next && !(ended && n != p.reported) {
+		return
+	}
+
+	p.reported = n
+	p.next = n + p.interval
+	p.fn(n)
+}
@@ -0,0 +1,58 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestOnProgress(t *testing.T) {
+	input := strings.Repeat("abc ", 1000)
+
+	p := textparser.NewScanner(strings.NewReader(input))
+	var got []int64
+	p.OnProgress(1000, func(n int64) {
+		got = append(got, n)
+	})
+	for p.Scan() {
+	}
+	p.Scan()
+
+	// Reports come after the first token read past each interval, and at
+	// the end of the input.
+	expected := []int64{1003, 2003, 3003, 4000}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestOnProgressIncludes(t *testing.T) {
+	p := textparser.NewScannerString("main one two")
+	var got []int64
+	p.OnProgress(6, func(n int64) {
+		got = append(got, n)
+	})
+
+	var tokens []string
+	for p.Scan() {
+		tokens = append(tokens, p.TokenText())
+		if p.TokenText() == "main" {
+			err := p.PushReader(strings.NewReader("inc x"), "inc.txt")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		}
+	}
+
+	expected_tokens := []string{"main", "inc", "x", "one", "two"}
+	if !reflect.DeepEqual(tokens, expected_tokens) {
+		t.Errorf("got %#v, expected %#v", tokens, expected_tokens)
+	}
+
+	expected := []int64{7, 13, 17}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
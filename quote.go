@@ -0,0 +1,137 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How Quote() writes a string literal.
+type QuoteStyle struct {
+	Open  rune // The opening quote, e.g., '"'.
+	Close rune // The closing quote, or 0 for the same as Open.
+
+	// The escape rune, e.g., '\\', or 0 for none.
+	Escape rune
+
+	// Whether a closing quote in the value is written twice, as with
+	// DoubledQuotes, rather than escaped.
+	Doubled bool
+
+	// The escape sequences to write, in the form read by Unquote(), e.g.,
+	// 'n' for "\n". Only entries standing for a single rune other than
+	// their own are used. Ignored without an escape rune.
+	Escapes EscapeMap
+}
+
+// Returns the value as a string literal in the style, which a scanner
+// configured accordingly reads back as a single token, and from whose text
+// Unquote() with the same escapes returns the value. Returns an error if the
+// value contains the closing quote, but the style can neither escape nor
+// double it.
+func Quote(value string, style QuoteStyle) (string, error) {
+	closing := style.Close
+	if closing == 0 {
+		closing = style.Open
+	}
+
+	// The escape sequence for each rune with one.
+	var named map[rune]rune
+	if style.Escape != 0 {
+		for name, text := range style.Escapes {
+			if ch := []rune(text); len(ch) == 1 && ch[0] != name {
+				if named == nil {
+					named = make(map[rune]rune)
+				}
+				named[ch[0]] = name
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(value) + 2)
+	b.WriteRune(style.Open)
+	for _, ch := range value {
+		switch {
+		case ch == closing && style.Doubled:
+			b.WriteRune(ch)
+		case ch == closing || (ch == style.Escape && ch != 0):
+			if style.Escape == 0 {
+				return "", fmt.Errorf("can't quote %q without an escape "+
+					"rune or doubled quotes", value)
+			}
+			b.WriteRune(style.Escape)
+		default:
+			if name, ok := named[ch]; ok {
+				b.WriteRune(style.Escape)
+				ch = name
+			}
+		}
+		b.WriteRune(ch)
+	}
+	b.WriteRune(closing)
+
+	return b.String(), nil
+}
+
+// Returns the style in which the scanner reads string literals opened by
+// the quote rune: its closing quote (see IsQuoteRune), escape rune (see
+// IsEscapeRune, preferring '\\' if there are several), and DoubledQuotes.
+// With '\\' as the escape rune, the escapes are DefaultEscapes. Returns an
+// error if the rune does not open a string.
+func (ts *TokenScanner) QuoteStyle(open rune) (QuoteStyle, error) {
+	ok, closing := ts.IsQuoteRune(open)
+	if !ok {
+		return QuoteStyle{}, fmt.Errorf("%q is not a quote rune", open)
+	}
+
+	style := QuoteStyle{
+		Open:    open,
+		Close:   closing,
+		Doubled: ts.DoubledQuotes,
+	}
+
+	if ts.IsEscapeRune != nil {
+		if ts.IsEscapeRune('\\', 1, []rune{open}) {
+			style.Escape = '\\'
+		} else {
+			for ch := rune(1); ch < probe_limit; ch++ {
+				if ts.IsEscapeRune(ch, 1, []rune{open}) {
+					style.Escape = ch
+					break
+				}
+			}
+		}
+	}
+
+	if style.Escape == '\\' {
+		style.Escapes = DefaultEscapes
+	}
+
+	return style, nil
+}
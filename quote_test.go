@@ -0,0 +1,103 @@
+package textparser_test
+
+import (
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestQuote(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Open     rune
+		Profile  *textparser.Profile
+		Value    string
+		Expected string
+		Err      bool
+	}
+
+	doubled := &textparser.Profile{
+		Name: "doubled",
+		Configure: func(ts *textparser.TokenScanner) {
+			ts.DoubledQuotes = true
+			ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+				return false
+			}
+		},
+	}
+
+	test_list := []TestData{
+		{"plain", '"', nil, "abc", `"abc"`, false},
+		{"quotes and escapes", '"', nil, "say \"hi\\\"\n",
+			`"say \"hi\\\"\n"`, false},
+		{"other quote kept", '\'', nil, `it's "x"`, `'it\'s "x"'`, false},
+		{"tab", '"', nil, "a\tb", `"a\tb"`, false},
+		{"doubled", '\'', doubled, "it's", `'it''s'`, false},
+		{"raw string", '`', textparser.ProfileGo(), "a\\n\"b", "`a\\n\"b`",
+			false},
+		{"raw string with quote", '`', textparser.ProfileGo(), "a`b", "",
+			true},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := test_data.Profile.NewScanner(strings.NewReader(""))
+			style, err := p.QuoteStyle(test_data.Open)
+			if err != nil {
+				st.Fatalf("QuoteStyle() failed: %s", err)
+			}
+
+			got, err := textparser.Quote(test_data.Value, style)
+			if test_data.Err {
+				if err == nil {
+					st.Errorf("got %q, expected an error", got)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+			if got != test_data.Expected {
+				st.Errorf("got %s, expected %s", got, test_data.Expected)
+			}
+
+			// The scanner reads the literal back as a single token, the
+			// value of which is the original value.
+			p = test_data.Profile.NewScanner(strings.NewReader(got))
+			tokens := scan_all(st, p)
+			if len(tokens) != 1 ||
+				tokens[0].Type != textparser.TokenTypeString {
+				st.Fatalf("got tokens %v, expected a single string",
+					tokens)
+			}
+			if style.Escape == 0 {
+				return
+			}
+			value, err := textparser.Unquote(tokens[0].Text, style.Escapes)
+			if err != nil {
+				st.Fatalf("Unquote() failed: %s", err)
+			}
+			if value != test_data.Value {
+				st.Errorf("got value %q, expected %q", value,
+					test_data.Value)
+			}
+		})
+	}
+}
+
+func TestQuoteStyle(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader(""))
+	if _, err := p.QuoteStyle('a'); err == nil {
+		t.Errorf("QuoteStyle() succeeded for a non-quote rune")
+	}
+
+	p.IsQuoteRune = textparser.IsQuoteRuneFancy
+	style, err := p.QuoteStyle('«')
+	if err != nil {
+		t.Fatalf("QuoteStyle() failed: %s", err)
+	}
+	if style.Open != '«' || style.Close != '»' || style.Escape != '\\' {
+		t.Errorf("got %#v, expected « » with escape \\", style)
+	}
+}
@@ -0,0 +1,254 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The methods shared by TokenScanner, Recorder, and Player, for code that
+// reads tokens from any of them.
+type TokenSource interface {
+	Scan() bool
+	Token() *Token
+	ScannedToken() *ScannedToken
+	Err() error
+}
+
+// A line of a recording: a token with its span, or the error that ended
+// scanning.
+type json_record struct {
+	Token *Token      `json:"token,omitempty"`
+	Start *Position   `json:"start,omitempty"`
+	End   *Position   `json:"end,omitempty"`
+	Error *json_error `json:"error,omitempty"`
+}
+
+type json_error struct {
+	Msg    string    `json:"msg"`
+	Detail string    `json:"detail,omitempty"`
+	Start  *Position `json:"start,omitempty"`
+	End    *Position `json:"end,omitempty"`
+	Code   string    `json:"code,omitempty"` // Set for a ScanError.
+	EOF    bool      `json:"eof,omitempty"`
+}
+
+// A TokenSource that scans tokens with a TokenScanner and writes them to a
+// recording, to be replayed by a Player, e.g., to reproduce a parser bug
+// from a production input without shipping the input itself. The recording
+// is JSON Lines, a token per line in the JSON form of Token along with its
+// span, and, if scanning ended in an error other than io.EOF, a line for the
+// error.
+type Recorder struct {
+	ts  *TokenScanner
+	enc *json.Encoder
+	err error // The error writing the recording, if any.
+}
+
+// Returns a Recorder scanning tokens with ts and recording them to w.
+func NewRecorder(ts *TokenScanner, w io.Writer) *Recorder {
+	return &Recorder{ts: ts, enc: json.NewEncoder(w)}
+}
+
+// Scans the next token, as TokenScanner.Scan() does, and records it. Returns
+// false if scanning ended or the recording could not be written.
+func (r *Recorder) Scan() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if !r.ts.Scan() {
+		if err := r.ts.Err(); err != nil && err != io.EOF {
+			r.write(&json_record{Error: error_record(err)})
+		}
+		return false
+	}
+
+	st := r.ts.ScannedToken()
+
+	return r.write(&json_record{Token: st.Token, Start: &st.Start,
+		End: &st.End})
+}
+
+// Writes a line of the recording, returning false on error.
+func (r *Recorder) write(rec *json_record) bool {
+	if err := r.enc.Encode(rec); err != nil {
+		r.err = fmt.Errorf("writing recording: %w", err)
+		return false
+	}
+
+	return true
+}
+
+// Returns the most recent token scanned.
+func (r *Recorder) Token() *Token {
+	return r.ts.Token()
+}
+
+// Returns the most recent token scanned along with its span.
+func (r *Recorder) ScannedToken() *ScannedToken {
+	return r.ts.ScannedToken()
+}
+
+// Returns the error writing the recording, if any, or else the scanner's
+// last error.
+func (r *Recorder) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.ts.Err()
+}
+
+// Returns the recorded form of an error.
+func error_record(err error) *json_error {
+	var scan_err *ScanError
+	if !errors.As(err, &scan_err) {
+		return &json_error{Msg: err.Error()}
+	}
+
+	start, end := scan_err.Start, scan_err.End
+
+	return &json_error{
+		Msg:    scan_err.Msg,
+		Detail: scan_err.Detail,
+		Start:  &start,
+		End:    &end,
+		Code:   scan_err.Code.String(),
+		EOF:    scan_err.Err == io.EOF,
+	}
+}
+
+// Returns the error recorded by error_record(). A ScanError keeps all but
+// its underlying error, other than io.EOF; any other error keeps its message.
+func (je *json_error) decode() (error, error) {
+	if je.Code == "" {
+		return errors.New(je.Msg), nil
+	}
+
+	code, err := ParseErrorCode(je.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	scan_err := &ScanError{Msg: je.Msg, Detail: je.Detail, Code: code}
+	if je.Start != nil {
+		scan_err.Start = *je.Start
+	}
+	if je.End != nil {
+		scan_err.End = *je.End
+	}
+	if je.EOF {
+		scan_err.Err = io.EOF
+	}
+
+	return scan_err, nil
+}
+
+// A TokenSource that replays the tokens, and the error, written by a
+// Recorder.
+type Player struct {
+	dec  *json.Decoder
+	last *ScannedToken
+	err  error
+}
+
+// Returns a Player replaying the recording read from r.
+func NewPlayer(r io.Reader) *Player {
+	return &Player{dec: json.NewDecoder(r)}
+}
+
+// Advances to the next recorded token, returning false at the end of the
+// recording, or at the recorded error.
+func (p *Player) Scan() bool {
+	if p.err != nil {
+		return false
+	}
+
+	var rec json_record
+	if err := p.dec.Decode(&rec); err != nil {
+		if err != io.EOF {
+			err = fmt.Errorf("reading recording: %w", err)
+		}
+		p.err = err
+		return false
+	}
+
+	if rec.Error != nil {
+		err, parse_err := rec.Error.decode()
+		if parse_err != nil {
+			err = fmt.Errorf("reading recording: %w", parse_err)
+		}
+		p.err = err
+		return false
+	}
+
+	if rec.Token == nil || rec.Start == nil || rec.End == nil {
+		p.err = errors.New("reading recording: incomplete token")
+		return false
+	}
+
+	p.last = &ScannedToken{
+		Token: rec.Token,
+		Span:  Span{Start: *rec.Start, End: *rec.End},
+	}
+
+	return true
+}
+
+// Returns the most recent token replayed.
+func (p *Player) Token() *Token {
+	if p.last == nil {
+		return nil
+	}
+
+	return p.last.Token
+}
+
+// Returns the most recent token replayed along with its span.
+func (p *Player) ScannedToken() *ScannedToken {
+	return p.last
+}
+
+// Returns the error that ended the replay: the recorded error, io.EOF at the
+// end of the recording, or an error reading it.
+func (p *Player) Err() error {
+	return p.err
+}
+
+// Returns the start of the most recent token replayed, or nil before the
+// first.
+func (p *Player) Position() *Position {
+	if p.last == nil {
+		return nil
+	}
+
+	return &p.last.Start
+}
@@ -0,0 +1,89 @@
+package textparser_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func scan_source(src textparser.TokenSource) []*textparser.ScannedToken {
+	tokens := []*textparser.ScannedToken{}
+	for src.Scan() {
+		tokens = append(tokens, src.ScannedToken())
+	}
+
+	return tokens
+}
+
+func TestRecordAndPlay(t *testing.T) {
+	type TestData struct {
+		Name  string
+		Input string
+	}
+
+	test_list := []TestData{
+		{"tokens", "a = 1.5 # note\nb = [\"x\", 2001-01-01]\n"},
+		{"scan error", "a = \"unterminated\nb"},
+		{"empty", ""},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			new_scanner := func() *textparser.TokenScanner {
+				ts := textparser.NewScanner(
+					strings.NewReader(test_data.Input))
+				ts.SetFilename("prod.conf")
+				return ts
+			}
+
+			var buf bytes.Buffer
+			r := textparser.NewRecorder(new_scanner(), &buf)
+			recorded := scan_source(r)
+
+			direct := new_scanner()
+			expected := scan_source(direct)
+			if !reflect.DeepEqual(recorded, expected) {
+				st.Errorf("recorder got %v, expected %v", recorded,
+					expected)
+			}
+
+			p := textparser.NewPlayer(&buf)
+			if got := scan_source(p); !reflect.DeepEqual(got, expected) {
+				st.Errorf("player got %v, expected %v", got, expected)
+			}
+
+			expected_err := direct.Err()
+			if expected_err == io.EOF {
+				if p.Err() != io.EOF {
+					st.Errorf("got %v, expected EOF", p.Err())
+				}
+				return
+			}
+
+			if p.Err() == nil || p.Err().Error() != expected_err.Error() {
+				st.Errorf("got %v, expected %v", p.Err(), expected_err)
+			}
+			got_code := textparser.ErrorCodeOf(p.Err())
+			expected_code := textparser.ErrorCodeOf(expected_err)
+			if got_code != expected_code {
+				st.Errorf("got code %s, expected %s", got_code.Name(),
+					expected_code.Name())
+			}
+		})
+	}
+}
+
+func TestPlayerInvalidRecording(t *testing.T) {
+	p := textparser.NewPlayer(strings.NewReader("{\"token\": 3}\n"))
+	if p.Scan() {
+		t.Errorf("Scan() succeeded on an invalid recording")
+	}
+	if err := p.Err(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("got %v, expected an error reading the recording", err)
+	}
+}
@@ -0,0 +1,179 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// A matcher for tokens matching a regular expression at the start of the
+// input.
+type regexp_matcher struct {
+	token_type TokenType
+	name       string
+	anchored   *regexp.Regexp
+
+	// The first rune of every match, from the literal prefix of the
+	// regular expression, or -1 if there is none.
+	first rune
+}
+
+// The result of matching a regular expression at an offset in the input.
+type regexp_match struct {
+	m      *regexp_matcher
+	reader *rune_buffer
+	at     int   // Bytes consumed from the reader before the match.
+	n      int   // Number of runes matched, or 0.
+	err    error // Set if the match reached the lookahead limit.
+}
+
+// Returns a matcher reading tokens of the given type that match the regular
+// expression at the current position in the input. The regular expression
+// is applied to the lookahead buffer, so matches are limited to 64K runes;
+// reading a token that needs more fails with a ScanError having the code
+// CodeTokenTooLong. Empty matches are ignored. Like the regexp package, the match preferred by
+// leftmost-first semantics is used, even if re.Longest() was called. The
+// name of the matcher is "regexp " followed by the regular expression.
+func NewRegexpMatcher(token_type TokenType, re *regexp.Regexp) TokenMatcher {
+	m := &regexp_matcher{
+		token_type: token_type,
+		name:       "regexp " + re.String(),
+		anchored:   regexp.MustCompile(`\A(?:` + re.String() + `)`),
+		first:      -1,
+	}
+
+	if prefix, _ := re.LiteralPrefix(); prefix != "" {
+		m.first, _ = utf8.DecodeRuneInString(prefix)
+	}
+
+	return m
+}
+
+// Adds a matcher for tokens of the given type that match the regular
+// expression at the current position in the input (see NewRegexpMatcher()).
+// The matcher is inserted just before the "ident" matcher, or at the end of
+// the chain, if there is none, so that it is tried after whitespace,
+// comments, and quoted strings, but before identifiers, numbers, and
+// symbols. Matchers added this way are tried in the order they are added.
+func (ts *TokenScanner) AddRegexpMatcher(token_type TokenType,
+	re *regexp.Regexp) {
+	before := class_ident.String()
+	if ts.matcher_index(before) < 0 {
+		before = ""
+	}
+
+	ts.InsertMatcher(before, NewRegexpMatcher(token_type, re))
+}
+
+func (m *regexp_matcher) Name() string {
+	return m.name
+}
+
+func (m *regexp_matcher) Match(ts *TokenScanner, ch rune) bool {
+	if m.first >= 0 && ch != m.first {
+		return false
+	}
+
+	match := m.match(ts)
+	return match.n > 0 || match.err != nil
+}
+
+func (m *regexp_matcher) Read(ts *TokenScanner) (*Token, error) {
+	match := m.match(ts)
+	if match.err != nil {
+		return nil, match.err
+	}
+	if match.n == 0 {
+		return nil, nil
+	}
+
+	return ts.ReadToken(match.n, m.token_type)
+}
+
+// Returns the match at the current position, reusing the last one if it was
+// made there, e.g., by Match() before Read().
+func (m *regexp_matcher) match(ts *TokenScanner) *regexp_match {
+	last := &ts.regexp_match
+	if last.m == m && last.reader == ts.reader &&
+		last.at == ts.reader.consumed {
+		return last
+	}
+
+	*last = regexp_match{m: m, reader: ts.reader, at: ts.reader.consumed}
+
+	r := &lookahead_reader{b: ts.reader}
+	loc := m.anchored.FindReaderIndex(r)
+	if r.limited {
+		end := ts.pending_end_pos()
+		last.err = &ScanError{
+			Msg: "Token too long",
+			Detail: fmt.Sprintf("The match of %s reached the lookahead "+
+				"limit of %d runes.", m.name, max_lookahead),
+			Start: end,
+			End:   end,
+			Err:   errLookaheadLimit,
+			Code:  CodeTokenTooLong,
+		}
+		return last
+	}
+	if loc == nil || loc[1] == 0 {
+		return last
+	}
+
+	// Count the runes in the match, whose end is given in bytes.
+	n := 0
+	for size := 0; size < loc[1]; n++ {
+		r, err := ts.reader.peek_at(n)
+		if err != nil {
+			return last
+		}
+		size += r.size
+	}
+	last.n = n
+
+	return last
+}
+
+// Reads runes from the lookahead buffer without consuming them.
+type lookahead_reader struct {
+	b       *rune_buffer
+	i       int
+	limited bool // Set once reading stops at the lookahead limit.
+}
+
+func (r *lookahead_reader) ReadRune() (rune, int, error) {
+	sr, err := r.b.peek_at(r.i)
+	if err != nil {
+		r.limited = err == errLookaheadLimit
+		return 0, 0, err
+	}
+	r.i++
+
+	return sr.ch, sr.size, nil
+}
@@ -0,0 +1,123 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestRegexpMatcher(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Patterns []string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "ticket ids",
+			Input:    "fixed JIRA-1234 and OPS-7, not ops-7 or X-",
+			Patterns: []string{`[A-Z]+-[0-9]+`},
+			Expected: []string{"Ident fixed", "Keyword JIRA-1234",
+				"Ident and", "Keyword OPS-7", "Symbol ,", "Ident not",
+				"Ident ops", "Int -7", "Ident or", "Ident X",
+				"Symbol -"},
+		},
+		{
+			Name:     "literal prefix",
+			Input:    "sku#A1-B2 sku",
+			Patterns: []string{`sku#[A-Z0-9-]+`},
+			Expected: []string{"Keyword sku#A1-B2", "Ident sku"},
+		},
+		{
+			Name:     "tried in order added",
+			Input:    "v1.2.3 v1",
+			Patterns: []string{`v[0-9]+\.[0-9]+\.[0-9]+`, `v[0-9]+`},
+			Expected: []string{"Keyword v1.2.3", "Keyword v1"},
+		},
+		{
+			Name:     "anchored in multi-line mode",
+			Input:    "a\nb",
+			Patterns: []string{`(?m)^b`},
+			Expected: []string{"Ident a", "Keyword b"},
+		},
+		{
+			Name:     "empty match ignored",
+			Input:    "a b",
+			Patterns: []string{`x*`},
+			Expected: []string{"Ident a", "Ident b"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			for _, pattern := range test_data.Patterns {
+				p.AddRegexpMatcher(textparser.TokenTypeKeyword,
+					regexp.MustCompile(pattern))
+			}
+
+			var got []string
+			for p.Scan() {
+				got = append(got,
+					p.Token().Type.String()+" "+p.TokenText())
+			}
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Errorf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+// Test that positions after a match spanning lines account for its runes.
+func TestRegexpMatcherPosition(t *testing.T) {
+	p := textparser.NewScannerString("<<é\nx>> y")
+	p.AddRegexpMatcher(textparser.TokenTypeString,
+		regexp.MustCompile(`(?s)<<.*?>>`))
+
+	var got []string
+	for p.Scan() {
+		pos := p.Position()
+		got = append(got, p.TokenText()+" "+pos.String())
+	}
+
+	expected := []string{"<<é\nx>> :1:1 (0)", "y :2:5 (9)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	names := p.ConfigSummary().Recognizers
+	if names[len(names)-5] != `regexp (?s)<<.*?>>` {
+		t.Errorf("got recognizers %#v", names)
+	}
+}
+
+func TestRegexpMatcherTooLong(t *testing.T) {
+	p := textparser.NewScannerString("x " + strings.Repeat("%", 70000))
+	p.AddRegexpMatcher(textparser.TokenTypeSymbol, regexp.MustCompile(`%+`))
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.TokenText())
+	}
+
+	if !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("got %#v, expected just \"x\"", got)
+	}
+
+	code := textparser.ErrorCodeOf(p.Err())
+	if code != textparser.CodeTokenTooLong {
+		t.Errorf("got error %v with code %s, expected %s", p.Err(),
+			code.Name(), textparser.CodeTokenTooLong.Name())
+	}
+}
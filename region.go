@@ -0,0 +1,172 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The markers starting and ending a foldable region in a comment, e.g.,
+// "#region" and "#endregion" for "//#region Setup" and "//#endregion" (see
+// SetRegionMarkers()).
+type RegionMarkers struct {
+	Start string // Marker opening a region, e.g., "#region".
+	End   string // Marker closing the innermost open region.
+}
+
+// The region markers of C# and Visual Studio Code, e.g., "//#region".
+var DefaultRegionMarkers = RegionMarkers{Start: "#region", End: "#endregion"}
+
+// A region opened by a region marker and not closed yet.
+type open_region struct {
+	name  string
+	start Position
+}
+
+// Sets the markers of foldable regions, replacing any set before. A comment
+// whose content, after any spaces and tabs, starts with either marker,
+// followed by the end of the comment or by a space or tab, is a
+// TokenTypeRegion token with Bracket set to BracketOpen or BracketClose,
+// and Region set to the name following the opening marker, e.g., "Setup"
+// for both the opening and closing markers of "//#region Setup". Region
+// tokens are not skipped with comments (see SkipTypes() to skip them). A
+// closing marker with no region open, or a region still open at the end of
+// the input, is a ScanError with CodeUnbalancedRegion. Zero markers turn
+// regions off.
+func (ts *TokenScanner) SetRegionMarkers(markers RegionMarkers) {
+	ts.region_markers = markers
+	ts.regions = nil
+}
+
+// Returns the markers set with SetRegionMarkers().
+func (ts *TokenScanner) RegionMarkers() RegionMarkers {
+	return ts.region_markers
+}
+
+// Returns the regions open at the current position, outermost first.
+func (ts *TokenScanner) OpenRegions() []string {
+	names := make([]string, 0, len(ts.regions))
+	for _, r := range ts.regions {
+		names = append(names, r.name)
+	}
+
+	return names
+}
+
+// Makes the comment token a region token, if it is a region marker. Returns
+// a ScanError if it closes a region when none is open.
+func (ts *TokenScanner) note_region(token *Token) error {
+	body := strings.TrimLeft(ts.comment_body(token.RawText()), " \t")
+
+	name, ok := region_marker_rest(body, ts.region_markers.End)
+	if ok {
+		if len(ts.regions) == 0 {
+			return &ScanError{
+				Msg:    fmt.Sprintf("Unexpected %q", ts.region_markers.End),
+				Detail: "No region is open.",
+				Start:  *ts.pos,
+				End:    *ts.pos,
+				Code:   CodeUnbalancedRegion,
+			}
+		}
+
+		last := len(ts.regions) - 1
+		token.Type = TokenTypeRegion
+		token.Bracket = BracketClose
+		token.Region = ts.regions[last].name
+		ts.regions = ts.regions[:last]
+
+		return nil
+	}
+
+	if name, ok = region_marker_rest(body, ts.region_markers.Start); ok {
+		token.Type = TokenTypeRegion
+		token.Bracket = BracketOpen
+		token.Region = name
+		ts.regions = append(ts.regions, open_region{name: name,
+			start: *ts.pos})
+	}
+
+	return nil
+}
+
+// Returns the trimmed text following the marker at the start of the body,
+// and whether the body starts with the marker as a word.
+func region_marker_rest(body, marker string) (string, bool) {
+	if marker == "" || !strings.HasPrefix(body, marker) {
+		return "", false
+	}
+
+	rest := body[len(marker):]
+	if rest != "" && !strings.ContainsRune(" \t\r\n", rune(rest[0])) {
+		// E.g., "#regions".
+		return "", false
+	}
+
+	return strings.TrimSpace(rest), true
+}
+
+// Returns a ScanError with CodeUnbalancedRegion for the innermost region
+// still open at the end of the input, or nil if there is none.
+func (ts *TokenScanner) region_eof_error() error {
+	if len(ts.regions) == 0 {
+		return nil
+	}
+
+	r := ts.regions[len(ts.regions)-1]
+	ts.regions = nil
+
+	return &ScanError{
+		Msg: "Unterminated region",
+		Detail: fmt.Sprintf("Couldn't find %q for region %q.",
+			ts.region_markers.End, r.name),
+		Start: r.start,
+		End:   *ts.pos,
+		Err:   io.EOF,
+		Code:  CodeUnbalancedRegion,
+	}
+}
+
+// Returns the text of the comment without its delimiters.
+func (ts *TokenScanner) comment_body(text string) string {
+	for _, c := range ts.comments {
+		start := string(c.start)
+		if !strings.HasPrefix(text, start) {
+			continue
+		}
+
+		body := text[len(start):]
+		if len(c.end) > 0 {
+			body = strings.TrimSuffix(body, string(c.end))
+		}
+		return body
+	}
+
+	return text
+}
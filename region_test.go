@@ -0,0 +1,123 @@
+package textparser_test
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestRegionMarkers(t *testing.T) {
+	input := "//#region Setup\nx = 1\n// #region  Inner part \n" +
+		"/* #endregion */\n//#regions\n// #endregion\ny"
+
+	p := textparser.NewScannerString(input)
+	p.SetRegionMarkers(textparser.DefaultRegionMarkers)
+
+	var got []string
+	for p.Scan() {
+		token := p.Token()
+		got = append(got, token.Type.String()+" "+token.Bracket.String()+
+			" "+token.Region)
+		if token.Type == textparser.TokenTypeRegion &&
+			token.Bracket == textparser.BracketOpen {
+			got = append(got, "open: "+joined(p.OpenRegions()))
+		}
+	}
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"Region open Setup", "open: Setup",
+		"Ident  ", "Symbol  ", "Int  ",
+		"Region open Inner part", "open: Setup/Inner part",
+		"Region close Inner part",
+		"Region close Setup",
+		"Ident  ",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestRegionJSON(t *testing.T) {
+	p := textparser.NewScannerString("// #region Setup\nx\n// #endregion")
+	p.SetRegionMarkers(textparser.DefaultRegionMarkers)
+	token_list := scan_all(t, p)
+
+	data, err := json.Marshal(token_list)
+	if err != nil {
+		t.Fatalf("couldn't marshal tokens: %s", err)
+	}
+
+	var got []*textparser.Token
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("couldn't unmarshal tokens: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, token_list) {
+		t.Errorf("got %+v, expected %+v", got, token_list)
+	}
+
+	if got[0].Region != "Setup" {
+		t.Errorf("got region %q, expected %q", got[0].Region, "Setup")
+	}
+}
+
+func joined(names []string) string {
+	s := ""
+	for i, name := range names {
+		if i > 0 {
+			s += "/"
+		}
+		s += name
+	}
+	return s
+}
+
+func TestRegionErrors(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "unexpected end",
+			Input: "a\n  // #endregion",
+			Expected: `Unexpected "#endregion" at :2:3 (4). ` +
+				`No region is open.`,
+		},
+		{
+			Name:  "unterminated",
+			Input: "// #region A\n// #region B\n// #endregion\nb",
+			Expected: `Unterminated region opened at :1:1 (0), ` +
+				`unterminated at EOF :4:2 (41). ` +
+				`Couldn't find "#endregion" for region "A".`,
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetRegionMarkers(textparser.DefaultRegionMarkers)
+			for p.Scan() {
+			}
+
+			err := p.Err()
+			if textparser.ErrorCodeOf(err) !=
+				textparser.CodeUnbalancedRegion {
+				st.Fatalf("got error %v, expected code %s", err,
+					textparser.CodeUnbalancedRegion)
+			}
+			if err.Error() != test_data.Expected {
+				st.Errorf("got %q, expected %q", err.Error(),
+					test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kinds of edits, in the order they apply at the same offset.
+const (
+	edit_insert_after = iota
+	edit_insert_before
+	edit_replace
+)
+
+// An edit recorded by a Rewriter.
+type rewrite_edit struct {
+	kind       int
+	start, end int // Byte offsets in the source.
+	text       string
+	seq        int // Order recorded, for edits at the same offset.
+}
+
+// Records edits to a source, keyed by the spans of tokens scanned from it,
+// and applies them all at once, e.g., to change a struct tag or migrate a
+// configuration file while leaving everything else as it was. Edits refer to
+// offsets in the original source, so they may be recorded in any order.
+type Rewriter struct {
+	src   []byte
+	edits []rewrite_edit
+}
+
+// Returns a Rewriter for the source, which tokens are scanned from to find
+// the spans to edit. The source must not be modified while the Rewriter is
+// in use.
+func NewRewriter(src []byte) *Rewriter {
+	return &Rewriter{src: src}
+}
+
+// Replaces the source within the span, e.g., of a token, with the text.
+// Replaced and deleted spans may touch but not overlap.
+func (rw *Rewriter) Replace(span Span, text string) {
+	rw.add(edit_replace, span.Start.Offset, span.End.Offset, text)
+}
+
+// Deletes the source within the span, as Replace() with no text.
+func (rw *Rewriter) Delete(span Span) {
+	rw.Replace(span, "")
+}
+
+// Inserts the text before the span, after any text inserted after the span
+// preceding it, in the order inserted.
+func (rw *Rewriter) InsertBefore(span Span, text string) {
+	rw.add(edit_insert_before, span.Start.Offset, span.Start.Offset, text)
+}
+
+// Inserts the text after the span, in the order inserted.
+func (rw *Rewriter) InsertAfter(span Span, text string) {
+	rw.add(edit_insert_after, span.End.Offset, span.End.Offset, text)
+}
+
+func (rw *Rewriter) add(kind, start, end int, text string) {
+	rw.edits = append(rw.edits, rewrite_edit{kind: kind, start: start,
+		end: end, text: text, seq: len(rw.edits)})
+}
+
+// A segment of rewritten output: either source copied unchanged, or the
+// replacement of a span of the source.
+type offset_segment struct {
+	old, new int // Starting offsets in the source and output.
+	replaced bool
+}
+
+// Maps byte offsets in a source to offsets in the output of a Rewriter.
+type OffsetMap struct {
+	segments []offset_segment
+}
+
+// Returns the offset in the output corresponding to the offset in the
+// source. Offsets in unchanged source move by the length of edits before
+// them, offsets within a replaced or deleted span map to the start of its
+// replacement, and offsets where text was inserted map to the source after
+// the inserted text.
+func (m *OffsetMap) Map(offset int) int {
+	i := sort.Search(len(m.segments), func(i int) bool {
+		return m.segments[i].old > offset
+	}) - 1
+	if i < 0 {
+		return offset
+	}
+
+	seg := m.segments[i]
+	if seg.replaced {
+		return seg.new
+	}
+
+	return seg.new + offset - seg.old
+}
+
+// Returns the source with the edits applied, along with a map from offsets
+// in the source to offsets in the result. Returns an error, without applying
+// anything, if a span is outside the source, if replaced spans overlap, or if
+// text is inserted within a replaced span.
+func (rw *Rewriter) Apply() ([]byte, *OffsetMap, error) {
+	edits := append([]rewrite_edit(nil), rw.edits...)
+	sort.Slice(edits, func(i, j int) bool {
+		a, b := edits[i], edits[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.seq < b.seq
+	})
+
+	var (
+		out  []byte
+		m    = new(OffsetMap)
+		done int // Offset in the source up to which output is written.
+	)
+
+	for _, e := range edits {
+		if e.start < 0 || e.end < e.start || e.end > len(rw.src) {
+			return nil, nil, fmt.Errorf("edit at %d-%d outside of source "+
+				"of %d bytes", e.start, e.end, len(rw.src))
+		}
+		if e.start < done {
+			return nil, nil, fmt.Errorf("edit at %d-%d overlaps "+
+				"replacement ending at %d", e.start, e.end, done)
+		}
+
+		if e.start > done {
+			m.segments = append(m.segments,
+				offset_segment{old: done, new: len(out)})
+			out = append(out, rw.src[done:e.start]...)
+			done = e.start
+		}
+
+		if e.kind == edit_replace {
+			m.segments = append(m.segments,
+				offset_segment{old: e.start, new: len(out), replaced: true})
+			done = e.end
+		}
+
+		out = append(out, e.text...)
+	}
+
+	m.segments = append(m.segments, offset_segment{old: done, new: len(out)})
+	out = append(out, rw.src[done:]...)
+
+	return out, m, nil
+}
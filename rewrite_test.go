@@ -0,0 +1,97 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestRewriter(t *testing.T) {
+	src := []byte("port = 8080\nhost = 'old'\n")
+
+	p := textparser.NewScannerBytes(src)
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("error from scanner: %s", err)
+	}
+
+	// port = 8080 host = 'old'
+	port, value, host, old := tokens[0], tokens[2], tokens[3], tokens[5]
+
+	rw := textparser.NewRewriter(src)
+	rw.Replace(old.Span, "'new.example.com'")
+	rw.InsertBefore(host.Span, "# moved\n")
+	rw.Delete(value.Span)
+	rw.InsertAfter(value.Span, "80")
+	rw.Replace(port.Span, "listen_port")
+
+	got, m, err := rw.Apply()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "listen_port = 80\n# moved\nhost = 'new.example.com'\n"
+	if string(got) != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	offsets := []int{0, 2, 4, 7, 11, 12, 19, 24, 25}
+	var mapped []int
+	for _, offset := range offsets {
+		mapped = append(mapped, m.Map(offset))
+	}
+
+	expected_offsets := []int{0, 0, 11, 14, 16, 25, 32, 49, 50}
+	if !reflect.DeepEqual(mapped, expected_offsets) {
+		t.Errorf("got offsets %v, expected %v", mapped, expected_offsets)
+	}
+}
+
+func TestRewriterErrors(t *testing.T) {
+	span := func(start, end int) textparser.Span {
+		return textparser.Span{
+			Start: textparser.Position{Offset: start},
+			End:   textparser.Position{Offset: end},
+		}
+	}
+
+	type TestData struct {
+		Name string
+		Edit func(rw *textparser.Rewriter)
+	}
+
+	test_list := []TestData{
+		{
+			Name: "overlapping replacements",
+			Edit: func(rw *textparser.Rewriter) {
+				rw.Replace(span(0, 4), "x")
+				rw.Replace(span(2, 6), "y")
+			},
+		},
+		{
+			Name: "insert within replacement",
+			Edit: func(rw *textparser.Rewriter) {
+				rw.Delete(span(0, 4))
+				rw.InsertAfter(span(0, 2), "y")
+			},
+		},
+		{
+			Name: "outside of source",
+			Edit: func(rw *textparser.Rewriter) {
+				rw.Replace(span(8, 20), "x")
+			},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			rw := textparser.NewRewriter([]byte("0123456789"))
+			test_data.Edit(rw)
+
+			if got, _, err := rw.Apply(); err == nil {
+				st.Errorf("expected an error, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	utf8 "unicode/utf8"
+)
+
+// Returns a new TokenScanner reading runes from rr, e.g., a custom decoder
+// that already handles the encoding of its input, so that the input is not
+// decoded twice. Byte offsets and counts reported by the scanner are the sums
+// of the sizes returned by rr.ReadRune(), so they refer to the input of rr.
+// A *bufio.Reader, *strings.Reader, or *bytes.Reader is read directly, as by
+// NewScanner(), without wrapping it in another buffer.
+func NewScannerRuneReader(rr io.RuneReader) *TokenScanner {
+	switch r := rr.(type) {
+	case *bufio.Reader:
+		return NewScanner(r)
+	case *strings.Reader:
+		return NewScanner(r)
+	case *bytes.Reader:
+		return NewScanner(r)
+	}
+
+	reader := &rune_reader{src: rr, sizes: new(rune_sizes)}
+
+	ts := NewScanner(reader)
+	ts.source_sizes = reader.sizes
+
+	return ts
+}
+
+// A reader providing the runes of an io.RuneReader as UTF-8, recording the
+// size of each rune as reported by the rune reader.
+type rune_reader struct {
+	src     io.RuneReader
+	sizes   *rune_sizes
+	pending []byte // Encoded bytes not yet returned by Read().
+	buf     [utf8.UTFMax]byte
+}
+
+// Reads at most one rune from the source per call, so that interactive
+// sources are not read ahead of what the scanner needs.
+func (rr *rune_reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(rr.pending) == 0 {
+		ch, size, err := rr.src.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		rr.sizes.push(0, size)
+		rr.pending = rr.buf[:utf8.EncodeRune(rr.buf[:], ch)]
+	}
+
+	n := copy(p, rr.pending)
+	rr.pending = rr.pending[n:]
+
+	return n, nil
+}
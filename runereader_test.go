@@ -0,0 +1,70 @@
+package textparser_test
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+// A rune reader reporting UTF-16 sizes, as a decoder of UTF-16 input would.
+type utf16_rune_reader struct {
+	runes []rune
+}
+
+func (r *utf16_rune_reader) ReadRune() (rune, int, error) {
+	if len(r.runes) == 0 {
+		return 0, 0, io.EOF
+	}
+
+	ch := r.runes[0]
+	r.runes = r.runes[1:]
+	if ch > 0xFFFF {
+		return ch, 4, nil
+	}
+
+	return ch, 2, nil
+}
+
+func TestScannerRuneReader(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Reader   io.RuneReader
+		Expected []string
+	}
+
+	input := "ab = '\U0001D11Ec'\n  x"
+
+	test_list := []TestData{
+		{"custom", &utf16_rune_reader{runes: []rune(input)},
+			[]string{":1:1 (0) ab", ":1:4 (6) =", ":1:6 (10) '\U0001D11Ec'",
+				":2:3 (26) x"}},
+		{"bufio", bufio.NewReader(strings.NewReader(input)),
+			[]string{":1:1 (0) ab", ":1:4 (3) =", ":1:6 (5) '\U0001D11Ec'",
+				":2:3 (15) x"}},
+		{"strings", strings.NewReader(input),
+			[]string{":1:1 (0) ab", ":1:4 (3) =", ":1:6 (5) '\U0001D11Ec'",
+				":2:3 (15) x"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerRuneReader(test_data.Reader)
+
+			var got []string
+			for p.Scan() {
+				got = append(got, p.Position().String()+" "+p.TokenText())
+			}
+			if p.Err() != io.EOF {
+				st.Fatalf("unexpected error: %s", p.Err())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
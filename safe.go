@@ -0,0 +1,80 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"sync"
+)
+
+// A TokenScanner wrapper that lets several goroutines pull tokens from one
+// stream, e.g., work-stealing consumers. The Scan() then LastToken pattern of
+// TokenScanner races when shared, as another goroutine may scan in between;
+// Next() instead returns each token along with its span as a single value.
+type SafeScanner struct {
+	mu  sync.Mutex
+	ts  *TokenScanner
+	err error // The error that ended scanning, if it has ended.
+}
+
+// Returns a SafeScanner pulling tokens from ts. The scanner should not be
+// used directly while the SafeScanner is in use.
+func NewSafeScanner(ts *TokenScanner) *SafeScanner {
+	return &SafeScanner{ts: ts}
+}
+
+// Returns the next token along with its span, or nil and the error that
+// ended scanning, which is io.EOF at the end of the input. Each token is
+// returned to exactly one caller and is not modified by later calls, even if
+// ReuseToken or ByteTokens is set.
+func (s *SafeScanner) Next() (*ScannedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if !s.ts.Scan() {
+		if s.err = s.ts.Err(); s.err == nil {
+			// Stopped by ScanLimit().
+			s.err = io.EOF
+		}
+		return nil, s.err
+	}
+
+	return s.ts.owned_token(), nil
+}
+
+// Calls fn with the underlying scanner while holding the lock, e.g., to
+// change its configuration between tokens or to read its state.
+func (s *SafeScanner) Do(fn func(ts *TokenScanner)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.ts)
+}
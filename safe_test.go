@@ -0,0 +1,94 @@
+package textparser_test
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSafeScanner(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&input, "a%d = %d;\n", i, i)
+	}
+
+	expected, err := textparser.TokenizeAll(
+		textparser.NewScanner(strings.NewReader(input.String())))
+	if err != nil {
+		t.Fatalf("TokenizeAll() failed: %s", err)
+	}
+
+	for _, reuse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reuse=%t", reuse), func(st *testing.T) {
+			ts := textparser.NewScanner(strings.NewReader(input.String()))
+			ts.ReuseToken = reuse
+			s := textparser.NewSafeScanner(ts)
+
+			var (
+				mu  sync.Mutex
+				wg  sync.WaitGroup
+				got []*textparser.ScannedToken
+			)
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						token, err := s.Next()
+						if err != nil {
+							if err != io.EOF {
+								st.Errorf("unexpected error: %s", err)
+							}
+							return
+						}
+						mu.Lock()
+						got = append(got, token)
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			sort.Slice(got, func(i, j int) bool {
+				return got[i].Start.Offset < got[j].Start.Offset
+			})
+			if !reflect.DeepEqual(got, expected) {
+				st.Errorf("got %d tokens, expected %d, or they differ",
+					len(got), len(expected))
+			}
+
+			if token, err := s.Next(); token != nil || err != io.EOF {
+				st.Errorf("got %v, %v after the end, expected nil, EOF",
+					token, err)
+			}
+		})
+	}
+}
+
+func TestSafeScannerDo(t *testing.T) {
+	s := textparser.NewSafeScanner(
+		textparser.NewScanner(strings.NewReader("a b")))
+	s.Do(func(ts *textparser.TokenScanner) {
+		ts.SkipWhitespace = true
+	})
+
+	got := []string{}
+	for {
+		token, err := s.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"a", "b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,381 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An opening quote rune and the rune closing strings it opens, e.g., '«'
+// and '»'.
+type QuotePair struct {
+	Open, Close rune
+}
+
+// A declarative scanner configuration, checked for conflicting settings
+// before it is applied, e.g., a quote rune that is also a symbol rune, which
+// would otherwise only show up as tokens scanned wrongly. The zero value
+// configures a scanner like NewScanner() does.
+type ScannerConfig struct {
+	// Quote pairs opening and closing strings. Nil means the defaults (see
+	// IsQuoteRune()), and an empty, non-nil list means no strings at all.
+	Quotes []QuotePair
+
+	// The escape rune in strings, or 0 for the default (see IsEscapeRune()).
+	Escape rune
+
+	CollapseEscapes bool
+	DoubledQuotes   bool
+	ConcatStrings   bool // See ConcatStrings in TokenScanner.
+
+	// Comment styles. Nil means the defaults (see DefaultCommentStyles), and
+	// an empty, non-nil list means no comments at all.
+	Comments []CommentStyle
+
+	// Identifiers emitted as TokenTypeKeyword (see SetKeywords()).
+	Keywords []string
+
+	// Runes allowed in identifiers besides those of IsIdentRune(), e.g.,
+	// "-$".
+	IdentRunes string
+
+	// Whether identifiers follow Unicode Standard Annex #31 (see
+	// IsIdentRuneUAX31()) instead of IsIdentRune(). IdentRunes still adds
+	// to them.
+	UAX31Identifiers bool
+
+	// If not empty, the only runes that are symbols, instead of all
+	// punctuation and symbol runes (see IsSymbolRune()).
+	SymbolRunes string
+
+	Operators []string // See SetOperators().
+	Brackets  []string // See SetBrackets().
+	Numbers   NumberSyntax
+
+	// Whether numbers are not scanned at all (see DisableNumbers in
+	// TokenScanner), which conflicts with Numbers.
+	DisableNumbers bool
+
+	// Suffixes taken as part of numbers, e.g., "u8" (see
+	// SetNumberSuffixes()).
+	NumberSuffixes []string
+
+	// The digit grouping and decimal separators of numbers (see
+	// NumberLocale in TokenScanner).
+	NumberLocale NumberLocale
+
+	// Prefixes that are part of a string literal directly before its
+	// opening quote, e.g., "r" (see SetStringPrefixes()).
+	StringPrefixes []StringPrefix
+
+	// Separators joining identifiers into qualified names, e.g., "::" (see
+	// SetNamespaceSeparators()).
+	NamespaceSeparators []string
+}
+
+// Returns nil if the configuration is consistent, or an error describing
+// each conflicting or invalid setting.
+func (cfg *ScannerConfig) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	is_symbol := cfg.symbol_rune()
+	is_ident := cfg.ident_rune()
+	quotes := make(map[rune]bool)
+	for _, q := range cfg.quote_pairs() {
+		// The closing rune only matters within strings.
+		switch ch := q.Open; {
+		case ch == 0 || q.Close == 0:
+			fail("quote pair %q has a zero rune",
+				string([]rune{q.Open, q.Close}))
+		case unicode.IsSpace(ch) || is_ident(ch, 0, nil) ||
+			is_ident(ch, 1, nil):
+			fail("quote rune %q is also a space or identifier rune", ch)
+		case is_symbol(ch, 0, nil):
+			fail("quote rune %q is also a symbol rune", ch)
+		}
+		quotes[q.Open] = true
+	}
+
+	if cfg.Escape != 0 && quotes[cfg.Escape] {
+		fail("escape rune %q is also a quote rune", cfg.Escape)
+	}
+
+	for _, ch := range cfg.SymbolRunes {
+		if strings.ContainsRune(cfg.IdentRunes, ch) {
+			fail("rune %q is both an identifier and a symbol rune", ch)
+		}
+		if unicode.IsSpace(ch) {
+			fail("symbol rune %q is a space", ch)
+		}
+	}
+	for _, ch := range cfg.IdentRunes {
+		if unicode.IsSpace(ch) {
+			fail("identifier rune %q is a space", ch)
+		}
+	}
+
+	for _, style := range cfg.Comments {
+		start := []rune(style.Start)
+		switch {
+		case len(start) == 0:
+			fail("comment style %q has no start", style.String())
+		case quotes[start[0]]:
+			fail("comment start %q begins with a quote rune, so strings "+
+				"starting with it would be read as comments", style.Start)
+		}
+	}
+
+	for _, word := range cfg.Keywords {
+		for i, ch := range word {
+			if !is_ident(ch, i, nil) {
+				fail("keyword %q is not an identifier", word)
+				break
+			}
+		}
+	}
+
+	for _, op := range cfg.Operators {
+		for _, ch := range op {
+			if quotes[ch] {
+				fail("operator %q has quote rune %q", op, ch)
+				break
+			}
+			if !is_symbol(ch, 0, nil) {
+				fail("operator %q has rune %q, which is not a symbol rune",
+					op, ch)
+				break
+			}
+		}
+	}
+
+	for _, pair := range cfg.Brackets {
+		runes := []rune(pair)
+		switch {
+		case len(runes) != 2:
+			fail("bracket pair %q is not two runes", pair)
+		case runes[0] == runes[1]:
+			fail("bracket pair %q opens and closes with the same rune", pair)
+		case quotes[runes[0]] || quotes[runes[1]]:
+			fail("bracket pair %q has a quote rune", pair)
+		case !is_symbol(runes[0], 0, nil) || !is_symbol(runes[1], 0, nil):
+			fail("bracket pair %q has a rune that is not a symbol rune", pair)
+		}
+	}
+
+	if cfg.DisableNumbers && cfg.Numbers != 0 {
+		fail("number syntax %s is set, but numbers are disabled",
+			cfg.Numbers)
+	}
+
+	if l := cfg.NumberLocale; l.Group != 0 && l.Group == l.decimal() {
+		fail("number locale uses %q both to group digits and as the "+
+			"decimal separator", l.Group)
+	}
+	for _, ch := range []rune{cfg.NumberLocale.Group,
+		cfg.NumberLocale.Decimal} {
+		if unicode.IsDigit(ch) || quotes[ch] {
+			fail("number locale separator %q is a digit or quote rune", ch)
+		}
+	}
+
+	for _, suffix := range cfg.NumberSuffixes {
+		ch, _ := utf8.DecodeRuneInString(suffix)
+		switch {
+		case suffix == "":
+			fail("number suffix is empty")
+		case unicode.IsDigit(ch) || ch == '.' || ch == '_':
+			fail("number suffix %q starts with a rune that continues "+
+				"numbers", suffix)
+		}
+	}
+
+	for _, p := range cfg.StringPrefixes {
+		switch {
+		case p.Prefix == "":
+			fail("string prefix is empty")
+		case strings.ContainsFunc(p.Prefix, func(ch rune) bool {
+			return quotes[ch]
+		}):
+			fail("string prefix %q contains a quote rune", p.Prefix)
+		}
+	}
+
+	for _, sep := range cfg.NamespaceSeparators {
+		ch, _ := utf8.DecodeRuneInString(sep)
+		switch {
+		case sep == "":
+			fail("namespace separator is empty")
+		case is_ident(ch, 1, nil):
+			fail("namespace separator %q starts with an identifier rune",
+				sep)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Configures the scanner according to the configuration, if it is valid
+// (see Validate()). Otherwise, returns the error, leaving the scanner as it
+// was. Every setting of the configuration replaces the scanner's, so a
+// setting left at its zero value restores the default of NewScanner()
+// rather than keeping what the scanner was configured with before.
+func (cfg *ScannerConfig) Apply(ts *TokenScanner) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	ts.IsQuoteRune = IsQuoteRune
+	if cfg.Quotes != nil {
+		pairs := cfg.quote_pairs()
+		ts.IsQuoteRune = func(ch rune) (bool, rune) {
+			for _, q := range pairs {
+				if ch == q.Open {
+					return true, q.Close
+				}
+			}
+			return false, 0
+		}
+	}
+
+	ts.IsEscapeRune = IsEscapeRune
+	if esc := cfg.Escape; esc != 0 {
+		ts.IsEscapeRune = func(ch rune, i int, runes []rune) bool {
+			return ch == esc
+		}
+	}
+	ts.CollapseEscapes = cfg.CollapseEscapes
+	ts.DoubledQuotes = cfg.DoubledQuotes
+	ts.ConcatStrings = cfg.ConcatStrings
+
+	if cfg.Comments != nil {
+		ts.SetComments(cfg.Comments...)
+	} else {
+		ts.SetComments(DefaultCommentStyles...)
+	}
+	ts.IsIdentRune = cfg.ident_rune()
+	ts.IsSymbolRune = cfg.symbol_rune()
+
+	ts.SetKeywords(cfg.Keywords...)
+	ts.SetOperators(cfg.Operators...)
+	ts.SetBrackets(cfg.Brackets...)
+	ts.Numbers = cfg.Numbers
+	ts.DisableNumbers = cfg.DisableNumbers
+	ts.SetNumberSuffixes(cfg.NumberSuffixes...)
+	ts.SetStringPrefixes(cfg.StringPrefixes...)
+	ts.SetNamespaceSeparators(cfg.NamespaceSeparators...)
+	ts.NumberLocale = cfg.NumberLocale
+
+	return nil
+}
+
+// Returns a new TokenScanner reading from r, configured according to the
+// configuration, or an error if it is not valid (see Validate()).
+func (cfg *ScannerConfig) NewScanner(r io.Reader) (*TokenScanner, error) {
+	ts := NewScanner(r)
+	if err := cfg.Apply(ts); err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// The quote pairs accepted by IsQuoteRune(), the default.
+var default_quote_pairs = probe_quote_pairs(IsQuoteRune)
+
+// Returns the quote pairs accepted by the predicate, as far as probing the
+// runes up to probe_limit shows.
+func probe_quote_pairs(is_quote func(ch rune) (bool, rune)) []QuotePair {
+	var pairs []QuotePair
+	for ch := rune(1); ch < probe_limit; ch++ {
+		if ok, closing := is_quote(ch); ok {
+			pairs = append(pairs, QuotePair{ch, closing})
+		}
+	}
+
+	return pairs
+}
+
+// Returns the quote pairs in effect.
+func (cfg *ScannerConfig) quote_pairs() []QuotePair {
+	if cfg.Quotes != nil {
+		return cfg.Quotes
+	}
+
+	return default_quote_pairs
+}
+
+// Returns the identifier predicate in effect.
+func (cfg *ScannerConfig) ident_rune() predicate_func {
+	is_ident := IsIdentRune
+	if cfg.UAX31Identifiers {
+		is_ident = IsIdentRuneUAX31
+	}
+
+	extra := cfg.IdentRunes
+	if extra == "" {
+		return is_ident
+	}
+
+	return func(ch rune, i int, runes []rune) bool {
+		return is_ident(ch, i, runes) || strings.ContainsRune(extra, ch)
+	}
+}
+
+// Returns the symbol predicate in effect. Without SymbolRunes, it is that
+// of IsSymbolRune(), but for the quote runes configured.
+func (cfg *ScannerConfig) symbol_rune() predicate_func {
+	if symbols := cfg.SymbolRunes; symbols != "" {
+		return func(ch rune, i int, runes []rune) bool {
+			return i == 0 && strings.ContainsRune(symbols, ch)
+		}
+	}
+
+	if cfg.Quotes == nil {
+		return IsSymbolRune
+	}
+
+	quotes := cfg.Quotes
+	return func(ch rune, i int, runes []rune) bool {
+		if i > 0 {
+			return false
+		}
+		for _, q := range quotes {
+			if ch == q.Open {
+				return false
+			}
+		}
+		return unicode.IsSymbol(ch) || unicode.IsPunct(ch)
+	}
+}
@@ -0,0 +1,230 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestScannerConfig(t *testing.T) {
+	cfg := &textparser.ScannerConfig{
+		Quotes:     []textparser.QuotePair{{'«', '»'}, {'"', '"'}},
+		Comments:   []textparser.CommentStyle{{Start: "#"}},
+		Keywords:   []string{"let", "in"},
+		IdentRunes: "-",
+		Operators:  []string{"=>"},
+		Brackets:   []string{"()"},
+		Numbers:    textparser.NumberPrefixes,
+	}
+
+	p, err := cfg.NewScanner(strings.NewReader(
+		"let x-y = «a b» in (0x1F => 'q') # done\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.Token().Type.String()+" "+p.TokenText())
+	}
+
+	expected := []string{"Keyword let", "Ident x-y", "Symbol =",
+		"String «a b»", "Keyword in", "Symbol (", "Int 0x1F", "Symbol =>",
+		"Symbol '", "Ident q", "Symbol '", "Symbol )"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestScannerConfigApplyResets(t *testing.T) {
+	input := "let x-y = «a b» 'c' # done\n"
+	scan := func(p *textparser.TokenScanner) []string {
+		var got []string
+		for p.Scan() {
+			got = append(got, p.Token().Type.String()+" "+p.TokenText())
+		}
+		return got
+	}
+
+	cfg := &textparser.ScannerConfig{
+		Quotes:      []textparser.QuotePair{{'«', '»'}},
+		Escape:      '%',
+		Comments:    []textparser.CommentStyle{{Start: "//"}},
+		Keywords:    []string{"let"},
+		IdentRunes:  "-",
+		SymbolRunes: "=#'",
+	}
+	p := textparser.NewScannerString(input)
+	if err := cfg.Apply(p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := new(textparser.ScannerConfig).Apply(p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := scan(p)
+	expected := scan(textparser.NewScannerString(input))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestScannerConfigValidate(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Config   textparser.ScannerConfig
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{Name: "zero value"},
+		{
+			Name: "quote is a symbol",
+			Config: textparser.ScannerConfig{
+				Quotes:      []textparser.QuotePair{{'|', '|'}},
+				SymbolRunes: "|+-",
+			},
+			Expected: []string{"quote rune '|' is also a symbol rune"},
+		},
+		{
+			Name: "default quote is an operator rune",
+			Config: textparser.ScannerConfig{
+				Operators: []string{"`+"},
+			},
+			Expected: []string{"operator \"`+\" has quote rune '`'"},
+		},
+		{
+			Name: "quote is an identifier rune",
+			Config: textparser.ScannerConfig{
+				Quotes: []textparser.QuotePair{{'q', 'q'}},
+			},
+			Expected: []string{
+				"quote rune 'q' is also a space or identifier rune",
+			},
+		},
+		{
+			Name: "comment starts with a quote",
+			Config: textparser.ScannerConfig{
+				Comments: []textparser.CommentStyle{{Start: `"""`}},
+			},
+			Expected: []string{`comment start "\"\"\"" begins with a ` +
+				`quote rune, so strings starting with it would be read ` +
+				`as comments`},
+		},
+		{
+			Name: "bad keywords, operators, and brackets",
+			Config: textparser.ScannerConfig{
+				Keywords:  []string{"ok", "not-ok"},
+				Operators: []string{"->", "a+"},
+				Brackets:  []string{"()", "|", "||", "<'"},
+				Escape:    '"',
+			},
+			Expected: []string{
+				`escape rune '"' is also a quote rune`,
+				`keyword "not-ok" is not an identifier`,
+				`operator "a+" has rune 'a', which is not a symbol rune`,
+				`bracket pair "|" is not two runes`,
+				`bracket pair "||" opens and closes with the same rune`,
+				`bracket pair "<'" has a quote rune`,
+			},
+		},
+		{
+			Name: "overlapping rune sets",
+			Config: textparser.ScannerConfig{
+				IdentRunes:  "-$ ",
+				SymbolRunes: "$+",
+			},
+			Expected: []string{
+				"rune '$' is both an identifier and a symbol rune",
+				"identifier rune ' ' is a space",
+			},
+		},
+		{
+			Name: "number syntax with numbers disabled",
+			Config: textparser.ScannerConfig{
+				Numbers:        textparser.NumberExponent,
+				DisableNumbers: true,
+			},
+			Expected: []string{
+				"number syntax Exponent is set, but numbers are disabled",
+			},
+		},
+		{
+			Name: "bad number suffixes",
+			Config: textparser.ScannerConfig{
+				NumberSuffixes: []string{"u8", "", "8u", ".f"},
+			},
+			Expected: []string{
+				"number suffix is empty",
+				`number suffix "8u" starts with a rune that continues ` +
+					`numbers`,
+				`number suffix ".f" starts with a rune that continues ` +
+					`numbers`,
+			},
+		},
+		{
+			Name: "bad string prefixes",
+			Config: textparser.ScannerConfig{
+				StringPrefixes: []textparser.StringPrefix{{Prefix: "r"},
+					{Prefix: ""}, {Prefix: "a'"}},
+			},
+			Expected: []string{
+				"string prefix is empty",
+				`string prefix "a'" contains a quote rune`,
+			},
+		},
+		{
+			Name: "bad namespace separators",
+			Config: textparser.ScannerConfig{
+				NamespaceSeparators: []string{"::", "", "x:"},
+			},
+			Expected: []string{
+				"namespace separator is empty",
+				`namespace separator "x:" starts with an identifier rune`,
+			},
+		},
+		{
+			Name: "bad number locales",
+			Config: textparser.ScannerConfig{
+				NumberLocale: textparser.NumberLocale{Group: '.'},
+			},
+			Expected: []string{
+				"number locale uses '.' both to group digits and as the " +
+					"decimal separator",
+			},
+		},
+		{
+			Name: "number locale with a quote",
+			Config: textparser.ScannerConfig{
+				NumberLocale: textparser.NumberLocale{Group: '\'',
+					Decimal: '7'},
+			},
+			Expected: []string{
+				`number locale separator '\'' is a digit or quote rune`,
+				`number locale separator '7' is a digit or quote rune`,
+			},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			err := test_data.Config.Validate()
+
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+
+			p, err := test_data.Config.NewScanner(strings.NewReader(""))
+			if (p == nil) != (len(test_data.Expected) > 0) {
+				st.Errorf("got scanner %v and error %v", p, err)
+			}
+		})
+	}
+}
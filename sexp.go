@@ -0,0 +1,137 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A node of an s-expression: an atom, or a list of nodes enclosed in
+// brackets.
+type Sexp struct {
+	// The atom, or the opening bracket of a list.
+	Token *ScannedToken
+
+	// The closing bracket of a list, or nil for an atom.
+	Close *ScannedToken
+
+	// The elements of a list.
+	List []*Sexp
+}
+
+// Returns true if the node is a list rather than an atom.
+func (s *Sexp) IsList() bool {
+	return s.Close != nil
+}
+
+// Returns the node as text, with lists in their brackets and elements
+// separated by single spaces, e.g., "(a [b c])".
+func (s *Sexp) String() string {
+	if !s.IsList() {
+		return s.Token.Text
+	}
+
+	items := make([]string, 0, len(s.List))
+	for _, item := range s.List {
+		items = append(items, item.String())
+	}
+
+	return s.Token.Text + strings.Join(items, " ") + s.Close.Text
+}
+
+// Scans all remaining tokens from the scanner, e.g., one set up by
+// ProfileSexp(), and returns the top-level nodes, nesting the tokens between
+// each pair of brackets (see SetBrackets()) in a list. All other tokens are
+// atoms, including prefix symbols such as the quote "'", which callers may
+// combine with the node that follows. Unbalanced or mismatched brackets
+// result in a ScanError with CodeUnbalancedBracket.
+func ParseSexp(ts *TokenScanner) ([]*Sexp, error) {
+	tokens, err := TokenizeAll(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lists being filled in, innermost last. The first is a stand-in
+	// for the top level.
+	stack := []*Sexp{{}}
+
+	for _, token := range tokens {
+		top := stack[len(stack)-1]
+
+		switch token.Bracket {
+		case BracketOpen:
+			list := &Sexp{Token: token}
+			top.List = append(top.List, list)
+			stack = append(stack, list)
+
+		case BracketClose:
+			if len(stack) == 1 {
+				return nil, &ScanError{
+					Msg:   fmt.Sprintf("Unexpected %q", token.Text),
+					Start: token.Start,
+					End:   token.Start,
+					Code:  CodeUnbalancedBracket,
+				}
+			}
+
+			want, _ := ts.MatchingBracket(top.Token.FirstRune)
+			if token.FirstRune != want {
+				return nil, &ScanError{
+					Msg: fmt.Sprintf("Mismatched %q", token.Text),
+					Detail: fmt.Sprintf("Expected %q to close %q opened at %s.",
+						want, top.Token.FirstRune, &top.Token.Start),
+					Start: token.Start,
+					End:   token.Start,
+					Code:  CodeUnbalancedBracket,
+				}
+			}
+
+			top.Close = token
+			stack = stack[:len(stack)-1]
+
+		default:
+			top.List = append(top.List, &Sexp{Token: token})
+		}
+	}
+
+	if len(stack) > 1 {
+		open := stack[len(stack)-1].Token
+		want, _ := ts.MatchingBracket(open.FirstRune)
+		return nil, &ScanError{
+			Msg:    "Unterminated list",
+			Detail: fmt.Sprintf("Couldn't find closing bracket (%c).", want),
+			Start:  open.Start,
+			End:    *ts.EndPosition(),
+			Err:    io.EOF,
+			Code:   CodeUnbalancedBracket,
+		}
+	}
+
+	return stack[0].List, nil
+}
@@ -0,0 +1,86 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestProfileSexp(t *testing.T) {
+	input := "(defn list->vec [xs] ; convert\n" +
+		"  {:a -1, :b 2.5e3} (+ *n* -x) '(\"s\\\"\" ~@y))"
+	p := textparser.ProfileSexp().NewScanner(strings.NewReader(input))
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Type.String()+" "+token.Text)
+	}
+
+	expected := []string{"Symbol (", "Ident defn", "Ident list->vec",
+		"Symbol [", "Ident xs", "Symbol ]", "Symbol {", "Ident :a",
+		"Int -1", "Ident :b", "Float 2.5e3", "Symbol }", "Symbol (",
+		"Symbol +", "Ident *n*", "Symbol -x", "Symbol )", "Symbol '",
+		"Symbol (", `String "s""`, "Symbol ~@", "Ident y", "Symbol )",
+		"Symbol )"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestParseSexp(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+		Err      string
+	}
+
+	test_list := []TestData{
+		{Name: "nested", Input: "(a (b [c d]) {}) e",
+			Expected: []string{"(a (b [c d]) {})", "e"}},
+		{Name: "empty", Input: " ; nothing", Expected: nil},
+		{Name: "unexpected", Input: "(a))",
+			Err: `Unexpected ")" at :1:4 (3).`},
+		{Name: "mismatched", Input: "(a [b)]",
+			Err: `Mismatched ")" at :1:6 (5). Expected ']' to close '[' ` +
+				`opened at :1:4 (3).`},
+		{Name: "unterminated", Input: "(a\n (b)",
+			Err: `Unterminated list opened at :1:1 (0), unterminated at EOF ` +
+				`:2:5 (7). Couldn't find closing bracket ()).`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.ProfileSexp().NewScanner(
+				strings.NewReader(test_data.Input))
+			nodes, err := textparser.ParseSexp(p)
+
+			if test_data.Err != "" {
+				if err == nil || err.Error() != test_data.Err {
+					st.Errorf("got error %v, expected %s", err, test_data.Err)
+				}
+				if code := textparser.ErrorCodeOf(err); code !=
+					textparser.CodeUnbalancedBracket {
+					st.Errorf("got code %s, expected %s", code,
+						textparser.CodeUnbalancedBracket)
+				}
+				return
+			}
+
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, node := range nodes {
+				got = append(got, node.String())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+)
+
+// Runes of shell control and redirection operators.
+const shell_operator_runes = "|&;<>()"
+
+// Splits a shell command line into words the way a POSIX shell would, using
+// ProfileShell(), without running a shell. Quotes and backslash escapes are
+// removed following the shell's rules, and adjacent quoted and unquoted parts
+// form a single word, e.g., --name="a b" is the word "--name=a b". Control
+// and redirection operators, e.g., "|", "&&", and ">>", are separate words.
+// Comments are dropped. Nothing is expanded: parameters, e.g., "$HOME",
+// command substitutions, e.g., "$(pwd)" or "`pwd`", and globs are kept
+// verbatim. Returns a ScanError for an unterminated quoted string.
+func SplitShellWords(line string) ([]string, error) {
+	ts := ProfileShell().NewScanner(strings.NewReader(line))
+	tokens, err := TokenizeAll(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		words    []string
+		word     strings.Builder
+		in_word  bool
+		depth    int // Nesting of "$(" command substitutions.
+		prev_end int
+	)
+
+	flush := func() {
+		if in_word {
+			words = append(words, word.String())
+			word.Reset()
+			in_word = false
+		}
+	}
+
+	for _, token := range tokens {
+		start, end := token.Start.Offset, token.End.Offset
+		src := line[start:end]
+
+		if depth > 0 {
+			// Keep command substitutions verbatim, including spaces.
+			word.WriteString(line[prev_end:end])
+			prev_end = end
+			switch src {
+			case "$(", "(":
+				depth++
+			case ")":
+				depth--
+			}
+			continue
+		}
+
+		if start != prev_end {
+			flush()
+		}
+		prev_end = end
+
+		switch {
+		case token.Type == TokenTypeSymbol && is_shell_operator(src):
+			flush()
+			words = append(words, src)
+			continue
+		case src == "$(":
+			depth = 1
+			word.WriteString(src)
+		case token.Type == TokenTypeString:
+			word.WriteString(shell_unquote(src))
+		default:
+			word.WriteString(shell_unescape(src))
+		}
+		in_word = true
+	}
+
+	flush()
+
+	return words, nil
+}
+
+// Returns true if the symbol is a control or redirection operator.
+func is_shell_operator(s string) bool {
+	for _, ch := range s {
+		if !strings.ContainsRune(shell_operator_runes, ch) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// Returns the contents of a quoted string. Single-quoted strings have no
+// escapes. In double-quoted strings, a backslash only escapes "$", "`", '"',
+// a backslash, or an end of line (which is removed). Back-quoted strings are
+// command substitutions and are returned as is.
+func shell_unquote(s string) string {
+	switch s[0] {
+	case '\'':
+		return s[1 : len(s)-1]
+	case '`':
+		return s
+	}
+
+	s = s[1 : len(s)-1]
+	b := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '$', '`', '"', '\\':
+				i++
+			case '\n':
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// Removes backslash escapes from an unquoted word. An escaped end of line is
+// removed along with the backslash.
+func shell_unescape(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+
+	b := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"simple", "ls -la  /tmp", []string{"ls", "-la", "/tmp"}},
+		{"quotes", `echo 'a b' "c \"d\" \$e \x"`,
+			[]string{"echo", "a b", `c "d" $e \x`}},
+		{"adjacent parts", `--name="a b"'c'd x''`,
+			[]string{"--name=a bcd", "x"}},
+		{"empty", `a '' ""`, []string{"a", "", ""}},
+		{"escapes", "a\\ b c\\\\ d\\\ne \\|",
+			[]string{"a b", `c\`, "de", "|"}},
+		{"operators", "a|b && c >>out 2>&1; d",
+			[]string{"a", "|", "b", "&&", "c", ">>", "out", "2", ">&",
+				"1", ";", "d"}},
+		{"comments", "a # b c\nd e#f",
+			[]string{"a", "d", "e#f"}},
+		{"expansions", "echo $HOME ${x}y $(ls -l | wc) `pwd` *.go",
+			[]string{"echo", "$HOME", "${x}y", "$(ls -l | wc)", "`pwd`",
+				"*.go"}},
+		{"nested substitution", "x=$(a $(b) (c)) d",
+			[]string{"x=$(a $(b) (c))", "d"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			got, err := textparser.SplitShellWords(test_data.Input)
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+
+	_, err := textparser.SplitShellWords(`echo "a`)
+	if code := textparser.ErrorCodeOf(err); code !=
+		textparser.CodeUnterminatedString {
+		t.Errorf("got %s (%v), expected %s", code, err,
+			textparser.CodeUnterminatedString)
+	}
+}
@@ -0,0 +1,161 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// Controls whether a sign followed by a digit, e.g., "-4", is scanned as part
+// of a number or as a symbol token of its own.
+type SignMode int
+
+const (
+	// A minus sign directly followed by a digit starts a number, e.g., "-4"
+	// and "+-4" is "+", "-4", as does a plus sign if AllowLeadingPlus is
+	// set. This is the default.
+	SignNumber SignMode = iota
+
+	// Plus and minus signs are always symbol tokens of their own, and never
+	// part of a number or of a run of signs, e.g., "+-4" is "+", "-", "4",
+	// and "--x" is "-", "-", "x", even if IsSymbolRune would group them.
+	// Operators made of signs only, e.g., "--", are skipped when matching
+	// those set with SetOperators(). Operators and symbols mixing signs with
+	// other runes, e.g., "->" or "+=", are still recognized. This suits
+	// expression parsers, which handle unary operators themselves.
+	SignSymbol
+
+	// Like SignSymbol, except that a minus sign (or, with
+	// AllowLeadingPlus, a plus sign) directly followed by a digit starts a
+	// number where an operand is expected, judging by the previous token
+	// other than white space and comments: at the start of the input, or
+	// after a symbol other than a sign or a closing bracket. E.g., "a-4" is
+	// "a", "-", "4", "(-4)" and "x = -4" contain "-4", and "+-4" is "+",
+	// "-", "4".
+	SignContext
+)
+
+// Returns a string representation of the sign mode.
+func (m SignMode) String() string {
+	switch m {
+	case SignNumber:
+		return "Number"
+	case SignSymbol:
+		return "Symbol"
+	case SignContext:
+		return "Context"
+	}
+
+	return ""
+}
+
+// The previous token other than white space and comments, for SignContext.
+type token_context struct {
+	valid   bool
+	kind    TokenType
+	first   rune
+	single  bool // The token is a single rune.
+	bracket BracketKind
+}
+
+// Records the token as the previous one for SignContext, unless it is white
+// space or a comment.
+func (ts *TokenScanner) note_context(class rune_class, token *Token) {
+	switch class {
+	case class_whitespace, class_comment, class_directive:
+		return
+	}
+
+	ts.context = token_context{
+		valid:   true,
+		kind:    token.Type,
+		first:   token.FirstRune,
+		single:  token.NumChars == 1,
+		bracket: token.Bracket,
+	}
+	ts.note_scan_context(token)
+}
+
+// Returns true if a minus sign at the current position may start a number.
+func (ts *TokenScanner) sign_starts_number() bool {
+	switch ts.Signs {
+	case SignSymbol:
+		return false
+	case SignContext:
+		c := &ts.context
+		if !c.valid {
+			return true
+		}
+		if c.kind != TokenTypeSymbol || c.bracket == BracketClose {
+			return false
+		}
+		if c.single && (is_sign(c.first) || c.first == ')' ||
+			c.first == ']' || c.first == '}') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func is_sign(ch rune) bool {
+	return ch == '+' || ch == '-'
+}
+
+// Returns true if the sign may start a number: a minus sign, or a plus sign
+// if AllowLeadingPlus is set.
+func (ts *TokenScanner) is_number_sign(ch rune) bool {
+	return ch == '-' || (ch == '+' && ts.AllowLeadingPlus)
+}
+
+// Returns true if the runes are all signs.
+func is_sign_run(runes []rune) bool {
+	for _, ch := range runes {
+		if !is_sign(ch) {
+			return false
+		}
+	}
+
+	return len(runes) > 0
+}
+
+// Reads a sign as a symbol token of its own, unless Signs is SignNumber.
+// Returns a nil token if the next rune is not a sign.
+func (ts *TokenScanner) get_sign() (*Token, error) {
+	if ts.Signs == SignNumber {
+		return nil, nil
+	}
+
+	runes := ts.peek_upto(2)
+	if len(runes) == 0 || !is_sign(runes[0]) {
+		return nil, nil
+	}
+
+	// Leave symbols such as "+=" to IsSymbolRune.
+	if len(runes) == 2 && !is_sign(runes[1]) &&
+		ts.IsSymbolRune(runes[1], 1, runes[:1]) {
+		return nil, nil
+	}
+
+	return ts.get_single_symbol(BracketNone)
+}
@@ -0,0 +1,146 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSigns(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Mode     textparser.SignMode
+		Input    string
+		Expected []string
+	}
+
+	input := "+-4 --x a-4 (-4) x = -4 y+=-2"
+
+	test_list := []TestData{
+		{"number", textparser.SignNumber, input,
+			[]string{"+", "-4", "--", "x", "a", "-4", "(", "-4", ")", "x",
+				"=", "-4", "y", "+=", "-2"}},
+		{"symbol", textparser.SignSymbol, input,
+			[]string{"+", "-", "4", "-", "-", "x", "a", "-", "4", "(", "-",
+				"4", ")", "x", "=", "-", "4", "y", "+=", "-", "2"}},
+		{"context", textparser.SignContext, input,
+			[]string{"+", "-", "4", "-", "-", "x", "a", "-", "4", "(", "-4",
+				")", "x", "=", "-4", "y", "+=", "-2"}},
+		{"symbol regardless of spacing", textparser.SignSymbol,
+			"a -1 a - 1 a-1", []string{"a", "-", "1", "a", "-", "1", "a",
+				"-", "1"}},
+		{"context at start", textparser.SignContext, "-4 - 1 /* c */ -2",
+			[]string{"-4", "-", "1", "-", "2"}},
+		{"context after closing bracket", textparser.SignContext,
+			"(a)-1 [b]-2", []string{"(", "a", ")", "-", "1", "[", "b", "]",
+				"-", "2"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.Signs = test_data.Mode
+			p.SetOperators("--", "+=")
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSignsSymbolPredicate(t *testing.T) {
+	p := textparser.NewScannerString("a += +-4")
+	p.Signs = textparser.SignSymbol
+	p.IsSymbolRune = func(ch rune, i int, runes []rune) bool {
+		if ch == '=' && i == 1 && runes[0] == '+' {
+			return true
+		}
+		return textparser.IsSymbolRune(ch, i, runes)
+	}
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"a", "+=", "+", "-", "4"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestSignsSymbolOperators(t *testing.T) {
+	p := textparser.NewScannerString("a--b ++c->d -= -4")
+	p.Signs = textparser.SignSymbol
+	p.SetOperators("--", "++", "->", "-=")
+
+	var got []string
+	for _, token := range scan_all(t, p) {
+		got = append(got, token.Text)
+	}
+
+	expected := []string{"a", "-", "-", "b", "+", "+", "c", "->", "d", "-=",
+		"-", "4"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestAllowLeadingPlus(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Mode     textparser.SignMode
+		Plus     bool
+		Input    string
+		Expected []string
+	}
+
+	input := "+42 +1.5 x = +7 a+4 ++4 + 4 +.5"
+
+	test_list := []TestData{
+		{"off", textparser.SignNumber, false, input,
+			[]string{"Symbol +", "Int 42", "Symbol +", "Float 1.5",
+				"Ident x", "Symbol =", "Symbol +", "Int 7", "Ident a",
+				"Symbol +", "Int 4", "Symbol +", "Symbol +", "Int 4",
+				"Symbol +", "Int 4", "Symbol +", "Symbol .", "Int 5"}},
+		{"number", textparser.SignNumber, true, input,
+			[]string{"Int +42", "Float +1.5", "Ident x", "Symbol =",
+				"Int +7", "Ident a", "Int +4", "Symbol +", "Int +4",
+				"Symbol +", "Int 4", "Symbol +", "Symbol .", "Int 5"}},
+		{"context", textparser.SignContext, true, input,
+			[]string{"Int +42", "Symbol +", "Float 1.5", "Ident x",
+				"Symbol =", "Int +7", "Ident a", "Symbol +", "Int 4",
+				"Symbol +",
+				"Symbol +", "Int 4", "Symbol +", "Int 4", "Symbol +",
+				"Symbol .", "Int 5"}},
+		{"symbol", textparser.SignSymbol, true, "+42",
+			[]string{"Symbol +", "Int 42"}},
+		{"prefixed", textparser.SignNumber, true, "+0x1F",
+			[]string{"Int +0x1F"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.Signs = test_data.Mode
+			p.AllowLeadingPlus = test_data.Plus
+			p.Numbers = textparser.NumberPrefixes
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import "sort"
+
+// Sets the token types to skip, e.g., TokenTypeNewline or a custom type
+// (see TokenMatcher), replacing any set before. Tokens of these types are
+// still read, but Scan() does not return them, and they do not count toward
+// ScanLimit(). This is in addition to SkipWhitespace and SkipComments, which
+// also cover line continuations and line directives. Call with no types to
+// switch this off.
+func (ts *TokenScanner) SkipTypes(types ...TokenType) {
+	ts.skip_types = nil
+	for _, token_type := range types {
+		if ts.skip_types == nil {
+			ts.skip_types = make(map[TokenType]bool, len(types))
+		}
+		ts.skip_types[token_type] = true
+	}
+}
+
+// Returns the token types set with SkipTypes(), sorted.
+func (ts *TokenScanner) SkippedTypes() []TokenType {
+	types := make([]TokenType, 0, len(ts.skip_types))
+	for token_type := range ts.skip_types {
+		types = append(types, token_type)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+// Returns true if tokens of the type should be skipped.
+func (ts *TokenScanner) skip_type(token_type TokenType) bool {
+	return len(ts.skip_types) > 0 && ts.skip_types[token_type]
+}
+
+// Returns the tokens whose types are not skipped, in place.
+func (ts *TokenScanner) unskipped(tokens []*ScannedToken) []*ScannedToken {
+	if len(ts.skip_types) == 0 {
+		return tokens
+	}
+
+	kept := tokens[:0]
+	for _, st := range tokens {
+		if !ts.skip_type(st.Token.Type) {
+			kept = append(kept, st)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,126 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSkipTypes(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Types    []textparser.TokenType
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "none",
+			Input:    "a, b",
+			Expected: []string{"Ident a", "Symbol ,", "Ident b"},
+		},
+		{
+			Name:     "symbols",
+			Input:    "a, b; c",
+			Types:    []textparser.TokenType{textparser.TokenTypeSymbol},
+			Expected: []string{"Ident a", "Ident b", "Ident c"},
+		},
+		{
+			Name:  "custom type",
+			Input: "see ABC-12 now",
+			Types: []textparser.TokenType{textparser.TokenType(100)},
+			Setup: func(p *textparser.TokenScanner) {
+				p.AddRegexpMatcher(textparser.TokenType(100),
+					regexp.MustCompile(`[A-Z]+-[0-9]+`))
+			},
+			Expected: []string{"Ident see", "Ident now"},
+		},
+		{
+			Name:  "newlines and dedents",
+			Input: "a\n\tb\n\t\tc\nd\n",
+			Types: []textparser.TokenType{textparser.TokenTypeNewline,
+				textparser.TokenTypeDedent},
+			Setup: func(p *textparser.TokenScanner) {
+				p.SetIndentation(textparser.IndentTabs)
+			},
+			Expected: []string{"Ident a", "Indent \t", "Ident b",
+				"Indent \t\t", "Ident c", "Ident d"},
+		},
+		{
+			Name:  "token starting a line",
+			Input: "a\n\tb\nc\n",
+			Types: []textparser.TokenType{textparser.TokenTypeIdent},
+			Setup: func(p *textparser.TokenScanner) {
+				p.SetIndentation(textparser.IndentTabs)
+			},
+			Expected: []string{"Newline ", "Indent \t", "Newline ",
+				"Dedent ", "Newline "},
+		},
+		{
+			Name:  "whitespace kept",
+			Input: "a b",
+			Types: []textparser.TokenType{textparser.TokenTypeIdent},
+			Setup: func(p *textparser.TokenScanner) {
+				p.SkipWhitespace = false
+			},
+			Expected: []string{"Whitespace  "},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+			p.SkipTypes(test_data.Types...)
+
+			var got []string
+			for p.Scan() {
+				got = append(got,
+					p.Token().Type.String()+" "+p.TokenText())
+			}
+			if err := p.Err(); err != nil && err != io.EOF {
+				st.Errorf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSkippedTypes(t *testing.T) {
+	p := textparser.NewScannerString("a, b, c")
+	p.SkipTypes(textparser.TokenTypeSymbol, textparser.TokenTypeNewline,
+		textparser.TokenTypeSymbol)
+	p.ScanLimit(2, 0)
+
+	expected_types := []textparser.TokenType{textparser.TokenTypeSymbol,
+		textparser.TokenTypeNewline}
+	if got := p.SkippedTypes(); !reflect.DeepEqual(got, expected_types) {
+		t.Errorf("got %#v, expected %#v", got, expected_types)
+	}
+
+	// Skipped tokens don't count toward the limit.
+	var got []string
+	for p.Scan() {
+		got = append(got, p.TokenText())
+	}
+	expected := []string{"a", "b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	p.SkipTypes()
+	if got := p.SkippedTypes(); len(got) != 0 {
+		t.Errorf("got %#v, expected none", got)
+	}
+}
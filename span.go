@@ -0,0 +1,114 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// A span of source text, from the position of its first rune up to the
+// position just past its last rune.
+type Span struct {
+	Start Position // Position of the first rune.
+	End   Position // Position just past the last rune.
+}
+
+// Returns true if the byte offset falls within the span.
+func (s Span) Contains(offset int) bool {
+	return s.Start.Offset <= offset && offset < s.End.Offset
+}
+
+// Returns a string representation of the span.
+func (s Span) String() string {
+	return fmt.Sprintf("%s:%d:%d-%d:%d (%d-%d)", s.Start.Filename,
+		s.Start.Line, s.Start.Column, s.End.Line, s.End.Column,
+		s.Start.Offset, s.End.Offset)
+}
+
+// A Token along with the span of source it was scanned from.
+type ScannedToken struct {
+	*Token
+	Span
+}
+
+// Returns a string representation of the token and its span.
+func (st *ScannedToken) String() string {
+	return fmt.Sprintf("%s %s", st.Span, st.Token)
+}
+
+// Returns the position just past the most recent token generated by a call
+// to Scan(). Unlike Position(), the returned object is a copy.
+func (ts *TokenScanner) EndPosition() *Position {
+	pos := new(Position)
+	*pos = ts.end_pos
+
+	return pos
+}
+
+// Returns the most recent token generated by a call to Scan(), along with its
+// span. If ReuseToken or ByteTokens is set, the returned token is still only
+// valid until the next call to Scan().
+func (ts *TokenScanner) ScannedToken() *ScannedToken {
+	if ts.LastToken == nil {
+		return nil
+	}
+
+	return &ScannedToken{
+		Token: ts.LastToken,
+		Span:  Span{Start: *ts.pos, End: ts.end_pos},
+	}
+}
+
+// Returns ScannedToken(), with the token copied if the scanner reuses tokens
+// (see TokensReused()), so that it may be retained past the next call to
+// Scan().
+func (ts *TokenScanner) owned_token() *ScannedToken {
+	st := ts.ScannedToken()
+	if st != nil && ts.TokensReused() {
+		st.Token = st.Token.CloneMutable()
+	}
+
+	return st
+}
+
+// Scans all remaining tokens from the scanner and returns them along with
+// their spans. Reaching the end of the input is not considered an error. On
+// error, the tokens scanned so far are returned along with the error.
+func TokenizeAll(ts *TokenScanner) ([]*ScannedToken, error) {
+	tokens := make([]*ScannedToken, 0)
+
+	for ts.Scan() {
+		tokens = append(tokens, ts.owned_token())
+	}
+
+	if err := ts.Err(); err != nil && err != io.EOF {
+		return tokens, err
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,181 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeAllSpans(t *testing.T) {
+	p := textparser.NewScannerString("foo = 'bar\nbaz' + 42")
+	p.SetFilename("test_file")
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("error from scanner: %s", err)
+	}
+
+	expected := []textparser.Span{
+		{
+			Start: textparser.Position{Filename: "test_file",
+				Offset: 0, Line: 1, Column: 1},
+			End: textparser.Position{Filename: "test_file",
+				Offset: 3, Line: 1, Column: 4},
+		},
+		{
+			Start: textparser.Position{Filename: "test_file",
+				Offset: 4, Line: 1, Column: 5},
+			End: textparser.Position{Filename: "test_file",
+				Offset: 5, Line: 1, Column: 6},
+		},
+		{
+			Start: textparser.Position{Filename: "test_file",
+				Offset: 6, Line: 1, Column: 7},
+			End: textparser.Position{Filename: "test_file",
+				Offset: 15, Line: 2, Column: 5},
+		},
+		{
+			Start: textparser.Position{Filename: "test_file",
+				Offset: 16, Line: 2, Column: 6},
+			End: textparser.Position{Filename: "test_file",
+				Offset: 17, Line: 2, Column: 7},
+		},
+		{
+			Start: textparser.Position{Filename: "test_file",
+				Offset: 18, Line: 2, Column: 8},
+			End: textparser.Position{Filename: "test_file",
+				Offset: 20, Line: 2, Column: 10},
+		},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("got %d tokens, expected %d", len(tokens), len(expected))
+	}
+
+	for i, tok := range tokens {
+		if !reflect.DeepEqual(tok.Span, expected[i]) {
+			t.Errorf("token %q: got %s, expected %s", tok.Text, tok.Span,
+				expected[i])
+		}
+	}
+}
+
+func TestEndPositionUnread(t *testing.T) {
+	p := textparser.NewScannerString(`foo bar`)
+	p.Scan()
+	p.Scan()
+	p.UnreadToken()
+	p.Scan()
+
+	expected := &textparser.Position{Offset: 7, Line: 1, Column: 8}
+	if !reflect.DeepEqual(p.EndPosition(), expected) {
+		t.Errorf("got %s, expected %s", p.EndPosition(), expected)
+	}
+}
+
+func TestEndPositionEmptyInput(t *testing.T) {
+	p := textparser.NewScannerString("")
+	p.SetFilename("empty")
+
+	expected := &textparser.Position{Filename: "empty", Line: 1, Column: 1}
+	if !reflect.DeepEqual(p.EndPosition(), expected) {
+		t.Errorf("before Scan(): got %s, expected %s", p.EndPosition(),
+			expected)
+	}
+
+	if p.Scan() {
+		t.Fatalf("got token %s from empty input", p.Token())
+	}
+
+	if !reflect.DeepEqual(p.EndPosition(), expected) {
+		t.Errorf("after Scan(): got %s, expected %s", p.EndPosition(),
+			expected)
+	}
+	if !reflect.DeepEqual(p.EndPosition(), p.Position()) {
+		t.Errorf("got end %s, expected it to match %s", p.EndPosition(),
+			p.Position())
+	}
+}
+
+func TestSpanIndex(t *testing.T) {
+	input := "foo = 'bar\nbaz' + 42\n// comment\nqux(1, 2)"
+	p := textparser.NewScannerString(input)
+	p.SkipWhitespace = false
+	p.SkipComments = false
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("error from scanner: %s", err)
+	}
+
+	idx := textparser.NewSpanIndex(tokens)
+	if idx.Len() != len(tokens) {
+		t.Errorf("got %d tokens, expected %d", idx.Len(), len(tokens))
+	}
+
+	// Compare against a linear scan.
+	for start := 0; start <= len(input); start++ {
+		for end := start + 1; end <= len(input)+1; end++ {
+			var expected []*textparser.ScannedToken
+			for _, tok := range tokens {
+				if tok.Start.Offset < end && tok.End.Offset > start {
+					expected = append(expected, tok)
+				}
+			}
+
+			got := idx.Overlapping(start, end)
+			if len(got) != len(expected) ||
+				(len(got) > 0 && !reflect.DeepEqual(got, expected)) {
+				t.Errorf("[%d, %d): got %v, expected %v", start, end, got,
+					expected)
+			}
+		}
+	}
+
+	if got := idx.At(8); len(got) != 1 || got[0].Text != "'bar\nbaz'" {
+		t.Errorf("At(8): got %v", got)
+	}
+
+	if got := idx.AtLineColumn(2, 2); len(got) != 1 ||
+		got[0].Text != "'bar\nbaz'" {
+		t.Errorf("AtLineColumn(2, 2): got %v", got)
+	}
+
+	if got := idx.AtLineColumn(4, 4); len(got) != 1 || got[0].Text != "(" {
+		t.Errorf("AtLineColumn(4, 4): got %v", got)
+	}
+
+	if got := idx.At(len(input)); len(got) != 0 {
+		t.Errorf("At(%d): got %v, expected nothing", len(input), got)
+	}
+}
+
+func TestSpanIndexOverlapping(t *testing.T) {
+	span := func(start, end int) *textparser.ScannedToken {
+		return &textparser.ScannedToken{
+			Token: &textparser.Token{},
+			Span: textparser.Span{
+				Start: textparser.Position{Offset: start, Line: 1,
+					Column: start + 1},
+				End: textparser.Position{Offset: end, Line: 1,
+					Column: end + 1},
+			},
+		}
+	}
+
+	tokens := []*textparser.ScannedToken{span(10, 20), span(0, 100),
+		span(15, 16), span(30, 40)}
+	idx := textparser.NewSpanIndex(tokens)
+
+	got := idx.At(15)
+	expected := []*textparser.ScannedToken{tokens[1], tokens[0], tokens[2]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	got = idx.Overlapping(20, 30)
+	expected = []*textparser.ScannedToken{tokens[1]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
@@ -0,0 +1,192 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"sort"
+)
+
+// A point in either offset space ({offset, 0}) or line/column space ({line,
+// column}), compared lexicographically.
+type span_point [2]int
+
+func (p span_point) less(o span_point) bool {
+	if p[0] != o[0] {
+		return p[0] < o[0]
+	}
+	return p[1] < o[1]
+}
+
+func max_point(a, b span_point) span_point {
+	if a.less(b) {
+		return b
+	}
+	return a
+}
+
+type span_space struct {
+	starts  []span_point
+	ends    []span_point
+	max_end []span_point // Maximum end in each node of the tree.
+}
+
+// An index over the spans of a list of tokens, supporting lookups of the
+// tokens at a given offset or line and column, and of the tokens overlapping
+// a range of offsets. Lookups take O(log n + k) time for n tokens and k
+// results. The index is implemented as an interval tree, so the tokens may
+// overlap (e.g., after merging or synthesizing tokens), but they are expected
+// to come from a single source, so that ordering by offset and ordering by
+// line and column agree.
+type SpanIndex struct {
+	tokens []*ScannedToken
+	offset *span_space
+	lc     *span_space
+}
+
+// Returns a new SpanIndex over the provided tokens, such as those returned by
+// TokenizeAll(). The slice is not modified.
+func NewSpanIndex(tokens []*ScannedToken) *SpanIndex {
+	sorted := make([]*ScannedToken, len(tokens))
+	copy(sorted, tokens)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Offset < sorted[j].Start.Offset
+	})
+
+	idx := &SpanIndex{
+		tokens: sorted,
+		offset: new(span_space),
+		lc:     new(span_space),
+	}
+
+	for _, t := range sorted {
+		idx.offset.starts = append(idx.offset.starts,
+			span_point{t.Start.Offset, 0})
+		idx.offset.ends = append(idx.offset.ends,
+			span_point{t.End.Offset, 0})
+		idx.lc.starts = append(idx.lc.starts,
+			span_point{t.Start.Line, t.Start.Column})
+		idx.lc.ends = append(idx.lc.ends,
+			span_point{t.End.Line, t.End.Column})
+	}
+
+	for _, space := range []*span_space{idx.offset, idx.lc} {
+		if len(sorted) > 0 {
+			space.max_end = make([]span_point, 4*len(sorted))
+			space.build(1, 0, len(sorted))
+		}
+	}
+
+	return idx
+}
+
+func (s *span_space) build(node, lo, hi int) span_point {
+	if hi-lo == 1 {
+		s.max_end[node] = s.ends[lo]
+		return s.max_end[node]
+	}
+
+	mid := (lo + hi) / 2
+	s.max_end[node] = max_point(s.build(2*node, lo, mid),
+		s.build(2*node+1, mid, hi))
+
+	return s.max_end[node]
+}
+
+// Appends the indexes of tokens below hi in the given node whose spans end
+// after q_start, in order. Tokens at or above hi start too late to be of
+// interest.
+func (s *span_space) query(
+	node, node_lo, node_hi, hi int,
+	q_start span_point,
+	found []int,
+) []int {
+	if node_lo >= hi || !q_start.less(s.max_end[node]) {
+		return found
+	}
+
+	if node_hi-node_lo == 1 {
+		return append(found, node_lo)
+	}
+
+	mid := (node_lo + node_hi) / 2
+	found = s.query(2*node, node_lo, mid, hi, q_start, found)
+	return s.query(2*node+1, mid, node_hi, hi, q_start, found)
+}
+
+func (idx *SpanIndex) overlapping(
+	s *span_space,
+	q_start, q_end span_point,
+) []*ScannedToken {
+	n := len(idx.tokens)
+	if n == 0 {
+		return nil
+	}
+
+	// Only tokens starting before the end of the query range can overlap.
+	hi := sort.Search(n, func(i int) bool {
+		return !s.starts[i].less(q_end)
+	})
+
+	found := s.query(1, 0, n, hi, q_start, nil)
+
+	tokens := make([]*ScannedToken, 0, len(found))
+	for _, i := range found {
+		tokens = append(tokens, idx.tokens[i])
+	}
+
+	return tokens
+}
+
+// Returns the tokens whose spans contain the given byte offset, in order of
+// their starting offsets.
+func (idx *SpanIndex) At(offset int) []*ScannedToken {
+	return idx.Overlapping(offset, offset+1)
+}
+
+// Returns the tokens whose spans contain the given line and column, in order
+// of their starting offsets.
+func (idx *SpanIndex) AtLineColumn(line, column int) []*ScannedToken {
+	return idx.overlapping(idx.lc, span_point{line, column},
+		span_point{line, column + 1})
+}
+
+// Returns the tokens whose spans overlap the range of byte offsets [start,
+// end), in order of their starting offsets.
+func (idx *SpanIndex) Overlapping(start, end int) []*ScannedToken {
+	return idx.overlapping(idx.offset, span_point{start, 0},
+		span_point{end, 0})
+}
+
+// Returns the number of tokens in the index.
+func (idx *SpanIndex) Len() int {
+	return len(idx.tokens)
+}
+
+// Returns the indexed tokens, in order of their starting offsets.
+func (idx *SpanIndex) Tokens() []*ScannedToken {
+	return idx.tokens
+}
@@ -0,0 +1,63 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestProfileSQL(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"doubled quotes", `'it''s' "a""b" 'c'`,
+			[]string{"String 'it's'", `Ident "a"b"`, "String 'c'"}},
+		{"quoted identifiers", "[order] `group` \"x y\"",
+			[]string{"Ident [order]", "Ident `group`", `Ident "x y"`}},
+		{"parameters", "a = :name AND b IN (?, ?2, @p, $1)",
+			[]string{"Ident a", "Symbol =", "Parameter :name", "Ident AND",
+				"Ident b", "Ident IN", "Symbol (", "Parameter ?",
+				"Symbol ,", "Parameter ?2", "Symbol ,", "Parameter @p",
+				"Symbol ,", "Parameter $1", "Symbol )"}},
+		{"not parameters", "x::int : @ $",
+			[]string{"Ident x", "Symbol ::", "Ident int", "Symbol :",
+				"Symbol @", "Symbol $"}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.ProfileSQL().NewScanner(
+				strings.NewReader(test_data.Input))
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestIsSQLKeyword(t *testing.T) {
+	p := textparser.ProfileSQL().NewScanner(
+		strings.NewReader(`select "from" Where x selected`))
+
+	var got []bool
+	for _, token := range scan_all(t, p) {
+		got = append(got, textparser.IsSQLKeyword(token))
+	}
+
+	expected := []bool{true, false, true, false, false}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,115 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+)
+
+// Scans all remaining tokens from the scanner and splits them into
+// statements at each separator, e.g., ";", that is not nested in brackets
+// (see DefaultTreeBrackets). A separator of "\n" splits at line breaks
+// instead, including TokenTypeNewline tokens, except for lines joined by a
+// continuation (see SetLineContinuations()). Separators are symbol tokens
+// whose text is exactly sep, so those in strings and comments are ignored.
+// The separators themselves are dropped, as are statements made of nothing
+// but white space and comments. Brackets are only counted, not validated
+// (see CheckBalanced()). On error, the statements scanned so far are
+// returned along with the error.
+func SplitStatements(ts *TokenScanner, sep string) ([][]*ScannedToken, error) {
+	opening, closing := bracket_pairs(nil)
+
+	var (
+		statements [][]*ScannedToken
+		current    []*ScannedToken
+		prev       *ScannedToken
+		joined     int // Lines joined by the scanner before prev.
+		depth      int
+		blank      = true // Current has only white space and comments.
+	)
+
+	end_statement := func() {
+		if !blank {
+			statements = append(statements, current)
+		}
+		current, blank = nil, true
+	}
+
+	for ts.Scan() {
+		token := ts.owned_token()
+
+		if sep == "\n" && depth == 0 {
+			if token.Type == TokenTypeNewline {
+				end_statement()
+				prev, joined = token, ts.joined
+				continue
+			}
+
+			if prev != nil &&
+				token.Start.Line-last_line(prev) > ts.joined-joined {
+				end_statement()
+			}
+		}
+		prev, joined = token, ts.joined
+
+		if token.Type == TokenTypeSymbol {
+			switch {
+			case depth == 0 && token.Text == sep:
+				end_statement()
+				continue
+			case opening[token.Text] != "":
+				depth++
+			case closing[token.Text] != "" && depth > 0:
+				depth--
+			}
+		}
+
+		switch token.Type {
+		case TokenTypeWhitespace, TokenTypeComment:
+		default:
+			blank = false
+		}
+		current = append(current, token)
+	}
+	end_statement()
+
+	if err := ts.Err(); err != nil && err != io.EOF {
+		return statements, err
+	}
+
+	return statements, nil
+}
+
+// Returns the line of the last rune of the token, which is the line before
+// its end position if it ends with a line break, e.g., a line comment.
+func last_line(token *ScannedToken) int {
+	if token.End.Column == 1 && token.End.Line > token.Start.Line {
+		return token.End.Line - 1
+	}
+
+	return token.End.Line
+}
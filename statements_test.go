@@ -0,0 +1,83 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSplitStatements(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Sep      string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{Name: "semicolons", Sep: ";",
+			Input: `a = f(1; 2); s = ";" /* ; */; ;; { x; y } z`,
+			Expected: []string{"a = f ( 1 ; 2 )", `s = ";"`,
+				"{ x ; y } z"}},
+		{Name: "newlines", Sep: "\n",
+			Input:    "a = 1\nb = [1,\n  2]\n\n// c\nd",
+			Expected: []string{"a = 1", "b = [ 1 , 2 ]", "d"}},
+		{Name: "newlines with comments", Sep: "\n",
+			Input: "a // c\nb /* x\ny */ c\nd",
+			Setup: func(p *textparser.TokenScanner) {
+				p.SkipComments = false
+			},
+			Expected: []string{"a // c\n", "b /* x\ny */ c", "d"}},
+		{Name: "continuations", Sep: "\n",
+			Input: "cc -c \\\n  main.c\nld",
+			Setup: func(p *textparser.TokenScanner) {
+				p.SetLineContinuations(textparser.BackslashContinuation)
+			},
+			Expected: []string{"cc - c main . c", "ld"}},
+		{Name: "empty", Sep: ";", Input: " ; ", Expected: nil},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+
+			statements, err := textparser.SplitStatements(p, test_data.Sep)
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, statement := range statements {
+				var texts []string
+				for _, token := range statement {
+					texts = append(texts, token.Text)
+				}
+				got = append(got, strings.Join(texts, " "))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSplitStatementsError(t *testing.T) {
+	p := textparser.NewScannerString(`a; b "c`)
+	statements, err := textparser.SplitStatements(p, ";")
+
+	if code := textparser.ErrorCodeOf(err); code !=
+		textparser.CodeUnterminatedString {
+		t.Errorf("got code %s, expected %s", code,
+			textparser.CodeUnterminatedString)
+	}
+	if len(statements) != 2 {
+		t.Errorf("got %d statements, expected 2", len(statements))
+	}
+}
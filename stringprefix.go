@@ -0,0 +1,127 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"sort"
+)
+
+// A prefix directly before the opening quote of a string literal, e.g., the
+// "r" of r"\d+" in Python (see SetStringPrefixes()).
+type StringPrefix struct {
+	Prefix string // The prefix, e.g., "r", "b", or "f".
+
+	// Indicator that escape runes are taken literally in strings with the
+	// prefix, e.g., so that r"\d" is a backslash followed by "d", and a
+	// quote always closes the string.
+	Raw bool
+}
+
+// Returns a string representation of the prefix, e.g., "r (raw)" or "f".
+func (p StringPrefix) String() string {
+	if p.Raw {
+		return p.Prefix + " (raw)"
+	}
+
+	return p.Prefix
+}
+
+// A string prefix, as set by SetStringPrefixes().
+type string_prefix struct {
+	runes []rune
+	raw   bool
+}
+
+// Sets the prefixes, e.g., "r" and "b", that are part of a string literal
+// when they directly precede its opening quote, replacing any set before.
+// The string is then a single TokenTypeString token, e.g., for r"\d+",
+// including the prefix in its Text, with the prefix as its Subtype, rather
+// than an identifier followed by a string. Prefixes are case-sensitive, and
+// the longest one matching wins. Call with no prefixes to switch this off.
+func (ts *TokenScanner) SetStringPrefixes(prefixes ...StringPrefix) {
+	ts.string_prefixes = ts.string_prefixes[:0]
+	for _, p := range prefixes {
+		if p.Prefix != "" {
+			ts.string_prefixes = append(ts.string_prefixes,
+				string_prefix{runes: []rune(p.Prefix), raw: p.Raw})
+		}
+	}
+
+	sort.SliceStable(ts.string_prefixes, func(i, j int) bool {
+		return len(ts.string_prefixes[i].runes) >
+			len(ts.string_prefixes[j].runes)
+	})
+}
+
+// Returns the prefixes set with SetStringPrefixes(), longest first.
+func (ts *TokenScanner) StringPrefixes() []StringPrefix {
+	list := make([]StringPrefix, 0, len(ts.string_prefixes))
+	for _, p := range ts.string_prefixes {
+		list = append(list, StringPrefix{Prefix: string(p.runes), Raw: p.raw})
+	}
+
+	return list
+}
+
+// Returns the prefix set with SetStringPrefixes() that starts at the next
+// rune and is directly followed by an opening quote, or nil if there is
+// none.
+func (ts *TokenScanner) match_string_prefix() *string_prefix {
+	if len(ts.string_prefixes) == 0 {
+		return nil
+	}
+
+	p := ts.peek_upto(len(ts.string_prefixes[0].runes) + 1)
+	for i := range ts.string_prefixes {
+		prefix := &ts.string_prefixes[i]
+		n := len(prefix.runes)
+		if len(p) <= n || !has_rune_prefix(p, prefix.runes) {
+			continue
+		}
+		if ok, _ := ts.IsQuoteRune(p[n]); ok {
+			return prefix
+		}
+	}
+
+	return nil
+}
+
+// Reads the prefix of a string literal. Returns the runes accepted.
+func (ts *TokenScanner) get_string_prefix(prefix *string_prefix) ([]rune,
+	error) {
+	for _, ch := range prefix.runes {
+		_, size, err := ts.get_one_rune()
+		if err != nil {
+			return nil, err
+		}
+
+		ts.last_byte_len += size
+		ts.advance_position(ch, size)
+	}
+
+	return append([]rune(nil), prefix.runes...), nil
+}
@@ -0,0 +1,69 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestStringPrefixes(t *testing.T) {
+	p := textparser.NewScannerString(
+		`r"\d+\" b'\x00' f"{x}\"" rb"\" br"a" u"b" r x"y"`)
+	p.SetStringPrefixes(
+		textparser.StringPrefix{Prefix: "r", Raw: true},
+		textparser.StringPrefix{Prefix: "rb", Raw: true},
+		textparser.StringPrefix{Prefix: "b"},
+		textparser.StringPrefix{Prefix: "f"},
+	)
+
+	var got []string
+	for p.Scan() {
+		token := p.Token()
+		got = append(got, token.Type.String()+" "+token.Subtype+" "+
+			token.Text+" "+p.TokenTextNoQuotes())
+	}
+	if err := p.Err(); err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		`String r r"\d+\" \d+\`,
+		`String b b'\x00' \x00`,
+		`String f f"{x}"" {x}"`,
+		`String rb rb"\" \`,
+		`Ident  br br`,
+		`String  "a" a`,
+		`Ident  u u`,
+		`String  "b" b`,
+		`Ident  r r`,
+		`Ident  x x`,
+		`String  "y" y`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	names := p.ConfigSummary().StringPrefixes
+	expected = []string{"rb (raw)", "r (raw)", "b", "f"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("got %#v, expected %#v", names, expected)
+	}
+}
+
+func TestStringPrefixContent(t *testing.T) {
+	p := textparser.NewScannerString(`r"a\b"`)
+	p.SetStringPrefixes(textparser.StringPrefix{Prefix: "r", Raw: true})
+
+	tokens := scan_all(t, p)
+	if len(tokens) != 1 {
+		t.Fatalf("got %d tokens, expected 1", len(tokens))
+	}
+
+	content, offset := tokens[0].Content(nil)
+	if content != `a\b` || offset != 2 {
+		t.Errorf("got %q at %d, expected %q at %d", content, offset,
+			`a\b`, 2)
+	}
+}
@@ -0,0 +1,172 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Use of one style (of quoting, commenting, or line ending) in a document.
+type StyleUse struct {
+	Style string   // E.g., `"`, "//", or "\r\n".
+	Count int      // Number of uses.
+	First Position // Position of the first use.
+}
+
+// Report of the quoting, comment, and end-of-line styles used in a
+// document, as returned by Document.Styles(). Each list is ordered by first
+// use.
+type StyleReport struct {
+	Quotes   []StyleUse // Keyed by opening quote.
+	Comments []StyleUse // E.g., "//", "/* */", or a line directive prefix.
+	EOLs     []StyleUse // "\n", "\r\n", or "\r".
+}
+
+// Returns the names of the categories ("quotes", "comments", or "eols")
+// that use more than one style, e.g., to warn about mixtures.
+func (r *StyleReport) Mixed() []string {
+	var mixed []string
+	if len(r.Quotes) > 1 {
+		mixed = append(mixed, "quotes")
+	}
+	if len(r.Comments) > 1 {
+		mixed = append(mixed, "comments")
+	}
+	if len(r.EOLs) > 1 {
+		mixed = append(mixed, "eols")
+	}
+
+	return mixed
+}
+
+// Returns the most used style in the list, or the empty string if the list
+// is empty. Ties go to the style used first.
+func MostUsedStyle(uses []StyleUse) string {
+	best := -1
+	for i, use := range uses {
+		if best < 0 || use.Count > uses[best].Count {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		return ""
+	}
+
+	return uses[best].Style
+}
+
+// Returns a report of the quoting, comment, and end-of-line styles used in
+// the document. Comments are only reported if the profile used to scan the
+// document does not skip them; see AnalyzeStyles().
+func (doc *Document) Styles() *StyleReport {
+	r := new(StyleReport)
+
+	for _, token := range doc.Tokens {
+		switch token.Type {
+		case TokenTypeString:
+			r.Quotes = add_style_use(r.Quotes, string(token.FirstRune),
+				token.Start)
+		case TokenTypeComment:
+			r.Comments = add_style_use(r.Comments, comment_style(token.Text,
+				doc.comments),
+				token.Start)
+		}
+	}
+
+	src := doc.Source
+	for offset := 0; offset < len(src); offset++ {
+		var eol string
+		switch {
+		case src[offset] == '\r' && offset+1 < len(src) &&
+			src[offset+1] == '\n':
+			eol = "\r\n"
+		case src[offset] == '\r':
+			eol = "\r"
+		case src[offset] == '\n':
+			eol = "\n"
+		default:
+			continue
+		}
+
+		pos, _ := doc.Index.OffsetToPosition(offset)
+		r.EOLs = add_style_use(r.EOLs, eol, pos)
+		offset += len(eol) - 1
+	}
+
+	return r
+}
+
+func add_style_use(uses []StyleUse, style string, pos Position) []StyleUse {
+	for i := range uses {
+		if uses[i].Style == style {
+			uses[i].Count++
+			return uses
+		}
+	}
+
+	return append(uses, StyleUse{Style: style, Count: 1, First: pos})
+}
+
+// Returns the name of the style of the comment, from the comment styles of
+// the scanner, or the text up to the first space, e.g., for line directives.
+func comment_style(text string, styles []CommentStyle) string {
+	for _, style := range styles {
+		if strings.HasPrefix(text, style.Start) {
+			return style.String()
+		}
+	}
+
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		return text[:i]
+	}
+
+	return text
+}
+
+// Scans all of the input using a scanner configured by the profile (nil for
+// the default configuration), but keeping comments, and returns a report of
+// the styles used. The returned error is only for failures reading the
+// input; scanning stops at the first scan error, as with ScanDocument().
+func AnalyzeStyles(r io.Reader, profile *Profile) (*StyleReport, error) {
+	keep_comments := &Profile{
+		Name: profile.display_name(),
+		Configure: func(ts *TokenScanner) {
+			profile.Apply(ts)
+			ts.SkipComments = false
+		},
+	}
+
+	doc, err := ScanDocument(r, keep_comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Styles(), nil
+}
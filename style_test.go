@@ -0,0 +1,71 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestAnalyzeStyles(t *testing.T) {
+	input := "a = \"x\" // one\r\n" +
+		"b = 'y' /* two */\n" +
+		"c = \"z\" // three\r\n"
+
+	report, err := textparser.AnalyzeStyles(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("analysis failed: %s", err)
+	}
+
+	expected := &textparser.StyleReport{
+		Quotes: []textparser.StyleUse{
+			{Style: `"`, Count: 2, First: textparser.Position{Offset: 4,
+				Line: 1, Column: 5}},
+			{Style: "'", Count: 1, First: textparser.Position{Offset: 20,
+				Line: 2, Column: 5}},
+		},
+		Comments: []textparser.StyleUse{
+			{Style: "//", Count: 2, First: textparser.Position{Offset: 8,
+				Line: 1, Column: 9}},
+			{Style: "/* */", Count: 1, First: textparser.Position{
+				Offset: 24, Line: 2, Column: 9}},
+		},
+		EOLs: []textparser.StyleUse{
+			{Style: "\r\n", Count: 2, First: textparser.Position{
+				Offset: 14, Line: 1, Column: 15}},
+			{Style: "\n", Count: 1, First: textparser.Position{Offset: 33,
+				Line: 2, Column: 18}},
+		},
+	}
+
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("got %#v, expected %#v", report, expected)
+	}
+
+	mixed := report.Mixed()
+	expected_mixed := []string{"quotes", "comments", "eols"}
+	if !reflect.DeepEqual(mixed, expected_mixed) {
+		t.Errorf("got %#v, expected %#v", mixed, expected_mixed)
+	}
+
+	if style := textparser.MostUsedStyle(report.EOLs); style != "\r\n" {
+		t.Errorf("got %q, expected %q", style, "\r\n")
+	}
+}
+
+func TestAnalyzeStylesUniform(t *testing.T) {
+	report, err := textparser.AnalyzeStyles(strings.NewReader(
+		"x = 'a'\ny = 'b'\n"), nil)
+	if err != nil {
+		t.Fatalf("analysis failed: %s", err)
+	}
+
+	if mixed := report.Mixed(); mixed != nil {
+		t.Errorf("got %#v, expected no mixtures", mixed)
+	}
+
+	if style := textparser.MostUsedStyle(report.Comments); style != "" {
+		t.Errorf("got %q, expected no comment style", style)
+	}
+}
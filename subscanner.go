@@ -0,0 +1,97 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Options for ScannedToken.Scanner().
+type SubScannerOptions struct {
+	// Configures the new scanner. Nil means the default configuration.
+	Profile *Profile
+
+	// The comment styles whose delimiters are stripped from a comment
+	// token. Nil means DefaultCommentStyles.
+	Comments []CommentStyle
+}
+
+// Returns the content of the token, i.e., its raw text without the quotes
+// and prefix of a quoted string or the delimiters of a comment, along with
+// the offset of the content within the raw text. Comment delimiters are
+// those of the styles, or of DefaultCommentStyles if styles is nil. Escape
+// sequences are kept as is.
+func (t *Token) Content(styles []CommentStyle) (string, int) {
+	text := t.RawText()
+
+	switch t.Type {
+	case TokenTypeString:
+		prefix := len(t.Subtype)
+		text = text[prefix:]
+		quote, size := utf8.DecodeRuneInString(text)
+		if size == 0 || !strings.HasSuffix(text[size:], string(quote)) {
+			return text[size:], prefix + size
+		}
+		return text[size : len(text)-size], prefix + size
+	case TokenTypeComment:
+		if styles == nil {
+			styles = DefaultCommentStyles
+		}
+		for _, style := range styles {
+			if style.Start == "" || !strings.HasPrefix(text, style.Start) {
+				continue
+			}
+			content := text[len(style.Start):]
+			if style.End != "" {
+				content = strings.TrimSuffix(content, style.End)
+			}
+			return content, len(style.Start)
+		}
+	}
+
+	return text, 0
+}
+
+// Returns a new scanner over the content of the token (see
+// Token.Content()), e.g., to scan an embedded language inside a string
+// literal, with positions pointing into the source the token was scanned
+// from. Nil options mean the default configuration. As escape sequences are
+// scanned as is, positions stay exact.
+func (st *ScannedToken) Scanner(opts *SubScannerOptions) *TokenScanner {
+	if opts == nil {
+		opts = &SubScannerOptions{}
+	}
+
+	content, offset := st.Token.Content(opts.Comments)
+
+	ts := opts.Profile.NewScanner(strings.NewReader(content))
+	start := ts.advance_over(st.Start, st.RawText()[:offset])
+	ts.pending_pos = &start
+
+	return ts
+}
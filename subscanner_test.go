@@ -0,0 +1,80 @@
+package textparser_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSubScanner(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Type     textparser.TokenType
+		Opts     *textparser.SubScannerOptions
+		Expected []string
+	}
+
+	hash_comments := &textparser.SubScannerOptions{
+		Comments: []textparser.CommentStyle{{Start: "#"}},
+	}
+
+	test_list := []TestData{
+		{"string", `call("SELECT a, b", 1)`, textparser.TokenTypeString,
+			nil, []string{
+				"Ident SELECT src:1:7 (6)",
+				"Ident a src:1:14 (13)",
+				"Symbol , src:1:15 (14)",
+				"Ident b src:1:17 (16)",
+			}},
+		{"block comment", "x /* one\n  two */", textparser.TokenTypeComment,
+			nil, []string{
+				"Ident one src:1:6 (5)",
+				"Ident two src:2:3 (11)",
+			}},
+		{"hash comment", "x # a=1", textparser.TokenTypeComment,
+			hash_comments, []string{
+				"Ident a src:1:5 (4)",
+				"Symbol = src:1:6 (5)",
+				"Int 1 src:1:7 (6)",
+			}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			ts := textparser.NewScanner(strings.NewReader(test_data.Input))
+			ts.SetFilename("src")
+			ts.SkipComments = false
+			ts.SetComments(append(textparser.DefaultCommentStyles,
+				textparser.CommentStyle{Start: "#"})...)
+
+			var token *textparser.ScannedToken
+			for ts.Scan() {
+				if ts.LastToken.Type == test_data.Type {
+					token = ts.ScannedToken()
+					break
+				}
+			}
+			if token == nil {
+				st.Fatalf("no %s token in %q", test_data.Type,
+					test_data.Input)
+			}
+
+			sub := token.Scanner(test_data.Opts)
+			got := []string{}
+			for sub.Scan() {
+				got = append(got, fmt.Sprintf("%s %s %s",
+					sub.LastToken.Type, sub.LastToken.Text,
+					sub.Position()))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
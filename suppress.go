@@ -0,0 +1,139 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The default directive for suppression comments, e.g.,
+//
+//	// textparser:ignore E001
+//	x := "unterminated
+const DefaultSuppressDirective = "textparser:ignore"
+
+// A suppression comment found while scanning. A comment that is alone on its
+// line suppresses diagnostics on the line following the comment; a comment
+// after other tokens suppresses diagnostics on its own line.
+type Suppression struct {
+	Pos   Position    // Position of the comment.
+	Line  int         // Line whose diagnostics are suppressed.
+	Codes []ErrorCode // Codes suppressed, or nil for all codes.
+}
+
+// Returns true if the suppression applies to the code.
+func (s *Suppression) Matches(code ErrorCode) bool {
+	if len(s.Codes) == 0 {
+		return true
+	}
+
+	for _, c := range s.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sets the directive that marks a comment as a suppression comment (see
+// DefaultSuppressDirective). The directive must be the first thing in the
+// comment, optionally followed by error codes or names (see
+// ParseErrorCode()) separated by spaces or commas. Without codes, all
+// diagnostics are suppressed. Words that are not error codes are ignored, so
+// that an explanation may follow the codes. Suppression comments are
+// recognized even if comment tokens are skipped. An empty directive turns
+// suppression comments off.
+func (ts *TokenScanner) SetSuppressDirective(directive string) {
+	ts.suppress_directive = directive
+}
+
+// Returns the directive for suppression comments, or the empty string if
+// they are turned off.
+func (ts *TokenScanner) SuppressDirective() string {
+	return ts.suppress_directive
+}
+
+// Returns the suppression comments found so far, in order.
+func (ts *TokenScanner) Suppressions() []Suppression {
+	return ts.suppressions
+}
+
+// Returns true if a suppression comment found so far suppresses diagnostics
+// with the code on the line.
+func (ts *TokenScanner) Suppressed(line int, code ErrorCode) bool {
+	for i := range ts.suppressions {
+		s := &ts.suppressions[i]
+		if s.Line == line && s.Matches(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Records a suppression if the comment body is a suppression comment. The
+// comment started at pos, on a line with nothing else before it if alone,
+// and ended on end_line.
+func (ts *TokenScanner) note_suppression(
+	body string,
+	pos Position,
+	alone bool,
+	end_line int,
+) {
+	if ts.suppress_directive == "" {
+		return
+	}
+
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, ts.suppress_directive) {
+		return
+	}
+
+	rest := body[len(ts.suppress_directive):]
+	if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+		// E.g., "textparser:ignored".
+		return
+	}
+
+	s := Suppression{Pos: pos, Line: pos.Line}
+	if alone {
+		s.Line = end_line + 1
+	}
+
+	words := strings.FieldsFunc(rest, func(ch rune) bool {
+		return ch == ',' || unicode.IsSpace(ch)
+	})
+	for _, word := range words {
+		if code, err := ParseErrorCode(word); err == nil {
+			s.Codes = append(s.Codes, code)
+		}
+	}
+
+	ts.suppressions = append(ts.suppressions, s)
+}
@@ -0,0 +1,108 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSuppressions(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []textparser.Suppression
+	}
+
+	test_list := []TestData{
+		{"next line", "a\n// textparser:ignore E001\nb",
+			[]textparser.Suppression{{
+				Pos:   textparser.Position{Offset: 2, Line: 2, Column: 1},
+				Line:  3,
+				Codes: []textparser.ErrorCode{1},
+			}}},
+		{"trailing", "a // textparser:ignore\nb",
+			[]textparser.Suppression{{
+				Pos:  textparser.Position{Offset: 2, Line: 1, Column: 3},
+				Line: 1,
+			}}},
+		{"block", "/* textparser:ignore UnterminatedString,\n" +
+			"   E002 because reasons */\nb",
+			[]textparser.Suppression{{
+				Pos:   textparser.Position{Offset: 0, Line: 1, Column: 1},
+				Line:  3,
+				Codes: []textparser.ErrorCode{1, 2},
+			}}},
+		{"not a directive", "// textparser:ignored\n# textparser:ignore",
+			nil},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			scan_all(st, p)
+
+			got := p.Suppressions()
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestSuppressedDiagnostics(t *testing.T) {
+	type TestData struct {
+		Name       string
+		Input      string
+		Suppressed bool
+	}
+
+	test_list := []TestData{
+		{"code", "a\n// textparser:ignore E001\n'b", true},
+		{"name", "a\n// textparser:ignore unterminatedstring\n'b", true},
+		{"all codes", "a\n// textparser:ignore\n'b", true},
+		{"trailing", "a // textparser:ignore\n'b", false},
+		{"other code", "a\n// textparser:ignore E002\n'b", false},
+		{"too far", "// textparser:ignore\na\n'b", false},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			doc, err := textparser.ScanDocument(
+				strings.NewReader(test_data.Input), nil)
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := len(doc.Suppressed) == 1; got != test_data.Suppressed {
+				st.Errorf("got suppressed %t, expected %t", got,
+					test_data.Suppressed)
+			}
+
+			if len(doc.Suppressed)+len(doc.Diagnostics) != 1 {
+				st.Errorf("got %d suppressed, %d diagnostics, expected 1",
+					len(doc.Suppressed), len(doc.Diagnostics))
+			}
+		})
+	}
+}
+
+func TestSetSuppressDirective(t *testing.T) {
+	p := textparser.NewScannerString("# nolint\n'a")
+	p.SetComments(textparser.CommentStyle{Start: "#"})
+	p.SetSuppressDirective("nolint")
+	textparser.TokenizeAll(p)
+
+	if !p.Suppressed(2, textparser.CodeUnterminatedString) {
+		t.Errorf("expected line 2 to be suppressed")
+	}
+
+	p = textparser.NewScannerString("// textparser:ignore\n'a")
+	p.SetSuppressDirective("")
+	textparser.TokenizeAll(p)
+
+	if got := p.Suppressions(); got != nil {
+		t.Errorf("got %#v, expected no suppressions", got)
+	}
+}
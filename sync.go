@@ -0,0 +1,92 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// What was discarded by SyncTo() or SyncToSymbol().
+type SyncResult struct {
+	// Whether a synchronization point was found, which is then the current
+	// token. Otherwise, the input ended first, or Scan() failed (see
+	// Err()).
+	Found bool
+
+	// The number of tokens discarded.
+	Tokens int
+
+	// The source of the tokens discarded, e.g., for graying it out, from the
+	// start of the first to the end of the last. The zero Span if none
+	// were discarded.
+	Skipped Span
+}
+
+// Discards tokens until one of any of the types, e.g., after reporting an
+// error, so that a parser can continue from a known point. The current token
+// is discarded first, unless it is of one of the types, and the token found
+// becomes the current token. Skipped tokens (see SkipTypes()) are never
+// seen, so they are not synchronization points.
+func (ts *TokenScanner) SyncTo(types ...TokenType) *SyncResult {
+	return ts.sync(func(token *Token) bool {
+		for _, token_type := range types {
+			if token.Type == token_type {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Like SyncTo(), but discards tokens until a symbol token with the text,
+// e.g., ";".
+func (ts *TokenScanner) SyncToSymbol(text string) *SyncResult {
+	return ts.sync(func(token *Token) bool {
+		return token.Type == TokenTypeSymbol && token.Text == text
+	})
+}
+
+// Discards tokens until one for which is_sync returns true.
+func (ts *TokenScanner) sync(is_sync func(token *Token) bool) *SyncResult {
+	r := &SyncResult{}
+	if ts.LastToken == nil && !ts.Scan() {
+		return r
+	}
+
+	for {
+		if is_sync(ts.LastToken) {
+			r.Found = true
+			return r
+		}
+
+		if r.Tokens == 0 {
+			r.Skipped.Start = *ts.pos
+		}
+		r.Skipped.End = ts.end_pos
+		r.Tokens++
+
+		if !ts.Scan() {
+			return r
+		}
+	}
+}
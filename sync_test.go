@@ -0,0 +1,94 @@
+package textparser_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestSyncTo(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Scans    int // Calls to Scan() before synchronizing.
+		Sync     func(p *textparser.TokenScanner) *textparser.SyncResult
+		Expected string // The result, and the current token, if found.
+		Rest     []string
+	}
+
+	to_semicolon := func(p *textparser.TokenScanner) *textparser.SyncResult {
+		return p.SyncToSymbol(";")
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "symbol",
+			Input:    "x = 1 2 3; y = 4;",
+			Scans:    4,
+			Sync:     to_semicolon,
+			Expected: "true 2 :1:7-1:10 (6-9) ;",
+			Rest:     []string{"y", "=", "4", ";"},
+		},
+		{
+			Name:     "at the synchronization point",
+			Input:    "x; y",
+			Scans:    2,
+			Sync:     to_semicolon,
+			Expected: "true 0 :0:0-0:0 (0-0) ;",
+			Rest:     []string{"y"},
+		},
+		{
+			Name:     "before the first token",
+			Input:    "a\nb; c",
+			Sync:     to_semicolon,
+			Expected: "true 2 :1:1-2:2 (0-3) ;",
+			Rest:     []string{"c"},
+		},
+		{
+			Name:  "types",
+			Input: `f(a b "s" 2) "t"`,
+			Scans: 3,
+			Sync: func(p *textparser.TokenScanner) *textparser.SyncResult {
+				return p.SyncTo(textparser.TokenTypeString,
+					textparser.TokenTypeInt)
+			},
+			Expected: `true 2 :1:3-1:6 (2-5) "s"`,
+			Rest:     []string{"2", ")", `"t"`},
+		},
+		{
+			Name:     "not found",
+			Input:    "a b",
+			Scans:    1,
+			Sync:     to_semicolon,
+			Expected: "false 2 :1:1-1:4 (0-3)",
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			for i := 0; i < test_data.Scans; i++ {
+				p.Scan()
+			}
+
+			r := test_data.Sync(p)
+			got := fmt.Sprintf("%t %d %s", r.Found, r.Tokens, r.Skipped)
+			if r.Found {
+				got += " " + p.TokenText()
+			}
+			if got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+
+			var rest []string
+			for p.Scan() {
+				rest = append(rest, p.TokenText())
+			}
+			if !reflect.DeepEqual(rest, test_data.Rest) {
+				st.Errorf("got %#v, expected %#v", rest, test_data.Rest)
+			}
+		})
+	}
+}
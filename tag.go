@@ -0,0 +1,364 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An editable list of options from a struct tag, such as
+//
+//	Verbose,del=',',usage='Use it like this.'
+//
+// Options are either bare keys (flags) or key=value pairs, separated by
+// commas. Values may be quoted. String() re-serializes the list, keeping the
+// original text, including quoting and spacing, of options that were not
+// changed, so that code generators can edit hand-written tags without
+// reformatting them.
+type TagSpec struct {
+	items []*tag_item
+}
+
+// One option in a struct tag.
+type tag_item struct {
+	key       string
+	value     string // Unquoted value.
+	has_value bool
+	quote     rune   // Opening quote of the value, or 0 if unquoted.
+	raw       string // Original text, including surrounding spaces.
+	key_pos   Position
+	value_pos Position
+}
+
+// Parses the options in a struct tag. An empty tag results in an empty
+// TagSpec. In quoted values, a backslash escapes the quote character or
+// another backslash.
+func ParseTagSpec(tag string) (*TagSpec, error) {
+	items, err := parse_tag_items(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TagSpec{items: items}, nil
+}
+
+// An option in a struct tag, as returned by ParseTagOptions().
+type TagOption struct {
+	Key      string
+	Value    string // Unquoted and unescaped value, if any.
+	HasValue bool   // True for key=value options, false for bare flags.
+	KeyPos   Position
+	ValuePos Position // Position of the value, if any, including any quote.
+}
+
+// Parses the options in a struct tag, e.g.,
+//
+//	name,del=',',usage='Use it like this.'
+//
+// into an ordered list of options, with values unquoted and unescaped as by
+// ParseTagSpec(). Positions refer to the tag string.
+func ParseTagOptions(tag string) ([]TagOption, error) {
+	items, err := parse_tag_items(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag_options(items), nil
+}
+
+func tag_options(items []*tag_item) []TagOption {
+	options := make([]TagOption, 0, len(items))
+	for _, item := range items {
+		options = append(options, TagOption{
+			Key:      item.key,
+			Value:    item.value,
+			HasValue: item.has_value,
+			KeyPos:   item.key_pos,
+			ValuePos: item.value_pos,
+		})
+	}
+
+	return options
+}
+
+// Splits the tag into options.
+func parse_tag_items(tag string) ([]*tag_item, error) {
+	ts := NewScannerString(tag)
+	ts.SkipWhitespace = true
+	ts.CollapseEscapes = true
+	ts.SetComments()
+	ts.Unknown = UnknownRun
+
+	tokens, err := TokenizeAll(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*tag_item
+	raw_start := 0
+
+	for i := 0; i < len(tokens); {
+		key := tokens[i]
+		if key.Type != TokenTypeIdent {
+			return nil, tag_error("Expected option name", key.Start,
+				fmt.Sprintf("Found %q.", key.Text))
+		}
+		i++
+
+		item := &tag_item{key: key.Text, key_pos: key.Start}
+
+		if i < len(tokens) && tokens[i].Text == "=" {
+			i++
+
+			// The value is everything up to the next comma.
+			first := i
+			for i < len(tokens) && tokens[i].Text != "," {
+				i++
+			}
+			if i == first {
+				return nil, tag_error(fmt.Sprintf("Missing value for %q",
+					item.key), tokens[first-1].End, "")
+			}
+
+			item.has_value = true
+			item.value_pos = tokens[first].Start
+			if i-first == 1 && tokens[first].Type == TokenTypeString {
+				text := tokens[first].Text
+				item.quote, _ = utf8.DecodeRuneInString(text)
+				_, close_size := utf8.DecodeLastRuneInString(text)
+				open_size := utf8.RuneLen(item.quote)
+				item.value = text[open_size : len(text)-close_size]
+			} else {
+				start, end := tokens[first].Start, tokens[i-1].End
+				item.value = tag[start.Offset:end.Offset]
+			}
+		}
+
+		raw_end := len(tag)
+		if i < len(tokens) {
+			if tokens[i].Text != "," {
+				return nil, tag_error("Expected ','", tokens[i].Start,
+					fmt.Sprintf("Found %q.", tokens[i].Text))
+			}
+			raw_end = tokens[i].Start.Offset
+			i++
+
+			if i == len(tokens) {
+				return nil, tag_error("Trailing ','", tokens[i-1].Start,
+					"")
+			}
+		}
+
+		item.raw = tag[raw_start:raw_end]
+		raw_start = raw_end + 1
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Returns a ScanError for a syntax error in a struct tag.
+func tag_error(msg string, pos Position, detail string) *ScanError {
+	return &ScanError{Msg: msg, Detail: detail, Start: pos, End: pos,
+		Code: CodeTagSyntax}
+}
+
+// Returns the options, in order. Positions of options added or changed since
+// parsing are zero.
+func (s *TagSpec) Options() []TagOption {
+	return tag_options(s.items)
+}
+
+// Returns the keys of the options, in order.
+func (s *TagSpec) Keys() []string {
+	keys := make([]string, 0, len(s.items))
+	for _, item := range s.items {
+		keys = append(keys, item.key)
+	}
+
+	return keys
+}
+
+// Returns the index of the first option with the key, or -1.
+func (s *TagSpec) index(key string) int {
+	for i, item := range s.items {
+		if item.key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns true if the tag has an option with the key, with or without a
+// value.
+func (s *TagSpec) Has(key string) bool {
+	return s.index(key) >= 0
+}
+
+// Returns the unquoted value of the first option with the key. The boolean
+// is false if there is no such option or it has no value.
+func (s *TagSpec) Get(key string) (string, bool) {
+	i := s.index(key)
+	if i < 0 || !s.items[i].has_value {
+		return "", false
+	}
+
+	return s.items[i].value, true
+}
+
+// Sets the value of the first option with the key, or appends a new option
+// if there is none. The value is quoted as needed, with the quote character
+// the option used originally, if any, or else with single quotes.
+func (s *TagSpec) Set(key, value string) {
+	item := s.edit(key)
+	item.value = value
+	item.has_value = true
+	item.raw = with_spacing(item.raw, key+"="+quote_tag_value(value,
+		item.quote))
+}
+
+// Sets the first option with the key to a bare flag, without a value, or
+// appends one if there is none.
+func (s *TagSpec) SetFlag(key string) {
+	item := s.edit(key)
+	item.value = ""
+	item.has_value = false
+	item.quote = 0
+	item.raw = with_spacing(item.raw, key)
+}
+
+// Returns the first option with the key, appending a new one if there is
+// none.
+func (s *TagSpec) edit(key string) *tag_item {
+	if i := s.index(key); i >= 0 {
+		return s.items[i]
+	}
+
+	item := &tag_item{key: key}
+
+	// Follow the spacing after commas used elsewhere.
+	if n := len(s.items); n > 1 {
+		item.raw = leading_space(s.items[n-1].raw)
+	} else if n == 1 {
+		item.raw = ""
+	}
+
+	s.items = append(s.items, item)
+
+	return item
+}
+
+// Removes all options with the key. Returns true if any were removed.
+func (s *TagSpec) Remove(key string) bool {
+	removed := false
+	for i := 0; i < len(s.items); {
+		if s.items[i].key != key {
+			i++
+			continue
+		}
+
+		if i == 0 && len(s.items) > 1 {
+			// Keep any spacing at the start of the tag.
+			next := s.items[1]
+			next.raw = leading_space(s.items[0].raw) +
+				strings.TrimLeftFunc(next.raw, unicode.IsSpace)
+		}
+
+		s.items = append(s.items[:i], s.items[i+1:]...)
+		removed = true
+	}
+
+	return removed
+}
+
+// Returns the tag text, keeping the original text of unchanged options.
+func (s *TagSpec) String() string {
+	raw := make([]string, 0, len(s.items))
+	for _, item := range s.items {
+		raw = append(raw, item.raw)
+	}
+
+	return strings.Join(raw, ",")
+}
+
+// Returns the spaces at the start of s.
+func leading_space(s string) string {
+	return s[:len(s)-len(strings.TrimLeftFunc(s, unicode.IsSpace))]
+}
+
+// Returns text with the spaces at the start and end of raw.
+func with_spacing(raw, text string) string {
+	leading := leading_space(raw)
+	trailing := raw[len(leading):]
+	trailing = trailing[len(strings.TrimRightFunc(trailing,
+		unicode.IsSpace)):]
+
+	return leading + text + trailing
+}
+
+// Returns the value, quoted with the quote rune (single quotes if 0) unless
+// it is a plain identifier or number.
+func quote_tag_value(value string, quote rune) string {
+	if quote == 0 {
+		if is_plain_tag_value(value) {
+			return value
+		}
+		quote = '\''
+	}
+
+	b := new(strings.Builder)
+	b.WriteRune(quote)
+	for _, ch := range value {
+		if ch == quote || ch == '\\' {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(ch)
+	}
+	b.WriteRune(quote)
+
+	return b.String()
+}
+
+// Returns true if the value scans as a single identifier or number.
+func is_plain_tag_value(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for i, ch := range value {
+		if !(unicode.IsLetter(ch) || ch == '_' || unicode.IsDigit(ch) ||
+			(i > 0 && ch == '.')) {
+			return false
+		}
+	}
+
+	return true
+}
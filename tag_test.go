@@ -0,0 +1,172 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestTagSpecParse(t *testing.T) {
+	spec, err := textparser.ParseTagSpec(
+		`Verbose, del=',', usage="Say \"hi\".", max=10, path=a/b`)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	expected_keys := []string{"Verbose", "del", "usage", "max", "path"}
+	if keys := spec.Keys(); !reflect.DeepEqual(keys, expected_keys) {
+		t.Errorf("got %#v, expected %#v", keys, expected_keys)
+	}
+
+	for key, expected := range map[string]string{
+		"del":   ",",
+		"usage": `Say "hi".`,
+		"max":   "10",
+		"path":  "a/b",
+	} {
+		if value, ok := spec.Get(key); !ok || value != expected {
+			t.Errorf("%s: got %q (%t), expected %q", key, value, ok,
+				expected)
+		}
+	}
+
+	if _, ok := spec.Get("Verbose"); ok || !spec.Has("Verbose") {
+		t.Errorf("expected Verbose to be a flag")
+	}
+}
+
+func TestTagSpecEdit(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Tag      string
+		Edit     func(spec *textparser.TagSpec)
+		Expected string
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "untouched",
+			Tag:      ` a ,b= "x" ,  c='y'`,
+			Edit:     func(spec *textparser.TagSpec) {},
+			Expected: ` a ,b= "x" ,  c='y'`,
+		},
+		{
+			Name: "set keeps quote style",
+			Tag:  `a, b="x", c='y'`,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.Set("b", `it's "z"`)
+			},
+			Expected: `a, b="it's \"z\"", c='y'`,
+		},
+		{
+			Name: "set plain value",
+			Tag:  `a,b=1`,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.Set("b", "2")
+			},
+			Expected: `a,b=2`,
+		},
+		{
+			Name: "append follows spacing",
+			Tag:  `a, b='x'`,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.Set("usage", "Use it.")
+				spec.SetFlag("Verbose")
+			},
+			Expected: `a, b='x', usage='Use it.', Verbose`,
+		},
+		{
+			Name: "remove",
+			Tag:  ` a, b='x', c`,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.Remove("a")
+				spec.Remove("c")
+			},
+			Expected: ` b='x'`,
+		},
+		{
+			Name: "set flag",
+			Tag:  `a='x',b`,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.SetFlag("a")
+			},
+			Expected: `a,b`,
+		},
+		{
+			Name: "empty",
+			Tag:  ``,
+			Edit: func(spec *textparser.TagSpec) {
+				spec.Set("a", `x\y`)
+			},
+			Expected: `a='x\\y'`,
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			spec, err := textparser.ParseTagSpec(test_data.Tag)
+			if err != nil {
+				st.Fatalf("parse failed: %s", err)
+			}
+
+			test_data.Edit(spec)
+
+			got := spec.String()
+			if got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+
+			// The result must parse to the same values.
+			again, err := textparser.ParseTagSpec(got)
+			if err != nil {
+				st.Fatalf("reparse of %q failed: %s", got, err)
+			}
+			for _, key := range spec.Keys() {
+				v1, ok1 := spec.Get(key)
+				v2, ok2 := again.Get(key)
+				if v1 != v2 || ok1 != ok2 {
+					st.Errorf("%s: got %q after reparse, expected %q", key,
+						v2, v1)
+				}
+			}
+		})
+	}
+}
+
+func TestTagSpecErrors(t *testing.T) {
+	for _, tag := range []string{`a,`, `a=`, `a=,b`, `=x`, `a b`, `'a'`,
+		`a='x`} {
+		if _, err := textparser.ParseTagSpec(tag); err == nil {
+			t.Errorf("%q: expected an error", tag)
+		}
+	}
+}
+
+func TestParseTagOptions(t *testing.T) {
+	got, err := textparser.ParseTagOptions(`name,del=',', usage='a \'b\''`)
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	pos := func(offset int) textparser.Position {
+		return textparser.Position{Offset: offset, Line: 1,
+			Column: offset + 1}
+	}
+
+	expected := []textparser.TagOption{
+		{Key: "name", KeyPos: pos(0)},
+		{Key: "del", Value: ",", HasValue: true, KeyPos: pos(5),
+			ValuePos: pos(9)},
+		{Key: "usage", Value: "a 'b'", HasValue: true, KeyPos: pos(14),
+			ValuePos: pos(20)},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if _, err = textparser.ParseTagOptions("a,,b"); err == nil {
+		t.Errorf("expected an error for an empty option")
+	}
+}
@@ -0,0 +1,192 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	duration_type         = reflect.TypeOf(time.Duration(0))
+	text_unmarshaler_type = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+)
+
+// Parses the options in a struct tag, as by ParseTagOptions(), and stores
+// them in the fields of the struct that out points to. Each option sets the
+// exported field named by the field's `textparser:"key"` tag, or else the
+// field whose name equals the key, ignoring case. Fields tagged
+// `textparser:"-"` are never set.
+//
+// Values are converted to the type of the field: strings as is; bools, ints,
+// uints, and floats as by the strconv package; time.Duration as by
+// time.ParseDuration(); and types implementing encoding.TextUnmarshaler with
+// their UnmarshalText method. A bare flag, e.g., "Verbose", sets a bool field
+// to true. Fields without a corresponding option are left unchanged, so out
+// may be filled with defaults beforehand.
+//
+// Unknown keys, flags for fields other than bools, and values that cannot be
+// converted result in a ScanError with CodeTagValue, giving the position of
+// the option in the tag.
+func UnmarshalTag(tag string, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("UnmarshalTag: out must be a non-nil pointer to "+
+			"a struct, not %T", out)
+	}
+	v = v.Elem()
+
+	options, err := ParseTagOptions(tag)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range options {
+		field, ok := tag_field(v, option.Key)
+		if !ok {
+			return tag_value_error(fmt.Sprintf("Unknown option %q",
+				option.Key), option.KeyPos, "")
+		}
+
+		if err = set_tag_field(field, &option); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the field of the struct value that the key sets.
+func tag_field(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+
+	// An explicit name wins over a matching field name.
+	match := -1
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, tagged := f.Tag.Lookup("textparser")
+		switch {
+		case name == "-":
+			continue
+		case tagged && name != "":
+			if name == key {
+				return v.Field(i), true
+			}
+		case match < 0 && EqualFold(f.Name, key):
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return reflect.Value{}, false
+	}
+
+	return v.Field(match), true
+}
+
+// Stores the value of the option in the field.
+func set_tag_field(field reflect.Value, option *TagOption) error {
+	if !option.HasValue {
+		if field.Kind() != reflect.Bool {
+			return tag_value_error(fmt.Sprintf("Missing value for %q",
+				option.Key), option.KeyPos, "Only bool options may be "+
+				"given without a value.")
+		}
+		field.SetBool(true)
+		return nil
+	}
+
+	value := option.Value
+	var err error
+
+	switch {
+	case reflect.PointerTo(field.Type()).Implements(text_unmarshaler_type):
+		err = field.Addr().Interface().(encoding.TextUnmarshaler).
+			UnmarshalText([]byte(value))
+
+	case field.Type() == duration_type:
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil {
+			field.SetInt(int64(d))
+		}
+
+	default:
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				field.SetBool(b)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(value, 0, field.Type().Bits())
+			if err == nil {
+				field.SetInt(n)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+			reflect.Uint64, reflect.Uintptr:
+			var n uint64
+			n, err = strconv.ParseUint(value, 0, field.Type().Bits())
+			if err == nil {
+				field.SetUint(n)
+			}
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(value, field.Type().Bits())
+			if err == nil {
+				field.SetFloat(f)
+			}
+		default:
+			return tag_value_error(fmt.Sprintf("Unsupported type %s for %q",
+				field.Type(), option.Key), option.KeyPos, "")
+		}
+	}
+
+	if err != nil {
+		return tag_value_error(fmt.Sprintf("Invalid value for %q",
+			option.Key), option.ValuePos, err.Error()+".")
+	}
+
+	return nil
+}
+
+// Returns a ScanError for an option that cannot be stored.
+func tag_value_error(msg string, pos Position, detail string) *ScanError {
+	return &ScanError{Msg: msg, Detail: detail, Start: pos, End: pos,
+		Code: CodeTagValue}
+}
@@ -0,0 +1,90 @@
+package textparser_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+type tag_options struct {
+	Verbose bool
+	Name    string
+	Delim   string `textparser:"del"`
+	Count   int8
+	Size    uint
+	Ratio   float64
+	Timeout time.Duration
+	Addr    net.IP
+	Skip    string `textparser:"-"`
+	hidden  string
+}
+
+func TestUnmarshalTag(t *testing.T) {
+	got := tag_options{Name: "default"}
+	err := textparser.UnmarshalTag(`verbose, del=',', count=-0x10, `+
+		`size=42, ratio=2.5e-1, timeout=1m30s, addr=127.0.0.1`, &got)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := tag_options{
+		Verbose: true,
+		Name:    "default",
+		Delim:   ",",
+		Count:   -16,
+		Size:    42,
+		Ratio:   0.25,
+		Timeout: 90 * time.Second,
+		Addr:    net.IPv4(127, 0, 0, 1),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestUnmarshalTagErrors(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Tag      string
+		Expected string
+	}
+
+	test_list := []TestData{
+		{"unknown", "name=a, bogus",
+			`Unknown option "bogus" at :1:9 (8).`},
+		{"skipped", "skip=a", `Unknown option "skip" at :1:1 (0).`},
+		{"unexported", "hidden=a", `Unknown option "hidden" at :1:1 (0).`},
+		{"flag", "name",
+			`Missing value for "name" at :1:1 (0). Only bool options ` +
+				`may be given without a value.`},
+		{"overflow", "count=300",
+			`Invalid value for "count" at :1:7 (6). strconv.ParseInt: ` +
+				`parsing "300": value out of range.`},
+		{"duration", "timeout='soon'",
+			`Invalid value for "timeout" at :1:9 (8). time: invalid ` +
+				`duration "soon".`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			var opts tag_options
+			err := textparser.UnmarshalTag(test_data.Tag, &opts)
+			if err == nil || err.Error() != test_data.Expected {
+				st.Errorf("got %v, expected %s", err, test_data.Expected)
+			}
+
+			if code := textparser.ErrorCodeOf(err); code !=
+				textparser.CodeTagValue {
+				st.Errorf("got code %s, expected %s", code,
+					textparser.CodeTagValue)
+			}
+		})
+	}
+
+	if err := textparser.UnmarshalTag("a", tag_options{}); err == nil {
+		t.Errorf("expected an error for a non-pointer")
+	}
+}
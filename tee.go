@@ -0,0 +1,152 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"sync"
+)
+
+// One of the readers returned by TeeTokens().
+type TokenTee struct {
+	tee    *token_tee
+	next   int64 // Index of the next token in the stream.
+	closed bool
+}
+
+// The state shared by the readers returned by TeeTokens().
+type token_tee struct {
+	mu      sync.Mutex
+	ts      *TokenScanner
+	buf     []*ScannedToken // Tokens not yet read by every reader.
+	base    int64           // Index of buf[0] in the stream.
+	readers []*TokenTee
+	err     error // The error that ended scanning, if it has ended.
+}
+
+// Returns n readers that each see every token scanned from ts, e.g., to feed
+// the same stream to a highlighter and a parser. The tokens are scanned once
+// and buffered only until the slowest reader has read them, so a reader that
+// falls far behind, or stops reading without calling Close(), makes the
+// buffer grow. The readers may be used from different goroutines. The
+// scanner should not be used directly while the readers are in use.
+func TeeTokens(ts *TokenScanner, n int) []*TokenTee {
+	tee := &token_tee{ts: ts}
+	for i := 0; i < n; i++ {
+		tee.readers = append(tee.readers, &TokenTee{tee: tee})
+	}
+
+	return append([]*TokenTee(nil), tee.readers...)
+}
+
+// Returns the next token along with its span, or nil and the error that
+// ended scanning, which is io.EOF at the end of the input. The tokens are
+// shared with the other readers, so they must not be modified.
+func (r *TokenTee) Next() (*ScannedToken, error) {
+	tee := r.tee
+	tee.mu.Lock()
+	defer tee.mu.Unlock()
+
+	if r.closed {
+		return nil, io.EOF
+	}
+
+	i := int(r.next - tee.base)
+	if i == len(tee.buf) {
+		if tee.err != nil {
+			return nil, tee.err
+		}
+		if !tee.scan() {
+			return nil, tee.err
+		}
+	}
+
+	st := tee.buf[i]
+	r.next++
+	tee.trim()
+
+	return st, nil
+}
+
+// Returns the number of tokens buffered for the reader, i.e., scanned but
+// not yet read by it.
+func (r *TokenTee) Buffered() int {
+	tee := r.tee
+	tee.mu.Lock()
+	defer tee.mu.Unlock()
+
+	if r.closed {
+		return 0
+	}
+
+	return int(tee.base + int64(len(tee.buf)) - r.next)
+}
+
+// Stops the reader, so that the other readers no longer buffer tokens for
+// it. Next() then returns io.EOF.
+func (r *TokenTee) Close() {
+	tee := r.tee
+	tee.mu.Lock()
+	defer tee.mu.Unlock()
+
+	r.closed = true
+	tee.trim()
+}
+
+// Scans the next token into the buffer, returning false, with err set, if
+// scanning has ended.
+func (tee *token_tee) scan() bool {
+	ts := tee.ts
+	if !ts.Scan() {
+		if tee.err = ts.Err(); tee.err == nil {
+			// Stopped by ScanLimit().
+			tee.err = io.EOF
+		}
+		return false
+	}
+
+	tee.buf = append(tee.buf, ts.owned_token())
+
+	return true
+}
+
+// Drops the buffered tokens every open reader has read.
+func (tee *token_tee) trim() {
+	low := tee.base + int64(len(tee.buf))
+	for _, r := range tee.readers {
+		if !r.closed && r.next < low {
+			low = r.next
+		}
+	}
+
+	n := int(low - tee.base)
+	for i := 0; i < n; i++ {
+		tee.buf[i] = nil
+	}
+	tee.buf = tee.buf[n:]
+	tee.base = low
+}
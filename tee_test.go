@@ -0,0 +1,98 @@
+package textparser_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func read_tee(r *textparser.TokenTee, n int) []string {
+	got := []string{}
+	for i := 0; n < 0 || i < n; i++ {
+		st, err := r.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, st.Text)
+	}
+
+	return got
+}
+
+func TestTeeTokens(t *testing.T) {
+	ts := textparser.NewScanner(strings.NewReader("a b c d e"))
+	ts.SkipWhitespace = true
+	ts.ReuseToken = true
+	readers := textparser.TeeTokens(ts, 3)
+	a, b, c := readers[0], readers[1], readers[2]
+
+	expected := []string{"a", "b", "c", "d", "e"}
+	if got := read_tee(a, -1); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+	if got := a.Buffered(); got != 0 {
+		t.Errorf("got %d buffered for the first reader, expected 0", got)
+	}
+	if got := b.Buffered(); got != 5 {
+		t.Errorf("got %d buffered for the second reader, expected 5", got)
+	}
+
+	if got := read_tee(b, 2); !reflect.DeepEqual(got, expected[:2]) {
+		t.Errorf("got %#v, expected %#v", got, expected[:2])
+	}
+	if got := b.Buffered(); got != 3 {
+		t.Errorf("got %d buffered for the second reader, expected 3", got)
+	}
+
+	c.Close()
+	if _, err := c.Next(); err != io.EOF {
+		t.Errorf("got %v from a closed reader, expected EOF", err)
+	}
+
+	if got := read_tee(b, -1); !reflect.DeepEqual(got, expected[2:]) {
+		t.Errorf("got %#v, expected %#v", got, expected[2:])
+	}
+	if _, err := b.Next(); err != io.EOF {
+		t.Errorf("got %v at the end, expected EOF", err)
+	}
+}
+
+func TestTeeTokensConcurrent(t *testing.T) {
+	input := strings.Repeat("x = 1 + foo(2, \"three\");\n", 200)
+	expected, err := textparser.TokenizeAll(
+		textparser.NewScanner(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("TokenizeAll() failed: %s", err)
+	}
+
+	readers := textparser.TeeTokens(
+		textparser.NewScanner(strings.NewReader(input)), 2)
+
+	got := make([][]*textparser.ScannedToken, len(readers))
+	var wg sync.WaitGroup
+	for i, r := range readers {
+		wg.Add(1)
+		go func(i int, r *textparser.TokenTee) {
+			defer wg.Done()
+			for {
+				st, err := r.Next()
+				if err != nil {
+					return
+				}
+				got[i] = append(got[i], st)
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	for i := range readers {
+		if !reflect.DeepEqual(got[i], expected) {
+			t.Errorf("reader %d got %d tokens, expected %d, or they differ",
+				i, len(got[i]), len(expected))
+		}
+	}
+}
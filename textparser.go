@@ -72,7 +72,7 @@
 //	nofile:4:9 (50)  - Ident  -> b
 //	nofile:4:11 (52) - Symbol -> =
 //	nofile:4:13 (54) - String -> "this is a string"
-//	nofile:4:30 (72) - Symbol -> ;
+//	nofile:4:31 (72) - Symbol -> ;
 //	nofile:5:9 (82)  - Ident  -> c
 //	nofile:5:11 (84) - Symbol -> =
 //	nofile:5:13 (86) - Float  -> 7.2
@@ -81,12 +81,13 @@
 package textparser
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
 	"io"
-	"strings"
-	utf8 "unicode/utf8"
+	"log/slog"
+	"net/netip"
+	"time"
+	"unicode/utf8"
+	"unsafe"
 )
 
 type TokenType int
@@ -100,17 +101,41 @@ const (
 	TokenTypeInt
 	TokenTypeFloat
 	TokenTypeSymbol
+	TokenTypeUnknown
+	TokenTypeSection  // A section header, e.g., "[server]".
+	TokenTypeDateTime // A date and/or time, e.g., "1979-05-27T07:32:00Z".
+	TokenTypeField    // A field in delimited mode (see SetDelimited()).
+	TokenTypeDelimiter
+	TokenTypeNewline
+	TokenTypeParameter // A bind parameter (see ParameterPrefixes).
+	TokenTypeVariable  // A variable reference (see Variables).
+	TokenTypeText      // Plain text in markdown inline mode.
+	TokenTypeEmphasis  // An emphasis delimiter run, e.g., "**".
+	TokenTypeCode      // A code span, e.g., "`x`".
+	TokenTypeAutolink  // An autolink, e.g., "<https://example.com>".
+	TokenTypeLevel     // A log level word (see SetLevelWords()).
+	TokenTypeAddress   // An IP address or CIDR prefix (see Addresses).
+	TokenTypeKey       // The key of a key=value pair (see KeyValuePairs).
+	TokenTypeURL       // A URL, e.g., "https://example.com/" (see URLs).
+	TokenTypeIndent    // An increase in indentation (see SetIndentation()).
+	TokenTypeDedent    // A decrease in indentation (see SetIndentation()).
+	TokenTypeKeyword   // A keyword (see SetKeywords()).
+	TokenTypeRegion    // A region marker (see SetRegionMarkers()).
 )
 
+var token_type_names = [...]string{"Whitespace", "Ident", "String",
+	"Comment", "Int", "Float", "Symbol", "Unknown", "Section", "DateTime",
+	"Field", "Delimiter", "Newline", "Parameter", "Variable", "Text",
+	"Emphasis", "Code", "Autolink", "Level", "Address", "Key",
+	"URL", "Indent", "Dedent", "Keyword", "Region"}
+
 // Returns a string representation of the token type.
 func (t TokenType) String() string {
-	types := [...]string{"Whitespace", "Ident", "String", "Comment",
-		"Int", "Float", "Symbol"}
-	if int(t) > len(types)-1 {
+	if t < 0 || int(t) > len(token_type_names)-1 {
 		return ""
 	}
 
-	return types[t]
+	return token_type_names[t]
 }
 
 // Represents the position of the current token.
@@ -134,40 +159,350 @@ type Token struct {
 	NumChars  int       // Number of characters/runes in the token.
 	FirstRune rune      // First rune in the token.
 	Type      TokenType // The type of token.
+
+	// The text of the token as scanned, if it differs from Text, e.g.,
+	// because an emit transform changed it, or for a quoted field in
+	// delimited mode (see SetDelimited()). Otherwise, empty. NumBytes and
+	// NumChars always describe the raw text.
+	Raw string
+
+	// Whether the token is an opening or closing bracket (see
+	// SetBrackets()), or region marker (see SetRegionMarkers()).
+	Bracket BracketKind
+
+	// The name of the region a TokenTypeRegion token opens or closes, e.g.,
+	// "Setup" for "//#region Setup". Otherwise, empty.
+	Region string
+
+	// Whether the token was cut at MaxTokenBytes, so that the rest of it
+	// follows in the next token.
+	Continued bool
+
+	// The parsed value of the token, if it has one: a time.Time for a
+	// TokenTypeDateTime token (see DateTimes), or a netip.Prefix for a
+	// TokenTypeAddress token (see Addresses). Otherwise, nil. Kept behind
+	// an interface, so that tokens without one stay small. See Time() and
+	// Prefix().
+	Parsed any
+
+	// The counts of spaces, tabs, and newlines in a TokenTypeWhitespace
+	// token. Otherwise, zero.
+	Whitespace WhitespaceInfo
+
+	// The suffix of a number token (see SetNumberSuffixes()), e.g., "u8"
+	// for "10u8", also included in Text. Otherwise, empty.
+	Suffix string
+
+	// The spans of the string literals merged into a TokenTypeString token
+	// (see ConcatStrings), if there is more than one. Otherwise, nil.
+	Parts []Span
+
+	// The prefix of a prefixed string literal (see SetStringPrefixes()),
+	// e.g., "r" for r"\d+", also included in Text. Otherwise, empty.
+	Subtype string
+
+	// The comment following the token on the same line, if
+	// TrailingComments is set and there is one. Otherwise, nil.
+	TrailingComment *Token
+
+	// The identifiers of a qualified name joined by namespace separators
+	// (see SetNamespaceSeparators()), e.g., "std" and "vector" for
+	// "std::vector". Otherwise, nil.
+	Segments []string
+
+	// The decoded text of the token, if it differs from Text, e.g., with
+	// line endings normalized (see NormalizeValueEOL), or a number without
+	// group separators (see NumberLocale). Otherwise, empty. ValueText()
+	// returns whichever applies.
+	Value string
+
+	// The UTF-8 bytes of Text, if ByteTokens is set. Otherwise, nil. The
+	// bytes must not be modified, and are only valid until the next call to
+	// Scan().
+	Bytes []byte
+}
+
+// Returns the parsed value of a TokenTypeDateTime token (see DateTimes). It
+// is the zero time for other tokens, and for dates that do not exist, e.g.,
+// "2024-02-30".
+func (t *Token) Time() time.Time {
+	value, _ := t.Parsed.(time.Time)
+	return value
+}
+
+// Returns the parsed value of a TokenTypeAddress token (see Addresses). A
+// plain address, e.g., "10.0.0.1", is a prefix covering just the address, so
+// Prefix().Addr() returns the address. Otherwise, the zero prefix.
+func (t *Token) Prefix() netip.Prefix {
+	value, _ := t.Parsed.(netip.Prefix)
+	return value
 }
 
+// Returns the text of the token as scanned, before any emit transforms set
+// with SetTransform() were applied.
+func (t *Token) RawText() string {
+	if t.Raw != "" {
+		return t.Raw
+	}
+
+	return t.Text
+}
+
+// Returns a string representation of the token, with text longer than
+// DisplayTextLimit cut short.
 func (t *Token) String() string {
-	s := fmt.Sprintf("t=%s r=%c nc=%d nb=%d: %q", t.Type, t.FirstRune,
-		t.NumChars, t.NumBytes, t.Text)
+	return t.format(DisplayTextLimit)
+}
+
+func (t *Token) format(limit int) string {
+	s := fmt.Sprintf("t=%s r=%c nc=%d nb=%d: %s", t.Type, t.FirstRune,
+		t.NumChars, t.NumBytes, display_text(t.Text, limit))
 	return s
 }
 
 // A TokenScanner.
 type TokenScanner struct {
 	filename           string
-	reader             *bufio.Reader
+	source             io.Reader
+	source_sizes       *rune_sizes
+	input_ready        bool
+	reader             *rune_buffer
 	pos                *Position
 	old_pos            *Position
 	last_err           error
 	last_byte_len      int
 	last_line_addition int
+	read_bytes         int // Bytes taken from the reader for the token.
+	last_rune_size     int // Size of the last rune read, for unreading.
 	last_col           int
 	eol                rune
+	grapheme           grapheme_state
+	line_blank         bool // Only white space so far on the current line.
+
+	logger *slog.Logger
+
+	transforms map[TokenType][]TextTransform
+
+	line_directive_prefix []rune
+	line_directive_parse  LineDirectiveParser
+	pending_filename      *string
+	pending_pos           *Position // Position to move to, e.g., a new file.
+	includes              []include_frame
+	appended              []appended_source
+
+	predicate_caches map[string]*PredicateCache
+	regexp_match     regexp_match // Last match of a regexp matcher.
+
+	comments  []comment_delims
+	operators [][]rune
+	brackets  [][2]rune
+	context   token_context
+	scan_ctx  scan_context
+	tx        *tx_state
+	progress  *progress_state
+	deadline  *deadline_state
+	dsv       *dsv_state
+	markdown  *markdown_state
+	indent    *indent_state
+
+	continuations []LineContinuation
+	after_marker  bool // The last token was a kept continuation marker.
+	joined        int  // Number of line continuations read.
+
+	level_words      []string
+	number_suffixes  [][]rune
+	string_run       *string_run // Open run of literals (see ConcatStrings).
+	trailing         *trailing_hold
+	region_markers   RegionMarkers
+	regions          []open_region // Open regions, innermost last.
+	string_prefixes  []string_prefix
+	name_separators  [][]rune
+	keywords         map[string]bool
+	datetime_layouts []string
+
+	file_root string
+
+	suppress_directive string
+	suppressions       []Suppression
+
+	limit scan_limit
 
 	did_unread_token bool
 	unread_token_pos *Position
 	unread_token     *Token
 	old_token        *Token
+	end_pos          Position
+	old_end_pos      Position
+	unread_end_pos   Position
+
+	// Alternating tokens handed out when ReuseToken is set. Two are needed
+	// so that the token restored by UnreadToken() stays intact.
+	token_buf     [2]Token
+	token_buf_idx int
+
+	bytes byte_buffers
+
+	// The file mapped by NewScannerFile(), until Close().
+	mapped []byte
+
+	cut *cut_token // The rest of a token cut at MaxTokenBytes.
+
+	// Tokens to return from Scan() before reading more input, e.g.,
+	// synthesized by indentation tracking, or split by middleware.
+	queue []*ScannedToken
+
+	// Functions applied to each token scanned (see Use()).
+	middleware []split_func
+
+	// Token types to skip, in addition to whitespace and comments (see
+	// SkipTypes()).
+	skip_types map[TokenType]bool
+
+	// The chain of matchers trying to recognize each token (see
+	// SetMatchers()). Shared with default_matchers until changed.
+	matchers []TokenMatcher
+
+	// Runes of the current token, reused from token to token by the most
+	// common recognizers, which copy them into the token's text.
+	scratch []rune
 
 	// Indicator to skip whitespace tokens.
 	SkipWhitespace bool
 
-	// Indicator to skip comment tokens.
+	// Indicator to skip comment tokens. To skip tokens of other types, see
+	// SkipTypes().
 	SkipComments bool
 
 	// The most recent Token generated by a call to Scan().
 	LastToken *Token
 
+	// Indicator to reuse the Token structs returned by Scan() instead of
+	// allocating a new one for each token. When set, the Token returned by
+	// Token() (and LastToken) is only valid until the next call to Scan().
+	// Callers that retain tokens must copy them first.
+	ReuseToken bool
+
+	// Indicator to scan in byte mode, where the text of each token refers to
+	// an internal buffer holding the bytes read for it, also available as
+	// Token.Bytes, instead of being copied into a new string, unless the
+	// text differs from the bytes read, e.g., for a quoted string with
+	// collapsed escapes. Combined with ReuseToken, this avoids allocating
+	// anything per token. Token text, including Raw, is then only valid until
+	// the next call to Scan(), so callers that retain tokens, or text taken
+	// from them, must copy them first (see CloneMutable()). For a file
+	// mapped by NewScannerFile(), the text refers to the file instead.
+	ByteTokens bool
+
+	// If positive, the most bytes of a string, identifier, or run of white
+	// space delivered in one token, so that, e.g., a huge base64 blob is
+	// never held in memory in full. A longer token is delivered in pieces,
+	// each but the last with Continued set, and the pieces after the first
+	// have no opening quote and are always TokenTypeIdent for identifiers.
+	// Other tokens are never cut.
+	MaxTokenBytes int
+
+	// The Unicode normalization form applied to the input before runes are
+	// classified, so that, e.g., identifiers written with combining marks
+	// compare equal to their precomposed forms. The default is
+	// NoNormalization. This must be set before the first call to Scan().
+	// Offsets reported in Position refer to the normalized text.
+	Normalize Normalization
+
+	// Indicator to set the Value of string and comment tokens with "\r\n"
+	// or "\r" line endings to their text with the line endings normalized
+	// to "\n", so that, e.g., values read on different platforms compare
+	// equal. Text keeps the line endings as scanned.
+	NormalizeValueEOL bool
+
+	// Indicator to merge string literals separated only by whitespace and
+	// comments into a single TokenTypeString token, as C does, e.g.,
+	// "\"ab\" /* x */ \"cd\"" is one token with the Text "\"abcd\"" (in
+	// the quotes of the first literal), with the literals, gaps included,
+	// as Raw, and the span of each literal in Parts. Whitespace and comments
+	// that are not skipped are dropped when another literal follows them.
+	ConcatStrings bool
+
+	// Indicator to set the TrailingComment of a token followed by a comment
+	// on the same line, with only spaces and tabs between them, e.g., for
+	// "foo = 1  # legacy", that of "1", whether or not comments are
+	// skipped.
+	TrailingComments bool
+
+	// Controls what happens when a rune is not recognized as the start of any
+	// kind of token. The default is UnknownStop.
+	Unknown UnknownMode
+
+	// The type of the tokens emitted for unrecognized runes when Unknown is
+	// UnknownRune or UnknownRun. The default is TokenTypeUnknown.
+	UnknownType TokenType
+
+	// Controls how Position.Column is counted. The default is ColumnRunes.
+	ColumnMode ColumnMode
+
+	// Extensions to the syntax of numbers, e.g., exponents. The default is
+	// none of them.
+	Numbers NumberSyntax
+
+	// The digit grouping and decimal separators of numbers, e.g.,
+	// NumberLocaleEuropean for "1.234,56", which is then a single
+	// TokenTypeFloat token with the Value "1234.56". The default is no
+	// grouping and a decimal point.
+	NumberLocale NumberLocale
+
+	// Indicator to scan no numbers. Digits are identifier runes instead,
+	// so that signs and decimal points are never part of a token with
+	// digits, e.g., "AB-12.5" is "AB", "-", "12", ".", "5".
+	DisableNumbers bool
+
+	// Controls whether a minus sign followed by a digit starts a number.
+	// The default is SignNumber. With SignSymbol, a minus sign is always a
+	// symbol token, so that, e.g., "a -1" and "a - 1" scan alike.
+	Signs SignMode
+
+	// Indicator to let a plus sign followed by a digit start a number, as a
+	// minus sign does, e.g., "+42" or "+1.5", subject to Signs.
+	AllowLeadingPlus bool
+
+	// Controls how file names set by SetFilename() or line directives are
+	// recorded in Position.Filename. The default is FilenameAsIs.
+	FilenameStyle FilenameStyle
+
+	// Indicator to emit a '[' that is the first non-space rune on a line,
+	// through the matching ']' (or "]]") on the same line, as a
+	// TokenTypeSection token, as in INI and TOML files.
+	SectionHeaders bool
+
+	// Indicator to emit dates and times in the forms used by TOML (RFC 3339
+	// with optional parts, e.g., "1979-05-27", "07:32:00", or
+	// "1979-05-27 07:32:00.5-07:00"), or in the layouts set by
+	// SetDateTimeLayouts(), as TokenTypeDateTime tokens, with the parsed
+	// value in Token.Time(). Values without a time zone are in UTC.
+	DateTimes bool
+
+	// Indicator to emit IPv4 and IPv6 addresses and CIDR prefixes, e.g.,
+	// "10.0.0.1", "::1", or "10.0.0.0/8", as TokenTypeAddress tokens, with
+	// the parsed value in Token.Prefix().
+	Addresses bool
+
+	// Indicator to emit an identifier immediately followed by "=" (but not
+	// "=="), e.g., "status" in "status=200", as a TokenTypeKey token.
+	KeyValuePairs bool
+
+	// Indicator to emit URLs with a scheme followed by "://", e.g.,
+	// "https://example.com/a?b=c#d", as TokenTypeURL tokens, rather than as
+	// a run of identifiers and symbols. Punctuation ending a sentence is
+	// left out, as is a closing parenthesis without a matching opening one.
+	// URLs longer than 1024 characters are cut short.
+	URLs bool
+
+	// Level at which recognizer and token events are logged, if a logger has
+	// been set with SetLogger(). The default is slog.LevelDebug.
+	LogLevel slog.Level
+
+	// Level at which scan errors are logged, if a logger has been set with
+	// SetLogger(). The default is slog.LevelWarn.
+	LogErrorLevel slog.Level
+
 	// Predicate controlling the characters accepted as the i'th rune in an
 	// identifier (starting at zero). `runes` is the slice of runes accepted
 	// so far for this token. The set of valid characters must not
@@ -192,6 +527,52 @@ type TokenScanner struct {
 	// runes already accepted for this token.
 	IsEscapeRune func(ch rune, i int, runes []rune) bool
 
+	// Controls how a doubled escape rune inside a quoted string is handled.
+	//
+	// Inside quotes, an escape rune escapes exactly one following rune, so a
+	// run of escape runes pairs up from the left: an even-length run is made
+	// up entirely of escaped escape runes, and only the last rune of an
+	// odd-length run escapes whatever follows it. An escaped closing quote
+	// is included in the token text without its escape rune. A closing quote
+	// preceded by an even-length run terminates the string, e.g.,
+	// 'ends with backslash\\'.
+	//
+	// If false (the default), both runes of an escaped escape rune are kept
+	// in the token text. If true, the pair is collapsed into a single escape
+	// rune. Other escaped runes are always kept along with their escape rune.
+	CollapseEscapes bool
+
+	// Indicator to take a closing quote immediately followed by another one
+	// inside a quoted string as a single literal quote, as in SQL, e.g.,
+	// 'it''s'. The token text includes just one of the two quotes.
+	DoubledQuotes bool
+
+	// Predicate controlling which opening quote runes start quoted
+	// identifiers rather than strings, e.g., "name" or [name] in SQL. Quoted
+	// identifiers are scanned like strings, but emitted as TokenTypeIdent
+	// tokens. The default, nil, means none do.
+	IsIdentQuoteRune func(ch rune) bool
+
+	// Runes that start a bind parameter, e.g., ":" for ":name", emitted as a
+	// TokenTypeParameter token. The prefix must be followed by identifier
+	// runes or digits, except for "?", which may stand alone, e.g., "?" or
+	// "?1". The default is none.
+	ParameterPrefixes string
+
+	// Indicator to emit variable references, "$NAME" or "${...}" (up to the
+	// closing brace on the same line), as TokenTypeVariable tokens. The name
+	// following the sigil is made of identifier runes (see IsIdentRune), so
+	// shell positional and special parameters, e.g., "$1" or "$@", are not
+	// variables unless IsIdentRune accepts their runes first.
+	Variables bool
+
+	// Sigils starting variable references when Variables is set, e.g.,
+	// "$@%&" for Perl's "$x", "@list", "%map", and "&sub". A sigil only
+	// starts a variable when a name or an opening brace directly follows
+	// it, so that, e.g., "a % b" is still a symbol. The default, "", means
+	// "$".
+	VariableSigils string
+
 	// Predicate controlling the characters accepted as the i'th rune in a
 	// symbol token (starting at zero). `runes` is the list of runes already
 	// accepted for this token. The default predicate considers each symbol to
@@ -212,7 +593,12 @@ func (ts *TokenScanner) Position() *Position {
 	return ts.pos
 }
 
-// Returns a new TokenScanner initialized with the provided reader.
+// Returns a new TokenScanner initialized with the provided reader. An
+// io.RuneScanner, e.g., a *bufio.Reader or *strings.Reader, is read directly
+// rather than through another buffer, and runes looked ahead at are given
+// back to it after each call to Scan(), so that the caller may interleave
+// its own reads: a single rune through UnreadRune(), or any number if it is
+// also an io.Seeker. Positions don't account for input read by the caller.
 func NewScanner(r io.Reader) *TokenScanner {
 	ts := new(TokenScanner)
 	ts.Init(r)
@@ -220,26 +606,35 @@ func NewScanner(r io.Reader) *TokenScanner {
 }
 
 // Returns a TokenScanner initialized with the contents of the provided
-// string.
+// string, which is scanned in place rather than through a buffer.
 func NewScannerString(s string) *TokenScanner {
-	return NewScanner(strings.NewReader(s))
+	return NewScanner(&slice_reader{
+		data: unsafe.Slice(unsafe.StringData(s), len(s)),
+	})
 }
 
 // Returns a TokenScanner initialized with the contents of the provided
-// byte slice.
+// byte slice, which is scanned in place rather than through a buffer, so it
+// must not be modified while the scanner is in use. In byte mode (see
+// ByteTokens), the text of tokens refers to the slice itself.
 func NewScannerBytes(b []byte) *TokenScanner {
-	return NewScanner(bytes.NewReader(b))
+	return NewScanner(&slice_reader{data: b})
 }
 
 // Initializes a TokenScanner with the provided reader. This is only needed if
 // a TokenScanner is created outside of one of the New* functions.
 func (ts *TokenScanner) Init(r io.Reader) {
-	ts.reader = bufio.NewReader(r)
+	ts.source = r
+	ts.source_sizes = nil
+	ts.input_ready = false
+	ts.reader = new_rune_buffer(r)
 	ts.pos = &Position{
 		Line:   1,
 		Column: 1,
 	}
 	ts.old_pos = &Position{}
+	ts.end_pos = *ts.pos
+	ts.old_end_pos = *ts.pos
 
 	ts.IsIdentRune = IsIdentRune
 	ts.IsSpaceRune = IsSpaceRune
@@ -251,13 +646,62 @@ func (ts *TokenScanner) Init(r io.Reader) {
 	ts.SkipWhitespace = true
 	ts.SkipComments = true
 
+	ts.UnknownType = TokenTypeUnknown
+
+	ts.LogLevel = slog.LevelDebug
+	ts.LogErrorLevel = slog.LevelWarn
+
 	ts.last_byte_len = 0
 	ts.last_line_addition = 0
+	ts.read_bytes = 0
 	ts.last_col = 1
+	ts.line_blank = true
 
 	ts.eol = '\n'
 
+	ts.SetComments(DefaultCommentStyles...)
+	ts.operators = nil
+	ts.brackets = nil
+	ts.level_words = nil
+	ts.number_suffixes = nil
+	ts.string_prefixes = nil
+	ts.name_separators = nil
+	ts.keywords = nil
+	ts.datetime_layouts = nil
+	ts.context = token_context{}
+	ts.scan_ctx = scan_context{}
+	ts.tx = nil
+	ts.progress = nil
+	ts.deadline = nil
+	ts.dsv = nil
+	ts.markdown = nil
+	ts.indent = nil
+	ts.continuations = nil
+	ts.after_marker = false
+	ts.joined = 0
+	ts.pending_pos = nil
+	ts.includes = nil
+	ts.regexp_match = regexp_match{}
+	ts.appended = nil
+	ts.cut = nil
+	ts.string_run = nil
+	ts.trailing = nil
+	ts.region_markers = RegionMarkers{}
+	ts.regions = nil
+	ts.matchers = default_matchers
+	ts.skip_types = nil
+	ts.queue = nil
+	ts.middleware = nil
+
+	ts.FilenameStyle = FilenameAsIs
+	ts.file_root = ""
+
+	ts.suppress_directive = DefaultSuppressDirective
+	ts.suppressions = nil
+
 	ts.unread_token_pos = &Position{}
+
+	ts.limit = scan_limit{}
 }
 
 // Returns the last error encountered.
@@ -270,9 +714,35 @@ func (ts *TokenScanner) Token() *Token {
 	return ts.LastToken
 }
 
+// Returns a Token to be filled in by a recognizer. This is a new Token unless
+// ReuseToken is set.
+func (ts *TokenScanner) new_token() *Token {
+	if !ts.ReuseToken {
+		return new(Token)
+	}
+
+	ts.token_buf_idx ^= 1
+
+	return &ts.token_buf[ts.token_buf_idx]
+}
+
 func (ts *TokenScanner) set_token(t *Token) {
 	ts.old_token = ts.LastToken
 	ts.LastToken = t
+
+	ts.old_end_pos = ts.end_pos
+	ts.end_pos = ts.pending_end_pos()
+}
+
+// Returns the position just past the runes accepted so far for the current
+// token.
+func (ts *TokenScanner) pending_end_pos() Position {
+	return Position{
+		Filename: ts.pos.Filename,
+		Offset:   ts.pos.Offset + ts.last_byte_len,
+		Line:     ts.pos.Line + ts.last_line_addition,
+		Column:   ts.last_col,
+	}
 }
 
 // Pretends the current token was not read. The next call to `Scan()` and
@@ -288,6 +758,8 @@ func (ts *TokenScanner) UnreadToken() error {
 	*ts.unread_token_pos = *ts.pos
 	*ts.pos = *ts.old_pos
 	ts.LastToken = ts.old_token
+	ts.unread_end_pos = ts.end_pos
+	ts.end_pos = ts.old_end_pos
 
 	ts.did_unread_token = true
 
@@ -304,17 +776,33 @@ func (ts *TokenScanner) TokenText() string {
 }
 
 // Returns the text from the most recent token generated by a call to Scan().
-// If the token is a quoted string, the surrounding quotes are removed.
+// If the token is a quoted string, the surrounding quotes, and any prefix
+// (see SetStringPrefixes()), are removed. For a string cut at MaxTokenBytes,
+// only the first piece has the opening quote removed, and only the last the
+// closing one.
 func (ts *TokenScanner) TokenTextNoQuotes() string {
-	if ts.LastToken == nil {
+	token := ts.LastToken
+	if token == nil {
 		return ""
 	}
 
-	if ts.LastToken.Type == TokenTypeString {
-		return ts.LastToken.Text[1 : len(ts.LastToken.Text)-1]
+	if token.Type != TokenTypeString {
+		return token.Text
 	}
 
-	return ts.LastToken.Text
+	text := token.Text
+	if ts.old_token == nil || !ts.old_token.Continued {
+		text = text[len(token.Subtype):]
+		_, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+	}
+
+	if !token.Continued {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+	}
+
+	return text
 }
 
 // Sets the rune considered to be the end-of-line character.
@@ -322,15 +810,29 @@ func (ts *TokenScanner) SetEOL(eol rune) {
 	ts.eol = eol
 }
 
-// Sets the file name returned in the Position object.
+// Sets the file name returned in the Position object, as transformed by
+// DisplayFilename().
 func (ts *TokenScanner) SetFilename(filename string) {
-	ts.pos.Filename = filename
+	ts.pos.Filename = ts.DisplayFilename(filename)
+	ts.end_pos.Filename = ts.pos.Filename
 }
 
 func (ts *TokenScanner) update_pos() {
 	pos := ts.pos
 
 	*ts.old_pos = *pos
+	ts.reset_bytes()
+	ts.read_bytes = 0
+
+	// Move to a different reader (see PushReader()).
+	if ts.pending_pos != nil {
+		*pos = *ts.pending_pos
+		ts.pending_pos = nil
+		ts.last_byte_len = 0
+		ts.last_line_addition = 0
+		ts.last_col = pos.Column
+		return
+	}
 
 	// Add the byte length of the last token.
 	pos.Offset += ts.last_byte_len
@@ -343,21 +845,53 @@ func (ts *TokenScanner) update_pos() {
 	// Set to the last column count. `last_col` gets reset to 1 anytime the
 	// end-of-line character is found.
 	pos.Column = ts.last_col
+
+	// Apply a file name change from a line directive.
+	if ts.pending_filename != nil {
+		pos.Filename = *ts.pending_filename
+		ts.pending_filename = nil
+	}
 }
 
 // Scans the next token, skipping whitespace and comments, unless configured
 // differently. Returns true if another token was found. Returns false when
 // parsing is completed. Check ts.Err() for parsing errors.
+//
+// The first rune of each token is classified once by the chain of matchers
+// (see SetMatchers()), and the token is read by the first one matching. By
+// default, the chain checks the following, in order: line directive (see
+// SetLineDirective()), line continuation (see SetLineContinuations()),
+// whitespace (IsSpaceRune), comment (see SetComments()), section header (if
+// SectionHeaders is set), quoted string (IsQuoteRune), bind parameter (see
+// ParameterPrefixes), variable (if Variables is set), URL (if URLs is set), IP
+// address (if Addresses is set), date/time (if DateTimes is set), identifier
+// (IsIdentRune), number (IsDigitRune, or a minus sign, or a plus sign if
+// AllowLeadingPlus is set, followed by a digit, unless DisableNumbers is
+// set), and symbol (IsSymbolRune). If the rune matches none of them, the
+// Unknown setting decides what happens.
 func (ts *TokenScanner) Scan() bool {
+	if ts.tx != nil {
+		return ts.scan_tx()
+	}
+
+	return ts.scan()
+}
+
+// Scans the next token from the input. See Scan().
+func (ts *TokenScanner) scan() bool {
 	var (
-		done  bool
 		err   error
 		token *Token
+		class rune_class
+		name  string
 	)
 
+	ts.prepare_input()
+
 	if ts.did_unread_token {
 		ts.LastToken = ts.unread_token
 		*ts.pos = *ts.unread_token_pos
+		ts.end_pos = ts.unread_end_pos
 		ts.unread_token = nil
 		ts.unread_token_pos = nil
 		ts.did_unread_token = false
@@ -365,66 +899,235 @@ func (ts *TokenScanner) Scan() bool {
 		return true
 	}
 
-	defer func() { ts.last_err = err }()
+	if len(ts.queue) > 0 {
+		if ts.token_limit_reached() {
+			ts.limit.truncated = true
+			return false
+		}
+		return ts.emit_queued()
+	}
+
+	defer func() {
+		ts.last_err = err
+		if err != nil && err != io.EOF {
+			ts.log_error(err)
+		}
+		if ts.progress != nil {
+			ts.report_progress(err == io.EOF)
+		}
+		ts.reader.release()
+	}()
+
+	if ts.deadline != nil {
+		ts.start_deadline()
+	}
+
+	for {
+		if ts.limit_reached() {
+			ts.flush_held()
+			if len(ts.queue) > 0 && !ts.token_limit_reached() {
+				return ts.emit_queued()
+			}
+			return false
+		}
 
-	for !done {
 		ts.update_pos()
 
-		token, err = ts.get_whitespace()
-		if token != nil {
-			if ts.SkipWhitespace {
-				continue
+		if ts.cut != nil {
+			class, token, err = ts.resume_cut()
+			name = class.String()
+		} else {
+			var m TokenMatcher
+			if ts.dsv != nil {
+				m = class_matcher(class_dsv)
+			} else if ts.markdown != nil {
+				m = class_matcher(class_markdown)
+			} else if m, err = ts.classify(); err != nil {
+				if err == io.EOF && ts.pop_reader() {
+					err = nil
+					continue
+				}
+				if err == io.EOF {
+					ts.flush_held()
+				}
+				if err == io.EOF && ts.indent_enabled() {
+					ts.enqueue(ts.indent_eof()...)
+				}
+				if err == io.EOF && len(ts.queue) > 0 {
+					err = nil
+					return ts.emit_queued()
+				}
+				if err == io.EOF && len(ts.regions) > 0 {
+					err = ts.region_eof_error()
+				}
+				return false
 			}
-			return true
+
+			class, name, token, err = ts.read_match(m)
+		}
+
+		if err == io.EOF && token == nil && ts.pop_reader() {
+			err = nil
+			continue
+		}
+
+		if ts.deadline != nil && ts.deadline.expired {
+			// Whatever the recognizer made of the error, e.g., an
+			// unterminated string, the scan was aborted.
+			err = ts.deadline_error()
 		}
+
 		if err != nil {
 			return false
 		}
 
-		token, err = ts.get_comment()
-		if token != nil {
-			if ts.SkipComments {
-				continue
+		if token == nil {
+			// The recognizer disagreed with the classification, which
+			// can only happen with inconsistent predicates.
+			return false
+		}
+
+		if ts.ByteTokens {
+			ts.pin_bytes(token)
+		}
+
+		if ts.NormalizeValueEOL {
+			ts.normalize_value_eol(token)
+		}
+
+		if class == class_comment && ts.region_markers != (RegionMarkers{}) {
+			if err = ts.note_region(token); err != nil {
+				return false
 			}
-			return true
 		}
-		if err != nil {
-			return false
+
+		ts.log_recognizer(name, token)
+		ts.note_context(class, token)
+		if len(ts.continuations) > 0 {
+			ts.note_continuation(class, token)
 		}
 
-		token, err = ts.get_quoted()
-		if token != nil {
-			return true
+		var synth []*ScannedToken
+		if ts.indent_enabled() {
+			if synth, err = ts.track_indent(class, token); err != nil {
+				return false
+			}
 		}
-		if err != nil {
-			return false
+
+		if token.Type == TokenTypeRegion {
+			// Not skipped, nor taken for a comment between tokens.
+			class = class_custom
 		}
 
-		token, err = ts.get_ident()
-		if token != nil {
-			return true
+		skip := ts.skip_class(class) || ts.skip_type(token.Type)
+
+		if ts.ConcatStrings && len(synth) == 0 {
+			span := Span{Start: *ts.pos, End: ts.end_pos}
+			if ts.string_run != nil {
+				if ts.add_to_string_run(class, token, span, skip) {
+					continue
+				}
+				synth = ts.end_string_run()
+			} else if !skip && token.Type == TokenTypeString {
+				ts.start_string_run(token, span)
+				continue
+			}
+		} else if ts.string_run != nil {
+			synth = append(ts.end_string_run(), synth...)
 		}
-		if err != nil {
-			return false
+
+		if ts.trailing != nil {
+			span := Span{Start: *ts.pos, End: ts.end_pos}
+			if len(synth) == 0 &&
+				ts.add_to_trailing_gap(class, token, span, skip) {
+				continue
+			}
+
+			var comment *Token
+			if class == class_comment {
+				comment = token
+			}
+			synth = append(ts.end_trailing(comment), synth...)
+		} else if ts.TrailingComments && !skip && !is_gap_class(class) &&
+			ts.trailing_comment_ahead() {
+			ts.hold_for_comment(synth, token, Span{Start: *ts.pos,
+				End: ts.end_pos})
+			continue
 		}
 
-		token, err = ts.get_number()
-		if token != nil {
-			return true
+		if skip && len(synth) == 0 {
+			continue
 		}
-		if err != nil {
-			return false
+
+		if len(synth) == 0 && len(ts.middleware) == 0 {
+			return ts.emit(token)
 		}
 
-		token, err = ts.get_symbol()
-		if token != nil {
-			return true
+		// The token, and any tokens synthesized before it, are returned
+		// from the queue instead, after going through the middleware.
+		// Scanning resumes at the end of the token, wherever the tokens
+		// returned end.
+		if !skip {
+			synth = append(synth, &ScannedToken{
+				Token: token,
+				Span:  Span{Start: *ts.pos, End: ts.end_pos},
+			})
 		}
-		if err != nil {
-			return false
+		end := ts.end_pos
+		ts.pending_pos = &end
+		ts.LastToken = ts.old_token
+		ts.end_pos = ts.old_end_pos
+		*ts.pos = *ts.old_pos
+
+		if ts.enqueue(synth...); len(ts.queue) > 0 {
+			return ts.emit_queued()
 		}
+	}
+}
 
-		done = true
+// Returns true if tokens of the class may come between two others without
+// separating them, e.g., for ConcatStrings.
+func is_gap_class(class rune_class) bool {
+	switch class {
+	case class_whitespace, class_continuation, class_comment,
+		class_directive:
+		return true
+	}
+
+	return false
+}
+
+// Stops holding back tokens (see ConcatStrings and TrailingComments),
+// queuing them to be returned, with scanning resuming at the end of the last
+// token read.
+func (ts *TokenScanner) flush_held() {
+	var tokens []*ScannedToken
+	if ts.string_run != nil {
+		tokens = ts.end_string_run()
+	}
+	if ts.trailing != nil {
+		tokens = append(tokens, ts.end_trailing(nil)...)
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	end := ts.end_pos
+	ts.pending_pos = &end
+	ts.LastToken = ts.old_token
+	ts.end_pos = ts.old_end_pos
+	*ts.pos = *ts.old_pos
+
+	ts.enqueue(tokens...)
+}
+
+// Returns true if tokens of the class should be skipped.
+func (ts *TokenScanner) skip_class(class rune_class) bool {
+	switch class {
+	case class_whitespace, class_continuation:
+		return ts.SkipWhitespace
+	case class_comment, class_directive:
+		return ts.SkipComments
 	}
 
 	return false
@@ -507,42 +1210,35 @@ func (ts *TokenScanner) peek_rune() (rune, error) {
 	return runes[0], nil
 }
 
+// Returns the next num_runes runes without consuming any input, or an error,
+// e.g., io.EOF, if there are fewer.
 func (ts *TokenScanner) peek_multirune(num_runes int) ([]rune, error) {
-	buf, err := ts.reader.Peek(4 * num_runes)
-	if err != nil {
-		if !(err == io.EOF && len(buf) > 0) {
-			return nil, err
-		}
-	}
-
-	runes := make([]rune, 0, num_runes)
-	offset := 0
-
-	for i := 0; i < num_runes; i++ {
-		ch, size := utf8.DecodeRune(buf[offset:])
-		if size == 0 {
-			return nil, io.EOF
+	runes, err := ts.reader.peek(num_runes)
+	if len(runes) < num_runes {
+		if err == nil {
+			err = io.EOF
 		}
-
-		offset += size
-
-		if ch == utf8.RuneError {
-			return runes, fmt.Errorf("invalid utf-8 sequence")
-		}
-
-		runes = append(runes, ch)
+		return nil, err
 	}
 
 	return runes, nil
 }
 
 func (ts *TokenScanner) get_ident() (*Token, error) {
+	return ts.get_ident_from(0)
+}
+
+// Returns an identifier, or the next piece of one cut at MaxTokenBytes,
+// where first is the index of its first rune in the whole identifier.
+func (ts *TokenScanner) get_ident_from(first int) (*Token, error) {
 	var (
-		runes      []rune
+		runes      = ts.scratch[:0]
 		total_size int
+		continued  bool
+		separators [][2]int // Rune ranges of namespace separators.
 	)
 
-	for i := 0; true; i++ {
+	for i := first; true; i++ {
 		ch, size, err := ts.get_one_rune()
 		if err != nil {
 			if err == io.EOF && len(runes) > 0 {
@@ -551,15 +1247,23 @@ func (ts *TokenScanner) get_ident() (*Token, error) {
 			return nil, err
 		}
 
-		if ts.IsIdentRune(ch, i, runes) {
-			total_size += size
-			if ch == ts.eol {
-				ts.last_line_addition++
-				ts.last_col = 1
-			} else {
-				ts.last_col++
+		if ts.IsIdentRune(ch, i, runes) ||
+			(ts.DisableNumbers && ts.IsDigitRune(ch, i, runes)) {
+			if len(runes) > 0 && ts.cut_at(total_size+size) {
+				if err = ts.unread_rune(); err != nil {
+					return nil, err
+				}
+				next := i
+				ts.cut_token(class_ident, func() (*Token, error) {
+					return ts.get_ident_from(next)
+				})
+				continued = true
+				break
 			}
 
+			total_size += size
+			ts.advance_position(ch, size)
+
 			runes = append(runes, ch)
 			continue
 		}
@@ -568,6 +1272,20 @@ func (ts *TokenScanner) get_ident() (*Token, error) {
 			return nil, nil
 		}
 
+		if n := ts.namespace_separator_len(); n > 0 && len(runes) > 0 {
+			sep, sep_size, err := ts.get_n_runes(n)
+			if err != nil {
+				return nil, err
+			}
+
+			separators = append(separators,
+				[2]int{len(runes), len(runes) + n})
+			runes = append(runes, sep...)
+			total_size += sep_size
+			i += n - 1
+			continue
+		}
+
 		break
 	}
 
@@ -575,18 +1293,25 @@ func (ts *TokenScanner) get_ident() (*Token, error) {
 		return nil, nil
 	}
 
-	b := new(strings.Builder)
-	for _, r := range runes {
-		b.WriteRune(r)
+	ts.scratch = runes
+	text := ts.token_text(runes)
+
+	token_type := TokenTypeIdent
+	if first == 0 && !continued {
+		token_type = ts.ident_type(text)
 	}
 
-	text := b.String()
-	token := &Token{
+	token := ts.new_token()
+	*token = Token{
 		Text:      text,
 		NumBytes:  total_size,
 		NumChars:  len(runes),
 		FirstRune: runes[0],
-		Type:      TokenTypeIdent,
+		Type:      token_type,
+		Continued: continued,
+	}
+	if len(separators) > 0 {
+		token.Segments = namespace_segments(runes, separators)
 	}
 
 	ts.last_byte_len = total_size
@@ -610,12 +1335,7 @@ func (ts *TokenScanner) read_until(end_ch rune) ([]rune, error) {
 
 		ts.last_byte_len += size
 
-		if ch == ts.eol {
-			ts.last_line_addition++
-			ts.last_col = 1
-		} else {
-			ts.last_col++
-		}
+		ts.advance_position(ch, size)
 
 		runes = append(runes, ch)
 
@@ -631,145 +1351,138 @@ func (ts *TokenScanner) read_until(end_ch rune) ([]rune, error) {
 	return runes, nil
 }
 
-func (ts *TokenScanner) get_comment() (*Token, error) {
-	ch, _, err := ts.get_one_rune()
+func (ts *TokenScanner) get_quoted() (*Token, error) {
+	var runes []rune
+
+	prefix := ts.match_string_prefix()
+	if prefix != nil {
+		var err error
+		if runes, err = ts.get_string_prefix(prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	ch, size, err := ts.get_one_rune()
 	if err != nil {
 		return nil, err
 	}
 
-	if ch == '/' {
+	ok, closing_char := ts.IsQuoteRune(ch)
+	if !ok {
 		if err = ts.unread_rune(); err != nil {
 			return nil, err
 		}
+		return nil, nil
+	}
 
-		var all_runes []rune
-
-		if ts.check_next_rune_char_n('/', 2) {
-			// This is a line comment.
-			chars, _, err := ts.get_n_runes(2)
-			if err != nil {
-				return nil, err
-			}
-
-			all_runes = append(all_runes, chars...)
+	ts.last_byte_len += size
+	ts.advance_position(ch, size)
 
-			chars, err = ts.read_until(ts.eol)
-			if err != nil && err != io.EOF {
-				return nil, err
-			}
+	token_type := TokenTypeString
+	if ts.IsIdentQuoteRune != nil && ts.IsIdentQuoteRune(ch) {
+		token_type = TokenTypeIdent
+	}
 
-			all_runes = append(all_runes, chars...)
+	if prefix == nil {
+		return ts.get_quoted_body([]rune{ch}, closing_char, token_type, false)
+	}
 
-		} else if ts.check_next_rune_char_n('*', 2) {
-			// This is a multi-line comment.
-			chars, _, err := ts.get_n_runes(2)
-			if err != nil {
-				return nil, err
-			}
+	token, err := ts.get_quoted_body(append(runes, ch), closing_char,
+		token_type, prefix.raw)
+	if token != nil {
+		token.Subtype = string(prefix.runes)
+	}
 
-			all_runes = append(all_runes, chars...)
+	return token, err
+}
 
-			done := false
-			for !done {
-				done = true
-				runes, err := ts.read_until('*')
-				if err != nil {
-					return nil, err
-				}
-				all_runes = append(all_runes, runes...)
+// Returns a quoted string whose opening quote has been read, or the next
+// piece of one cut at MaxTokenBytes. The runes are those accepted so far.
+// Escape runes are taken literally if raw is set.
+func (ts *TokenScanner) get_quoted_body(
+	runes []rune,
+	closing_char rune,
+	token_type TokenType,
+	raw bool,
+) (*Token, error) {
+	// Set when the previous rune was an unescaped escape rune, so the
+	// current rune is taken literally.
+	escaped := false
+	continued := false
 
-				ch, _, err = ts.get_one_rune()
-				if err != nil {
-					return nil, err
-				}
-				all_runes = append(all_runes, ch)
-				if ch != '/' {
-					// keep going
-					done = false
-				}
-			}
+	for {
+		ch, size, err := ts.get_one_rune()
+		if err != nil {
+			return nil, ts.unterminated_error("string",
+				fmt.Sprintf("Couldn't find end quote (%c).", closing_char),
+				CodeUnterminatedString, err)
 		}
 
-		if len(all_runes) > 0 {
-			token := &Token{
-				Text:      runes_to_string(all_runes),
-				NumBytes:  ts.last_byte_len,
-				NumChars:  len(all_runes),
-				FirstRune: '/',
-				Type:      TokenTypeComment,
+		if !escaped && ch != closing_char && len(runes) > 0 &&
+			ts.cut_at(ts.last_byte_len+size) {
+			if err = ts.unread_rune(); err != nil {
+				return nil, err
 			}
-
-			ts.set_token(token)
-
-			return token, nil
+			ts.cut_token(class_quoted, func() (*Token, error) {
+				return ts.get_quoted_body(nil, closing_char, token_type,
+					raw)
+			})
+			continued = true
+			break
 		}
 
-		return nil, nil
-	}
-
-	if err = ts.unread_rune(); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
-}
-
-func (ts *TokenScanner) get_quoted() (*Token, error) {
-	ch, size, err := ts.get_one_rune()
-	if err != nil {
-		return nil, err
-	}
+		ts.last_byte_len += size
+		ts.advance_position(ch, size)
 
-	ok, closing_char := ts.IsQuoteRune(ch)
-	if !ok {
-		if err = ts.unread_rune(); err != nil {
-			return nil, err
-		}
-		return nil, nil
-	}
+		if escaped {
+			escaped = false
 
-	ts.last_byte_len += size
+			if ch == closing_char {
+				// Overwrite the escape rune with the quote.
+				runes[len(runes)-1] = ch
+				continue
+			}
 
-	all_runes := []rune{}
+			if ts.CollapseEscapes && ts.IsEscapeRune(ch, len(runes), runes) {
+				// The escape rune already accepted stands for both.
+				continue
+			}
 
-	done := true
-	loop_num := 0
-	for i := 0; true; i++ {
-		done = true
-		loop_num++
-		runes, err := ts.read_until(closing_char)
-		if err != nil {
-			return nil, fmt.Errorf("Unterminated string at %s. Couldn't "+
-				"find end quote (%c).", ts.Position(), closing_char)
+			runes = append(runes, ch)
+			continue
 		}
 
-		if len(runes) > 1 {
-			i := len(runes) - 1 // last element
-			if ts.IsEscapeRune(runes[i-1], i, runes) {
-				// Overwrite the escape character with the last character and
-				// truncate.
-				runes = append(runes[:i-1], runes[i])
+		if ch == closing_char {
+			runes = append(runes, ch)
+			if !ts.DoubledQuotes || !ts.check_next_rune_char(ch) {
+				break
+			}
 
-				// Make sure we loop again to get the rest of the quoted
-				// string.
-				done = false
+			// Take the second quote as well, keeping just one.
+			_, size, err = ts.get_one_rune()
+			if err != nil {
+				return nil, err
 			}
+			ts.last_byte_len += size
+			ts.advance_position(ch, size)
+			continue
 		}
 
-		all_runes = append(all_runes, runes...)
-		if done {
-			break
+		if !raw && ts.IsEscapeRune(ch, len(runes), runes) {
+			escaped = true
 		}
-	}
 
-	text := runes_to_string([]rune{ch}, all_runes)
+		runes = append(runes, ch)
+	}
 
-	token := &Token{
-		Text:      text,
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
 		NumBytes:  ts.last_byte_len,
-		NumChars:  len(all_runes) + 1,
-		FirstRune: ch,
-		Type:      TokenTypeString,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      token_type,
+		Continued: continued,
 	}
 
 	ts.set_token(token)
@@ -783,13 +1496,26 @@ func (ts *TokenScanner) get_general(
 	token_type TokenType,
 	rune_check predicate_func,
 	exceptions ...predicate_func,
+) (*Token, error) {
+	return ts.get_general_from(0, token_type, rune_check, exceptions...)
+}
+
+// Returns a token of the runes accepted by rune_check, or the next piece of
+// one cut at MaxTokenBytes, where first is the index of its first rune in
+// the whole token.
+func (ts *TokenScanner) get_general_from(
+	first int,
+	token_type TokenType,
+	rune_check predicate_func,
+	exceptions ...predicate_func,
 ) (*Token, error) {
 	var (
-		runes      []rune
+		runes      = ts.scratch[:0]
 		total_size int
+		continued  bool
 	)
 
-	for i := 0; true; i++ {
+	for i := first; true; i++ {
 		ch, size, err := ts.get_one_rune()
 		if err != nil {
 			if err == io.EOF && len(runes) > 0 {
@@ -808,14 +1534,26 @@ func (ts *TokenScanner) get_general(
 
 		if !is_exception {
 			if rune_check(ch, i, runes) {
-				total_size += size
-				if ch == ts.eol {
-					ts.last_line_addition++
-					ts.last_col = 1
-				} else {
-					ts.last_col++
+				// Only white space is cut, since keeping the predicates
+				// for the rest of the token would make those of every
+				// caller escape to the heap.
+				if token_type == TokenTypeWhitespace && len(runes) > 0 &&
+					ts.cut_at(total_size+size) {
+					if err = ts.unread_rune(); err != nil {
+						return nil, err
+					}
+					next := i
+					ts.cut_token(class_whitespace, func() (*Token, error) {
+						return ts.get_general_from(next, TokenTypeWhitespace,
+							ts.IsSpaceRune)
+					})
+					continued = true
+					break
 				}
 
+				total_size += size
+				ts.advance_position(ch, size)
+
 				runes = append(runes, ch)
 				continue
 			}
@@ -832,14 +1570,20 @@ func (ts *TokenScanner) get_general(
 		return nil, nil
 	}
 
-	text := runes_to_string(runes)
+	ts.scratch = runes
+	text := ts.token_text(runes)
 
-	token := &Token{
+	token := ts.new_token()
+	*token = Token{
 		Text:      text,
 		NumBytes:  total_size,
 		NumChars:  len(runes),
 		FirstRune: runes[0],
 		Type:      token_type,
+		Continued: continued,
+	}
+	if token_type == TokenTypeWhitespace {
+		token.Whitespace = ts.whitespace_info(runes)
 	}
 
 	ts.last_byte_len = total_size
@@ -848,21 +1592,13 @@ func (ts *TokenScanner) get_general(
 	return token, nil
 }
 
-func runes_to_string(args ...[]rune) string {
-	b := new(strings.Builder)
-
-	for _, runes := range args {
-		for _, r := range runes {
-			b.WriteRune(r)
-		}
-	}
-
-	return b.String()
+func runes_to_string(runes []rune) string {
+	return string(runes)
 }
 
 func (ts *TokenScanner) get_number() (*Token, error) {
 	var (
-		runes      []rune
+		runes      = ts.scratch[:0]
 		total_size int
 	)
 
@@ -870,6 +1606,21 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 	found_decimal := false
 	is_float := false
 
+	n := &number_state{ts: ts, runes: runes}
+	if found, err := ts.get_prefixed_number(n); found || err != nil {
+		if err != nil && !(err == io.EOF && len(n.runes) > 0) {
+			return nil, err
+		}
+		if n.is_float {
+			return ts.suffixed_number_token(n, TokenTypeFloat)
+		}
+		return ts.suffixed_number_token(n, TokenTypeInt)
+	}
+
+	if ts.NumberLocale != (NumberLocale{}) {
+		return ts.get_locale_number(n)
+	}
+
 	for i := 0; true; i++ {
 		ch, size, err := ts.get_one_rune()
 		if err != nil {
@@ -897,9 +1648,6 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 				if ts.check_next_rune_class_n(ts.IsDigitRune, 2) {
 					found_decimal = true
 					is_float = true
-					total_size += size
-					ts.last_col++
-					runes = append(runes, ch)
 
 					// Read the period back in and continue on.
 					ch, size, err = ts.get_one_rune()
@@ -909,6 +1657,9 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 						}
 						return nil, err
 					}
+					total_size += size
+					ts.advance_position(ch, size)
+					runes = append(runes, ch)
 					continue
 				} else {
 					break
@@ -916,20 +1667,16 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 			}
 		}
 
-		if ch == '-' {
+		if ts.is_number_sign(ch) {
 			if !found_digits {
 				if err = ts.unread_rune(); err != nil {
 					return nil, err
 				}
 
-				// Check if there is a digit after the minus sign to determine
-				// if we're reading umber or this is just a a minus sign.
+				// Check if there is a digit after the sign to determine if
+				// we're reading a number or this is just a sign.
 				if ts.check_next_rune_class_n(ts.IsDigitRune, 2) {
-					total_size += size
-					ts.last_col++
-					runes = append(runes, ch)
-
-					// Read back in the minus sign and continue
+					// Read back in the sign and continue
 					ch, size, err = ts.get_one_rune()
 					if err != nil {
 						if err == io.EOF && len(runes) > 0 {
@@ -937,6 +1684,9 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 						}
 						return nil, err
 					}
+					total_size += size
+					ts.advance_position(ch, size)
+					runes = append(runes, ch)
 					continue
 				} else {
 					break
@@ -944,15 +1694,31 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 			}
 		}
 
+		if ch == '_' && found_digits &&
+			ts.Numbers&NumberUnderscores != 0 {
+			if err = ts.unread_rune(); err != nil {
+				return nil, err
+			}
+
+			// Underscores are only allowed between digits.
+			next := ts.peek_upto(2)
+			if len(next) < 2 || !ts.IsDigitRune(next[1], i, runes) {
+				break
+			}
+
+			if ch, size, err = ts.get_one_rune(); err != nil {
+				return nil, err
+			}
+			total_size += size
+			ts.advance_position(ch, size)
+			runes = append(runes, ch)
+			continue
+		}
+
 		if ts.IsDigitRune(ch, i, runes) {
 			found_digits = true
 			total_size += size
-			if ch == ts.eol {
-				ts.last_line_addition++
-				ts.last_col = 1
-			} else {
-				ts.last_col++
-			}
+			ts.advance_position(ch, size)
 
 			runes = append(runes, ch)
 			continue
@@ -969,28 +1735,78 @@ func (ts *TokenScanner) get_number() (*Token, error) {
 		return nil, nil
 	}
 
-	text := runes_to_string(runes)
+	n.runes, n.total_size = runes, total_size
+	if found, err := ts.get_exponent(n); found {
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		is_float = true
+	}
 
 	token_type := TokenTypeInt
 	if is_float {
 		token_type = TokenTypeFloat
 	}
 
-	token := &Token{
-		Text:      text,
-		NumBytes:  total_size,
-		NumChars:  len(runes),
-		FirstRune: runes[0],
+	return ts.suffixed_number_token(n, token_type)
+}
+
+// Returns a token for the runes of the number, along with any suffix (see
+// SetNumberSuffixes()).
+func (ts *TokenScanner) suffixed_number_token(
+	n *number_state,
+	token_type TokenType,
+) (*Token, error) {
+	suffix, err := ts.get_number_suffix(n)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	token := ts.number_token(n, token_type)
+	token.Suffix = suffix
+
+	return token, nil
+}
+
+// Returns a token for the runes of the number.
+func (ts *TokenScanner) number_token(n *number_state,
+	token_type TokenType) *Token {
+	ts.scratch = n.runes
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(n.runes),
+		NumBytes:  n.total_size,
+		NumChars:  len(n.runes),
+		FirstRune: n.runes[0],
 		Type:      token_type,
 	}
 
-	ts.last_byte_len = total_size
+	ts.last_byte_len = n.total_size
 	ts.set_token(token)
 
-	return token, nil
+	return token
 }
 
 func (ts *TokenScanner) get_symbol() (*Token, error) {
+	if len(ts.brackets) > 0 {
+		token, err := ts.get_bracket()
+		if token != nil || err != nil {
+			return token, err
+		}
+	}
+
+	if len(ts.operators) > 0 {
+		token, err := ts.get_operator()
+		if token != nil || err != nil {
+			return token, err
+		}
+	}
+
+	if token, err := ts.get_sign(); token != nil || err != nil {
+		return token, err
+	}
+
 	quote_func := func(ch rune, i int, runes []rune) bool {
 		if ok, _ := ts.IsQuoteRune(ch); ok {
 			return true
@@ -1005,7 +1821,21 @@ func (ts *TokenScanner) get_whitespace() (*Token, error) {
 }
 
 func (ts *TokenScanner) unread_rune() error {
-	return ts.reader.UnreadRune()
+	if err := ts.reader.UnreadRune(); err != nil {
+		return err
+	}
+
+	if ts.ByteTokens {
+		ts.unrecord_rune()
+	}
+
+	if ts.source_sizes != nil {
+		ts.source_sizes.back()
+	}
+
+	ts.read_bytes -= ts.last_rune_size
+
+	return nil
 }
 
 func (ts *TokenScanner) get_n_runes(
@@ -1021,31 +1851,54 @@ func (ts *TokenScanner) get_n_runes(
 	)
 
 	for i := 0; i < n; i++ {
-		ch, size, err = ts.reader.ReadRune()
+		ch, size, err = ts.get_one_rune()
 		if err != nil {
-			ts.last_err = err
 			return
 		}
 		chars = append(chars, ch)
 		total_size += size
 
 		ts.last_byte_len += size
-		ts.last_col++
-		if ch == ts.eol {
-			ts.last_line_addition++
-			ts.last_col = 1
-		}
+		ts.advance_position(ch, size)
 	}
 
 	return
 }
 
 func (ts *TokenScanner) get_one_rune() (ch rune, size int, err error) {
+	// The clock is checked before reading, so an aborted read leaves the
+	// rune in the reader for the next call to Scan().
+	if ts.deadline != nil && ts.deadline_passed() {
+		err = ts.deadline_error()
+		ts.last_err = err
+		return
+	}
+
 	ch, size, err = ts.reader.ReadRune()
 	if err != nil {
 		ts.last_err = err
 		return
 	}
 
+	if ts.source_sizes != nil {
+		var skip int
+		skip, size = ts.source_sizes.next()
+		if skip > 0 && ts.read_bytes == 0 {
+			// Bytes skipped before the first rune of a token, e.g., a
+			// byte order mark, are not part of it.
+			ts.pos.Offset += skip
+			ts.source_sizes.drop_skip()
+		} else {
+			size += skip
+		}
+	}
+
+	ts.read_bytes += size
+	ts.last_rune_size = size
+
+	if ts.ByteTokens {
+		ts.record_rune(ch)
+	}
+
 	return
 }
@@ -1,11 +1,13 @@
 package textparser_test
 
 import (
+	"bytes"
 	"fmt"
 	textparser "github.com/cuberat/go-textparser"
 	"io"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -192,6 +194,11 @@ func TestComments(t *testing.T) {
 			Input:    `foo = /* h4x0r and * stuff */`,
 			Expected: []string{"foo", "=", `/* h4x0r and * stuff */`},
 		},
+		&TestData{
+			Name:     `multi-line comment ending in (**/)`,
+			Input:    `foo = /* h4x0r **/ bar`,
+			Expected: []string{"foo", "=", `/* h4x0r **/`, "bar"},
+		},
 	}
 
 	for _, test_data := range tests {
@@ -588,6 +595,9 @@ func TestTokens(t *testing.T) {
 					NumChars:  1,
 					FirstRune: ' ',
 					Type:      textparser.TokenTypeWhitespace,
+					Whitespace: textparser.WhitespaceInfo{
+						Spaces: 1,
+					},
 				},
 				&textparser.Token{
 					Text:      "=",
@@ -602,6 +612,9 @@ func TestTokens(t *testing.T) {
 					NumChars:  1,
 					FirstRune: ' ',
 					Type:      textparser.TokenTypeWhitespace,
+					Whitespace: textparser.WhitespaceInfo{
+						Spaces: 1,
+					},
 				},
 				&textparser.Token{
 					Text:      `// h4x0r and stuff`,
@@ -630,6 +643,9 @@ func TestTokens(t *testing.T) {
 					NumChars:  1,
 					FirstRune: ' ',
 					Type:      textparser.TokenTypeWhitespace,
+					Whitespace: textparser.WhitespaceInfo{
+						Spaces: 1,
+					},
 				},
 				&textparser.Token{
 					Text:      "42.5",
@@ -935,7 +951,13 @@ func TestUnreadToken(t *testing.T) {
 	}
 }
 
-func ExampleSetVar() {
+// The examples are named Example_* since go vet rejects example names, such
+// as ExampleSetVar, referring to identifiers the package does not have. The
+// columns in their output count the opening quote of each string, which the
+// scanner left out before quoted strings were read one rune at a time, so
+// every token following a string on the same line used to be reported one
+// column to the left.
+func Example_setVar() {
 	src := `
     // This is a comment.
     if a > 5 {
@@ -964,7 +986,7 @@ func ExampleSetVar() {
 	// nofile:4:9 (50)  - Ident  -> b
 	// nofile:4:11 (52) - Symbol -> =
 	// nofile:4:13 (54) - String -> "this is a string"
-	// nofile:4:30 (72) - Symbol -> ;
+	// nofile:4:31 (72) - Symbol -> ;
 	// nofile:5:9 (82)  - Ident  -> c
 	// nofile:5:11 (84) - Symbol -> =
 	// nofile:5:13 (86) - Float  -> 7.2
@@ -972,7 +994,7 @@ func ExampleSetVar() {
 	// nofile:6:5 (95)  - Symbol -> }
 }
 
-func ExampleStructTag() {
+func Example_structTag() {
 	src := `Verbose,del=',',usage='Use it like this.'`
 	s := textparser.NewScanner(strings.NewReader(src))
 	s.SetFilename("")
@@ -992,14 +1014,14 @@ func ExampleStructTag() {
 	// :1:9 (8)         - Ident  -> del
 	// :1:12 (11)       - Symbol -> =
 	// :1:13 (12)       - String -> ','
-	// :1:15 (15)       - Symbol -> ,
-	// :1:16 (16)       - Ident  -> usage
-	// :1:21 (21)       - Symbol -> =
-	// :1:22 (22)       - String -> 'Use it like this.'
+	// :1:16 (15)       - Symbol -> ,
+	// :1:17 (16)       - Ident  -> usage
+	// :1:22 (21)       - Symbol -> =
+	// :1:23 (22)       - String -> 'Use it like this.'
 }
 
 // Example with customized symbol tokenization.
-func ExampleCustomSymbols() {
+func Example_customSymbols() {
 	input := "(foo += 5 +-4)"
 
 	ts := textparser.NewScanner(strings.NewReader(input))
@@ -1034,3 +1056,367 @@ func ExampleCustomSymbols() {
 	// -4
 	// )
 }
+
+// Example of scanning signs as symbols, for parsing arithmetic expressions.
+func Example_expressionSigns() {
+	ts := textparser.NewScanner(strings.NewReader("5 +-4"))
+	ts.Signs = textparser.SignSymbol
+
+	for ts.Scan() {
+		fmt.Printf("%s\n", ts.TokenText())
+	}
+
+	// Output:
+	// 5
+	// +
+	// -
+	// 4
+}
+
+// Test how runs of escape runes compose inside quoted strings, for each
+// supported quote style.
+func TestQuotedEscapes(t *testing.T) {
+	quotes := [][2]string{
+		{`"`, `"`}, {`'`, `'`}, {"`", "`"},
+		{"“", "”"}, {"‘", "’"}, {"‹", "›"}, {"«", "»"},
+	}
+
+	// Each case uses "O" and "C" as place holders for the opening and
+	// closing quotes.
+	tests := []struct {
+		Name      string
+		Input     string
+		Expected  string
+		Collapsed string
+	}{
+		{`no escapes`, `OabcC`, `OabcC`, `OabcC`},
+		{`escaped quote`, `Oa\CbC`, `OaCbC`, `OaCbC`},
+		{`terminal escaped escape`, `Oa\\C`, `Oa\\C`, `Oa\C`},
+		{`escaped escape then escaped quote`, `Oa\\\CbC`, `Oa\\CbC`,
+			`Oa\CbC`},
+		{`two escaped escapes`, `Oa\\\\C`, `Oa\\\\C`, `Oa\\C`},
+		{`escaped other rune`, `Oa\nbC`, `Oa\nbC`, `Oa\nbC`},
+		{`escaped escape then other rune`, `Oa\\nC`, `Oa\\nC`, `Oa\nC`},
+		{`only escaped escape`, `O\\C`, `O\\C`, `O\C`},
+	}
+
+	for _, quote := range quotes {
+		for _, test_data := range tests {
+			for _, collapse := range []bool{false, true} {
+				name := fmt.Sprintf("%s%s %s collapse=%t", quote[0], quote[1],
+					test_data.Name, collapse)
+				t.Run(name, func(st *testing.T) {
+					r := strings.NewReplacer("O", quote[0], "C", quote[1])
+					input := r.Replace(test_data.Input) + " foo"
+					expected := r.Replace(test_data.Expected)
+					if collapse {
+						expected = r.Replace(test_data.Collapsed)
+					}
+
+					p := textparser.NewScannerString(input)
+					p.IsQuoteRune = textparser.IsQuoteRuneFancy
+					p.CollapseEscapes = collapse
+
+					token_list := make([]string, 0, 2)
+					for p.Scan() {
+						token_list = append(token_list, p.TokenText())
+					}
+
+					if err := p.Err(); err != nil && err != io.EOF {
+						st.Errorf("error from scanner: %s", err)
+						return
+					}
+
+					if !reflect.DeepEqual([]string{expected, "foo"},
+						token_list) {
+						st.Errorf("got %#v, expected %#v", token_list,
+							[]string{expected, "foo"})
+					}
+				})
+			}
+		}
+	}
+}
+
+// Test that an odd run of escape runes before the final quote leaves the
+// string unterminated.
+func TestQuotedEscapedUnterminated(t *testing.T) {
+	for _, input := range []string{`'abc\'`, `'abc\\\'`, `"abc\"`} {
+		t.Run(input, func(st *testing.T) {
+			p := textparser.NewScannerString(input)
+			for p.Scan() {
+			}
+
+			if err := p.Err(); err == nil || err == io.EOF {
+				st.Errorf("expected error for unterminated string %q", input)
+			}
+		})
+	}
+}
+
+// Test that positions following a quoted string account for the opening
+// quote.
+func TestPositionAfterQuoted(t *testing.T) {
+	p := textparser.NewScannerString(`'a\\' b`)
+	p.Scan()
+	p.Scan()
+
+	expected := &textparser.Position{Offset: 6, Line: 1, Column: 7}
+	if !reflect.DeepEqual(p.Position(), expected) {
+		t.Errorf("got %s, expected %s", p.Position(), expected)
+	}
+}
+
+func TestReuseToken(t *testing.T) {
+	input := `foo = "bar" + 4.2 // done`
+
+	p := textparser.NewScannerString(input)
+	expected := make([]textparser.Token, 0)
+	for p.Scan() {
+		expected = append(expected, *p.Token())
+	}
+
+	p = textparser.NewScannerString(input)
+	p.ReuseToken = true
+	token_list := make([]textparser.Token, 0, len(expected))
+	seen := make(map[*textparser.Token]bool)
+	for p.Scan() {
+		seen[p.Token()] = true
+		token_list = append(token_list, *p.Token())
+	}
+
+	if !reflect.DeepEqual(token_list, expected) {
+		t.Errorf("got %+v, expected %+v", token_list, expected)
+	}
+
+	if len(seen) > 2 {
+		t.Errorf("expected at most 2 distinct tokens, got %d", len(seen))
+	}
+}
+
+func TestReuseTokenUnread(t *testing.T) {
+	p := textparser.NewScannerString(`foo bar baz`)
+	p.ReuseToken = true
+
+	expected := []string{"foo", "bar", "bar", "baz"}
+	token_list := make([]string, 0, len(expected))
+	for i := 0; p.Scan(); i++ {
+		token_list = append(token_list, p.TokenText())
+		if i == 1 {
+			p.UnreadToken()
+		}
+	}
+
+	if !reflect.DeepEqual(token_list, expected) {
+		t.Errorf("got %#v, expected %#v", token_list, expected)
+	}
+}
+
+func benchmark_scan(b *testing.B, reuse bool) {
+	input := strings.Repeat(`foo = "bar" + 4.2; // done`+"\n", 1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+
+	for i := 0; i < b.N; i++ {
+		p := textparser.NewScannerString(input)
+		p.ReuseToken = reuse
+		for p.Scan() {
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	benchmark_scan(b, false)
+}
+
+func BenchmarkScanReuseToken(b *testing.B) {
+	benchmark_scan(b, true)
+}
+
+// Benchmarks scanning the same input from a reader and in place from memory.
+func benchmark_scan_source(
+	b *testing.B,
+	new_scanner func(input string) *textparser.TokenScanner,
+) {
+	input := strings.Repeat(`foo = "bar" + 4.2; // done`+"\n", 1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+
+	for i := 0; i < b.N; i++ {
+		p := new_scanner(input)
+		p.ReuseToken = true
+		for p.Scan() {
+		}
+	}
+}
+
+func BenchmarkScanSourceReader(b *testing.B) {
+	benchmark_scan_source(b, func(input string) *textparser.TokenScanner {
+		return textparser.NewScanner(strings.NewReader(input))
+	})
+}
+
+func BenchmarkScanSourceString(b *testing.B) {
+	benchmark_scan_source(b, textparser.NewScannerString)
+}
+
+func BenchmarkScanSourceBytes(b *testing.B) {
+	data := []byte(strings.Repeat(`foo = "bar" + 4.2; // done`+"\n", 1000))
+	benchmark_scan_source(b, func(string) *textparser.TokenScanner {
+		return textparser.NewScannerBytes(data)
+	})
+}
+
+// Size of the corpus scanned by BenchmarkScanCorpus.
+const bench_corpus_size = 100 << 20
+
+var bench_corpus struct {
+	once sync.Once
+	data []byte
+}
+
+// Returns a corpus of about bench_corpus_size bytes of identifiers, numbers,
+// strings, symbols, and comments, built once.
+func corpus() []byte {
+	bench_corpus.once.Do(func() {
+		line := "alpha_1 = beta.gamma(42, -3.5e2, \"delta\") + x_y_z; " +
+			"// epsilon\n"
+		bench_corpus.data = bytes.Repeat([]byte(line),
+			bench_corpus_size/len(line))
+	})
+
+	return bench_corpus.data
+}
+
+func benchmark_corpus(b *testing.B, reuse, byte_tokens bool) {
+	benchmark_corpus_source(b, reuse, byte_tokens, textparser.NewScannerBytes)
+}
+
+func benchmark_corpus_source(
+	b *testing.B,
+	reuse, byte_tokens bool,
+	new_scanner func(data []byte) *textparser.TokenScanner,
+) {
+	data := corpus()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p := new_scanner(data)
+		p.ReuseToken = reuse
+		p.ByteTokens = byte_tokens
+		p.Numbers = textparser.NumberExponent
+		for p.Scan() {
+		}
+	}
+}
+
+func BenchmarkScanCorpus(b *testing.B) {
+	benchmark_corpus(b, false, false)
+}
+
+func BenchmarkScanCorpusReuseToken(b *testing.B) {
+	benchmark_corpus(b, true, false)
+}
+
+func BenchmarkScanCorpusByteTokens(b *testing.B) {
+	benchmark_corpus(b, true, true)
+}
+
+func BenchmarkScanCorpusReader(b *testing.B) {
+	benchmark_corpus_source(b, true, false,
+		func(data []byte) *textparser.TokenScanner {
+			return textparser.NewScanner(bytes.NewReader(data))
+		})
+}
+
+// Test that the first matching class, in the documented order, determines the
+// recognizer used for a token.
+func TestRecognizerOrder(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string
+		Types    []textparser.TokenType
+	}{
+		{
+			Name:     `ident before number`,
+			Input:    `42abc -1`,
+			Expected: []string{"42abc", "-1"},
+			Types: []textparser.TokenType{textparser.TokenTypeIdent,
+				textparser.TokenTypeInt},
+			Setup: func(p *textparser.TokenScanner) {
+				p.IsIdentRune = func(ch rune, i int, runes []rune) bool {
+					return textparser.IsIdentRune(ch, 1, runes)
+				}
+			},
+		},
+		{
+			Name:     `quote before symbol`,
+			Input:    `#foo#`,
+			Expected: []string{"#foo#"},
+			Types:    []textparser.TokenType{textparser.TokenTypeString},
+			Setup: func(p *textparser.TokenScanner) {
+				p.IsQuoteRune = func(ch rune) (bool, rune) {
+					return ch == '#', '#'
+				}
+			},
+		},
+		{
+			Name:     `minus without digit is a symbol`,
+			Input:    `- -x -5`,
+			Expected: []string{"-", "-", "x", "-5"},
+			Types: []textparser.TokenType{textparser.TokenTypeSymbol,
+				textparser.TokenTypeSymbol, textparser.TokenTypeIdent,
+				textparser.TokenTypeInt},
+		},
+		{
+			Name:     `slash without comment is a symbol`,
+			Input:    `a/b //c`,
+			Expected: []string{"a", "/", "b"},
+			Types: []textparser.TokenType{textparser.TokenTypeIdent,
+				textparser.TokenTypeSymbol, textparser.TokenTypeIdent},
+		},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+
+			token_list := make([]string, 0, len(test_data.Expected))
+			type_list := make([]textparser.TokenType, 0,
+				len(test_data.Expected))
+			for p.Scan() {
+				token_list = append(token_list, p.TokenText())
+				type_list = append(type_list, p.Token().Type)
+			}
+
+			if !reflect.DeepEqual(token_list, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", token_list,
+					test_data.Expected)
+			}
+
+			if !reflect.DeepEqual(type_list, test_data.Types) {
+				st.Errorf("got types %v, expected %v", type_list,
+					test_data.Types)
+			}
+		})
+	}
+}
+
+// Test that positions following a multi-line comment account for every rune
+// in the comment.
+func TestPositionAfterComment(t *testing.T) {
+	p := textparser.NewScannerString("/* a * b\n * c */ foo")
+	p.Scan()
+
+	expected := &textparser.Position{Offset: 17, Line: 2, Column: 9}
+	if !reflect.DeepEqual(p.Position(), expected) {
+		t.Errorf("got %s, expected %s", p.Position(), expected)
+	}
+}
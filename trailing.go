@@ -0,0 +1,144 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+)
+
+// The most spaces and tabs looked at between a token and a comment trailing
+// it (see TrailingComments).
+const max_trailing_space = 256
+
+// Tokens held back until the comment trailing the last of them has been
+// read (see TrailingComments).
+type trailing_hold struct {
+	tokens []*ScannedToken // The token, after any synthesized before it.
+	gap    []*ScannedToken // Whitespace after the token, not skipped.
+
+	// The token returned before the held ones, and its span.
+	old_token *Token
+	old_span  Span
+}
+
+// Returns true if only spaces and tabs separate the next rune from the start
+// of a comment on the same line.
+func (ts *TokenScanner) trailing_comment_ahead() bool {
+	longest := 0
+	for _, c := range ts.comments {
+		if len(c.start) > longest {
+			longest = len(c.start)
+		}
+	}
+	if longest == 0 {
+		return false
+	}
+
+	runes := ts.peek_upto(max_trailing_space + longest)
+	i := 0
+	for i < len(runes) && runes[i] != ts.eol && runes[i] != '\r' &&
+		ts.IsSpaceRune(runes[i], 0, nil) {
+		i++
+	}
+
+	for _, c := range ts.comments {
+		if has_rune_prefix(runes[i:], c.start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Holds back the token, and any tokens synthesized before it, until the
+// comment trailing it has been read.
+func (ts *TokenScanner) hold_for_comment(
+	synth []*ScannedToken,
+	token *Token,
+	span Span,
+) {
+	ts.trailing = &trailing_hold{
+		tokens: append(synth, &ScannedToken{
+			Token: clone_token(token),
+			Span:  span,
+		}),
+		old_token: ts.old_token,
+		old_span:  Span{Start: *ts.old_pos, End: ts.old_end_pos},
+	}
+}
+
+// Adds the token, read while tokens are held back, to the whitespace
+// following them. Returns false if the token is not whitespace.
+func (ts *TokenScanner) add_to_trailing_gap(
+	class rune_class,
+	token *Token,
+	span Span,
+	skip bool,
+) bool {
+	if class != class_whitespace {
+		return false
+	}
+
+	if !skip {
+		ts.trailing.gap = append(ts.trailing.gap, &ScannedToken{
+			Token: clone_token(token),
+			Span:  span,
+		})
+	}
+
+	return true
+}
+
+// Stops holding back tokens, setting the trailing comment of the last held
+// one if comment is not nil. Returns the held tokens, followed by the
+// whitespace after them that is not skipped, and restores the token
+// returned before them as the previous token.
+func (ts *TokenScanner) end_trailing(comment *Token) []*ScannedToken {
+	hold := ts.trailing
+	ts.trailing = nil
+
+	ts.old_token = hold.old_token
+	*ts.old_pos = hold.old_span.Start
+	ts.old_end_pos = hold.old_span.End
+
+	if comment != nil {
+		hold.tokens[len(hold.tokens)-1].TrailingComment = clone_token(comment)
+	}
+
+	return append(hold.tokens, hold.gap...)
+}
+
+// Returns a copy of the token that does not refer to buffers reused for
+// later tokens.
+func clone_token(token *Token) *Token {
+	t := *token
+	t.Text = strings.Clone(t.Text)
+	t.Raw = strings.Clone(t.Raw)
+	t.Bytes = nil
+
+	return &t
+}
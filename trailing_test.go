@@ -0,0 +1,77 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestTrailingComments(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Setup    func(p *textparser.TokenScanner)
+		Expected []string // Text of each token, and its trailing comment.
+	}
+
+	input := "foo = 1  # legacy\n# own line\nbar = [2, 3] # list\nbaz"
+
+	test_list := []TestData{
+		{
+			Name:  "comments skipped",
+			Input: input,
+			Expected: []string{"foo", "=", "1 # legacy\n", "bar", "=", "[",
+				"2", ",", "3", "] # list\n", "baz"},
+		},
+		{
+			Name:  "comments kept",
+			Input: input,
+			Setup: func(p *textparser.TokenScanner) {
+				p.SkipComments = false
+			},
+			Expected: []string{"foo", "=", "1 # legacy\n", "# legacy\n",
+				"# own line\n", "bar", "=", "[", "2", ",", "3",
+				"] # list\n", "# list\n", "baz"},
+		},
+		{
+			Name:  "whitespace kept",
+			Input: "a /* b */ c // d",
+			Setup: func(p *textparser.TokenScanner) {
+				p.SkipWhitespace = false
+			},
+			Expected: []string{"a /* b */", " ", " ", "c // d", " "},
+		},
+		{
+			Name:     "comment at end of input",
+			Input:    "x #",
+			Expected: []string{"x #"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SetComments(textparser.CommentStyle{Start: "#"},
+				textparser.CommentStyle{Start: "//"},
+				textparser.CommentStyle{Start: "/*", End: "*/"})
+			p.TrailingComments = true
+			if test_data.Setup != nil {
+				test_data.Setup(p)
+			}
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				text := token.Text
+				if token.TrailingComment != nil {
+					text += " " + token.TrailingComment.Text
+				}
+				got = append(got, text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
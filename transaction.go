@@ -0,0 +1,182 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+)
+
+// The state of transactions over the token stream (see Begin()).
+type tx_state struct {
+	// Tokens scanned since the outermost open transaction began, or since
+	// the last rollback, if they are still being replayed.
+	buf []*ScannedToken
+
+	// Index in buf of the next token to return.
+	next int
+
+	// The open transactions, innermost last.
+	marks []tx_mark
+
+	// Whether the current token was set by Rollback() or replayed, and
+	// the span of the last token scanned from the input, to return to
+	// before scanning more.
+	replaying bool
+	live      Position
+	live_end  Position
+}
+
+// Where a transaction began.
+type tx_mark struct {
+	next    int           // Index in buf of the next token to return.
+	current *ScannedToken // The current token, or nil.
+}
+
+// Begins a transaction over the token stream, e.g., to parse speculatively.
+// The tokens returned by Scan() from now on are kept, so that Rollback() can
+// return to this point, and Scan() returns them again. Commit() ends the
+// transaction, keeping the tokens consumed. Transactions nest, each Commit()
+// or Rollback() ending the innermost one. Tokens are kept as copies, so they
+// stay valid even if ReuseToken or ByteTokens is set.
+func (ts *TokenScanner) Begin() {
+	if ts.tx == nil {
+		ts.tx = &tx_state{}
+	}
+
+	ts.tx.marks = append(ts.tx.marks, tx_mark{
+		next:    ts.tx.next,
+		current: ts.copy_current(),
+	})
+}
+
+// Ends the innermost transaction, keeping the tokens consumed since it began
+// (see Begin()). Returns an error if there is no transaction.
+func (ts *TokenScanner) Commit() error {
+	tx := ts.tx
+	if tx == nil || len(tx.marks) == 0 {
+		return fmt.Errorf("no transaction to commit")
+	}
+	tx.marks = tx.marks[:len(tx.marks)-1]
+
+	if len(tx.marks) == 0 && !tx.replaying {
+		ts.tx = nil
+	}
+
+	return nil
+}
+
+// Ends the innermost transaction, returning to where it began (see Begin()):
+// the current token is again the one current then, and Scan() returns the
+// tokens consumed since, before reading more input. Returns an error if
+// there is no transaction.
+func (ts *TokenScanner) Rollback() error {
+	tx := ts.tx
+	if tx == nil || len(tx.marks) == 0 {
+		return fmt.Errorf("no transaction to roll back")
+	}
+	mark := tx.marks[len(tx.marks)-1]
+	tx.marks = tx.marks[:len(tx.marks)-1]
+
+	if !tx.replaying {
+		tx.replaying = true
+		tx.live = *ts.pos
+		tx.live_end = ts.end_pos
+	}
+	tx.next = mark.next
+
+	if st := mark.current; st != nil {
+		ts.set_current(st)
+	} else {
+		ts.LastToken = nil
+	}
+
+	return nil
+}
+
+// Returns the number of open transactions.
+func (ts *TokenScanner) TransactionDepth() int {
+	if ts.tx == nil {
+		return 0
+	}
+
+	return len(ts.tx.marks)
+}
+
+// Scans the next token, replaying tokens after a rollback, and keeping
+// tokens for open transactions.
+func (ts *TokenScanner) scan_tx() bool {
+	tx := ts.tx
+
+	if tx.next < len(tx.buf) {
+		ts.set_current(tx.buf[tx.next])
+		tx.next++
+		return true
+	}
+
+	if tx.replaying {
+		// Continue from the last token scanned from the input.
+		tx.replaying = false
+		*ts.pos = tx.live
+		ts.end_pos = tx.live_end
+	}
+
+	if len(tx.marks) == 0 {
+		ts.tx = nil
+		return ts.scan()
+	}
+
+	if !ts.scan() {
+		return false
+	}
+
+	tx.buf = append(tx.buf, ts.copy_current())
+	tx.next = len(tx.buf)
+
+	return true
+}
+
+// Makes the token the current one.
+func (ts *TokenScanner) set_current(st *ScannedToken) {
+	ts.old_token = ts.LastToken
+	ts.LastToken = st.Token
+	*ts.old_pos = *ts.pos
+	*ts.pos = st.Start
+	ts.old_end_pos = ts.end_pos
+	ts.end_pos = st.End
+}
+
+// Returns a copy of the current token, or nil if there is none.
+func (ts *TokenScanner) copy_current() *ScannedToken {
+	if ts.LastToken == nil {
+		return nil
+	}
+
+	return &ScannedToken{
+		Token: ts.LastToken.CloneMutable(),
+		Span:  Span{Start: *ts.pos, End: ts.end_pos},
+	}
+}
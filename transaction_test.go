@@ -0,0 +1,147 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestTransactions(t *testing.T) {
+	p := textparser.NewScannerString("a b\nc d e")
+	p.ReuseToken = true
+
+	var got []string
+	next := func() {
+		if !p.Scan() {
+			got = append(got, "EOF")
+			return
+		}
+		got = append(got, p.TokenText()+" "+p.ScannedToken().Span.String())
+	}
+
+	next() // a
+	p.Begin()
+	next() // b
+	next() // c
+	p.Begin()
+	next() // d
+	if err := p.Rollback(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got = append(got, "current "+p.TokenText())
+	next() // d again
+	if err := p.Rollback(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got = append(got, "current "+p.TokenText())
+	if n := p.TransactionDepth(); n != 0 {
+		t.Errorf("got %d open transactions, expected 0", n)
+	}
+	next() // b again
+	p.Begin()
+	next() // c again
+	next() // d again
+	next() // e
+	if err := p.Commit(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	next()
+
+	expected := []string{
+		"a :1:1-1:2 (0-1)",
+		"b :1:3-1:4 (2-3)",
+		"c :2:1-2:2 (4-5)",
+		"d :2:3-2:4 (6-7)",
+		"current c",
+		"d :2:3-2:4 (6-7)",
+		"current a",
+		"b :1:3-1:4 (2-3)",
+		"c :2:1-2:2 (4-5)",
+		"d :2:3-2:4 (6-7)",
+		"e :2:5-2:6 (8-9)",
+		"EOF",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if err := p.Commit(); err == nil {
+		t.Errorf("expected an error committing without a transaction")
+	}
+	if err := p.Rollback(); err == nil {
+		t.Errorf("expected an error rolling back without a transaction")
+	}
+}
+
+// Test that rolling back to before the first token starts over.
+func TestRollbackToStart(t *testing.T) {
+	p := textparser.NewScannerString("x y")
+	p.Begin()
+
+	var first []string
+	for p.Scan() {
+		first = append(first, p.TokenText())
+	}
+	if err := p.Rollback(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if p.Token() != nil {
+		t.Errorf("got current token %#v, expected none", p.Token())
+	}
+
+	var second []string
+	for p.Scan() {
+		second = append(second, p.TokenText())
+	}
+
+	expected := []string{"x", "y"}
+	if !reflect.DeepEqual(first, expected) ||
+		!reflect.DeepEqual(second, expected) {
+		t.Errorf("got %#v then %#v, expected %#v twice", first, second,
+			expected)
+	}
+}
+
+// Test that replayed tokens keep their own bytes in byte mode.
+func TestRollbackByteTokens(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader("alpha beta gamma"))
+	p.ByteTokens = true
+
+	p.Begin()
+	for p.Scan() {
+	}
+	if err := p.Rollback(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for p.Scan() {
+		token := p.Token()
+		got = append(got, token.Text+" "+string(token.Bytes))
+	}
+
+	expected := []string{"alpha alpha", "beta beta", "gamma gamma"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+// Test that changes made by the caller to a token do not show up when it is
+// replayed.
+func TestRollbackMutatedToken(t *testing.T) {
+	p := textparser.NewScannerString("a b")
+
+	p.Begin()
+	p.Scan()
+	p.Token().Text = "changed"
+	if err := p.Rollback(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p.Scan()
+	if got := p.TokenText(); got != "a" {
+		t.Errorf("got %q, expected %q", got, "a")
+	}
+}
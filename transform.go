@@ -0,0 +1,149 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A function transforming the text of a token as it is emitted by Scan().
+type TextTransform func(text string) string
+
+// Sets the transforms applied, in order, to the text of each emitted token of
+// the given type, replacing any transforms previously set for that type. If
+// the transforms change the text, the original is kept in the Raw field of
+// the token, and NumBytes and NumChars continue to describe the original.
+// Call with no transforms to remove them.
+func (ts *TokenScanner) SetTransform(
+	token_type TokenType,
+	transforms ...TextTransform,
+) {
+	if len(transforms) == 0 {
+		delete(ts.transforms, token_type)
+		return
+	}
+
+	if ts.transforms == nil {
+		ts.transforms = make(map[TokenType][]TextTransform)
+	}
+
+	ts.transforms[token_type] = transforms
+}
+
+func (ts *TokenScanner) apply_transforms(token *Token) {
+	transforms, ok := ts.transforms[token.Type]
+	if !ok {
+		return
+	}
+
+	text := token.Text
+	for _, transform := range transforms {
+		text = transform(text)
+	}
+
+	if text != token.Text {
+		if token.Raw == "" {
+			token.Raw = token.Text
+		}
+		token.Text = text
+	}
+}
+
+// Emits the token as the result of a call to Scan(). Always returns true.
+func (ts *TokenScanner) emit(token *Token) bool {
+	ts.limit.count++
+	if ts.ByteTokens {
+		ts.pin_bytes(token)
+	}
+	ts.apply_transforms(token)
+	ts.log_token(token)
+
+	return true
+}
+
+// Transform that removes trailing white space, e.g., from line comments.
+func TrimTrailingSpace(text string) string {
+	return strings.TrimRightFunc(text, unicode.IsSpace)
+}
+
+// Transform that folds the text to lower case, e.g., for case-insensitive
+// keywords.
+func FoldCase(text string) string {
+	return strings.ToLower(text)
+}
+
+// Transform that folds the text to upper case.
+func UpperCase(text string) string {
+	return strings.ToUpper(text)
+}
+
+// Transform that normalizes the text of a decimal number, removing leading
+// zeros from the integer part and trailing zeros from the fractional part,
+// e.g., "-007.250" becomes "-7.25", and "0.0" stays "0.0". Text that does not
+// look like a decimal number is returned unchanged.
+func NormalizeNumber(text string) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+
+	int_part, frac_part, has_frac := strings.Cut(text, ".")
+	if !is_ascii_digits(int_part) || (has_frac && !is_ascii_digits(frac_part)) {
+		return sign + text
+	}
+
+	int_part = strings.TrimLeft(int_part, "0")
+	if int_part == "" {
+		int_part = "0"
+	}
+
+	if !has_frac {
+		return sign + int_part
+	}
+
+	frac_part = strings.TrimRight(frac_part, "0")
+	if frac_part == "" {
+		frac_part = "0"
+	}
+
+	return sign + int_part + "." + frac_part
+}
+
+func is_ascii_digits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
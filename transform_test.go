@@ -0,0 +1,57 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"reflect"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	p := textparser.NewScannerString("SELECT x = 007.50 // note  \n")
+	p.SkipComments = false
+	p.SetTransform(textparser.TokenTypeIdent, textparser.FoldCase)
+	p.SetTransform(textparser.TokenTypeFloat, textparser.NormalizeNumber)
+	p.SetTransform(textparser.TokenTypeComment, textparser.TrimTrailingSpace)
+
+	expected := []*textparser.Token{
+		{Text: "select", NumBytes: 6, NumChars: 6, FirstRune: 'S',
+			Type: textparser.TokenTypeIdent, Raw: "SELECT"},
+		{Text: "x", NumBytes: 1, NumChars: 1, FirstRune: 'x',
+			Type: textparser.TokenTypeIdent},
+		{Text: "=", NumBytes: 1, NumChars: 1, FirstRune: '=',
+			Type: textparser.TokenTypeSymbol},
+		{Text: "7.5", NumBytes: 6, NumChars: 6, FirstRune: '0',
+			Type: textparser.TokenTypeFloat, Raw: "007.50"},
+		{Text: "// note", NumBytes: 10, NumChars: 10, FirstRune: '/',
+			Type: textparser.TokenTypeComment, Raw: "// note  \n"},
+	}
+
+	token_list := scan_all(t, p)
+	if !reflect.DeepEqual(token_list, expected) {
+		t.Errorf("got %+v, expected %+v", token_list, expected)
+	}
+
+	if token_list[0].RawText() != "SELECT" || token_list[1].RawText() != "x" {
+		t.Errorf("unexpected raw text: %q, %q", token_list[0].RawText(),
+			token_list[1].RawText())
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := map[string]string{
+		"42":      "42",
+		"007":     "7",
+		"-007.50": "-7.5",
+		"0.0":     "0.0",
+		"000":     "0",
+		"+1.000":  "+1.0",
+		"1e5":     "1e5",
+		"abc":     "abc",
+	}
+
+	for input, expected := range tests {
+		if got := textparser.NormalizeNumber(input); got != expected {
+			t.Errorf("%q: got %q, expected %q", input, got, expected)
+		}
+	}
+}
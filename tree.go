@@ -0,0 +1,280 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// The bracket pairs used by BuildTree() when none are given.
+var DefaultTreeBrackets = []string{"()", "[]", "{}"}
+
+// A node of a token tree: a single token, or a group of nodes enclosed in a
+// pair of brackets.
+type TokenGroup struct {
+	// The token, or the opening bracket of a group.
+	Token *ScannedToken
+
+	// The closing bracket of a group, or nil for a single token.
+	Close *ScannedToken
+
+	// The nodes inside the brackets of a group.
+	Items []*TokenGroup
+}
+
+// Returns true if the node is a group rather than a single token.
+func (g *TokenGroup) IsGroup() bool {
+	return g.Close != nil
+}
+
+// Returns the span of the node, including the brackets of a group.
+func (g *TokenGroup) Span() Span {
+	if !g.IsGroup() {
+		return g.Token.Span
+	}
+
+	return Span{Start: g.Token.Start, End: g.Close.End}
+}
+
+// Returns the tokens inside the brackets of a group, in order, without the
+// brackets themselves. For a single token, returns the token.
+func (g *TokenGroup) Inner() []*ScannedToken {
+	if !g.IsGroup() {
+		return []*ScannedToken{g.Token}
+	}
+
+	var tokens []*ScannedToken
+	for _, item := range g.Items {
+		tokens = append(tokens, item.Tokens()...)
+	}
+
+	return tokens
+}
+
+// Returns all tokens of the node in order, including the brackets of groups.
+func (g *TokenGroup) Tokens() []*ScannedToken {
+	if !g.IsGroup() {
+		return []*ScannedToken{g.Token}
+	}
+
+	tokens := []*ScannedToken{g.Token}
+	tokens = append(tokens, g.Inner()...)
+
+	return append(tokens, g.Close)
+}
+
+// Returns the node as text, with groups in their brackets and items
+// separated by single spaces, e.g., "f (a [b c])".
+func (g *TokenGroup) String() string {
+	if !g.IsGroup() {
+		return g.Token.Text
+	}
+
+	items := make([]string, 0, len(g.Items))
+	for _, item := range g.Items {
+		items = append(items, item.String())
+	}
+
+	return g.Token.Text + strings.Join(items, " ") + g.Close.Text
+}
+
+// Nests the tokens, e.g., from TokenizeAll(), between each bracket pair,
+// e.g., "()", into groups, and returns the top-level nodes. If no pairs are
+// given, DefaultTreeBrackets is used. Only symbol tokens whose text is
+// exactly a bracket count as brackets, so brackets in strings, comments, or
+// longer symbols are left alone (see SetBrackets() to split them off).
+// Unbalanced or mismatched brackets result in a ScanError with
+// CodeUnbalancedBracket.
+func BuildTree(tokens []*ScannedToken, pairs ...string) ([]*TokenGroup, error) {
+	opening, closing := bracket_pairs(pairs)
+
+	// The groups being filled in, innermost last. The first is a stand-in
+	// for the top level.
+	stack := []*TokenGroup{{}}
+
+	for _, token := range tokens {
+		top := stack[len(stack)-1]
+
+		if token.Type != TokenTypeSymbol {
+			top.Items = append(top.Items, &TokenGroup{Token: token})
+			continue
+		}
+
+		if _, ok := opening[token.Text]; ok {
+			group := &TokenGroup{Token: token}
+			top.Items = append(top.Items, group)
+			stack = append(stack, group)
+			continue
+		}
+
+		if _, ok := closing[token.Text]; !ok {
+			top.Items = append(top.Items, &TokenGroup{Token: token})
+			continue
+		}
+
+		if len(stack) == 1 {
+			return nil, &ScanError{
+				Msg:   fmt.Sprintf("Unexpected %q", token.Text),
+				Start: token.Start,
+				End:   token.Start,
+				Code:  CodeUnbalancedBracket,
+			}
+		}
+
+		want := opening[top.Token.Text]
+		if token.Text != want {
+			return nil, &ScanError{
+				Msg: fmt.Sprintf("Mismatched %q", token.Text),
+				Detail: fmt.Sprintf("Expected %q to close %q opened at %s.",
+					want, top.Token.Text, &top.Token.Start),
+				Start: token.Start,
+				End:   token.Start,
+				Code:  CodeUnbalancedBracket,
+			}
+		}
+
+		top.Close = token
+		stack = stack[:len(stack)-1]
+	}
+
+	if len(stack) > 1 {
+		open := stack[len(stack)-1].Token
+		return nil, &ScanError{
+			Msg: "Unterminated group",
+			Detail: fmt.Sprintf("Couldn't find closing bracket (%s).",
+				opening[open.Text]),
+			Start: open.Start,
+			End:   tokens[len(tokens)-1].End,
+			Err:   io.EOF,
+			Code:  CodeUnbalancedBracket,
+		}
+	}
+
+	return stack[0].Items, nil
+}
+
+// Returns maps from the opening brackets of the pairs, or
+// DefaultTreeBrackets if none are given, to the closing ones, and back.
+func bracket_pairs(pairs []string) (map[string]string, map[string]string) {
+	if len(pairs) == 0 {
+		pairs = DefaultTreeBrackets
+	}
+
+	opening := make(map[string]string, len(pairs))
+	closing := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		runes := []rune(pair)
+		if len(runes) == 2 {
+			opening[string(runes[0])] = string(runes[1])
+			closing[string(runes[1])] = string(runes[0])
+		}
+	}
+
+	return opening, closing
+}
+
+// Scans all remaining tokens from the scanner and checks that the brackets
+// of each pair, e.g., "()", or of DefaultTreeBrackets if none are given, are
+// balanced, e.g., to validate a snippet before parsing it. Brackets are
+// found anywhere in symbol tokens, even in longer symbols such as "))", so
+// brackets in strings and comments are skipped. Returns nil if they are
+// balanced, a ScanError with CodeUnbalancedBracket at the first unbalanced
+// bracket, or the scanner's error, e.g., CodeUnterminatedString for an
+// unterminated quote.
+func CheckBalanced(ts *TokenScanner, pairs ...string) error {
+	opening, closing := bracket_pairs(pairs)
+
+	// The open brackets and their positions, innermost last.
+	var open []string
+	var open_pos []Position
+
+	for ts.Scan() {
+		token := ts.Token()
+		if token.Type != TokenTypeSymbol {
+			continue
+		}
+
+		pos := *ts.Position()
+		for _, ch := range token.Text {
+			bracket := string(ch)
+			if _, ok := opening[bracket]; ok {
+				open = append(open, bracket)
+				open_pos = append(open_pos, pos)
+			} else if _, ok := closing[bracket]; ok {
+				if len(open) == 0 {
+					return &ScanError{
+						Msg:   fmt.Sprintf("Unexpected %q", bracket),
+						Start: pos,
+						End:   pos,
+						Code:  CodeUnbalancedBracket,
+					}
+				}
+
+				top := len(open) - 1
+				if want := opening[open[top]]; bracket != want {
+					return &ScanError{
+						Msg: fmt.Sprintf("Mismatched %q", bracket),
+						Detail: fmt.Sprintf(
+							"Expected %q to close %q opened at %s.",
+							want, open[top], &open_pos[top]),
+						Start: pos,
+						End:   pos,
+						Code:  CodeUnbalancedBracket,
+					}
+				}
+
+				open = open[:top]
+				open_pos = open_pos[:top]
+			}
+
+			pos.Offset += utf8.RuneLen(ch)
+			pos.Column++
+		}
+	}
+
+	if err := ts.Err(); err != nil && err != io.EOF {
+		return err
+	}
+
+	if len(open) > 0 {
+		top := len(open) - 1
+		return &ScanError{
+			Msg: "Unterminated group",
+			Detail: fmt.Sprintf("Couldn't find closing bracket (%s).",
+				opening[open[top]]),
+			Start: open_pos[top],
+			End:   *ts.EndPosition(),
+			Err:   io.EOF,
+			Code:  CodeUnbalancedBracket,
+		}
+	}
+
+	return nil
+}
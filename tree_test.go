@@ -0,0 +1,161 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestBuildTree(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Pairs    []string
+		Expected []string
+		Err      string
+	}
+
+	test_list := []TestData{
+		{Name: "nested", Input: `f(a, [b "(" c]) {}`,
+			Expected: []string{"f", `(a , [b "(" c])`, "{}"}},
+		{Name: "empty", Input: "", Expected: nil},
+		{Name: "custom pairs", Input: "<a (b> c", Pairs: []string{"<>"},
+			Expected: []string{"<a ( b>", "c"}},
+		{Name: "unexpected", Input: "(a))",
+			Err: `Unexpected ")" at :1:4 (3).`},
+		{Name: "mismatched", Input: "(a [b)]",
+			Err: `Mismatched ")" at :1:6 (5). Expected "]" to close "[" ` +
+				`opened at :1:4 (3).`},
+		{Name: "unterminated", Input: "(a\n (b)",
+			Err: `Unterminated group opened at :1:1 (0), unterminated at ` +
+				`EOF :2:5 (7). Couldn't find closing bracket ()).`},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			tokens, err := textparser.TokenizeAll(p)
+			if err != nil {
+				st.Fatalf("couldn't tokenize: %s", err)
+			}
+
+			nodes, err := textparser.BuildTree(tokens, test_data.Pairs...)
+
+			if test_data.Err != "" {
+				if err == nil || err.Error() != test_data.Err {
+					st.Errorf("got error %v, expected %s", err, test_data.Err)
+				}
+				if code := textparser.ErrorCodeOf(err); code !=
+					textparser.CodeUnbalancedBracket {
+					st.Errorf("got code %s, expected %s", code,
+						textparser.CodeUnbalancedBracket)
+				}
+				return
+			}
+
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, node := range nodes {
+				got = append(got, node.String())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestTokenGroupInner(t *testing.T) {
+	p := textparser.NewScannerString("call(x, (y))")
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("couldn't tokenize: %s", err)
+	}
+
+	nodes, err := textparser.BuildTree(tokens)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	group := nodes[1]
+	if !group.IsGroup() || nodes[0].IsGroup() {
+		t.Fatalf("got %v, expected a token and a group", nodes)
+	}
+
+	var inner []string
+	for _, token := range group.Inner() {
+		inner = append(inner, token.Text)
+	}
+	expected := []string{"x", ",", "(", "y", ")"}
+	if !reflect.DeepEqual(inner, expected) {
+		t.Errorf("got %#v, expected %#v", inner, expected)
+	}
+
+	if got, expected := len(group.Tokens()), 7; got != expected {
+		t.Errorf("got %d tokens, expected %d", got, expected)
+	}
+
+	span := group.Span()
+	if span.Start.Offset != 4 || span.End.Offset != 12 {
+		t.Errorf("got span %s, expected offsets 4-12", span)
+	}
+}
+
+func TestCheckBalanced(t *testing.T) {
+	type TestData struct {
+		Name  string
+		Input string
+		Pairs []string
+		Err   string
+		Code  textparser.ErrorCode
+	}
+
+	test_list := []TestData{
+		{Name: "balanced", Input: `f(a, [b "(" /* { */ c]) {}`,
+			Code: textparser.CodeNone},
+		{Name: "empty", Input: "", Code: textparser.CodeNone},
+		{Name: "custom pairs", Input: "<a (b>", Pairs: []string{"<>"},
+			Code: textparser.CodeNone},
+		{Name: "unexpected", Input: "(a))",
+			Err:  `Unexpected ")" at :1:4 (3).`,
+			Code: textparser.CodeUnbalancedBracket},
+		{Name: "long symbol", Input: "((a)))",
+			Err:  `Unexpected ")" at :1:6 (5).`,
+			Code: textparser.CodeUnbalancedBracket},
+		{Name: "mismatched", Input: "(a [b)]",
+			Err: `Mismatched ")" at :1:6 (5). Expected "]" to close "[" ` +
+				`opened at :1:4 (3).`,
+			Code: textparser.CodeUnbalancedBracket},
+		{Name: "unterminated", Input: "(a\n (b)",
+			Err: `Unterminated group opened at :1:1 (0), unterminated at ` +
+				`EOF :2:5 (7). Couldn't find closing bracket ()).`,
+			Code: textparser.CodeUnbalancedBracket},
+		{Name: "unterminated string", Input: `(a "b)`,
+			Code: textparser.CodeUnterminatedString},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.IsSymbolRune = func(ch rune, i int, runes []rune) bool {
+				return strings.ContainsRune("()[]{}<>,", ch)
+			}
+			err := textparser.CheckBalanced(p, test_data.Pairs...)
+
+			if code := textparser.ErrorCodeOf(err); code != test_data.Code {
+				st.Errorf("got code %s (%v), expected %s", code, err,
+					test_data.Code)
+			}
+			if test_data.Err != "" &&
+				(err == nil || err.Error() != test_data.Err) {
+				st.Errorf("got error %v, expected %s", err, test_data.Err)
+			}
+		})
+	}
+}
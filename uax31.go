@@ -0,0 +1,124 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Runes with the ID_Start property that lack XID_Start, because they are
+// not closed under NFKC normalization.
+var not_xid_start = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x0e33, Hi: 0x0e33, Stride: 1},
+		{Lo: 0x0eb3, Hi: 0x0eb3, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	},
+}
+
+// Runes with the ID_Continue property that lack XID_Continue.
+var not_xid_continue = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+	},
+}
+
+// Returns true if the rune has the Unicode XID_Start property, i.e., it may
+// start an identifier according to Unicode Standard Annex #31. Note that
+// this excludes the underscore.
+func IsXIDStart(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return ascii_props[ch]&ascii_letter != 0
+	}
+
+	return is_id_start(ch) && !unicode.Is(not_xid_start, ch)
+}
+
+// Returns true if the rune has the Unicode XID_Continue property, i.e., it
+// may follow the first rune of an identifier according to Unicode Standard
+// Annex #31.
+func IsXIDContinue(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return ascii_props[ch]&(ascii_letter|ascii_digit) != 0 || ch == '_'
+	}
+
+	if unicode.Is(not_xid_continue, ch) {
+		return false
+	}
+
+	if is_id_start(ch) {
+		return true
+	}
+
+	if unicode.In(ch, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc,
+		unicode.Other_ID_Continue) {
+		return !is_pattern(ch)
+	}
+
+	return false
+}
+
+// Returns true if the rune has the ID_Start property.
+func is_id_start(ch rune) bool {
+	if !unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start) {
+		return false
+	}
+
+	return !is_pattern(ch)
+}
+
+// Returns true if the rune is reserved for the syntax of patterns, so it is
+// never part of an identifier.
+func is_pattern(ch rune) bool {
+	return unicode.In(ch, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// Alternative predicate for identifiers following the default identifier
+// syntax of Unicode Standard Annex #31, as used by Go, Rust, and Python: an
+// identifier starts with an XID_Start rune or an underscore, followed by
+// XID_Continue runes (see IsXIDStart() and IsXIDContinue()). Set the
+// `IsIdentRune` field of `TokenScanner` to this function, or set
+// UAX31Identifiers in a ScannerConfig, to use it. Unlike IsIdentRune(), it
+// rejects combining marks at the start of an identifier, and accepts
+// connector punctuation, e.g., U+203F, and letter numbers, e.g., U+2167.
+func IsIdentRuneUAX31(ch rune, i int, runes []rune) bool {
+	if i == 0 {
+		return ch == '_' || IsXIDStart(ch)
+	}
+
+	return IsXIDContinue(ch)
+}
@@ -0,0 +1,94 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestXIDProperties(t *testing.T) {
+	type TestData struct {
+		Rune     rune
+		Start    bool
+		Continue bool
+	}
+
+	test_list := []TestData{
+		{'a', true, true},
+		{'Z', true, true},
+		{'_', false, true},
+		{'7', false, true},
+		{'$', false, false},
+		{'-', false, false},
+		{' ', false, false},
+		{'é', true, true},
+		{'Ω', true, true},
+		{'日', true, true},
+		{0x0300, false, true}, // Combining grave accent.
+		{0x00b7, false, true}, // Middle dot.
+		{0x0e33, false, true}, // Thai sara am.
+		{0x037a, false, false},
+		{0xff9e, false, true},
+		{0x203f, false, true}, // Undertie.
+		{0x2167, true, true},  // Roman numeral eight.
+		{0x0660, false, true}, // Arabic-indic digit zero.
+		{0x00ab, false, false},
+		{0x2190, false, false},
+	}
+
+	for _, test_data := range test_list {
+		name := string(test_data.Rune)
+		t.Run(name, func(st *testing.T) {
+			if got := textparser.IsXIDStart(test_data.Rune); got !=
+				test_data.Start {
+				st.Errorf("start: got %t, expected %t", got,
+					test_data.Start)
+			}
+			if got := textparser.IsXIDContinue(test_data.Rune); got !=
+				test_data.Continue {
+				st.Errorf("continue: got %t, expected %t", got,
+					test_data.Continue)
+			}
+		})
+	}
+}
+
+func TestIdentRuneUAX31(t *testing.T) {
+	input := "_x1 ‿a b‿c ٣d é́ Ⅷx a·b"
+
+	p := textparser.NewScannerString(input)
+	p.IsIdentRune = textparser.IsIdentRuneUAX31
+	p.Unknown = textparser.UnknownRune
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.Token().Type.String()+" "+p.TokenText())
+	}
+
+	expected := []string{"Ident _x1", "Symbol ‿", "Ident a", "Ident b‿c",
+		"Int ٣", "Ident d", "Ident é́", "Ident Ⅷx", "Ident a·b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
+
+func TestScannerConfigUAX31(t *testing.T) {
+	cfg := &textparser.ScannerConfig{UAX31Identifiers: true,
+		IdentRunes: "$"}
+	p, err := cfg.NewScanner(strings.NewReader("$a‿b c"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for p.Scan() {
+		got = append(got, p.TokenText())
+	}
+
+	expected := []string{"$a‿b", "c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+}
@@ -0,0 +1,123 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Controls how runes that do not start any kind of token are handled.
+type UnknownMode int
+
+// Supported modes for unrecognized runes.
+const (
+	// Stop scanning, as if the end of the input had been reached.
+	UnknownStop UnknownMode = iota
+
+	// Stop scanning with an error.
+	UnknownError
+
+	// Emit each unrecognized rune as a separate token.
+	UnknownRune
+
+	// Emit each maximal run of unrecognized runes as a single token.
+	UnknownRun
+)
+
+// Returns a string representation of the mode.
+func (m UnknownMode) String() string {
+	names := [...]string{"Stop", "Error", "Rune", "Run"}
+	if m < 0 || int(m) > len(names)-1 {
+		return ""
+	}
+
+	return names[m]
+}
+
+// Handles a rune that classify() did not recognize, according to the Unknown
+// setting. Returns a nil token and error to stop scanning quietly.
+func (ts *TokenScanner) get_unknown() (*Token, error) {
+	switch ts.Unknown {
+	case UnknownStop:
+		return nil, nil
+	case UnknownError:
+		ch, _, err := ts.get_one_rune()
+		if err != nil {
+			return nil, err
+		}
+		ts.unread_rune()
+		return nil, &ScanError{
+			Msg:   fmt.Sprintf("Unrecognized character %q", ch),
+			Start: *ts.pos,
+			End:   *ts.pos,
+			Code:  CodeUnrecognizedCharacter,
+		}
+	}
+
+	var (
+		runes      []rune
+		total_size int
+	)
+
+	for {
+		ch, size, err := ts.get_one_rune()
+		if err != nil {
+			return nil, err
+		}
+
+		total_size += size
+		ts.advance_position(ch, size)
+		runes = append(runes, ch)
+
+		if ts.Unknown != UnknownRun {
+			break
+		}
+
+		m, err := ts.classify()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF || m != nil {
+			break
+		}
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      ts.UnknownType,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
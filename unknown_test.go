@@ -0,0 +1,72 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestUnknown(t *testing.T) {
+	input := "foo \x01\x02 bar\x03"
+
+	tests := []struct {
+		Name     string
+		Mode     textparser.UnknownMode
+		Expected []string
+		Error    bool
+	}{
+		{"stop", textparser.UnknownStop, []string{"foo"}, false},
+		{"error", textparser.UnknownError, []string{"foo"}, true},
+		{"rune", textparser.UnknownRune,
+			[]string{"foo", "\x01", "\x02", "bar", "\x03"}, false},
+		{"run", textparser.UnknownRun,
+			[]string{"foo", "\x01\x02", "bar", "\x03"}, false},
+	}
+
+	for _, test_data := range tests {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(input)
+			p.Unknown = test_data.Mode
+
+			token_list := make([]string, 0, len(test_data.Expected))
+			for p.Scan() {
+				token_list = append(token_list, p.TokenText())
+				if p.TokenText()[0] < ' ' &&
+					p.Token().Type != textparser.TokenTypeUnknown {
+					st.Errorf("got type %s for %q", p.Token().Type,
+						p.TokenText())
+				}
+			}
+
+			if !reflect.DeepEqual(token_list, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", token_list,
+					test_data.Expected)
+			}
+
+			err := p.Err()
+			if got_error := err != nil && err != io.EOF; got_error !=
+				test_data.Error {
+				st.Errorf("unexpected error state: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	p := textparser.NewScannerString("a\x01b")
+	p.Unknown = textparser.UnknownRune
+	p.UnknownType = textparser.TokenTypeSymbol
+
+	p.Scan()
+	p.Scan()
+	if p.Token().Type != textparser.TokenTypeSymbol {
+		t.Errorf("got type %s, expected Symbol", p.Token().Type)
+	}
+
+	p.Scan()
+	expected := &textparser.Position{Offset: 2, Line: 1, Column: 3}
+	if !reflect.DeepEqual(p.Position(), expected) {
+		t.Errorf("got %s, expected %s", p.Position(), expected)
+	}
+}
@@ -0,0 +1,157 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Maps the rune following an escape rune (\) in a quoted string to the text
+// the pair stands for, e.g., 'n' to "\n", for Unquote() and Unescape().
+type EscapeMap map[rune]string
+
+// The escapes of C-like languages: \a, \b, \f, \n, \r, \t, \v, and escaped
+// backslashes and quotes.
+var DefaultEscapes = EscapeMap{
+	'a':  "\a",
+	'b':  "\b",
+	'f':  "\f",
+	'n':  "\n",
+	'r':  "\r",
+	't':  "\t",
+	'v':  "\v",
+	'\\': "\\",
+	'\'': "'",
+	'"':  "\"",
+	'`':  "`",
+}
+
+// Returns the value of a quoted string as scanned, i.e., the text of a
+// TokenTypeString token, without its quotes (any of those recognized by
+// IsQuoteRuneFancy()) and with escape sequences decoded by Unescape(). As
+// in the token text, escaped closing quotes and doubled quotes (see
+// DoubledQuotes) may already stand for a single quote. Returns an error if
+// the text is not quoted or has a malformed escape sequence.
+func Unquote(text string, escapes EscapeMap) (string, error) {
+	open, size := utf8.DecodeRuneInString(text)
+	ok, closing := IsQuoteRuneFancy(open)
+	if !ok {
+		return "", fmt.Errorf("%q is not quoted", text)
+	}
+
+	inner := text[size:]
+	if !strings.HasSuffix(inner, string(closing)) {
+		return "", fmt.Errorf("%q is missing the closing quote (%c)", text,
+			closing)
+	}
+	inner = inner[:len(inner)-utf8.RuneLen(closing)]
+
+	return Unescape(inner, escapes)
+}
+
+// Returns the text with each escape sequence decoded: a backslash followed
+// by a rune in escapes (DefaultEscapes if nil) is replaced by its text, and
+// \xhh, \uhhhh, and \Uhhhhhhhh, unless in escapes, by the rune with that
+// code point (or, for \x, the byte). Other escape sequences are kept as is,
+// as the scanner keeps them in the token text. Returns an error for a
+// malformed numeric escape sequence.
+func Unescape(text string, escapes EscapeMap) (string, error) {
+	i := strings.IndexByte(text, '\\')
+	if i < 0 {
+		return text, nil
+	}
+
+	if escapes == nil {
+		escapes = DefaultEscapes
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i >= 0 {
+		b.WriteString(text[:i])
+		text = text[i+1:]
+
+		ch, size := utf8.DecodeRuneInString(text)
+		if repl, ok := escapes[ch]; ok && size > 0 {
+			b.WriteString(repl)
+			text = text[size:]
+		} else if ch == 'x' || ch == 'u' || ch == 'U' {
+			n, err := write_numeric_escape(&b, ch, text[size:])
+			if err != nil {
+				return "", err
+			}
+			text = text[size+n:]
+		} else {
+			// Kept as is, including a trailing escape rune, which
+			// escapes nothing.
+			b.WriteByte('\\')
+			b.WriteString(text[:size])
+			text = text[size:]
+		}
+
+		i = strings.IndexByte(text, '\\')
+	}
+	b.WriteString(text)
+
+	return b.String(), nil
+}
+
+// Writes the rune, or byte, of the numeric escape sequence \x, \u, or \U
+// (kind) whose hex digits start the text, returning the number of digits.
+func write_numeric_escape(
+	b *strings.Builder,
+	kind rune,
+	text string,
+) (int, error) {
+	n := map[rune]int{'x': 2, 'u': 4, 'U': 8}[kind]
+	if len(text) < n {
+		return 0, fmt.Errorf("escape sequence \\%c%s is too short", kind,
+			text)
+	}
+
+	value, err := strconv.ParseUint(text[:n], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape sequence \\%c%s", kind,
+			text[:n])
+	}
+
+	if kind == 'x' {
+		b.WriteByte(byte(value))
+		return n, nil
+	}
+
+	if !utf8.ValidRune(rune(value)) {
+		return 0, fmt.Errorf("escape sequence \\%c%s is not a valid rune",
+			kind, text[:n])
+	}
+	b.WriteRune(rune(value))
+
+	return n, nil
+}
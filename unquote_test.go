@@ -0,0 +1,77 @@
+package textparser_test
+
+import (
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestUnquote(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Text     string
+		Escapes  textparser.EscapeMap
+		Expected string
+		Err      bool
+	}
+
+	test_list := []TestData{
+		{"plain", `"abc"`, nil, "abc", false},
+		{"single quotes", `'a b'`, nil, "a b", false},
+		{"fancy quotes", `“a\tb”`, nil, "a\tb", false},
+		{"escapes", `"a\n\t\\b\'"`, nil, "a\n\t\\b'", false},
+		{"unknown escape kept", `"a\qb"`, nil, `a\qb`, false},
+		{"escaped escape rune", `"\\\\q"`, textparser.EscapeMap{}, `\\\\q`,
+			false},
+		{"hex", `"\x41\u00e9\U0001F600"`, nil, "Aé😀", false},
+		{"custom map", `"\n\e"`, textparser.EscapeMap{'e': "\x1b"},
+			"\\n\x1b", false},
+		{"custom map overrides hex", `"\x"`, textparser.EscapeMap{'x': "X"},
+			"X", false},
+		{"trailing escape", `"a\"`, nil, `a\`, false},
+		{"not quoted", `abc`, nil, "", true},
+		{"unterminated", `"abc`, nil, "", true},
+		{"short hex", `"\x4"`, nil, "", true},
+		{"bad hex", `"\u12g4"`, nil, "", true},
+		{"bad rune", `"\UFFFFFFFF"`, nil, "", true},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			got, err := textparser.Unquote(test_data.Text,
+				test_data.Escapes)
+			if test_data.Err {
+				if err == nil {
+					st.Errorf("got %q, expected an error", got)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestUnquoteScannedToken(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader(`x = "say \"hi\"\n"`))
+	var text string
+	for p.Scan() {
+		if p.LastToken.Type == textparser.TokenTypeString {
+			text = p.LastToken.Text
+		}
+	}
+
+	got, err := textparser.Unquote(text, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := "say \"hi\"\n"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,153 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Longest scheme, including "://", checked before looking further ahead.
+const max_url_scheme_runes = 35
+
+// URLs longer than this many runes are cut short.
+const max_url_runes = 1024
+
+// Runes other than letters and digits allowed in URLs.
+const url_special_runes = "-._~:/?#[]@!$&'()*+,;=%"
+
+// Runes that end a sentence rather than a URL when they end the URL.
+const url_trailing_runes = ".,;:!?'\"*"
+
+// Returns the number of runes of the URL starting at the next rune, e.g.,
+// "https://example.com/a?b=c#d", or 0 if there is none, without consuming
+// any input.
+func (ts *TokenScanner) url_len() int {
+	if !ts.URLs {
+		return 0
+	}
+
+	if match_url_scheme(ts.peek_upto(max_url_scheme_runes)) == 0 {
+		return 0
+	}
+
+	return match_url(ts.peek_upto(max_url_runes))
+}
+
+// Returns the length of the scheme at the start of s, including "://", or 0
+// if there is none.
+func match_url_scheme(s []rune) int {
+	i := 0
+	for i < len(s) && (is_ascii_letter(s[i]) || (i > 0 &&
+		(is_ascii_digit(s[i]) || s[i] == '+' || s[i] == '-' ||
+			s[i] == '.'))) {
+		i++
+	}
+
+	if i == 0 || !strings.HasPrefix(string(s[i:]), "://") {
+		return 0
+	}
+
+	return i + 3
+}
+
+// Returns the length of the URL at the start of s, or 0 if there is none.
+// Punctuation at the end is left out, e.g., the period ending a sentence, as
+// is a closing parenthesis or bracket without a matching opening one, e.g.,
+// in "(see https://example.com)".
+func match_url(s []rune) int {
+	n := match_url_scheme(s)
+	if n == 0 {
+		return 0
+	}
+	scheme_len := n
+
+	for n < len(s) && is_url_rune(s[n]) {
+		n++
+	}
+
+	for n > scheme_len {
+		ch := s[n-1]
+		switch {
+		case strings.ContainsRune(url_trailing_runes, ch):
+		case ch == ')' && count_runes(s[:n], ')') >
+			count_runes(s[:n], '('):
+		case ch == ']' && count_runes(s[:n], ']') >
+			count_runes(s[:n], '['):
+		default:
+			return n
+		}
+		n--
+	}
+
+	// Nothing follows the scheme.
+	return 0
+}
+
+func is_url_rune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) ||
+		strings.ContainsRune(url_special_runes, ch)
+}
+
+// Returns the number of times ch occurs in s.
+func count_runes(s []rune, ch rune) int {
+	n := 0
+	for _, r := range s {
+		if r == ch {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Reads a URL matched by url_len().
+func (ts *TokenScanner) get_url() (*Token, error) {
+	n := ts.url_len()
+	if n == 0 {
+		return nil, nil
+	}
+
+	runes, total_size, err := ts.get_n_runes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  total_size,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeURL,
+	}
+
+	ts.last_byte_len = total_size
+	ts.set_token(token)
+
+	return token, nil
+}
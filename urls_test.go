@@ -0,0 +1,58 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestURLs(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Expected []string
+	}
+
+	test_list := []TestData{
+		{"full", "see https://user@example.com:8080/a/b?c=d&e=f#g now",
+			[]string{`Ident "see"`,
+				`URL "https://user@example.com:8080/a/b?c=d&e=f#g"`,
+				`Ident "now"`}},
+		{"sentence end", "Go to http://example.com/x. Or not",
+			[]string{`Ident "Go"`, `Ident "to"`,
+				`URL "http://example.com/x"`, `Symbol "."`, `Ident "Or"`,
+				`Ident "not"`}},
+		{"parentheses", "(see https://en.wikipedia.org/wiki/Go_(lang))",
+			[]string{`Symbol "("`, `Ident "see"`,
+				`URL "https://en.wikipedia.org/wiki/Go_(lang)"`,
+				`Symbol ")"`}},
+		{"scheme only", "http:// x",
+			[]string{`Ident "http"`, `Symbol ":"`, `Symbol "/"`,
+				`Symbol "/"`, `Ident "x"`}},
+		{"not a url", "a:b", []string{`Ident "a"`, `Symbol ":"`,
+			`Ident "b"`}},
+		{"non-ascii", "git+ssh://h\u00e9/d\u00e9p\u00f4t",
+			[]string{"URL \"git+ssh://h\u00e9/d\u00e9p\u00f4t\""}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.SkipWhitespace = true
+			p.URLs = true
+			p.SetComments()
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+
+					strconv.Quote(token.Text))
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import "strings"
+
+// Replaces "\r\n" and lone "\r" line endings with "\n".
+var eol_normalizer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Returns the value of the token: Value if set (see NormalizeValueEOL),
+// otherwise Text.
+func (t *Token) ValueText() string {
+	if t.Value != "" {
+		return t.Value
+	}
+
+	return t.Text
+}
+
+// Sets the Value of a string or comment token whose text has "\r" line
+// endings, as requested by NormalizeValueEOL.
+func (ts *TokenScanner) normalize_value_eol(token *Token) {
+	if token.Type != TokenTypeString && token.Type != TokenTypeComment {
+		return
+	}
+
+	if strings.IndexByte(token.Text, '\r') >= 0 {
+		token.Value = eol_normalizer.Replace(token.Text)
+	}
+}
@@ -0,0 +1,66 @@
+package textparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestNormalizeValueEOL(t *testing.T) {
+	input := "a = \"one\r\ntwo\rthree\" /* x\r\ny */ // z\r\nb\r\n"
+
+	type TestData struct {
+		Name      string
+		Normalize bool
+		Expected  []string
+	}
+
+	test_list := []TestData{
+		{"off", false, []string{
+			"Ident a", "Symbol =", "String \"one\r\ntwo\rthree\"",
+			"Comment /* x\r\ny */", "Comment // z\r\n", "Ident b",
+		}},
+		{"on", true, []string{
+			"Ident a", "Symbol =", "String \"one\ntwo\nthree\"",
+			"Comment /* x\ny */", "Comment // z\n", "Ident b",
+		}},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScanner(strings.NewReader(input))
+			p.SkipComments = false
+			p.NormalizeValueEOL = test_data.Normalize
+
+			got := []string{}
+			for _, token := range scan_all(st, p) {
+				if token.Value != "" && token.Value == token.Text {
+					st.Errorf("got Value set to the text for %s", token)
+				}
+				got = append(got,
+					token.Type.String()+" "+token.ValueText())
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got,
+					test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeValueEOLKeepsText(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader("\"a\r\nb\""))
+	p.NormalizeValueEOL = true
+
+	tokens := scan_all(t, p)
+	if len(tokens) != 1 {
+		t.Fatalf("got %d tokens, expected 1", len(tokens))
+	}
+	if tokens[0].Text != "\"a\r\nb\"" || tokens[0].Value != "\"a\nb\"" {
+		t.Errorf("got text %q and value %q, expected %q and %q",
+			tokens[0].Text, tokens[0].Value, "\"a\r\nb\"", "\"a\nb\"")
+	}
+}
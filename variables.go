@@ -0,0 +1,138 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"strings"
+)
+
+// Returns true if the rune is a sigil starting a variable reference (see
+// VariableSigils).
+func (ts *TokenScanner) is_variable_sigil(ch rune) bool {
+	if ts.VariableSigils == "" {
+		return ch == '$'
+	}
+
+	return strings.ContainsRune(ts.VariableSigils, ch)
+}
+
+// Returns true if the upcoming input starts a variable reference, without
+// consuming any input.
+func (ts *TokenScanner) is_variable_start() bool {
+	if !ts.Variables {
+		return false
+	}
+
+	runes := ts.peek_upto(2)
+
+	return len(runes) == 2 && ts.is_variable_sigil(runes[0]) &&
+		(runes[1] == '{' || ts.IsIdentRune(runes[1], 0, nil))
+}
+
+// Reads a variable reference: a sigil followed by a name made of identifier
+// runes (see IsIdentRune), e.g., "$NAME", or by "{...}" up to the closing
+// brace on the same line.
+func (ts *TokenScanner) get_variable() (*Token, error) {
+	if !ts.is_variable_start() {
+		return nil, nil
+	}
+
+	runes, _, err := ts.get_n_runes(2)
+	if err != nil {
+		return nil, err
+	}
+
+	braced := runes[1] == '{'
+
+	for {
+		ch, size, err := ts.get_one_rune()
+		if braced && (err != nil || ch == ts.eol) {
+			if err == nil {
+				ts.unread_rune()
+			}
+			return nil, ts.unterminated_error("variable",
+				"Couldn't find closing brace (}).",
+				CodeUnterminatedVariable, err)
+		}
+		if err != nil {
+			break
+		}
+
+		if !braced && !ts.IsIdentRune(ch, len(runes)-1, runes[1:]) {
+			if err = ts.unread_rune(); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		ts.last_byte_len += size
+		ts.advance_position(ch, size)
+		runes = append(runes, ch)
+
+		if braced && ch == '}' {
+			break
+		}
+	}
+
+	token := ts.new_token()
+	*token = Token{
+		Text:      ts.token_text(runes),
+		NumBytes:  ts.last_byte_len,
+		NumChars:  len(runes),
+		FirstRune: runes[0],
+		Type:      TokenTypeVariable,
+	}
+
+	ts.set_token(token)
+
+	return token, nil
+}
+
+// Returns the name of the variable referenced by the text of a
+// TokenTypeVariable token, e.g., "HOME" for "$HOME" or "${HOME:-/}", or
+// "list" for "@list". The name is taken to end at the first rune that the
+// default IsIdentRune() does not accept.
+func VariableName(text string) string {
+	runes := []rune(text)
+	if len(runes) < 2 || IsIdentRune(runes[0], 0, nil) ||
+		runes[0] == '{' {
+		return ""
+	}
+
+	runes = runes[1:]
+	if runes[0] == '{' {
+		runes = runes[1:]
+	}
+
+	for i, ch := range runes {
+		if !IsIdentRune(ch, i, runes[:i]) {
+			return string(runes[:i])
+		}
+	}
+
+	return string(runes)
+}
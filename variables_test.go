@@ -0,0 +1,94 @@
+package textparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestVariableSigils(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		Sigils   string
+		Ident    func(ch rune, i int, runes []rune) bool
+		Expected []string // Type and text of each token.
+	}
+
+	test_list := []TestData{
+		{
+			Name:  "default",
+			Input: "$x @y ${z}",
+			Expected: []string{"Variable $x", "Symbol @", "Ident y",
+				"Variable ${z}"},
+		},
+		{
+			Name:   "perl",
+			Input:  "my @list = %map{$k} & &sub; $a % 2",
+			Sigils: "$@%&",
+			Expected: []string{"Ident my", "Variable @list", "Symbol =",
+				"Variable %map", "Symbol {", "Variable $k", "Symbol }",
+				"Symbol &", "Variable &sub", "Symbol ;", "Variable $a",
+				"Symbol %", "Int 2"},
+		},
+		{
+			Name:     "braced",
+			Input:    "@{refs}",
+			Sigils:   "@",
+			Expected: []string{"Variable @{refs}"},
+		},
+		{
+			Name:  "configured identifier runes",
+			Input: "$foo-bar foo-bar",
+			Ident: func(ch rune, i int, runes []rune) bool {
+				return textparser.IsIdentRune(ch, i, runes) ||
+					(i > 0 && ch == '-')
+			},
+			Expected: []string{"Variable $foo-bar", "Ident foo-bar"},
+		},
+		{
+			Name:  "positionals are not variables",
+			Input: "$1 $@",
+			Expected: []string{"Symbol $", "Int 1", "Symbol $",
+				"Symbol @"},
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			p.Variables = true
+			p.VariableSigils = test_data.Sigils
+			if test_data.Ident != nil {
+				p.IsIdentRune = test_data.Ident
+			}
+
+			var got []string
+			for _, token := range scan_all(st, p) {
+				got = append(got, token.Type.String()+" "+token.Text)
+			}
+
+			if !reflect.DeepEqual(got, test_data.Expected) {
+				st.Errorf("got %#v, expected %#v", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+func TestVariableName(t *testing.T) {
+	test_list := map[string]string{
+		"$HOME":      "HOME",
+		"${HOME:-/}": "HOME",
+		"@list":      "list",
+		"%{map}":     "map",
+		"x":          "",
+		"{x}":        "",
+	}
+
+	for text, expected := range test_list {
+		if got := textparser.VariableName(text); got != expected {
+			t.Errorf("got %q for %q, expected %q", got, text, expected)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import "strings"
+
+// A read-only view of a Token. A TokenView holds its own copy of the token,
+// so it is safe to share between consumers and goroutines: no one can
+// modify the underlying token through it, and modifying the Token the view
+// was created from does not affect the view. Use CloneMutable() to get a
+// Token that may be edited.
+type TokenView struct {
+	t *Token
+}
+
+// Returns a read-only view of a copy of the token.
+func (t *Token) View() TokenView {
+	return TokenView{t: t.CloneMutable()}
+}
+
+// Returns a copy of the token that may be modified without affecting the
+// original.
+func (t *Token) CloneMutable() *Token {
+	if t == nil {
+		return nil
+	}
+
+	c := new(Token)
+	*c = *t
+
+	if t.Parts != nil {
+		c.Parts = append([]Span(nil), t.Parts...)
+	}
+
+	if t.Segments != nil {
+		c.Segments = append([]string(nil), t.Segments...)
+	}
+
+	// In byte mode, the text refers to a buffer reused by the scanner.
+	if t.Bytes != nil {
+		c.Text = strings.Clone(t.Text)
+		c.Raw = strings.Clone(t.Raw)
+		c.Bytes = []byte(c.Text)
+	}
+
+	if t.TrailingComment != nil {
+		// The comment holds no bytes, but its text may still be shared.
+		c.TrailingComment = t.TrailingComment.CloneMutable()
+		c.TrailingComment.Text = strings.Clone(c.TrailingComment.Text)
+		c.TrailingComment.Raw = strings.Clone(c.TrailingComment.Raw)
+	}
+
+	return c
+}
+
+// Returns a read-only view of the most recent token generated by a call to
+// Scan().
+func (ts *TokenScanner) TokenView() TokenView {
+	if ts.LastToken == nil {
+		return TokenView{}
+	}
+
+	return ts.LastToken.View()
+}
+
+// Returns true if the view does not refer to a token.
+func (v TokenView) IsZero() bool {
+	return v.t == nil
+}
+
+// Returns the text of the token.
+func (v TokenView) Text() string {
+	if v.t == nil {
+		return ""
+	}
+
+	return v.t.Text
+}
+
+// Returns the text of the token as scanned, before any emit transforms.
+func (v TokenView) RawText() string {
+	if v.t == nil {
+		return ""
+	}
+
+	return v.t.RawText()
+}
+
+// Returns the number of bytes in the token.
+func (v TokenView) NumBytes() int {
+	if v.t == nil {
+		return 0
+	}
+
+	return v.t.NumBytes
+}
+
+// Returns the number of characters/runes in the token.
+func (v TokenView) NumChars() int {
+	if v.t == nil {
+		return 0
+	}
+
+	return v.t.NumChars
+}
+
+// Returns the first rune in the token.
+func (v TokenView) FirstRune() rune {
+	if v.t == nil {
+		return 0
+	}
+
+	return v.t.FirstRune
+}
+
+// Returns the type of the token.
+func (v TokenView) Type() TokenType {
+	if v.t == nil {
+		return 0
+	}
+
+	return v.t.Type
+}
+
+// Returns a string representation of the token.
+func (v TokenView) String() string {
+	if v.t == nil {
+		return "<nil>"
+	}
+
+	return v.t.String()
+}
+
+// Returns a copy of the token that may be modified without affecting the
+// view.
+func (v TokenView) CloneMutable() *Token {
+	return v.t.CloneMutable()
+}
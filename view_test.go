@@ -0,0 +1,93 @@
+package textparser_test
+
+import (
+	textparser "github.com/cuberat/go-textparser"
+	"testing"
+)
+
+func TestTokenView(t *testing.T) {
+	p := textparser.NewScannerString(`foo bar`)
+	p.Scan()
+
+	tok := p.Token()
+	view := tok.View()
+
+	tok.Text = "changed"
+	if view.Text() != "foo" {
+		t.Errorf("view changed along with token: got %q", view.Text())
+	}
+
+	if view.Type() != textparser.TokenTypeIdent || view.NumBytes() != 3 ||
+		view.NumChars() != 3 || view.FirstRune() != 'f' {
+		t.Errorf("unexpected view contents: %s", view)
+	}
+
+	c := view.CloneMutable()
+	c.Text = "edited"
+	if view.Text() != "foo" {
+		t.Errorf("view changed along with clone: got %q", view.Text())
+	}
+
+	var zero textparser.TokenView
+	if !zero.IsZero() || zero.Text() != "" || zero.CloneMutable() != nil {
+		t.Errorf("unexpected zero view contents: %s", zero)
+	}
+}
+
+func TestTokenCloneMutable(t *testing.T) {
+	p := textparser.NewScannerString("a::b \"x\" \"y\" c # note\n")
+	p.SetComments(textparser.CommentStyle{Start: "#"})
+	p.SetNamespaceSeparators("::")
+	p.ConcatStrings = true
+	p.TrailingComments = true
+	p.ByteTokens = true
+
+	var clones []*textparser.Token
+	for p.Scan() {
+		token := p.Token()
+		clones = append(clones, token.CloneMutable())
+		if token.Segments != nil {
+			token.Segments[0] = "changed"
+		}
+		if token.Parts != nil {
+			token.Parts[0] = textparser.Span{}
+		}
+		if token.TrailingComment != nil {
+			token.TrailingComment.Text = "changed"
+		}
+	}
+
+	if len(clones) != 3 {
+		t.Fatalf("got %d tokens, expected 3", len(clones))
+	}
+
+	if clones[0].Segments[0] != "a" {
+		t.Errorf("got segment %q, expected %q", clones[0].Segments[0], "a")
+	}
+
+	if clones[1].Parts[0] == (textparser.Span{}) {
+		t.Errorf("got parts %v, expected non-zero spans", clones[1].Parts)
+	}
+
+	comment := clones[2].TrailingComment
+	if comment == nil || comment.Text != "# note\n" {
+		t.Errorf("got trailing comment %v, expected %q", comment,
+			"# note\n")
+	}
+}
+
+func TestTokenViewReuseToken(t *testing.T) {
+	p := textparser.NewScannerString(`foo bar baz`)
+	p.ReuseToken = true
+
+	views := make([]textparser.TokenView, 0, 3)
+	for p.Scan() {
+		views = append(views, p.TokenView())
+	}
+
+	for i, expected := range []string{"foo", "bar", "baz"} {
+		if views[i].Text() != expected {
+			t.Errorf("[%d] got %q, expected %q", i, views[i].Text(), expected)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+// The composition of a run of white space (see Token.Whitespace), e.g., for
+// a style checker to flag indentation mixing tabs and spaces.
+type WhitespaceInfo struct {
+	Spaces   int `json:"spaces"`   // Number of spaces (' ').
+	Tabs     int `json:"tabs"`     // Number of tabs ('\t').
+	Newlines int `json:"newlines"` // Number of end-of-line characters.
+	Other    int `json:"other"`    // Number of other runes, e.g., '\r'.
+}
+
+// Returns true if the run contains both spaces and tabs.
+func (w WhitespaceInfo) Mixed() bool {
+	return w.Spaces > 0 && w.Tabs > 0
+}
+
+// Returns the composition of the runes of a white space token.
+func (ts *TokenScanner) whitespace_info(runes []rune) WhitespaceInfo {
+	var w WhitespaceInfo
+	for _, ch := range runes {
+		switch ch {
+		case ' ':
+			w.Spaces++
+		case '\t':
+			w.Tabs++
+		case ts.eol:
+			w.Newlines++
+		default:
+			w.Other++
+		}
+	}
+
+	return w
+}
@@ -0,0 +1,62 @@
+package textparser_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestWhitespaceInfo(t *testing.T) {
+	p := textparser.NewScanner(strings.NewReader("a \t \n\t\r\n  b c"))
+	p.SkipWhitespace = false
+
+	got := []textparser.WhitespaceInfo{}
+	for p.Scan() {
+		if p.LastToken.Type == textparser.TokenTypeWhitespace {
+			got = append(got, p.LastToken.Whitespace)
+		} else if p.LastToken.Whitespace != (textparser.WhitespaceInfo{}) {
+			t.Errorf("got %#v for %s", p.LastToken.Whitespace,
+				p.LastToken)
+		}
+	}
+
+	expected := []textparser.WhitespaceInfo{
+		{Spaces: 4, Tabs: 2, Newlines: 2, Other: 1},
+		{Spaces: 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, expected %#v", got, expected)
+	}
+
+	if !expected[0].Mixed() || expected[1].Mixed() {
+		t.Errorf("got Mixed() %t, %t, expected true, false",
+			expected[0].Mixed(), expected[1].Mixed())
+	}
+}
+
+func TestWhitespaceInfoJSON(t *testing.T) {
+	token := &textparser.Token{
+		Text:       " \t",
+		NumBytes:   2,
+		NumChars:   2,
+		FirstRune:  ' ',
+		Type:       textparser.TokenTypeWhitespace,
+		Whitespace: textparser.WhitespaceInfo{Spaces: 1, Tabs: 1},
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("couldn't marshal: %s", err)
+	}
+
+	got := new(textparser.Token)
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("couldn't unmarshal %s: %s", data, err)
+	}
+	if !reflect.DeepEqual(got, token) {
+		t.Errorf("got %#v, expected %#v", got, token)
+	}
+}
@@ -0,0 +1,231 @@
+// BSD 2-Clause License
+//
+// Copyright (c) 2020 Don Owens <don@regexguy.com>.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// * Redistributions of source code must retain the above copyright notice,
+//   this list of conditions and the following disclaimer.
+//
+// * Redistributions in binary form must reproduce the above copyright notice,
+//   this list of conditions and the following disclaimer in the documentation
+//   and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package textparser
+
+import (
+	"io"
+	"strings"
+)
+
+// Controls how a TokenWriter lays out the tokens it writes.
+type SpacingPolicy int
+
+const (
+	// Tokens are written as they are, white space tokens included, so that
+	// tokens scanned with SkipWhitespace and SkipComments off are written
+	// back unchanged. Nothing is written between tokens.
+	SpacingPreserve SpacingPolicy = iota
+
+	// White space tokens are dropped, and the space between tokens is
+	// regenerated from their spans: a newline for each line between the end
+	// of a token and the start of the next, then spaces up to the column of
+	// the next. Tabs thus become spaces. The space is relative to the end of
+	// the previous token, so a token edited to be longer or shorter moves
+	// the rest of its line. Tokens without a span are spaced as for
+	// SpacingMinimal.
+	SpacingPositions
+
+	// White space tokens are dropped, and a single space is written between
+	// tokens only where they would otherwise scan as one, e.g., two
+	// identifiers.
+	SpacingMinimal
+
+	// White space tokens are dropped, and a single space is written between
+	// tokens, except after one ending a line, e.g., a line comment.
+	SpacingSingle
+)
+
+// Returns a string representation of the spacing policy.
+func (p SpacingPolicy) String() string {
+	switch p {
+	case SpacingPreserve:
+		return "Preserve"
+	case SpacingPositions:
+		return "Positions"
+	case SpacingMinimal:
+		return "Minimal"
+	case SpacingSingle:
+		return "Single"
+	}
+
+	return ""
+}
+
+// Writes a stream of tokens back out as text, e.g., after transforming
+// tokens scanned from a source, spacing them according to a policy.
+// Indentation tokens (see SetIndentation()) are never written, as the
+// indentation is already white space or regenerated from spans.
+type TokenWriter struct {
+	// Indicator to write the raw text of tokens (see Token.RawText()), e.g.,
+	// the quoted text of fields in delimited mode, rather than Text.
+	Raw bool
+
+	w       io.Writer
+	policy  SpacingPolicy
+	profile *Profile
+	err     error
+
+	started   bool
+	prev_text string   // Text written for the previous token.
+	prev_end  Position // End of the previous token, if it has a span.
+}
+
+// Returns a TokenWriter writing to w according to the policy. The profile
+// (nil for the default configuration) is the one the tokens were scanned
+// with, used to tell whether tokens need space between them.
+func NewTokenWriter(
+	w io.Writer,
+	policy SpacingPolicy,
+	profile *Profile,
+) *TokenWriter {
+	return &TokenWriter{w: w, policy: policy, profile: profile}
+}
+
+// Writes the token, preceded by any space the policy calls for. Once a
+// write fails, this and later calls return the error.
+func (tw *TokenWriter) Write(st *ScannedToken) error {
+	if tw.err != nil {
+		return tw.err
+	}
+
+	switch st.Type {
+	case TokenTypeIndent, TokenTypeDedent:
+		return nil
+	case TokenTypeWhitespace:
+		if tw.policy != SpacingPreserve {
+			return nil
+		}
+	}
+
+	text := st.Text
+	if tw.Raw {
+		text = st.RawText()
+	}
+
+	space := tw.space(st, text)
+	if space != "" {
+		if _, tw.err = io.WriteString(tw.w, space); tw.err != nil {
+			return tw.err
+		}
+	}
+	if _, tw.err = io.WriteString(tw.w, text); tw.err != nil {
+		return tw.err
+	}
+
+	tw.started = true
+	tw.prev_end = st.End
+	if tw.policy != SpacingPreserve {
+		// The token may be reused by the scanner (see TokensReused()).
+		tw.prev_text = strings.Clone(text)
+	}
+
+	return nil
+}
+
+// Writes a token without a span, as Write().
+func (tw *TokenWriter) WriteToken(t *Token) error {
+	return tw.Write(&ScannedToken{Token: t})
+}
+
+// Writes the tokens in order, as Write().
+func (tw *TokenWriter) WriteAll(tokens []*ScannedToken) error {
+	for _, st := range tokens {
+		if err := tw.Write(st); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the tokens written back out as text by a TokenWriter with the
+// policy and profile.
+func Unparse(
+	tokens []*ScannedToken,
+	policy SpacingPolicy,
+	profile *Profile,
+) string {
+	b := new(strings.Builder)
+
+	// Writing to a strings.Builder cannot fail.
+	NewTokenWriter(b, policy, profile).WriteAll(tokens)
+
+	return b.String()
+}
+
+// Returns the space to write before the token, whose text is given.
+func (tw *TokenWriter) space(st *ScannedToken, text string) string {
+	if tw.policy == SpacingPreserve || text == "" {
+		return ""
+	}
+
+	if tw.policy == SpacingPositions && st.Start.Line > 0 {
+		if !tw.started {
+			return strings.Repeat("\n", st.Start.Line-1) +
+				strings.Repeat(" ", st.Start.Column-1)
+		}
+
+		if tw.prev_end.Line > 0 {
+			if lines := st.Start.Line - tw.prev_end.Line; lines > 0 {
+				return strings.Repeat("\n", lines) +
+					strings.Repeat(" ", st.Start.Column-1)
+			}
+
+			if n := st.Start.Column - tw.prev_end.Column; n > 0 {
+				return strings.Repeat(" ", n)
+			}
+		}
+	}
+
+	if !tw.started || strings.HasSuffix(tw.prev_text, "\n") {
+		return ""
+	}
+
+	if tw.policy == SpacingSingle || !tw.apart(text) {
+		return " "
+	}
+
+	return ""
+}
+
+// Returns true if the previous token and the next, with the text given,
+// scan as the same two tokens when written without space between them.
+func (tw *TokenWriter) apart(text string) bool {
+	joined := tw.prev_text + text
+
+	ts := tw.profile.NewScanner(strings.NewReader(joined))
+	ts.SkipWhitespace = false
+	ts.SkipComments = false
+
+	var ends []int
+	for ts.Scan() && len(ends) < 3 {
+		ends = append(ends, ts.EndPosition().Offset)
+	}
+
+	return len(ends) == 2 && ends[0] == len(tw.prev_text) &&
+		ends[1] == len(joined)
+}
@@ -0,0 +1,148 @@
+package textparser_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	textparser "github.com/cuberat/go-textparser"
+)
+
+func TestTokenWriter(t *testing.T) {
+	type TestData struct {
+		Name     string
+		Input    string
+		KeepAll  bool
+		Policy   textparser.SpacingPolicy
+		Edit     func(tokens []*textparser.ScannedToken)
+		Expected string
+	}
+
+	input := "foo  = bar(1, -2) // note\n\n\tbaz = 'x y'+ - 3\n"
+	rename := func(tokens []*textparser.ScannedToken) {
+		for _, st := range tokens {
+			if st.Text == "foo" {
+				st.Text = "renamed"
+			}
+		}
+	}
+
+	test_list := []TestData{
+		{
+			Name:     "preserve",
+			Input:    input,
+			KeepAll:  true,
+			Policy:   textparser.SpacingPreserve,
+			Expected: input,
+		},
+		{
+			Name:     "positions",
+			Input:    input,
+			Policy:   textparser.SpacingPositions,
+			Expected: "foo  = bar(1, -2)\n\n baz = 'x y'+ - 3",
+		},
+		{
+			Name:     "positions with comments",
+			Input:    input,
+			KeepAll:  true,
+			Policy:   textparser.SpacingPositions,
+			Expected: "foo  = bar(1, -2) // note\n\n baz = 'x y'+ - 3",
+		},
+		{
+			Name:     "positions after edit",
+			Input:    input,
+			Policy:   textparser.SpacingPositions,
+			Edit:     rename,
+			Expected: "renamed  = bar(1, -2)\n\n baz = 'x y'+ - 3",
+		},
+		{
+			Name:     "minimal",
+			Input:    input,
+			Policy:   textparser.SpacingMinimal,
+			Expected: "foo=bar(1,-2)baz='x y'+- 3",
+		},
+		{
+			Name:     "minimal with comments",
+			Input:    input,
+			KeepAll:  true,
+			Policy:   textparser.SpacingMinimal,
+			Expected: "foo=bar(1,-2)// note\nbaz='x y'+- 3",
+		},
+		{
+			Name:     "single",
+			Input:    input,
+			Policy:   textparser.SpacingSingle,
+			Expected: "foo = bar ( 1 , -2 ) baz = 'x y' + - 3",
+		},
+	}
+
+	for _, test_data := range test_list {
+		t.Run(test_data.Name, func(st *testing.T) {
+			p := textparser.NewScannerString(test_data.Input)
+			if test_data.KeepAll {
+				p.SkipWhitespace = false
+				p.SkipComments = false
+			}
+
+			tokens, err := textparser.TokenizeAll(p)
+			if err != nil {
+				st.Fatalf("error from scanner: %s", err)
+			}
+			if test_data.Edit != nil {
+				test_data.Edit(tokens)
+			}
+
+			got := textparser.Unparse(tokens, test_data.Policy, nil)
+			if got != test_data.Expected {
+				st.Errorf("got %q, expected %q", got, test_data.Expected)
+			}
+		})
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failing_writer struct{ writes int }
+
+func (w *failing_writer) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errWriteFailed
+}
+
+func TestTokenWriterError(t *testing.T) {
+	w := new(failing_writer)
+	tw := textparser.NewTokenWriter(w, textparser.SpacingSingle, nil)
+
+	for _, text := range []string{"a", "b"} {
+		err := tw.WriteToken(&textparser.Token{Text: text,
+			Type: textparser.TokenTypeIdent})
+		if err != errWriteFailed {
+			t.Errorf("got error %v, expected %v", err, errWriteFailed)
+		}
+	}
+
+	if w.writes != 1 {
+		t.Errorf("got %d writes, expected 1", w.writes)
+	}
+}
+
+func TestTokenWriterRaw(t *testing.T) {
+	p := textparser.NewScannerString(`a,"b ""c""",d` + "\n")
+	p.SetDelimited(',', '"')
+	p.SkipWhitespace = false
+
+	tokens, err := textparser.TokenizeAll(p)
+	if err != nil {
+		t.Fatalf("error from scanner: %s", err)
+	}
+
+	b := new(strings.Builder)
+	tw := textparser.NewTokenWriter(b, textparser.SpacingPreserve, nil)
+	tw.Raw = true
+	tw.WriteAll(tokens)
+
+	expected := `a,"b ""c""",d` + "\n"
+	if got := b.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}